@@ -24,6 +24,7 @@ import (
 	"github.com/vmware-tanzu/velero/pkg/util/kube"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/openshift/oadp-operator/pkg/common"
 )
@@ -34,6 +35,56 @@ const ReconciledReasonComplete = "Complete"
 const ReconciledReasonError = "Error"
 const ReconcileCompleteMessage = "Reconcile complete"
 
+// Pause and rollout conditions
+const ConditionPaused = "Paused"
+const PausedReason = "Paused"
+const PausedMessage = "Reconcile is paused, managed objects will not be updated"
+const ConditionRolloutDeferred = "RolloutDeferred"
+const RolloutDeferredReason = "OutsideMaintenanceWindow"
+const RolloutDeferredOperationsReason = "OperationsInProgress"
+
+// Component conditions
+const ConditionVeleroReady = "VeleroReady"
+const ConditionNodeAgentReady = "NodeAgentReady"
+const ConditionBackupLocationsAvailable = "BackupLocationsAvailable"
+const ConditionNonAdminReady = "NonAdminReady"
+const ConditionImagesResolved = "ImagesResolved"
+const ConditionPluginsCompatible = "PluginsCompatible"
+const ConditionUnsupportedServerArgsValid = "UnsupportedServerArgsValid"
+const ConditionBackupLocationDeletionBlocked = "BackupLocationDeletionBlocked"
+const ConditionSnapshotLocationsReady = "SnapshotLocationsReady"
+const ConditionObjectBucketClaimsBound = "ObjectBucketClaimsBound"
+
+const ComponentReasonReady = "Ready"
+const ComponentReasonNotReady = "NotReady"
+const ComponentReasonNotFound = "NotFound"
+const ComponentReasonAvailable = "Available"
+const ComponentReasonUnavailable = "Unavailable"
+const ComponentReasonPending = "Pending"
+const ImagesReasonResolved = "Resolved"
+const ImagesReasonUnreachable = "ImageUnreachable"
+const PluginsReasonCompatible = "Compatible"
+const PluginsReasonIncompatible = "IncompatiblePlugin"
+const PluginsReasonVeleroVersionUnknown = "VeleroVersionUnknown"
+const UnsupportedServerArgsReasonValid = "Valid"
+const UnsupportedServerArgsReasonInvalidFlags = "InvalidFlags"
+const BackupLocationDeletionBlockedReason = "ReferencedByBackups"
+const ObjectBucketClaimsReasonBound = "Bound"
+const ObjectBucketClaimsReasonPending = "Pending"
+
+// Failure reasons of unavailable backup storage locations
+const BackupLocationFailureReasonDNS = "DNS"
+const BackupLocationFailureReasonTLS = "TLS"
+const BackupLocationFailureReasonAuthentication = "Authentication"
+const BackupLocationFailureReasonBucketNotFound = "BucketNotFound"
+const BackupLocationFailureReasonClockSkew = "ClockSkew"
+const BackupLocationFailureReasonNetwork = "Network"
+const BackupLocationFailureReasonUnknown = "Unknown"
+
+// Reasons of the switches of the default backup storage location, see spec.backupLocationFailover
+const BackupLocationSwitchReasonFailover = "Failover"
+const BackupLocationSwitchReasonFailback = "Failback"
+
 const OadpOperatorLabel = "openshift.io/oadp"
 
 // +kubebuilder:validation:Enum=aws;legacy-aws;gcp;azure;csi;vsm;openshift;kubevirt;hypershift
@@ -61,6 +112,42 @@ const (
 	LogFormatJSON LogFormat = "json"
 )
 
+// DataProtectionApplicationMode is the mode of a DataProtectionApplication
+type DataProtectionApplicationMode string
+
+const (
+	// DataProtectionApplicationModeReadWrite backs up and restores
+	DataProtectionApplicationModeReadWrite DataProtectionApplicationMode = "ReadWrite"
+	// DataProtectionApplicationModeRestoreOnly only restores, for disaster recovery clusters sharing
+	// the backup storage locations of another cluster
+	DataProtectionApplicationModeRestoreOnly DataProtectionApplicationMode = "RestoreOnly"
+)
+
+// BackupLocationProfile is a preset of the velero config of an S3 compatible backup location
+type BackupLocationProfile string
+
+const (
+	// BackupLocationProfileODF is the Multicloud Object Gateway (NooBaa) of OpenShift Data Foundation on the cluster
+	BackupLocationProfileODF BackupLocationProfile = "odf"
+	// BackupLocationProfileMinIO is a MinIO server
+	BackupLocationProfileMinIO BackupLocationProfile = "minio"
+	// BackupLocationProfileIBMCOS is IBM Cloud Object Storage
+	BackupLocationProfileIBMCOS BackupLocationProfile = "ibm-cos"
+	// BackupLocationProfileWasabi is Wasabi hot cloud storage
+	BackupLocationProfileWasabi BackupLocationProfile = "wasabi"
+)
+
+// BackupScheduleTier is a retention tier of a schedule of spec.schedules
+type BackupScheduleTier string
+
+const (
+	BackupScheduleTierHourly  BackupScheduleTier = "hourly"
+	BackupScheduleTierDaily   BackupScheduleTier = "daily"
+	BackupScheduleTierWeekly  BackupScheduleTier = "weekly"
+	BackupScheduleTierMonthly BackupScheduleTier = "monthly"
+	BackupScheduleTierYearly  BackupScheduleTier = "yearly"
+)
+
 // Field does not have enum validation for development flexibility
 type UnsupportedImageKey string
 
@@ -131,8 +218,8 @@ type ServerFlags struct {
 	// +optional
 	// RestoreOnly *bool `json:"restore-only,omitempty"`
 
-	// List of controllers to disable on startup. Valid values are backup,backup-operations,backup-deletion,backup-finalizer,backup-sync,download-request,gc,backup-repo,restore,restore-operations,schedule,server-status-request
-	// +kubebuilder:validation:Enum=backup;backup-operations;backup-deletion;backup-finalizer;backup-sync;download-request;gc;backup-repo;restore;restore-operations;schedule;server-status-request
+	// List of controllers to disable on startup. Valid values are backup,backup-operations,backup-deletion,backup-finalizer,backup-sync,download-request,gc,backup-repo,restore,restore-operations,schedule,server-status-request,restore-finalizer
+	// +kubebuilder:validation:items:Enum=backup;backup-operations;backup-deletion;backup-finalizer;backup-sync;download-request;gc;backup-repo;restore;restore-operations;schedule;server-status-request;restore-finalizer
 	// +optional
 	DisabledControllers []string `json:"disabled-controllers,omitempty"`
 	// Maximum number of requests per second by the server to the Kubernetes API once the burst limit has been reached.
@@ -140,9 +227,11 @@ type ServerFlags struct {
 	// +optional
 	ClientQPS *string `json:"client-qps,omitempty"`
 	// Maximum number of requests by the server to the Kubernetes API in a short period of time.
+	// +kubebuilder:validation:Minimum=1
 	// +optional
 	ClientBurst *int `json:"client-burst,omitempty"`
 	// Page size of requests by the server to the Kubernetes API when listing objects during a backup. Set to 0 to disable paging.
+	// +kubebuilder:validation:Minimum=0
 	// +optional
 	ClientPageSize *int `json:"client-page-size,omitempty"`
 	// The address to expose the pprof profiler.
@@ -165,11 +254,36 @@ type ServerFlags struct {
 	// +optional
 	DefaultVolumesToFsBackup *bool `json:"default-volumes-to-fs-backup,omitempty"`
 	// How long (in nanoseconds) to wait on asynchronous BackupItemActions and RestoreItemActions to complete before timing out. (default is 1 hour)
+	// +optional
 	DefaultItemOperationTimeout *time.Duration `json:"default-item-operation-timeout,omitempty"`
 	// How long (in nanoseconds) to wait for resource processes which are not covered by other specific timeout parameters. (default is 10 minutes)
+	// +optional
 	ResourceTimeout *time.Duration `json:"resource-timeout,omitempty"`
 	// Max concurrent connections number that Velero can create with kube-apiserver. Default is 30. (default 30)
+	// +kubebuilder:validation:Minimum=1
+	// +optional
 	MaxConcurrentK8SConnections *int `json:"max-concurrent-k8s-connections,omitempty"`
+	// Move data by default for all snapshots supporting data movement.
+	// +optional
+	DefaultSnapshotMoveData *bool `json:"default-snapshot-move-data,omitempty"`
+	// Disable informer cache for Get calls on restore. (default false)
+	// +optional
+	DisableInformerCache *bool `json:"disable-informer-cache,omitempty"`
+	// Skip the first scheduled backup immediately after creating a schedule. (default false)
+	// +optional
+	ScheduleSkipImmediately *bool `json:"schedule-skip-immediately,omitempty"`
+	// Number of latest maintenance jobs to keep each repository. (default 3)
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepLatestMaintenanceJobs *int `json:"keep-latest-maintenance-jobs,omitempty"`
+	// Number of worker threads to process ItemBlocks. (default 1)
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ItemBlockWorkerCount *int `json:"item-block-worker-count,omitempty"`
+	// uploader-type is set from spec.configuration.nodeAgent.uploaderType
+	// backup-repository-configmap and repo-maintenance-job-configmap are set from the ConfigMaps the operator creates
+	// maintenance-job-cpu-request, maintenance-job-mem-request, maintenance-job-cpu-limit and maintenance-job-mem-limit
+	// are set in spec.configuration.repositoryMaintenance podResources
 }
 
 // GlobalFlags are flags that are defined across Velero CLI commands
@@ -334,7 +448,8 @@ type VeleroConfig struct {
 	// maximum number of requests per second by the server to the Kubernetes API once the burst limit has been reached. (default 100)
 	// +optional
 	ClientQPS *int `json:"client-qps,omitempty"`
-	// Velero args are settings to customize velero server arguments. Overrides values in other fields.
+	// Velero args are settings to customize velero server arguments. Fields outside args setting the same
+	// server arguments are used when args does not set them, and must not conflict with args.
 	// +optional
 	Args *VeleroServerArgs `json:"args,omitempty"`
 	// LoadAffinityConfig is the config for data path load affinity.
@@ -360,6 +475,24 @@ type PodConfig struct {
 	// env defines the list of environment variables to be supplied to podSpec
 	// +optional
 	Env []corev1.EnvVar `json:"env,omitempty"`
+	// priorityClassName defines the PriorityClass to be supplied to podSpec
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// topologySpreadConstraints defines how pods are spread across topology domains
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	// podAntiAffinity defines the pod anti-affinity scheduling rules to be supplied to podSpec
+	// +optional
+	PodAntiAffinity *corev1.PodAntiAffinity `json:"podAntiAffinity,omitempty"`
+	// annotations to add to pods, overriding spec.podAnnotations for this component
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// podSecurityContext replaces the pod security context set by the operator
+	// +optional
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+	// containerSecurityContext replaces the security context set by the operator on the component container
+	// +optional
+	ContainerSecurityContext *corev1.SecurityContext `json:"containerSecurityContext,omitempty"`
 }
 
 type NodeAgentCommonFields struct {
@@ -459,6 +592,48 @@ type NodeAgentConfig struct {
 	// Embedding KopiaRepoOptions
 	// +optional
 	KopiaRepoOptions `json:",inline"`
+	// Velero node-agent server's args. When set, the node-agent server arguments are recreated from them,
+	// overriding dataMoverPrepareTimeout, resourceTimeout and spec.logFormat.
+	// +optional
+	Args *NodeAgentServerArgs `json:"args,omitempty"`
+}
+
+// NodeAgentServerArgs are the arguments that are passed to the Velero node-agent server.
+// The node-agent server does not accept client QPS and burst flags, the Kubernetes client
+// rate limits of the node-agent are not configurable.
+// https://github.com/openshift/velero/blob/584cf1148a746838ee67aa27e3e4e0ded1f5c069/pkg/cmd/cli/nodeagent/server.go#L126-L131
+type NodeAgentServerArgs struct {
+	// The level at which to log. Valid values are trace, debug, info, warning, error, fatal, panic. (default info)
+	// +kubebuilder:validation:Enum=trace;debug;info;warning;error;fatal;panic
+	// +optional
+	LogLevel string `json:"log-level,omitempty"`
+	// The format for log output. Valid values are text, json. (default text)
+	// +kubebuilder:validation:Enum=text;json
+	// +optional
+	FormatFlag string `json:"log-format,omitempty"`
+	// The address to expose prometheus metrics. (default :8085)
+	// +optional
+	MetricsAddress string `json:"metrics-address,omitempty"`
+	// How long (in nanoseconds) to wait for preparing a DataUpload/DataDownload. (default is 30 minutes)
+	// +optional
+	DataMoverPrepareTimeout *time.Duration `json:"data-mover-prepare-timeout,omitempty"`
+	// How long (in nanoseconds) to wait for resource processes which are not covered by other specific timeout parameters. (default is 10 minutes)
+	// +optional
+	ResourceTimeout *time.Duration `json:"resource-timeout,omitempty"`
+	// Number of concurrent data path operations (data uploads, data downloads, pod volume backups and restores) per node.
+	// Set in the node-agent ConfigMap as loadConcurrency.globalConfig, which must not be set as well. (default 1)
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	DataPathConcurrency *int `json:"data-path-concurrency,omitempty"`
+	// Host path of the kubelet pods directory, mounted by the node-agent to access pod volumes.
+	// Defaults to the platform kubelet pods directory.
+	// +optional
+	HostPodsPath string `json:"host-pods-path,omitempty"`
+	// Host path of the kubelet plugins directory, mounted by the node-agent to access CSI volumes.
+	// Defaults to the platform kubelet plugins directory.
+	// +optional
+	HostPluginsPath string `json:"host-plugins-path,omitempty"`
+	GlobalFlags     `json:",inline"`
 }
 
 type KopiaRepoOptions struct {
@@ -554,16 +729,59 @@ type CloudStorageLocation struct {
 	CACert []byte `json:"caCert,omitempty"`
 }
 
+// ObjectBucketClaimLocation defines a backup location in the bucket of an ObjectBucketClaim
+type ObjectBucketClaimLocation struct {
+	// objectBucketClaimRef is the ObjectBucketClaim in the DPA namespace. The ConfigMap and the Secret of the bound
+	// claim provide the bucket, the endpoint and the access keys of the location.
+	ObjectBucketClaimRef corev1.LocalObjectReference `json:"objectBucketClaimRef"`
+
+	// config is for provider-specific configuration fields, set over the config derived from the ObjectBucketClaim.
+	// +optional
+	Config map[string]string `json:"config,omitempty"`
+
+	// default indicates this location is the default backup storage location.
+	// +optional
+	Default bool `json:"default,omitempty"`
+
+	// backupSyncPeriod defines how frequently to sync backup API objects from object storage. A value of 0 disables sync.
+	// +optional
+	// +nullable
+	BackupSyncPeriod *metav1.Duration `json:"backupSyncPeriod,omitempty"`
+
+	// Prefix is the path inside a bucket to use for Velero storage. Optional.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// CACert defines a CA bundle to use when verifying TLS connections to the provider. The service CA of the
+	// cluster is used when not set.
+	// +optional
+	CACert []byte `json:"caCert,omitempty"`
+}
+
 // BackupLocation defines the configuration for the DPA backup storage
 type BackupLocation struct {
 	// TODO: Add name/annotations/labels support
 
 	// +optional
 	Name string `json:"name,omitempty"`
+	// previousName renames the BackupStorageLocation previousName created by the DPA to this location:
+	// Backups and Schedules referencing previousName are updated to reference this location before
+	// previousName is deleted
+	// +optional
+	PreviousName string `json:"previousName,omitempty"`
+	// profile expands to the vetted s3Url, s3ForcePathStyle, region and checksumAlgorithm of an S3 compatible
+	// vendor in the velero config, where the values set in the config take precedence. With odf, the s3Url and
+	// caCert are discovered from the NooBaa S3 route of the cluster.
+	// +kubebuilder:validation:Enum=odf;minio;ibm-cos;wasabi
+	// +optional
+	Profile BackupLocationProfile `json:"profile,omitempty"`
 	// +optional
 	Velero *velero.BackupStorageLocationSpec `json:"velero,omitempty"`
 	// +optional
 	CloudStorage *CloudStorageLocation `json:"bucket,omitempty"`
+	// objectBucketClaim creates the location in the bucket of an ObjectBucketClaim, once it is bound
+	// +optional
+	ObjectBucketClaim *ObjectBucketClaimLocation `json:"objectBucketClaim,omitempty"`
 }
 
 // SnapshotLocation defines the configuration for the DPA snapshot store
@@ -669,6 +887,10 @@ type NonAdmin struct {
 	// By default 2m
 	// +optional
 	BackupSyncPeriod *metav1.Duration `json:"backupSyncPeriod,omitempty"`
+
+	// Pod specific configuration of the non-admin controller
+	// +optional
+	PodConfig *PodConfig `json:"podConfig,omitempty"`
 }
 
 // DataMover defines the various config for DPA data mover
@@ -821,6 +1043,405 @@ type DataProtectionApplicationSpec struct {
 	// +kubebuilder:default=text
 	// +optional
 	LogFormat LogFormat `json:"logFormat,omitempty"`
+	// paused stops the operator from reconciling the DPA managed objects, so manual changes are not reverted.
+	// Setting the oadp.openshift.io/paused annotation to "true" has the same effect.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+	// maintenanceWindow restricts disruptive rollouts of the Velero deployment and NodeAgent daemonset to specific hours.
+	// Changes that do not restart pods, and the first deployment of a component, are applied right away.
+	// +optional
+	MaintenanceWindow *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+	// availability defines how the Velero server, NodeAgent and non-admin controller tolerate voluntary disruptions
+	// such as node drains during cluster upgrades
+	// +optional
+	Availability *Availability `json:"availability,omitempty"`
+	// pinImageDigests resolves the Velero, plugin and non-admin controller images to digests and deploys them by digest.
+	// Images are looked up through the cluster ImageDigestMirrorSets, ImageTagMirrorSets and ImageContentSourcePolicies,
+	// using the cluster pull secret. Images that cannot be resolved are deployed as configured.
+	// +optional
+	PinImageDigests bool `json:"pinImageDigests,omitempty"`
+	// monitoring creates ServiceMonitors for the OADP components and a PrometheusRule alerting on backup failures.
+	// Requires the Prometheus operator APIs, for example OpenShift user workload monitoring.
+	// +optional
+	Monitoring *Monitoring `json:"monitoring,omitempty"`
+	// networkPolicy creates least-privilege NetworkPolicies for the OADP pods, for namespaces with default-deny
+	// NetworkPolicies
+	// +optional
+	NetworkPolicy *NetworkPolicyConfig `json:"networkPolicy,omitempty"`
+	// backupLocationProbe probes the endpoints of the unavailable backup storage locations from the operator
+	// to classify their failure in status.backupLocations
+	// +optional
+	BackupLocationProbe *BackupLocationProbe `json:"backupLocationProbe,omitempty"`
+	// snapshotLocationCheck checks with the provider API that the credentials of each snapshot location are allowed
+	// to create snapshots, without creating one. The result is reported in status.snapshotLocations.
+	// +optional
+	SnapshotLocationCheck *SnapshotLocationCheck `json:"snapshotLocationCheck,omitempty"`
+	// backupLocationFailover switches the default backup storage location from a primary backup location to
+	// a secondary one, for example a replicated bucket in another region, while the primary is unavailable
+	// +optional
+	BackupLocationFailover *BackupLocationFailover `json:"backupLocationFailover,omitempty"`
+	// mode RestoreOnly is for disaster recovery clusters that must never write into the backup storage locations:
+	// all BackupStorageLocations are ReadOnly, the Velero backup and schedule controllers are disabled,
+	// SnapshotLocations do not require the default credentials, and Schedules cannot be created in the namespace.
+	// +kubebuilder:validation:Enum=ReadWrite;RestoreOnly
+	// +kubebuilder:default=ReadWrite
+	// +optional
+	Mode DataProtectionApplicationMode `json:"mode,omitempty"`
+	// schedules creates a Velero Schedule for each retention tier of each schedule, and prunes the Backups of each
+	// tier beyond its retention, keeping a grandfather-father-son set of Backups. Schedules cannot be set in
+	// RestoreOnly mode.
+	// +optional
+	Schedules []BackupSchedule `json:"schedules,omitempty"`
+}
+
+// BackupSchedule defines the Velero Schedule of a schedule and the retention tiers of its Backups
+type BackupSchedule struct {
+	// name of the schedule. Its Velero Schedule is named <dpa name>-<name>.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=40
+	Name string `json:"name"`
+	// template is the spec of the Backups of the schedule. Its ttl is set from the retention of the longest tier,
+	// so Velero deletes the Backups that were not pruned, for example while the DPA is removed.
+	// +optional
+	Template velero.BackupSpec `json:"template,omitempty"`
+	// retention is the number of Backups kept in each tier. The Velero Schedule takes a Backup every period of
+	// the shortest tier kept, and a Backup is kept while a tier retains it.
+	Retention BackupScheduleRetention `json:"retention"`
+	// paused pauses the Velero Schedule of the schedule, its Backups are still pruned
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+// BackupScheduleRetention defines the number of Backups kept in each tier of a schedule. A tier retains the most
+// recent completed Backup of each of its last periods, in UTC.
+type BackupScheduleRetention struct {
+	// hourly is the number of hours whose most recent Backup is kept
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Hourly int32 `json:"hourly,omitempty"`
+	// daily is the number of days whose most recent Backup is kept
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Daily int32 `json:"daily,omitempty"`
+	// weekly is the number of weeks, starting on Sunday, whose most recent Backup is kept
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Weekly int32 `json:"weekly,omitempty"`
+	// monthly is the number of months whose most recent Backup is kept
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Monthly int32 `json:"monthly,omitempty"`
+	// yearly is the number of years whose most recent Backup is kept
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Yearly int32 `json:"yearly,omitempty"`
+}
+
+// BackupLocationProbe defines the connectivity probe of the unavailable backup storage locations
+type BackupLocationProbe struct {
+	// enable sends an anonymous request to the bucket of each unavailable backup storage location when Velero
+	// validates it, to detect DNS, TLS and network errors, a missing bucket and a clock skew with the endpoint.
+	// The result is reported in status.backupLocations[].probeMessage.
+	Enable bool `json:"enable"`
+}
+
+// SnapshotLocationCheck defines the snapshot permission check of the snapshot locations
+type SnapshotLocationCheck struct {
+	// enable runs a CreateSnapshot dry run on AWS, and a testIamPermissions request on the GCP project.
+	// Azure snapshot locations and AWS STS credentials are not checked.
+	Enable bool `json:"enable"`
+	// interval is the interval between the checks of a snapshot location, 1h if not set
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// BackupLocationFailover defines a primary and secondary pair of backup locations
+type BackupLocationFailover struct {
+	// primary is the name of the backup location of spec.backupLocations that is the default while it is available.
+	// It must be set as default.
+	Primary string `json:"primary"`
+	// secondary is the name of the backup location of spec.backupLocations that becomes the default when the primary
+	// is unavailable for unavailabilityWindow. Schedules using the primary are repointed to the secondary.
+	Secondary string `json:"secondary"`
+	// unavailabilityWindow is how long the primary must be unavailable before failing over to the secondary,
+	// and available before failing back, 10m if not set
+	// +optional
+	UnavailabilityWindow *metav1.Duration `json:"unavailabilityWindow,omitempty"`
+	// failback makes the primary the default again once it is available for unavailabilityWindow.
+	// Without failback, the secondary stays the default until backupLocationFailover is removed.
+	// +optional
+	Failback bool `json:"failback,omitempty"`
+}
+
+// Monitoring defines the Prometheus monitoring of the OADP components
+type Monitoring struct {
+	// enable creates ServiceMonitors for the Velero server, NodeAgent, non-admin controller and operator metrics,
+	// and the OADP alerts PrometheusRule
+	Enable bool `json:"enable"`
+	// scrapeInterval is the interval at which metrics are scraped, the Prometheus default if not set
+	// +kubebuilder:validation:Pattern=`^(0|(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`
+	// +optional
+	ScrapeInterval string `json:"scrapeInterval,omitempty"`
+	// alerts defines the thresholds of the OADP alerts
+	// +optional
+	Alerts *MonitoringAlerts `json:"alerts,omitempty"`
+}
+
+// MonitoringAlerts defines the thresholds of the OADP alerts
+type MonitoringAlerts struct {
+	// disable does not create the OADP alerts PrometheusRule
+	// +optional
+	Disable bool `json:"disable,omitempty"`
+	// failedBackups is the number of failed backups within failuresWindow firing OADPBackupFailed. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailedBackups *int32 `json:"failedBackups,omitempty"`
+	// partiallyFailedBackups is the number of partially failed backups within failuresWindow firing
+	// OADPBackupPartiallyFailed. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PartiallyFailedBackups *int32 `json:"partiallyFailedBackups,omitempty"`
+	// failuresWindow is the time window in which failed and partially failed backups are counted. Defaults to 1h.
+	// +optional
+	FailuresWindow *metav1.Duration `json:"failuresWindow,omitempty"`
+	// backupLocationUnavailableFor is how long a backup storage location is unavailable before
+	// OADPBackupStorageLocationUnavailable fires. Defaults to 15m.
+	// +optional
+	BackupLocationUnavailableFor *metav1.Duration `json:"backupLocationUnavailableFor,omitempty"`
+	// scheduleStaleAfter is how long after the last successful backup of a schedule OADPScheduleStale fires.
+	// Defaults to 25h, for daily schedules.
+	// +optional
+	ScheduleStaleAfter *metav1.Duration `json:"scheduleStaleAfter,omitempty"`
+	// repositoryMaintenanceFailedFor is how long the last maintenance of a backup repository has failed before
+	// OADPRepositoryMaintenanceFailed fires. Defaults to 1h.
+	// +optional
+	RepositoryMaintenanceFailedFor *metav1.Duration `json:"repositoryMaintenanceFailedFor,omitempty"`
+}
+
+// NetworkPolicyConfig defines the NetworkPolicies of the OADP pods
+type NetworkPolicyConfig struct {
+	// enable creates NetworkPolicies for the Velero server, NodeAgent, data mover, repository maintenance and
+	// non-admin controller pods. They allow egress to DNS, the API server and the backup and snapshot location
+	// endpoints, and ingress to the metrics ports from monitoringNamespace.
+	Enable bool `json:"enable"`
+	// monitoringNamespace is the namespace allowed to scrape the metrics ports
+	// +kubebuilder:default=openshift-user-workload-monitoring
+	// +optional
+	MonitoringNamespace string `json:"monitoringNamespace,omitempty"`
+	// dnsNamespace is the namespace of the cluster DNS pods
+	// +kubebuilder:default=openshift-dns
+	// +optional
+	DNSNamespace string `json:"dnsNamespace,omitempty"`
+}
+
+// Availability defines the disruption handling of the DPA workloads
+type Availability struct {
+	// podDisruptionBudget creates PodDisruptionBudgets for the Velero server and the non-admin controller
+	// +optional
+	PodDisruptionBudget *PodDisruptionBudgetConfig `json:"podDisruptionBudget,omitempty"`
+	// nodeAgentMaxUnavailable is the maximum number of NodeAgent pods that can be unavailable during a rolling update,
+	// as a number or a percentage of nodes. Defaults to 1.
+	// +optional
+	NodeAgentMaxUnavailable *intstr.IntOrString `json:"nodeAgentMaxUnavailable,omitempty"`
+}
+
+// PodDisruptionBudgetConfig defines the PodDisruptionBudget of a DPA workload.
+// Only one of minAvailable and maxUnavailable can be set, if none is set maxUnavailable defaults to 1.
+// While Backups or Restores are in progress, no Velero server pod can be evicted. As the Velero server runs a
+// single replica, the default only blocks its evictions while Backups or Restores are in progress.
+type PodDisruptionBudgetConfig struct {
+	// minAvailable is the number of pods that must stay available, as a number or a percentage
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+	// maxUnavailable is the number of pods that can be unavailable, as a number or a percentage
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// MaintenanceWindow defines a daily time window, in UTC, in which pod restarts are allowed
+type MaintenanceWindow struct {
+	// startHour is the hour of the day, in UTC, at which the window opens
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=23
+	StartHour int32 `json:"startHour"`
+	// durationHours is how many hours the window stays open
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=24
+	DurationHours int32 `json:"durationHours"`
+}
+
+// DeploymentComponentStatus defines the observed state of a Deployment managed by the DPA
+type DeploymentComponentStatus struct {
+	// image is the image of the main container of the Deployment
+	// +optional
+	Image string `json:"image,omitempty"`
+	// replicas is the number of desired pods
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+	// readyReplicas is the number of pods with a Ready condition
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+}
+
+// NodeAgentStatus defines the observed state of the NodeAgent DaemonSet
+type NodeAgentStatus struct {
+	// image is the image of the node-agent container
+	// +optional
+	Image string `json:"image,omitempty"`
+	// desiredNumberScheduled is the number of nodes that should be running the node-agent pod
+	// +optional
+	DesiredNumberScheduled int32 `json:"desiredNumberScheduled,omitempty"`
+	// numberReady is the number of nodes that are running a ready node-agent pod
+	// +optional
+	NumberReady int32 `json:"numberReady,omitempty"`
+}
+
+// BackupStorageLocationStatus defines the observed state of a BackupStorageLocation created by the DPA
+type BackupStorageLocationStatus struct {
+	// name is the name of the BackupStorageLocation
+	Name string `json:"name"`
+	// default indicates this location is the default backup storage location
+	// +optional
+	Default bool `json:"default,omitempty"`
+	// phase is the phase reported by Velero for the BackupStorageLocation
+	// +optional
+	Phase velero.BackupStorageLocationPhase `json:"phase,omitempty"`
+	// lastValidationTime is the last time Velero validated the BackupStorageLocation
+	// +optional
+	// +nullable
+	LastValidationTime *metav1.Time `json:"lastValidationTime,omitempty"`
+	// message is the message reported by Velero for the BackupStorageLocation
+	// +optional
+	Message string `json:"message,omitempty"`
+	// failureReason classifies why the BackupStorageLocation is unavailable: DNS, TLS, Authentication,
+	// BucketNotFound, ClockSkew, Network or Unknown
+	// +optional
+	FailureReason string `json:"failureReason,omitempty"`
+	// probeMessage is the result of the probe of the unavailable BackupStorageLocation, see spec.backupLocationProbe
+	// +optional
+	ProbeMessage string `json:"probeMessage,omitempty"`
+}
+
+// BackupLocationName defines the name generated for a backup location of the spec without name
+type BackupLocationName struct {
+	// name is the name of the BackupStorageLocation
+	Name string `json:"name"`
+	// storage identifies the backup location by its provider, bucket, prefix, and the s3Url and region of its
+	// config when set, as provider:bucket/prefix,s3Url=<s3Url>,region=<region>
+	Storage string `json:"storage"`
+}
+
+// BackupLocationFailoverStatus defines the observed state of spec.backupLocationFailover
+type BackupLocationFailoverStatus struct {
+	// active is the name of the backup location that is the default, the primary or the secondary
+	Active string `json:"active"`
+	// primaryPhase is the last phase observed for the primary BackupStorageLocation
+	// +optional
+	PrimaryPhase velero.BackupStorageLocationPhase `json:"primaryPhase,omitempty"`
+	// primaryPhaseSince is when the primary BackupStorageLocation was first observed in primaryPhase
+	// +optional
+	// +nullable
+	PrimaryPhaseSince *metav1.Time `json:"primaryPhaseSince,omitempty"`
+	// switches are the last switches of the default backup storage location, most recent last
+	// +optional
+	Switches []BackupLocationSwitch `json:"switches,omitempty"`
+}
+
+// BackupLocationSwitch records a switch of the default backup storage location
+type BackupLocationSwitch struct {
+	// from is the name of the backup location that was the default
+	From string `json:"from"`
+	// to is the name of the backup location that became the default
+	To string `json:"to"`
+	// reason is Failover or Failback
+	Reason string `json:"reason"`
+	// time is when the default backup storage location was switched
+	Time metav1.Time `json:"time"`
+	// schedules are the Schedules repointed from the previous default backup storage location
+	// +optional
+	Schedules []string `json:"schedules,omitempty"`
+}
+
+// VolumeSnapshotLocationStatus defines the observed state of a VolumeSnapshotLocation created by the DPA
+type VolumeSnapshotLocationStatus struct {
+	// name is the name of the VolumeSnapshotLocation
+	Name string `json:"name"`
+	// provider is the provider of the VolumeSnapshotLocation
+	// +optional
+	Provider string `json:"provider,omitempty"`
+	// ready indicates snapshots can be created with the VolumeSnapshotLocation: its provider config and credentials
+	// are complete, and the snapshot permission check passed when spec.snapshotLocationCheck is enabled
+	// +optional
+	Ready bool `json:"ready,omitempty"`
+	// message explains why the VolumeSnapshotLocation is not ready, or reports the snapshot permission check
+	// +optional
+	Message string `json:"message,omitempty"`
+	// lastCheckTime is the last time the snapshot permission check ran
+	// +optional
+	// +nullable
+	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
+}
+
+// PluginStatus defines a Velero plugin injected in the Velero Deployment
+type PluginStatus struct {
+	// name is the name of the plugin init container
+	Name string `json:"name"`
+	// image is the resolved image of the plugin
+	Image string `json:"image"`
+}
+
+// ImageStatus defines a managed image resolved to its digest
+type ImageStatus struct {
+	// component is the component using the image, a default plugin name or custom plugin name for plugins
+	Component string `json:"component"`
+	// image is the configured image
+	Image string `json:"image"`
+	// resolvedImage is the image pinned to its digest, empty if it could not be resolved
+	// +optional
+	ResolvedImage string `json:"resolvedImage,omitempty"`
+	// resolvedFrom is the repository, source or mirror, the digest was resolved from
+	// +optional
+	ResolvedFrom string `json:"resolvedFrom,omitempty"`
+	// message is the error met resolving the image
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// UnsupportedServerArgsStatus defines the override of a server args by an unsupported server args ConfigMap
+type UnsupportedServerArgsStatus struct {
+	// component is the overridden server, velero or node-agent
+	Component string `json:"component"`
+	// configMap is the name of the unsupported server args ConfigMap
+	ConfigMap string `json:"configMap"`
+	// added are the server args of the override that the operator does not compute
+	// +optional
+	Added []string `json:"added,omitempty"`
+	// removed are the server args computed by the operator that the override drops
+	// +optional
+	Removed []string `json:"removed,omitempty"`
+	// invalidFlags are the unknown or malformed flags of the override
+	// +optional
+	InvalidFlags []string `json:"invalidFlags,omitempty"`
+}
+
+// BackupScheduleStatus defines the observed state of a schedule of spec.schedules
+type BackupScheduleStatus struct {
+	// name is the name of the schedule
+	Name string `json:"name"`
+	// schedule is the name of the Velero Schedule of the schedule
+	Schedule string `json:"schedule"`
+	// tiers are the retention tiers of the schedule
+	// +optional
+	Tiers []BackupScheduleTierStatus `json:"tiers,omitempty"`
+}
+
+// BackupScheduleTierStatus defines the observed state of a retention tier of a schedule
+type BackupScheduleTierStatus struct {
+	// tier is hourly, daily, weekly, monthly or yearly
+	Tier BackupScheduleTier `json:"tier"`
+	// retainedBackups are the completed Backups retained by the tier, most recent first
+	// +optional
+	RetainedBackups []string `json:"retainedBackups,omitempty"`
 }
 
 // DataProtectionApplicationStatus defines the observed state of DataProtectionApplication
@@ -828,6 +1449,40 @@ type DataProtectionApplicationStatus struct {
 	// Conditions defines the observed state of DataProtectionApplication
 	//+operator-sdk:csv:customresourcedefinitions:type=status
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// velero defines the observed state of the Velero Deployment
+	// +optional
+	Velero *DeploymentComponentStatus `json:"velero,omitempty"`
+	// nodeAgent defines the observed state of the NodeAgent DaemonSet
+	// +optional
+	NodeAgent *NodeAgentStatus `json:"nodeAgent,omitempty"`
+	// nonAdmin defines the observed state of the Non-Admin Controller Deployment
+	// +optional
+	NonAdmin *DeploymentComponentStatus `json:"nonAdmin,omitempty"`
+	// backupLocations defines the observed state of the BackupStorageLocations created by the DPA
+	// +optional
+	BackupLocations []BackupStorageLocationStatus `json:"backupLocations,omitempty"`
+	// backupLocationNames defines the names generated for the backup locations of the spec without name,
+	// so they keep their name when the spec.backupLocations list is reordered
+	// +optional
+	BackupLocationNames []BackupLocationName `json:"backupLocationNames,omitempty"`
+	// backupLocationFailover defines the observed state of spec.backupLocationFailover
+	// +optional
+	BackupLocationFailover *BackupLocationFailoverStatus `json:"backupLocationFailover,omitempty"`
+	// snapshotLocations defines the VolumeSnapshotLocations created by the DPA
+	// +optional
+	SnapshotLocations []VolumeSnapshotLocationStatus `json:"snapshotLocations,omitempty"`
+	// plugins defines the Velero plugins injected in the Velero Deployment
+	// +optional
+	Plugins []PluginStatus `json:"plugins,omitempty"`
+	// images defines the managed images resolved to digests when spec.pinImageDigests is set
+	// +optional
+	Images []ImageStatus `json:"images,omitempty"`
+	// unsupportedServerArgs defines the server args overridden by the unsupported server args ConfigMaps
+	// +optional
+	UnsupportedServerArgs []UnsupportedServerArgsStatus `json:"unsupportedServerArgs,omitempty"`
+	// schedules defines the observed state of spec.schedules
+	// +optional
+	Schedules []BackupScheduleStatus `json:"schedules,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -865,6 +1520,11 @@ func (dpa *DataProtectionApplication) BackupImages() bool {
 	return dpa.Spec.BackupImages == nil || *dpa.Spec.BackupImages
 }
 
+// IsRestoreOnly returns true when the DPA is in RestoreOnly mode
+func (dpa *DataProtectionApplication) IsRestoreOnly() bool {
+	return dpa.Spec.Mode == DataProtectionApplicationModeRestoreOnly
+}
+
 // Default DisableInformerCache behavior when nil to false
 func (dpa *DataProtectionApplication) GetDisableInformerCache() bool {
 	if dpa.Spec.Configuration.Velero.DisableInformerCache == nil {
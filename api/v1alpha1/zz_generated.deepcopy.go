@@ -27,6 +27,7 @@ import (
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	timex "time"
 )
 
@@ -67,6 +68,31 @@ func (in *ApplicationConfig) DeepCopy() *ApplicationConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Availability) DeepCopyInto(out *Availability) {
+	*out = *in
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(PodDisruptionBudgetConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeAgentMaxUnavailable != nil {
+		in, out := &in.NodeAgentMaxUnavailable, &out.NodeAgentMaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Availability.
+func (in *Availability) DeepCopy() *Availability {
+	if in == nil {
+		return nil
+	}
+	out := new(Availability)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupLocation) DeepCopyInto(out *BackupLocation) {
 	*out = *in
@@ -80,6 +106,11 @@ func (in *BackupLocation) DeepCopyInto(out *BackupLocation) {
 		*out = new(CloudStorageLocation)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ObjectBucketClaim != nil {
+		in, out := &in.ObjectBucketClaim, &out.ObjectBucketClaim
+		*out = new(ObjectBucketClaimLocation)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupLocation.
@@ -92,6 +123,196 @@ func (in *BackupLocation) DeepCopy() *BackupLocation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupLocationFailover) DeepCopyInto(out *BackupLocationFailover) {
+	*out = *in
+	if in.UnavailabilityWindow != nil {
+		in, out := &in.UnavailabilityWindow, &out.UnavailabilityWindow
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupLocationFailover.
+func (in *BackupLocationFailover) DeepCopy() *BackupLocationFailover {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupLocationFailover)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupLocationFailoverStatus) DeepCopyInto(out *BackupLocationFailoverStatus) {
+	*out = *in
+	if in.PrimaryPhaseSince != nil {
+		in, out := &in.PrimaryPhaseSince, &out.PrimaryPhaseSince
+		*out = (*in).DeepCopy()
+	}
+	if in.Switches != nil {
+		in, out := &in.Switches, &out.Switches
+		*out = make([]BackupLocationSwitch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupLocationFailoverStatus.
+func (in *BackupLocationFailoverStatus) DeepCopy() *BackupLocationFailoverStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupLocationFailoverStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupLocationName) DeepCopyInto(out *BackupLocationName) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupLocationName.
+func (in *BackupLocationName) DeepCopy() *BackupLocationName {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupLocationName)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupLocationProbe) DeepCopyInto(out *BackupLocationProbe) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupLocationProbe.
+func (in *BackupLocationProbe) DeepCopy() *BackupLocationProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupLocationProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupLocationSwitch) DeepCopyInto(out *BackupLocationSwitch) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.Schedules != nil {
+		in, out := &in.Schedules, &out.Schedules
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupLocationSwitch.
+func (in *BackupLocationSwitch) DeepCopy() *BackupLocationSwitch {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupLocationSwitch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupSchedule) DeepCopyInto(out *BackupSchedule) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+	out.Retention = in.Retention
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupSchedule.
+func (in *BackupSchedule) DeepCopy() *BackupSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupScheduleRetention) DeepCopyInto(out *BackupScheduleRetention) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupScheduleRetention.
+func (in *BackupScheduleRetention) DeepCopy() *BackupScheduleRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupScheduleRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupScheduleStatus) DeepCopyInto(out *BackupScheduleStatus) {
+	*out = *in
+	if in.Tiers != nil {
+		in, out := &in.Tiers, &out.Tiers
+		*out = make([]BackupScheduleTierStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupScheduleStatus.
+func (in *BackupScheduleStatus) DeepCopy() *BackupScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupScheduleTierStatus) DeepCopyInto(out *BackupScheduleTierStatus) {
+	*out = *in
+	if in.RetainedBackups != nil {
+		in, out := &in.RetainedBackups, &out.RetainedBackups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupScheduleTierStatus.
+func (in *BackupScheduleTierStatus) DeepCopy() *BackupScheduleTierStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupScheduleTierStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupStorageLocationStatus) DeepCopyInto(out *BackupStorageLocationStatus) {
+	*out = *in
+	if in.LastValidationTime != nil {
+		in, out := &in.LastValidationTime, &out.LastValidationTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStorageLocationStatus.
+func (in *BackupStorageLocationStatus) DeepCopy() *BackupStorageLocationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupStorageLocationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BucketMetadata) DeepCopyInto(out *BucketMetadata) {
 	*out = *in
@@ -451,6 +672,48 @@ func (in *DataProtectionApplicationSpec) DeepCopyInto(out *DataProtectionApplica
 		*out = new(NonAdmin)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.MaintenanceWindow != nil {
+		in, out := &in.MaintenanceWindow, &out.MaintenanceWindow
+		*out = new(MaintenanceWindow)
+		**out = **in
+	}
+	if in.Availability != nil {
+		in, out := &in.Availability, &out.Availability
+		*out = new(Availability)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Monitoring != nil {
+		in, out := &in.Monitoring, &out.Monitoring
+		*out = new(Monitoring)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(NetworkPolicyConfig)
+		**out = **in
+	}
+	if in.BackupLocationProbe != nil {
+		in, out := &in.BackupLocationProbe, &out.BackupLocationProbe
+		*out = new(BackupLocationProbe)
+		**out = **in
+	}
+	if in.SnapshotLocationCheck != nil {
+		in, out := &in.SnapshotLocationCheck, &out.SnapshotLocationCheck
+		*out = new(SnapshotLocationCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BackupLocationFailover != nil {
+		in, out := &in.BackupLocationFailover, &out.BackupLocationFailover
+		*out = new(BackupLocationFailover)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Schedules != nil {
+		in, out := &in.Schedules, &out.Schedules
+		*out = make([]BackupSchedule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataProtectionApplicationSpec.
@@ -473,6 +736,69 @@ func (in *DataProtectionApplicationStatus) DeepCopyInto(out *DataProtectionAppli
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Velero != nil {
+		in, out := &in.Velero, &out.Velero
+		*out = new(DeploymentComponentStatus)
+		**out = **in
+	}
+	if in.NodeAgent != nil {
+		in, out := &in.NodeAgent, &out.NodeAgent
+		*out = new(NodeAgentStatus)
+		**out = **in
+	}
+	if in.NonAdmin != nil {
+		in, out := &in.NonAdmin, &out.NonAdmin
+		*out = new(DeploymentComponentStatus)
+		**out = **in
+	}
+	if in.BackupLocations != nil {
+		in, out := &in.BackupLocations, &out.BackupLocations
+		*out = make([]BackupStorageLocationStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.BackupLocationNames != nil {
+		in, out := &in.BackupLocationNames, &out.BackupLocationNames
+		*out = make([]BackupLocationName, len(*in))
+		copy(*out, *in)
+	}
+	if in.BackupLocationFailover != nil {
+		in, out := &in.BackupLocationFailover, &out.BackupLocationFailover
+		*out = new(BackupLocationFailoverStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SnapshotLocations != nil {
+		in, out := &in.SnapshotLocations, &out.SnapshotLocations
+		*out = make([]VolumeSnapshotLocationStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Plugins != nil {
+		in, out := &in.Plugins, &out.Plugins
+		*out = make([]PluginStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]ImageStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.UnsupportedServerArgs != nil {
+		in, out := &in.UnsupportedServerArgs, &out.UnsupportedServerArgs
+		*out = make([]UnsupportedServerArgsStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Schedules != nil {
+		in, out := &in.Schedules, &out.Schedules
+		*out = make([]BackupScheduleStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataProtectionApplicationStatus.
@@ -601,6 +927,21 @@ func (in *DataProtectionTestStatus) DeepCopy() *DataProtectionTestStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentComponentStatus) DeepCopyInto(out *DeploymentComponentStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentComponentStatus.
+func (in *DeploymentComponentStatus) DeepCopy() *DeploymentComponentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentComponentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnforceBackupStorageLocationSpec) DeepCopyInto(out *EnforceBackupStorageLocationSpec) {
 	*out = *in
@@ -680,6 +1021,21 @@ func (in *GlobalFlags) DeepCopy() *GlobalFlags {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageStatus) DeepCopyInto(out *ImageStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageStatus.
+func (in *ImageStatus) DeepCopy() *ImageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KopiaRepoOptions) DeepCopyInto(out *KopiaRepoOptions) {
 	*out = *in
@@ -798,6 +1154,101 @@ func (in *LoggingFlags) DeepCopy() *LoggingFlags {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Monitoring) DeepCopyInto(out *Monitoring) {
+	*out = *in
+	if in.Alerts != nil {
+		in, out := &in.Alerts, &out.Alerts
+		*out = new(MonitoringAlerts)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Monitoring.
+func (in *Monitoring) DeepCopy() *Monitoring {
+	if in == nil {
+		return nil
+	}
+	out := new(Monitoring)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonitoringAlerts) DeepCopyInto(out *MonitoringAlerts) {
+	*out = *in
+	if in.FailedBackups != nil {
+		in, out := &in.FailedBackups, &out.FailedBackups
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PartiallyFailedBackups != nil {
+		in, out := &in.PartiallyFailedBackups, &out.PartiallyFailedBackups
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailuresWindow != nil {
+		in, out := &in.FailuresWindow, &out.FailuresWindow
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.BackupLocationUnavailableFor != nil {
+		in, out := &in.BackupLocationUnavailableFor, &out.BackupLocationUnavailableFor
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ScheduleStaleAfter != nil {
+		in, out := &in.ScheduleStaleAfter, &out.ScheduleStaleAfter
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RepositoryMaintenanceFailedFor != nil {
+		in, out := &in.RepositoryMaintenanceFailedFor, &out.RepositoryMaintenanceFailedFor
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringAlerts.
+func (in *MonitoringAlerts) DeepCopy() *MonitoringAlerts {
+	if in == nil {
+		return nil
+	}
+	out := new(MonitoringAlerts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicyConfig) DeepCopyInto(out *NetworkPolicyConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicyConfig.
+func (in *NetworkPolicyConfig) DeepCopy() *NetworkPolicyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeAgentCommonFields) DeepCopyInto(out *NodeAgentCommonFields) {
 	*out = *in
@@ -844,6 +1295,11 @@ func (in *NodeAgentConfig) DeepCopyInto(out *NodeAgentConfig) {
 	}
 	in.NodeAgentConfigMapSettings.DeepCopyInto(&out.NodeAgentConfigMapSettings)
 	in.KopiaRepoOptions.DeepCopyInto(&out.KopiaRepoOptions)
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = new(NodeAgentServerArgs)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeAgentConfig.
@@ -904,6 +1360,52 @@ func (in *NodeAgentConfigMapSettings) DeepCopy() *NodeAgentConfigMapSettings {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeAgentServerArgs) DeepCopyInto(out *NodeAgentServerArgs) {
+	*out = *in
+	if in.DataMoverPrepareTimeout != nil {
+		in, out := &in.DataMoverPrepareTimeout, &out.DataMoverPrepareTimeout
+		*out = new(timex.Duration)
+		**out = **in
+	}
+	if in.ResourceTimeout != nil {
+		in, out := &in.ResourceTimeout, &out.ResourceTimeout
+		*out = new(timex.Duration)
+		**out = **in
+	}
+	if in.DataPathConcurrency != nil {
+		in, out := &in.DataPathConcurrency, &out.DataPathConcurrency
+		*out = new(int)
+		**out = **in
+	}
+	in.GlobalFlags.DeepCopyInto(&out.GlobalFlags)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeAgentServerArgs.
+func (in *NodeAgentServerArgs) DeepCopy() *NodeAgentServerArgs {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeAgentServerArgs)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeAgentStatus) DeepCopyInto(out *NodeAgentStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeAgentStatus.
+func (in *NodeAgentStatus) DeepCopy() *NodeAgentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeAgentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NonAdmin) DeepCopyInto(out *NonAdmin) {
 	*out = *in
@@ -942,6 +1444,11 @@ func (in *NonAdmin) DeepCopyInto(out *NonAdmin) {
 		*out = new(metav1.Duration)
 		**out = **in
 	}
+	if in.PodConfig != nil {
+		in, out := &in.PodConfig, &out.PodConfig
+		*out = new(PodConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NonAdmin.
@@ -954,6 +1461,39 @@ func (in *NonAdmin) DeepCopy() *NonAdmin {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectBucketClaimLocation) DeepCopyInto(out *ObjectBucketClaimLocation) {
+	*out = *in
+	out.ObjectBucketClaimRef = in.ObjectBucketClaimRef
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.BackupSyncPeriod != nil {
+		in, out := &in.BackupSyncPeriod, &out.BackupSyncPeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.CACert != nil {
+		in, out := &in.CACert, &out.CACert
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectBucketClaimLocation.
+func (in *ObjectBucketClaimLocation) DeepCopy() *ObjectBucketClaimLocation {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectBucketClaimLocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObjectStorageLocation) DeepCopyInto(out *ObjectStorageLocation) {
 	*out = *in
@@ -974,6 +1514,21 @@ func (in *ObjectStorageLocation) DeepCopy() *ObjectStorageLocation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PluginStatus) DeepCopyInto(out *PluginStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginStatus.
+func (in *PluginStatus) DeepCopy() *PluginStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodConfig) DeepCopyInto(out *PodConfig) {
 	*out = *in
@@ -1006,6 +1561,35 @@ func (in *PodConfig) DeepCopyInto(out *PodConfig) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PodAntiAffinity != nil {
+		in, out := &in.PodAntiAffinity, &out.PodAntiAffinity
+		*out = new(v1.PodAntiAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PodSecurityContext != nil {
+		in, out := &in.PodSecurityContext, &out.PodSecurityContext
+		*out = new(v1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ContainerSecurityContext != nil {
+		in, out := &in.ContainerSecurityContext, &out.ContainerSecurityContext
+		*out = new(v1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodConfig.
@@ -1018,6 +1602,31 @@ func (in *PodConfig) DeepCopy() *PodConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodDisruptionBudgetConfig) DeepCopyInto(out *PodDisruptionBudgetConfig) {
+	*out = *in
+	if in.MinAvailable != nil {
+		in, out := &in.MinAvailable, &out.MinAvailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodDisruptionBudgetConfig.
+func (in *PodDisruptionBudgetConfig) DeepCopy() *PodDisruptionBudgetConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PodDisruptionBudgetConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RepositoryMaintenanceConfig) DeepCopyInto(out *RepositoryMaintenanceConfig) {
 	*out = *in
@@ -1179,6 +1788,31 @@ func (in *ServerFlags) DeepCopyInto(out *ServerFlags) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.DefaultSnapshotMoveData != nil {
+		in, out := &in.DefaultSnapshotMoveData, &out.DefaultSnapshotMoveData
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DisableInformerCache != nil {
+		in, out := &in.DisableInformerCache, &out.DisableInformerCache
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ScheduleSkipImmediately != nil {
+		in, out := &in.ScheduleSkipImmediately, &out.ScheduleSkipImmediately
+		*out = new(bool)
+		**out = **in
+	}
+	if in.KeepLatestMaintenanceJobs != nil {
+		in, out := &in.KeepLatestMaintenanceJobs, &out.KeepLatestMaintenanceJobs
+		*out = new(int)
+		**out = **in
+	}
+	if in.ItemBlockWorkerCount != nil {
+		in, out := &in.ItemBlockWorkerCount, &out.ItemBlockWorkerCount
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerFlags.
@@ -1211,6 +1845,26 @@ func (in *SnapshotLocation) DeepCopy() *SnapshotLocation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotLocationCheck) DeepCopyInto(out *SnapshotLocationCheck) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotLocationCheck.
+func (in *SnapshotLocationCheck) DeepCopy() *SnapshotLocationCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotLocationCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SnapshotTestStatus) DeepCopyInto(out *SnapshotTestStatus) {
 	*out = *in
@@ -1246,6 +1900,36 @@ func (in *StorageType) DeepCopy() *StorageType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnsupportedServerArgsStatus) DeepCopyInto(out *UnsupportedServerArgsStatus) {
+	*out = *in
+	if in.Added != nil {
+		in, out := &in.Added, &out.Added
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Removed != nil {
+		in, out := &in.Removed, &out.Removed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.InvalidFlags != nil {
+		in, out := &in.InvalidFlags, &out.InvalidFlags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnsupportedServerArgsStatus.
+func (in *UnsupportedServerArgsStatus) DeepCopy() *UnsupportedServerArgsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UnsupportedServerArgsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UploadSpeedTestConfig) DeepCopyInto(out *UploadSpeedTestConfig) {
 	*out = *in
@@ -1390,6 +2074,25 @@ func (in *VolumeOptions) DeepCopy() *VolumeOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotLocationStatus) DeepCopyInto(out *VolumeSnapshotLocationStatus) {
+	*out = *in
+	if in.LastCheckTime != nil {
+		in, out := &in.LastCheckTime, &out.LastCheckTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotLocationStatus.
+func (in *VolumeSnapshotLocationStatus) DeepCopy() *VolumeSnapshotLocationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotLocationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VolumeSnapshotSource) DeepCopyInto(out *VolumeSnapshotSource) {
 	*out = *in
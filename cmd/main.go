@@ -26,11 +26,13 @@ import (
 
 	snapshotv1api "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
 	configv1 "github.com/openshift/api/config/v1"
+	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
 	routev1 "github.com/openshift/api/route/v1"
 	security "github.com/openshift/api/security/v1"
 	monitor "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -87,6 +89,14 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == renderCommand {
+		if err := runRender(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
@@ -228,6 +238,9 @@ func main() {
 	utilruntime.Must(oadpv1alpha1.AddToScheme(uncachedClientScheme))
 	utilruntime.Must(appsv1.AddToScheme(uncachedClientScheme))
 	utilruntime.Must(snapshotv1api.AddToScheme(uncachedClientScheme))
+	utilruntime.Must(corev1.AddToScheme(uncachedClientScheme))
+	utilruntime.Must(configv1.AddToScheme(uncachedClientScheme))
+	utilruntime.Must(operatorv1alpha1.AddToScheme(uncachedClientScheme))
 	uncachedClient, err := client.New(kubeconf, client.Options{
 		Scheme: uncachedClientScheme,
 	})
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	snapshotv1api "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
+	routev1 "github.com/openshift/api/route/v1"
+	security "github.com/openshift/api/security/v1"
+	monitor "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/yaml"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/internal/controller"
+	pkgclient "github.com/openshift/oadp-operator/pkg/client"
+)
+
+// renderCommand is the manager subcommand that prints what the DPA controller would apply
+const renderCommand = "render"
+
+const (
+	renderOutputAll       = "all"
+	renderOutputManifests = "manifests"
+	renderOutputDiff      = "diff"
+)
+
+// runRender renders a DataProtectionApplication without writing to the cluster and prints the
+// resulting manifests and/or their diff against the live objects
+func runRender(args []string, out io.Writer) error {
+	var file, name, namespace, output string
+	flags := flag.NewFlagSet(renderCommand, flag.ContinueOnError)
+	flags.StringVar(&file, "f", "", "Path to a DataProtectionApplication manifest to render. Defaults to the live DataProtectionApplication.")
+	flags.StringVar(&name, "name", "", "Name of the live DataProtectionApplication to render.")
+	flags.StringVar(&namespace, "namespace", "", "Namespace of the DataProtectionApplication. Defaults to WATCH_NAMESPACE.")
+	flags.StringVar(&output, "output", renderOutputAll, "What to print: all, manifests or diff.")
+	opts := zap.Options{}
+	opts.BindFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if output != renderOutputAll && output != renderOutputManifests && output != renderOutputDiff {
+		return fmt.Errorf("invalid output %q, must be one of all, manifests or diff", output)
+	}
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	dpa := &oadpv1alpha1.DataProtectionApplication{}
+	if file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := yaml.UnmarshalStrict(content, dpa); err != nil {
+			return fmt.Errorf("unable to parse %s: %w", file, err)
+		}
+	} else if name == "" {
+		return fmt.Errorf("either -f or -name must be set")
+	} else {
+		dpa.Name = name
+	}
+	if namespace == "" {
+		namespace = dpa.Namespace
+	}
+	if namespace == "" {
+		watchNamespace, err := getWatchNamespace()
+		if err != nil {
+			return fmt.Errorf("unable to determine namespace, set -namespace: %w", err)
+		}
+		namespace = watchNamespace
+	}
+	dpa.Namespace = namespace
+
+	addRenderToScheme(scheme)
+
+	kubeconf, err := ctrl.GetConfig()
+	if err != nil {
+		return err
+	}
+	pkgclient.SetKubeconf(kubeconf)
+	c, err := client.New(kubeconf, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+
+	return render(context.Background(), c, dpa, file != "", output, out)
+}
+
+// addRenderToScheme adds the APIs the DPA controller reads and writes to s
+func addRenderToScheme(s *runtime.Scheme) {
+	utilruntime.Must(velerov1.AddToScheme(s))
+	utilruntime.Must(security.AddToScheme(s))
+	utilruntime.Must(routev1.AddToScheme(s))
+	utilruntime.Must(monitor.AddToScheme(s))
+	utilruntime.Must(configv1.AddToScheme(s))
+	utilruntime.Must(operatorv1alpha1.AddToScheme(s))
+	utilruntime.Must(snapshotv1api.AddToScheme(s))
+}
+
+// render prints the manifests and/or the diff of dpa, read from a file when fromFile is true, otherwise the live
+// DataProtectionApplication of its name
+func render(ctx context.Context, c client.Client, dpa *oadpv1alpha1.DataProtectionApplication, fromFile bool, output string, out io.Writer) error {
+	live := &oadpv1alpha1.DataProtectionApplication{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name}, live)
+	switch {
+	case err == nil && !fromFile:
+		dpa = live
+	case err == nil:
+		// keep owner references of rendered objects identical to the live ones
+		dpa.UID = live.UID
+	case errors.IsNotFound(err) && fromFile:
+	default:
+		return err
+	}
+
+	result, err := controller.RenderDataProtectionApplication(ctx, c, c, dpa, ctrl.Log.WithName(renderCommand))
+	if err != nil {
+		return err
+	}
+	if output != renderOutputDiff {
+		fmt.Fprint(out, result.Manifests)
+	}
+	if output != renderOutputManifests {
+		fmt.Fprint(out, result.Diff)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func TestRunRender(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{
+			name: "invalid output",
+			args: []string{"-name", "dpa", "-output", "yaml"},
+		},
+		{
+			name: "no DataProtectionApplication",
+			args: []string{"-namespace", "openshift-adp"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := runRender(tt.args, &bytes.Buffer{}); err == nil {
+				t.Errorf("runRender() expected an error")
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	newDPA := func() *oadpv1alpha1.DataProtectionApplication {
+		return &oadpv1alpha1.DataProtectionApplication{
+			ObjectMeta: v1.ObjectMeta{Name: "dpa", Namespace: "openshift-adp"},
+			Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+				Configuration: &oadpv1alpha1.ApplicationConfig{
+					Velero: &oadpv1alpha1.VeleroConfig{
+						NoDefaultBackupLocation: true,
+						DefaultPlugins:          []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginOpenShift},
+					},
+				},
+				BackupImages: ptr.To(false),
+			},
+		}
+	}
+	tests := []struct {
+		name     string
+		live     bool
+		fromFile bool
+		output   string
+		want     []string
+		wantNot  []string
+		wantErr  bool
+	}{
+		{
+			name:   "live DataProtectionApplication",
+			live:   true,
+			output: renderOutputAll,
+			want:   []string{"kind: Deployment\n", "+++ rendered/Deployment/openshift-adp/velero"},
+		},
+		{
+			name:     "DataProtectionApplication from a file, manifests only",
+			fromFile: true,
+			output:   renderOutputManifests,
+			want:     []string{"kind: Deployment\n"},
+			wantNot:  []string{"+++ rendered/"},
+		},
+		{
+			name:    "live DataProtectionApplication not found",
+			output:  renderOutputAll,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := runtime.NewScheme()
+			utilruntime.Must(clientgoscheme.AddToScheme(s))
+			utilruntime.Must(oadpv1alpha1.AddToScheme(s))
+			addRenderToScheme(s)
+			objects := []client.Object{&configv1.Infrastructure{ObjectMeta: v1.ObjectMeta{Name: "cluster"}}}
+			if tt.live {
+				objects = append(objects, newDPA())
+			}
+			c := fake.NewClientBuilder().WithScheme(s).WithObjects(objects...).Build()
+
+			out := &bytes.Buffer{}
+			err := render(context.Background(), c, newDPA(), tt.fromFile, tt.output, out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("expected output to contain %q, got:\n%s", want, out.String())
+				}
+			}
+			for _, wantNot := range tt.wantNot {
+				if strings.Contains(out.String(), wantNot) {
+					t.Errorf("expected output not to contain %q, got:\n%s", wantNot, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+// operationsRequeueInterval is how often the DPA is reconciled while Backups or Restores are in progress,
+// to apply deferred rollouts and release the Velero PodDisruptionBudget once they complete
+const operationsRequeueInterval = time.Minute
+
+// inProgressBackupPhases and inProgressRestorePhases are the phases in which Velero server or NodeAgent
+// pods are still working on the operation
+var (
+	inProgressBackupPhases = []velerov1.BackupPhase{
+		velerov1.BackupPhaseInProgress,
+		velerov1.BackupPhaseWaitingForPluginOperations,
+		velerov1.BackupPhaseWaitingForPluginOperationsPartiallyFailed,
+		velerov1.BackupPhaseFinalizing,
+		velerov1.BackupPhaseFinalizingPartiallyFailed,
+	}
+	inProgressRestorePhases = []velerov1.RestorePhase{
+		velerov1.RestorePhaseInProgress,
+		velerov1.RestorePhaseWaitingForPluginOperations,
+		velerov1.RestorePhaseWaitingForPluginOperationsPartiallyFailed,
+		velerov1.RestorePhaseFinalizing,
+		velerov1.RestorePhaseFinalizingPartiallyFailed,
+	}
+)
+
+// ReconcileInProgressOperations records the Backups and Restores in progress in the DPA namespace,
+// so workload rollouts are deferred and Velero server pods are not evicted until they complete
+func (r *DataProtectionApplicationReconciler) ReconcileInProgressOperations(log logr.Logger) (bool, error) {
+	r.inProgressOperations = nil
+
+	backups := &velerov1.BackupList{}
+	if err := r.List(r.Context, backups, client.InNamespace(r.dpa.Namespace)); err != nil {
+		return false, err
+	}
+	for _, backup := range backups.Items {
+		for _, phase := range inProgressBackupPhases {
+			if backup.Status.Phase == phase {
+				r.inProgressOperations = append(r.inProgressOperations, "backup/"+backup.Name)
+			}
+		}
+	}
+
+	restores := &velerov1.RestoreList{}
+	if err := r.List(r.Context, restores, client.InNamespace(r.dpa.Namespace)); err != nil {
+		return false, err
+	}
+	for _, restore := range restores.Items {
+		for _, phase := range inProgressRestorePhases {
+			if restore.Status.Phase == phase {
+				r.inProgressOperations = append(r.inProgressOperations, "restore/"+restore.Name)
+			}
+		}
+	}
+
+	if len(r.inProgressOperations) > 0 {
+		log.Info("Backups or Restores in progress", "operations", r.inProgressOperations)
+	}
+	return true, nil
+}
+
+// ReconcilePodDisruptionBudgets creates the PodDisruptionBudgets of the Velero server and non-admin controller
+// when spec.availability.podDisruptionBudget is set, and deletes them otherwise
+func (r *DataProtectionApplicationReconciler) ReconcilePodDisruptionBudgets(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	var pdbConfig *oadpv1alpha1.PodDisruptionBudgetConfig
+	if dpa.Spec.Availability != nil {
+		pdbConfig = dpa.Spec.Availability.PodDisruptionBudget
+	}
+
+	veleroSelector := common.AppendTTMapAsCopy(getDpaAppLabels(dpa), map[string]string{
+		"component": common.Velero,
+		"deploy":    common.Velero,
+	})
+	if pdbConfig == nil {
+		if err := r.deletePodDisruptionBudget(common.Velero); err != nil {
+			return false, err
+		}
+	} else {
+		veleroConfig := pdbConfig
+		if len(r.inProgressOperations) > 0 {
+			// block evictions of the Velero server until in progress operations complete
+			veleroConfig = &oadpv1alpha1.PodDisruptionBudgetConfig{MaxUnavailable: ptr.To(intstr.FromInt32(0))}
+		}
+		if err := r.reconcilePodDisruptionBudget(common.Velero, veleroSelector, veleroConfig); err != nil {
+			return false, err
+		}
+	}
+
+	if pdbConfig == nil || !r.checkNonAdminEnabled() {
+		if err := r.deletePodDisruptionBudget(nonAdminObjectName); err != nil {
+			return false, err
+		}
+	} else if err := r.reconcilePodDisruptionBudget(nonAdminObjectName, controlPlaneLabel, pdbConfig); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *DataProtectionApplicationReconciler) reconcilePodDisruptionBudget(name string, selector map[string]string, pdbConfig *oadpv1alpha1.PodDisruptionBudgetConfig) error {
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: r.dpa.Namespace,
+		},
+	}
+	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, pdb, r.withDriftDetection(pdb, func() error {
+		pdb.Labels = common.AppendTTMapAsCopy(pdb.Labels, getDpaAppLabels(r.dpa))
+		pdb.Spec.Selector = &metav1.LabelSelector{MatchLabels: selector}
+		pdb.Spec.MinAvailable = pdbConfig.MinAvailable
+		pdb.Spec.MaxUnavailable = pdbConfig.MaxUnavailable
+		if pdb.Spec.MinAvailable == nil && pdb.Spec.MaxUnavailable == nil {
+			// a single replica Velero server can then always be evicted, outside of in progress operations
+			pdb.Spec.MaxUnavailable = ptr.To(intstr.FromInt32(1))
+		}
+		return controllerutil.SetControllerReference(r.dpa, pdb, r.Scheme)
+	}))
+	if err != nil {
+		return err
+	}
+	if op == controllerutil.OperationResultCreated || op == controllerutil.OperationResultUpdated {
+		r.EventRecorder.Event(pdb,
+			corev1.EventTypeNormal,
+			"PodDisruptionBudgetReconciled",
+			fmt.Sprintf("performed %s on pod disruption budget %s/%s", op, pdb.Namespace, pdb.Name),
+		)
+	}
+	return nil
+}
+
+func (r *DataProtectionApplicationReconciler) deletePodDisruptionBudget(name string) error {
+	pdb := &policyv1.PodDisruptionBudget{}
+	if err := r.Get(r.Context, client.ObjectKey{Namespace: r.dpa.Namespace, Name: name}, pdb); err != nil {
+		if k8serror.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !metav1.IsControlledBy(pdb, r.dpa) {
+		return nil
+	}
+	if err := r.Delete(r.Context, pdb); err != nil && !k8serror.IsNotFound(err) {
+		return err
+	}
+	r.EventRecorder.Event(r.dpa,
+		corev1.EventTypeNormal,
+		"PodDisruptionBudgetDeleted",
+		fmt.Sprintf("deleted pod disruption budget %s/%s", pdb.Namespace, pdb.Name),
+	)
+	return nil
+}
+
+// validateAvailability checks the DPA spec.availability configuration
+func validateAvailability(availability *oadpv1alpha1.Availability) error {
+	if availability == nil || availability.PodDisruptionBudget == nil {
+		return nil
+	}
+	if availability.PodDisruptionBudget.MinAvailable != nil && availability.PodDisruptionBudget.MaxUnavailable != nil {
+		return fmt.Errorf("only one of minAvailable and maxUnavailable can be set in spec.availability.podDisruptionBudget")
+	}
+	return nil
+}
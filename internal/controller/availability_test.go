@@ -0,0 +1,294 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func TestDPAReconciler_ReconcilePodDisruptionBudgets(t *testing.T) {
+	tests := []struct {
+		name                   string
+		availability           *oadpv1alpha1.Availability
+		nonAdmin               bool
+		objects                []client.Object
+		wantVeleroPDB          *policyv1.PodDisruptionBudgetSpec
+		wantNonAdminPDB        *policyv1.PodDisruptionBudgetSpec
+		wantVeleroPDBDeleted   bool
+		wantNonAdminPDBDeleted bool
+	}{
+		{
+			name: "no podDisruptionBudget, owned PodDisruptionBudgets are deleted",
+			objects: []client.Object{
+				&policyv1.PodDisruptionBudget{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      common.Velero,
+						Namespace: "test-ns",
+						OwnerReferences: []metav1.OwnerReference{{
+							APIVersion: oadpv1alpha1.GroupVersion.String(),
+							Kind:       "DataProtectionApplication",
+							Name:       "test-dpa",
+							UID:        "test-dpa-uid",
+							Controller: ptr.To(true),
+						}},
+					},
+				},
+			},
+			wantVeleroPDBDeleted:   true,
+			wantNonAdminPDBDeleted: true,
+		},
+		{
+			name:         "default podDisruptionBudget",
+			availability: &oadpv1alpha1.Availability{PodDisruptionBudget: &oadpv1alpha1.PodDisruptionBudgetConfig{}},
+			wantVeleroPDB: &policyv1.PodDisruptionBudgetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{
+					"app.kubernetes.io/name":       common.Velero,
+					"app.kubernetes.io/instance":   "test-dpa",
+					"app.kubernetes.io/managed-by": common.OADPOperator,
+					"app.kubernetes.io/component":  Server,
+					oadpv1alpha1.OadpOperatorLabel: "True",
+					"component":                    common.Velero,
+					"deploy":                       common.Velero,
+				}},
+				MaxUnavailable: ptr.To(intstr.FromInt32(1)),
+			},
+			wantNonAdminPDBDeleted: true,
+		},
+		{
+			name: "podDisruptionBudget with minAvailable and non-admin enabled",
+			availability: &oadpv1alpha1.Availability{
+				PodDisruptionBudget: &oadpv1alpha1.PodDisruptionBudgetConfig{MinAvailable: ptr.To(intstr.FromInt32(1))},
+			},
+			nonAdmin: true,
+			wantNonAdminPDB: &policyv1.PodDisruptionBudgetSpec{
+				Selector:     &metav1.LabelSelector{MatchLabels: controlPlaneLabel},
+				MinAvailable: ptr.To(intstr.FromInt32(1)),
+			},
+		},
+		{
+			name:         "backup in progress, Velero server pods can not be evicted",
+			availability: &oadpv1alpha1.Availability{PodDisruptionBudget: &oadpv1alpha1.PodDisruptionBudgetConfig{}},
+			objects: []client.Object{
+				&velerov1.Backup{
+					ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "test-ns"},
+					Status:     velerov1.BackupStatus{Phase: velerov1.BackupPhaseInProgress},
+				},
+			},
+			wantVeleroPDB: &policyv1.PodDisruptionBudgetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{
+					"app.kubernetes.io/name":       common.Velero,
+					"app.kubernetes.io/instance":   "test-dpa",
+					"app.kubernetes.io/managed-by": common.OADPOperator,
+					"app.kubernetes.io/component":  Server,
+					oadpv1alpha1.OadpOperatorLabel: "True",
+					"component":                    common.Velero,
+					"deploy":                       common.Velero,
+				}},
+				MaxUnavailable: ptr.To(intstr.FromInt32(0)),
+			},
+			wantNonAdminPDBDeleted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := createTestDryRunDPA()
+			dpa.Annotations = nil
+			dpa.UID = "test-dpa-uid"
+			dpa.Spec.Availability = tt.availability
+			if tt.nonAdmin {
+				dpa.Spec.NonAdmin = &oadpv1alpha1.NonAdmin{Enable: ptr.To(true)}
+			}
+			fakeClient, err := getFakeClientFromObjects(append(tt.objects, dpa)...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:  fakeClient,
+				Scheme:  fakeClient.Scheme(),
+				Log:     logr.Discard(),
+				Context: newContextForTest(),
+				NamespacedName: types.NamespacedName{
+					Namespace: dpa.Namespace,
+					Name:      dpa.Name,
+				},
+				EventRecorder: record.NewFakeRecorder(10),
+				dpa:           dpa,
+			}
+			if _, err := r.ReconcileInProgressOperations(r.Log); err != nil {
+				t.Fatalf("ReconcileInProgressOperations() unexpected error = %v", err)
+			}
+			if _, err := r.ReconcilePodDisruptionBudgets(r.Log); err != nil {
+				t.Fatalf("ReconcilePodDisruptionBudgets() unexpected error = %v", err)
+			}
+
+			for name, want := range map[string]*policyv1.PodDisruptionBudgetSpec{
+				common.Velero:      tt.wantVeleroPDB,
+				nonAdminObjectName: tt.wantNonAdminPDB,
+			} {
+				pdb := &policyv1.PodDisruptionBudget{}
+				err := fakeClient.Get(r.Context, types.NamespacedName{Namespace: dpa.Namespace, Name: name}, pdb)
+				if want == nil {
+					continue
+				}
+				if err != nil {
+					t.Fatalf("unable to get %s PodDisruptionBudget: %v", name, err)
+				}
+				if !reflect.DeepEqual(pdb.Spec, *want) {
+					t.Errorf("expected %s PodDisruptionBudget spec %v, got %v", name, *want, pdb.Spec)
+				}
+				if !metav1.IsControlledBy(pdb, dpa) {
+					t.Errorf("expected %s PodDisruptionBudget to be controlled by the DPA", name)
+				}
+			}
+			for name, wantDeleted := range map[string]bool{
+				common.Velero:      tt.wantVeleroPDBDeleted,
+				nonAdminObjectName: tt.wantNonAdminPDBDeleted,
+			} {
+				err := fakeClient.Get(r.Context, types.NamespacedName{Namespace: dpa.Namespace, Name: name}, &policyv1.PodDisruptionBudget{})
+				if wantDeleted && !k8serror.IsNotFound(err) {
+					t.Errorf("expected %s PodDisruptionBudget to be deleted, got %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_deferRolloutDuringOperations(t *testing.T) {
+	tests := []struct {
+		name         string
+		objects      []client.Object
+		wantImage    string
+		wantDeferred bool
+	}{
+		{
+			name: "restore in progress, rollout deferred",
+			objects: []client.Object{
+				&velerov1.Restore{
+					ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "test-ns"},
+					Status:     velerov1.RestoreStatus{Phase: velerov1.RestorePhaseWaitingForPluginOperations},
+				},
+			},
+			wantImage:    "quay.io/konveyor/velero:old",
+			wantDeferred: true,
+		},
+		{
+			name: "backup completed, rollout applied",
+			objects: []client.Object{
+				&velerov1.Backup{
+					ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "test-ns"},
+					Status:     velerov1.BackupStatus{Phase: velerov1.BackupPhaseCompleted},
+				},
+			},
+			wantImage: getVeleroImage(createTestDryRunDPA()),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := createTestDryRunDPA()
+			dpa.Annotations = nil
+			objects := append(tt.objects, dpa, testGenericInfrastructure, &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              common.Velero,
+					Namespace:         dpa.Namespace,
+					CreationTimestamp: metav1.Now(),
+				},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: getDpaAppLabels(dpa)},
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: common.Velero, Image: "quay.io/konveyor/velero:old"}},
+						},
+					},
+				},
+			})
+			fakeClient, err := getFakeClientFromObjects(objects...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:  fakeClient,
+				Scheme:  fakeClient.Scheme(),
+				Log:     logr.Discard(),
+				Context: newContextForTest(),
+				NamespacedName: types.NamespacedName{
+					Namespace: dpa.Namespace,
+					Name:      dpa.Name,
+				},
+				EventRecorder: record.NewFakeRecorder(10),
+				dpa:           dpa,
+			}
+			if _, err := r.ReconcileInProgressOperations(r.Log); err != nil {
+				t.Fatalf("ReconcileInProgressOperations() unexpected error = %v", err)
+			}
+			if _, err := r.ReconcileVeleroDeployment(r.Log); err != nil {
+				t.Fatalf("ReconcileVeleroDeployment() unexpected error = %v", err)
+			}
+			deployment := &appsv1.Deployment{}
+			if err := fakeClient.Get(r.Context, types.NamespacedName{Namespace: dpa.Namespace, Name: common.Velero}, deployment); err != nil {
+				t.Fatalf("unable to get velero deployment: %v", err)
+			}
+			if image := deployment.Spec.Template.Spec.Containers[0].Image; image != tt.wantImage {
+				t.Errorf("expected velero image %s, got %s", tt.wantImage, image)
+			}
+			requeueAfter := r.updateRolloutDeferredCondition()
+			if tt.wantDeferred {
+				condition := apimeta.FindStatusCondition(r.dpa.Status.Conditions, oadpv1alpha1.ConditionRolloutDeferred)
+				if condition == nil || condition.Reason != oadpv1alpha1.RolloutDeferredOperationsReason {
+					t.Errorf("expected RolloutDeferred condition with reason %s, got %v", oadpv1alpha1.RolloutDeferredOperationsReason, r.dpa.Status.Conditions)
+				}
+				if requeueAfter != operationsRequeueInterval {
+					t.Errorf("expected requeue after %v, got %v", operationsRequeueInterval, requeueAfter)
+				}
+			} else if requeueAfter != 0 {
+				t.Errorf("expected no requeue, got %v", requeueAfter)
+			}
+		})
+	}
+}
+
+func TestValidateAvailability(t *testing.T) {
+	tests := []struct {
+		name         string
+		availability *oadpv1alpha1.Availability
+		wantErr      bool
+	}{
+		{
+			name: "no availability",
+		},
+		{
+			name:         "maxUnavailable",
+			availability: &oadpv1alpha1.Availability{PodDisruptionBudget: &oadpv1alpha1.PodDisruptionBudgetConfig{MaxUnavailable: ptr.To(intstr.FromString("50%"))}},
+		},
+		{
+			name: "minAvailable and maxUnavailable",
+			availability: &oadpv1alpha1.Availability{PodDisruptionBudget: &oadpv1alpha1.PodDisruptionBudgetConfig{
+				MinAvailable:   ptr.To(intstr.FromInt32(1)),
+				MaxUnavailable: ptr.To(intstr.FromInt32(1)),
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateAvailability(tt.availability); (err != nil) != tt.wantErr {
+				t.Errorf("validateAvailability() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -89,9 +89,9 @@ func (r *DataProtectionApplicationReconciler) ReconcileBackupRepositoryConfigMap
 		return true, nil
 	}
 
-	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, &configMap, func() error {
+	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, &configMap, r.withDriftDetection(&configMap, func() error {
 		return r.updateBackupRepositoryCM(&configMap)
-	})
+	}))
 	if err != nil {
 		return false, fmt.Errorf("failed to create or patch config map: %w", err)
 	}
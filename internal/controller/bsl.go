@@ -3,12 +3,17 @@ package controller
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"net/url"
 	"slices"
 	"strings"
 
 	"github.com/go-logr/logr"
 	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	"github.com/vmware-tanzu/velero/pkg/label"
 	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -23,6 +28,9 @@ func (r *DataProtectionApplicationReconciler) ValidateBackupStorageLocations() (
 	// Ensure BSL is a valid configuration
 	// First, check for provider and then call functions based on the cloud provider for each backupstoragelocation configured
 	dpa := r.dpa
+	if err := r.applyBackupLocationProfiles(); err != nil {
+		return false, err
+	}
 	numDefaultLocations := 0
 	for _, bslSpec := range dpa.Spec.BackupLocations {
 		if err := r.ensureBackupLocationHasVeleroOrCloudStorage(&bslSpec); err != nil {
@@ -42,6 +50,9 @@ func (r *DataProtectionApplicationReconciler) ValidateBackupStorageLocations() (
 			} else if bslSpec.Name == "default" {
 				return false, fmt.Errorf("Storage location named 'default' must be set as default")
 			}
+			if dpa.IsRestoreOnly() && bslSpec.Velero.AccessMode == velerov1.BackupStorageLocationAccessModeReadWrite {
+				return false, fmt.Errorf("backup location %s cannot have accessMode %s in mode %s", bslSpec.Name, bslSpec.Velero.AccessMode, oadpv1alpha1.DataProtectionApplicationModeRestoreOnly)
+			}
 			provider := bslSpec.Velero.Provider
 			if len(provider) == 0 {
 				return false, fmt.Errorf("no provider specified for one of the backupstoragelocations configured")
@@ -50,7 +61,7 @@ func (r *DataProtectionApplicationReconciler) ValidateBackupStorageLocations() (
 			// TODO: cases might need some updates for IBM/Minio/noobaa
 			switch provider {
 			case AWSProvider, "velero.io/aws":
-				err := r.validateAWSBackupStorageLocation(*bslSpec.Velero)
+				err := r.validateAWSBackupStorageLocation(*bslSpec.Velero, bslSpec.Profile)
 				if err != nil {
 					return false, err
 				}
@@ -76,9 +87,25 @@ func (r *DataProtectionApplicationReconciler) ValidateBackupStorageLocations() (
 			}
 		}
 	}
+	// a pending ObjectBucketClaim location is the default location once bound
+	for _, location := range r.pendingBackupLocations {
+		if location.ObjectBucketClaim.Default {
+			numDefaultLocations++
+		}
+	}
 	if numDefaultLocations > 1 {
 		return false, fmt.Errorf("Only one Storage Location be set as default")
 	}
+	names, _, err := r.getBackupLocationNames()
+	if err != nil {
+		return false, err
+	}
+	if err := validateBackupLocationRenames(dpa, names); err != nil {
+		return false, err
+	}
+	if err := validateBackupLocationFailover(dpa); err != nil {
+		return false, err
+	}
 	if numDefaultLocations == 0 && !dpa.Spec.Configuration.Velero.NoDefaultBackupLocation {
 		return false, errors.New("no default backupstoragelocations configured, ensure that one backupstoragelocation has been configured as the default location")
 	}
@@ -89,19 +116,18 @@ func (r *DataProtectionApplicationReconciler) ValidateBackupStorageLocations() (
 
 func (r *DataProtectionApplicationReconciler) ReconcileBackupStorageLocations(log logr.Logger) (bool, error) {
 	dpa := r.dpa
-	dpaBSLNames := []string{}
+	dpaBSLNames, generatedNames, err := r.getBackupLocationNames()
+	if err != nil {
+		return false, err
+	}
+	dpa.Status.BackupLocationNames = generatedNames
 
 	// Loop through all configured BSLs
+	credentialsSecretNames := []string{}
 	for i, bslSpec := range dpa.Spec.BackupLocations {
 		// Create BSL as is, we can safely assume they are valid from
 		// ValidateBackupStorageLocations
-
-		// check if BSL name is specified in DPA spec
-		bslName := fmt.Sprintf("%s-%d", r.NamespacedName.Name, i+1)
-		if bslSpec.Name != "" {
-			bslName = bslSpec.Name
-		}
-		dpaBSLNames = append(dpaBSLNames, bslName)
+		bslName := dpaBSLNames[i]
 
 		bsl := velerov1.BackupStorageLocation{
 			ObjectMeta: metav1.ObjectMeta{
@@ -126,17 +152,26 @@ func (r *DataProtectionApplicationReconciler) ReconcileBackupStorageLocations(lo
 			return false, err
 		}
 
+		// Render the location config the credentials lack, for example the AWS region, in a secret of the BSL
+		credential, err := r.reconcileBackupLocationCredentials(bslName, bslSpec)
+		if err != nil {
+			return false, err
+		}
+		if credential != nil {
+			credentialsSecretNames = append(credentialsSecretNames, credential.Name)
+		}
+
 		// Create BSL
-		op, err := controllerutil.CreateOrPatch(r.Context, r.Client, &bsl, func() error {
+		op, err := controllerutil.CreateOrPatch(r.Context, r.Client, &bsl, r.withDriftDetection(&bsl, func() error {
 			// TODO: Velero may be setting controllerReference as
 			// well and taking ownership. If so move this to
 			// SetOwnerReference instead
 
-			// TODO: check for BSL status condition errors and respond here
+			// BSL status is reflected in the DPA status by updateBackupLocationsStatus
 			if bslSpec.Velero != nil {
-				err := r.updateBSLFromSpec(&bsl, *bslSpec.Velero)
-
-				return err
+				if err := r.updateBSLFromSpec(&bsl, *bslSpec.Velero); err != nil {
+					return err
+				}
 			}
 			if bslSpec.CloudStorage != nil {
 				bucket := &oadpv1alpha1.CloudStorage{}
@@ -174,8 +209,20 @@ func (r *DataProtectionApplicationReconciler) ReconcileBackupStorageLocations(lo
 					return fmt.Errorf("invalid provider")
 				}
 			}
+			if credential != nil {
+				bsl.Spec.Credential = credential
+			}
+			// the default backup storage location follows the active location of the failover
+			if failover := dpa.Spec.BackupLocationFailover; failover != nil && dpa.Status.BackupLocationFailover != nil &&
+				(bslName == failover.Primary || bslName == failover.Secondary) {
+				bsl.Spec.Default = bslName == dpa.Status.BackupLocationFailover.Active
+			}
+			// a restore only cluster never writes into the backup storage locations
+			if dpa.IsRestoreOnly() {
+				bsl.Spec.AccessMode = velerov1.BackupStorageLocationAccessModeReadOnly
+			}
 			return nil
-		})
+		}))
 		if err != nil {
 			return false, err
 		}
@@ -187,14 +234,9 @@ func (r *DataProtectionApplicationReconciler) ReconcileBackupStorageLocations(lo
 				fmt.Sprintf("performed %s on backupstoragelocation %s/%s", op, bsl.Namespace, bsl.Name),
 			)
 		}
-
-		// Patch secrets with BSL-specific configuration (only for the first BSL)
-		if i == 0 {
-			if err := r.patchSecretsForBSL(&bsl, bslSpec); err != nil {
-				r.Log.Error(err, "Failed to patch secret for BSL", "bsl", bsl.Name)
-				// Don't return error as this is an enhancement, log and continue
-			}
-		}
+	}
+	if err := r.deleteStaleLocationCredentialsSecrets(bslCredentialsComponent, credentialsSecretNames); err != nil {
+		return false, err
 	}
 
 	dpaBSLs := velerov1.BackupStorageLocationList{}
@@ -203,27 +245,291 @@ func (r *DataProtectionApplicationReconciler) ReconcileBackupStorageLocations(lo
 		"app.kubernetes.io/managed-by": common.OADPOperator,
 		"app.kubernetes.io/component":  "bsl",
 	}
-	err := r.List(r.Context, &dpaBSLs, client.InNamespace(r.NamespacedName.Namespace), client.MatchingLabels(dpaBSLLabels))
+	err = r.List(r.Context, &dpaBSLs, client.InNamespace(r.NamespacedName.Namespace), client.MatchingLabels(dpaBSLLabels))
 	if err != nil {
 		return false, err
 	}
-	// If current BSLs do not match the spec, delete extra BSLs
-	if len(dpaBSLNames) != len(dpaBSLs.Items) {
+	// Delete the BSLs removed from the spec, unless Backups or Schedules still reference them
+	renamedBSLs := map[string]string{}
+	for i, bslSpec := range dpa.Spec.BackupLocations {
+		if bslSpec.PreviousName != "" {
+			renamedBSLs[bslSpec.PreviousName] = dpaBSLNames[i]
+		}
+	}
+	blockedBSLs := []string{}
+	for _, bsl := range dpaBSLs.Items {
+		if slices.Contains(dpaBSLNames, bsl.Name) {
+			continue
+		}
+		if newName, ok := renamedBSLs[bsl.Name]; ok {
+			if err := r.renameBackupStorageLocationReferences(bsl.Name, newName); err != nil {
+				return false, err
+			}
+		} else if dpa.Annotations[common.ForceBackupLocationDeletionAnnotation] != "true" {
+			references, err := r.getBackupStorageLocationReferences(bsl.Name)
+			if err != nil {
+				return false, err
+			}
+			if len(references) > 0 {
+				blockedBSLs = append(blockedBSLs, fmt.Sprintf("%s (%s)", bsl.Name, strings.Join(references, ", ")))
+				continue
+			}
+		}
+		if err := r.Delete(r.Context, &bsl); err != nil && !k8serror.IsNotFound(err) {
+			return false, err
+		}
+		// Record event for BSL deletion
+		r.EventRecorder.Event(&bsl,
+			corev1.EventTypeNormal,
+			"BackupStorageLocationDeleted",
+			fmt.Sprintf("BackupStorageLocation %s created by OADP in namespace %s was deleted as it was not in DPA spec.", bsl.Name, bsl.Namespace))
+	}
+
+	if len(blockedBSLs) == 0 {
+		apimeta.RemoveStatusCondition(&dpa.Status.Conditions, oadpv1alpha1.ConditionBackupLocationDeletionBlocked)
+		return true, nil
+	}
+	message := fmt.Sprintf("BackupStorageLocations removed from the DPA spec are not deleted as they are still referenced: %s. "+
+		"Rename them with previousName, delete the references, or set the %s annotation to \"true\" to delete them anyway",
+		strings.Join(blockedBSLs, "; "), common.ForceBackupLocationDeletionAnnotation)
+	if apimeta.SetStatusCondition(&dpa.Status.Conditions, metav1.Condition{
+		Type:    oadpv1alpha1.ConditionBackupLocationDeletionBlocked,
+		Status:  metav1.ConditionTrue,
+		Reason:  oadpv1alpha1.BackupLocationDeletionBlockedReason,
+		Message: message,
+	}) {
+		r.EventRecorder.Event(dpa, corev1.EventTypeWarning, "BackupStorageLocationDeletionBlocked", message)
+	}
+	return true, nil
+}
+
+// getBackupStorageLocationReferences describes the Backups and Schedules referencing the BSL bslName
+func (r *DataProtectionApplicationReconciler) getBackupStorageLocationReferences(bslName string) ([]string, error) {
+	backups := &velerov1.BackupList{}
+	if err := r.List(r.Context, backups, client.InNamespace(r.NamespacedName.Namespace)); err != nil {
+		return nil, err
+	}
+	schedules := &velerov1.ScheduleList{}
+	if err := r.List(r.Context, schedules, client.InNamespace(r.NamespacedName.Namespace)); err != nil {
+		return nil, err
+	}
+
+	references := []string{}
+	backupCount := 0
+	for _, backup := range backups.Items {
+		if backupReferencesStorageLocation(&backup, bslName) {
+			backupCount++
+		}
+	}
+	if backupCount > 0 {
+		references = append(references, fmt.Sprintf("%d Backups", backupCount))
+	}
+	scheduleCount := 0
+	for _, schedule := range schedules.Items {
+		if schedule.Spec.Template.StorageLocation == bslName {
+			scheduleCount++
+		}
+	}
+	if scheduleCount > 0 {
+		references = append(references, fmt.Sprintf("%d Schedules", scheduleCount))
+	}
+	return references, nil
+}
+
+// renameBackupStorageLocationReferences updates the Backups and Schedules referencing the BSL oldName to
+// reference the BSL newName
+func (r *DataProtectionApplicationReconciler) renameBackupStorageLocationReferences(oldName, newName string) error {
+	backups := &velerov1.BackupList{}
+	if err := r.List(r.Context, backups, client.InNamespace(r.NamespacedName.Namespace)); err != nil {
+		return err
+	}
+	for i := range backups.Items {
+		backup := &backups.Items[i]
+		if !backupReferencesStorageLocation(backup, oldName) {
+			continue
+		}
+		patch := client.MergeFrom(backup.DeepCopy())
+		backup.Spec.StorageLocation = newName
+		if backup.Labels == nil {
+			backup.Labels = map[string]string{}
+		}
+		backup.Labels[velerov1.StorageLocationLabel] = label.GetValidName(newName)
+		if err := r.Patch(r.Context, backup, patch); err != nil {
+			return fmt.Errorf("unable to rename the storage location of Backup %s from %s to %s: %w", backup.Name, oldName, newName, err)
+		}
+	}
+
+	schedules := &velerov1.ScheduleList{}
+	if err := r.List(r.Context, schedules, client.InNamespace(r.NamespacedName.Namespace)); err != nil {
+		return err
+	}
+	for i := range schedules.Items {
+		schedule := &schedules.Items[i]
+		if schedule.Spec.Template.StorageLocation != oldName {
+			continue
+		}
+		patch := client.MergeFrom(schedule.DeepCopy())
+		schedule.Spec.Template.StorageLocation = newName
+		if err := r.Patch(r.Context, schedule, patch); err != nil {
+			return fmt.Errorf("unable to rename the storage location of Schedule %s from %s to %s: %w", schedule.Name, oldName, newName, err)
+		}
+	}
+	r.EventRecorder.Event(r.dpa,
+		corev1.EventTypeNormal,
+		"BackupStorageLocationRenamed",
+		fmt.Sprintf("Backups and Schedules referencing BackupStorageLocation %s now reference %s", oldName, newName),
+	)
+	return nil
+}
+
+// backupReferencesStorageLocation returns true if the backup is stored in the BSL bslName
+func backupReferencesStorageLocation(backup *velerov1.Backup, bslName string) bool {
+	return backup.Spec.StorageLocation == bslName || backup.Labels[velerov1.StorageLocationLabel] == label.GetValidName(bslName)
+}
+
+// validateBackupLocationRenames checks the previousName of the backup locations do not reference
+// a location of the spec, and that each location is renamed once. names are the BSL names of the locations.
+func validateBackupLocationRenames(dpa *oadpv1alpha1.DataProtectionApplication, names []string) error {
+	previousNames := []string{}
+	for i, bslSpec := range dpa.Spec.BackupLocations {
+		if bslSpec.PreviousName == "" {
+			continue
+		}
+		if slices.Contains(names, bslSpec.PreviousName) {
+			return fmt.Errorf("previousName %s of backup location %s is the name of a backup location of the DPA", bslSpec.PreviousName, names[i])
+		}
+		if slices.Contains(previousNames, bslSpec.PreviousName) {
+			return fmt.Errorf("previousName %s is set on more than one backup location", bslSpec.PreviousName)
+		}
+		previousNames = append(previousNames, bslSpec.PreviousName)
+	}
+	return nil
+}
+
+// getBackupLocationNames returns the BSL names of the backup locations of the spec, and the names generated
+// for the locations without name. A location without name keeps the name recorded for its storage in
+// status.backupLocationNames, or adopts the name of the existing DPA BSL with the same storage, so names do
+// not depend on the order of spec.backupLocations. Other locations are named <dpa name>-<n> with the lowest
+// unused n.
+func (r *DataProtectionApplicationReconciler) getBackupLocationNames() ([]string, []oadpv1alpha1.BackupLocationName, error) {
+	dpa := r.dpa
+	names := make([]string, len(dpa.Spec.BackupLocations))
+	taken := map[string]bool{}
+	storages := map[string]int{}
+	for i, bslSpec := range dpa.Spec.BackupLocations {
+		if bslSpec.Name != "" {
+			names[i] = bslSpec.Name
+			taken[bslSpec.Name] = true
+			continue
+		}
+		storage := getBackupLocationStorage(bslSpec)
+		if previous, ok := storages[storage]; ok {
+			return nil, nil, fmt.Errorf("backup locations %d and %d use the same storage %s, set their name", previous+1, i+1, storage)
+		}
+		storages[storage] = i
+	}
+
+	dpaBSLs := &velerov1.BackupStorageLocationList{}
+	if len(storages) > 0 {
+		if err := r.List(r.Context, dpaBSLs, client.InNamespace(dpa.Namespace)); err != nil {
+			return nil, nil, err
+		}
+	}
+	// recorded names first, then adopted names, so generated names do not take them
+	for i, bslSpec := range dpa.Spec.BackupLocations {
+		if bslSpec.Name != "" {
+			continue
+		}
+		storage := getBackupLocationStorage(bslSpec)
+		for _, recorded := range dpa.Status.BackupLocationNames {
+			if recorded.Storage == storage && !taken[recorded.Name] {
+				names[i] = recorded.Name
+				taken[recorded.Name] = true
+				break
+			}
+		}
+	}
+	for i, bslSpec := range dpa.Spec.BackupLocations {
+		if names[i] != "" {
+			continue
+		}
 		for _, bsl := range dpaBSLs.Items {
-			if !slices.Contains(dpaBSLNames, bsl.Name) {
-				if err := r.Delete(r.Context, &bsl); err != nil {
-					return false, err
-				}
-				// Record event for BSL deletion
-				r.EventRecorder.Event(&bsl,
-					corev1.EventTypeNormal,
-					"BackupStorageLocationDeleted",
-					fmt.Sprintf("BackupStorageLocation %s created by OADP in namespace %s was deleted as it was not in DPA spec.", bsl.Name, bsl.Namespace))
+			if taken[bsl.Name] || !metav1.IsControlledBy(&bsl, dpa) {
+				continue
+			}
+			matches, err := r.backupStorageLocationMatches(&bsl, bslSpec)
+			if err != nil {
+				return nil, nil, err
+			}
+			if matches {
+				names[i] = bsl.Name
+				taken[bsl.Name] = true
+				break
+			}
+		}
+	}
+	generated := []oadpv1alpha1.BackupLocationName{}
+	for i, bslSpec := range dpa.Spec.BackupLocations {
+		if bslSpec.Name != "" {
+			continue
+		}
+		for n := 1; names[i] == ""; n++ {
+			name := fmt.Sprintf("%s-%d", dpa.Name, n)
+			if !taken[name] && !slices.ContainsFunc(dpaBSLs.Items, func(bsl velerov1.BackupStorageLocation) bool { return bsl.Name == name }) {
+				names[i] = name
+				taken[name] = true
 			}
 		}
+		generated = append(generated, oadpv1alpha1.BackupLocationName{Name: names[i], Storage: getBackupLocationStorage(bslSpec)})
+	}
+	if len(generated) == 0 {
+		generated = nil
 	}
+	return names, generated, nil
+}
 
-	return true, nil
+// getBackupLocationStorage identifies the storage of a backup location, as provider:bucket/prefix followed by the
+// s3Url and region of the config when set, since S3 compatible endpoints commonly use the same bucket names
+func getBackupLocationStorage(bslSpec oadpv1alpha1.BackupLocation) string {
+	if bslSpec.CloudStorage != nil {
+		return fmt.Sprintf("cloudstorage:%s/%s", bslSpec.CloudStorage.CloudStorageRef.Name, bslSpec.CloudStorage.Prefix)
+	}
+	if bslSpec.Velero != nil {
+		return getBackupStorageLocationStorage(bslSpec.Velero)
+	}
+	return ""
+}
+
+func getBackupStorageLocationStorage(bslSpec *velerov1.BackupStorageLocationSpec) string {
+	bucket, prefix := "", ""
+	if bslSpec.ObjectStorage != nil {
+		bucket, prefix = bslSpec.ObjectStorage.Bucket, bslSpec.ObjectStorage.Prefix
+	}
+	storage := fmt.Sprintf("%s:%s/%s", bslSpec.Provider, bucket, prefix)
+	for _, key := range []string{S3URL, Region} {
+		if value := bslSpec.Config[key]; value != "" {
+			storage += fmt.Sprintf(",%s=%s", key, value)
+		}
+	}
+	return storage
+}
+
+// backupStorageLocationMatches returns true if the BSL stores backups in the storage of the backup location
+func (r *DataProtectionApplicationReconciler) backupStorageLocationMatches(bsl *velerov1.BackupStorageLocation, bslSpec oadpv1alpha1.BackupLocation) (bool, error) {
+	if bslSpec.Velero != nil {
+		return getBackupStorageLocationStorage(&bsl.Spec) == getBackupStorageLocationStorage(bslSpec.Velero), nil
+	}
+	if bslSpec.CloudStorage == nil || bsl.Spec.ObjectStorage == nil {
+		return false, nil
+	}
+	bucket := &oadpv1alpha1.CloudStorage{}
+	if err := r.Get(r.Context, client.ObjectKey{Namespace: r.dpa.Namespace, Name: bslSpec.CloudStorage.CloudStorageRef.Name}, bucket); err != nil {
+		if k8serror.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return bsl.Spec.ObjectStorage.Bucket == bucket.Spec.Name && bsl.Spec.ObjectStorage.Prefix == bslSpec.CloudStorage.Prefix, nil
 }
 
 func (r *DataProtectionApplicationReconciler) UpdateCredentialsSecretLabels(secretName string, dpaName string) error {
@@ -266,6 +572,20 @@ func (r *DataProtectionApplicationReconciler) updateBSLFromSpec(bsl *velerov1.Ba
 		return err
 	}
 
+	// The AWS plugin sends CRC32 checksums when checksumAlgorithm is not set, which S3 compatible vendors may reject.
+	// The default of the vendor is set here, common.UpdateBackupStorageLocation disables the checksums otherwise.
+	// While the vendor cannot be detected, the BSL keeps its checksumAlgorithm.
+	if strings.TrimPrefix(bslSpec.Provider, veleroIOPrefix) == AWSProvider && bslSpec.Config != nil {
+		if _, set := bslSpec.Config[checksumAlgorithm]; !set {
+			algorithm, resolved := r.getDefaultChecksumAlgorithm(bslSpec)
+			if current, found := bsl.Spec.Config[checksumAlgorithm]; !resolved && found {
+				algorithm = current
+			}
+			bslSpec.Config = maps.Clone(bslSpec.Config)
+			bslSpec.Config[checksumAlgorithm] = algorithm
+		}
+	}
+
 	// Update BSL spec and registry-deployment label
 	if err := common.UpdateBackupStorageLocation(bsl, bslSpec); err != nil {
 		return err
@@ -282,7 +602,7 @@ func (r *DataProtectionApplicationReconciler) updateBSLFromSpec(bsl *velerov1.Ba
 	return nil
 }
 
-func (r *DataProtectionApplicationReconciler) validateAWSBackupStorageLocation(bslSpec velerov1.BackupStorageLocationSpec) error {
+func (r *DataProtectionApplicationReconciler) validateAWSBackupStorageLocation(bslSpec velerov1.BackupStorageLocationSpec, profile oadpv1alpha1.BackupLocationProfile) error {
 	// validate provider plugin and secret
 	err := r.validateProviderPluginAndSecret(bslSpec)
 	if err != nil {
@@ -311,6 +631,24 @@ func (r *DataProtectionApplicationReconciler) validateAWSBackupStorageLocation(b
 		return fmt.Errorf("region for AWS backupstoragelocation not automatically discoverable. Please set the region in the backupstoragelocation config")
 	}
 
+	if err := r.validateChecksumAlgorithm(bslSpec); err != nil {
+		return err
+	}
+
+	// the S3 compatible vendors of the profiles have no default endpoint, see applyBackupLocationProfiles
+	if profile != "" {
+		s3Url := bslSpec.Config[S3URL]
+		if len(s3Url) == 0 {
+			if profile == oadpv1alpha1.BackupLocationProfileODF {
+				return fmt.Errorf("s3Url for AWS backupstoragelocation with profile %s cannot be empty, the NooBaa S3 route %s/%s was not found", profile, noobaaS3RouteNamespace, noobaaS3RouteName)
+			}
+			return fmt.Errorf("s3Url for AWS backupstoragelocation with profile %s cannot be empty", profile)
+		}
+		if parsed, err := url.Parse(s3Url); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("s3Url %s for AWS backupstoragelocation with profile %s must be an http or https URL", s3Url, profile)
+		}
+	}
+
 	//TODO: Add minio, noobaa, local storage validations
 
 	return nil
@@ -510,185 +848,3 @@ func (r *DataProtectionApplicationReconciler) ensureSecretDataExists(bsl *oadpv1
 
 	return nil
 }
-
-// patchSecretsForBSL patches cloud provider secrets with BSL-specific configuration
-func (r *DataProtectionApplicationReconciler) patchSecretsForBSL(bsl *velerov1.BackupStorageLocation, bslSpec oadpv1alpha1.BackupLocation) error {
-	// Determine provider and secret details
-	var provider, secretName string
-	var bslConfig map[string]string
-
-	if bslSpec.Velero != nil {
-		provider = string(bslSpec.Velero.Provider)
-		secretName, _, _ = r.getSecretNameAndKey(bslSpec.Velero.Config, bslSpec.Velero.Credential, oadpv1alpha1.DefaultPlugin(bslSpec.Velero.Provider))
-		bslConfig = bslSpec.Velero.Config
-	} else if bslSpec.CloudStorage != nil {
-		// For CloudStorage, get provider from the CloudStorage resource
-		bucket := &oadpv1alpha1.CloudStorage{}
-		err := r.Get(r.Context, client.ObjectKey{Namespace: bsl.Namespace, Name: bslSpec.CloudStorage.CloudStorageRef.Name}, bucket)
-		if err != nil {
-			return err
-		}
-		switch bucket.Spec.Provider {
-		case oadpv1alpha1.AWSBucketProvider:
-			provider = AWSProvider
-		case oadpv1alpha1.AzureBucketProvider:
-			provider = AzureProvider
-		case oadpv1alpha1.GCPBucketProvider:
-			provider = GCPProvider
-		}
-		secretName, _, _ = r.getSecretNameAndKeyFromCloudStorage(bslSpec.CloudStorage)
-		bslConfig = bslSpec.CloudStorage.Config
-	}
-
-	if secretName == "" {
-		// No secret to patch
-		return nil
-	}
-
-	// Get the secret
-	secret := &corev1.Secret{}
-	if err := r.Get(r.Context, client.ObjectKey{Name: secretName, Namespace: bsl.Namespace}, secret); err != nil {
-		return fmt.Errorf("failed to get secret %s: %w", secretName, err)
-	}
-
-	// Patch based on provider
-	switch provider {
-	case AWSProvider:
-		return r.patchAWSSecretWithRegion(secret, bslSpec, bslConfig)
-	case AzureProvider:
-		return r.patchAzureSecretWithResourceGroup(secret, bslConfig)
-	}
-
-	return nil
-}
-
-// patchAWSSecretWithRegion patches AWS secret with region from BSL config or bucket discovery
-func (r *DataProtectionApplicationReconciler) patchAWSSecretWithRegion(secret *corev1.Secret, bslSpec oadpv1alpha1.BackupLocation, bslConfig map[string]string) error {
-	// Check if this is an STS secret by looking for STS-specific key
-	// AWS STS secrets created by stsflow use "credentials" key
-	credData, exists := secret.Data["credentials"]
-	if !exists {
-		// Not an STS secret, skip
-		return nil
-	}
-
-	credString := string(credData)
-	// Check if this is an STS secret by looking for role_arn and web_identity_token_file
-	isSTS := strings.Contains(credString, "role_arn") && strings.Contains(credString, "web_identity_token_file")
-	if !isSTS {
-		// Not an STS secret, skip patching
-		return nil
-	}
-
-	// Check if region is already in the credentials
-	// Look for region as a separate config line (not part of another word like "role_region")
-	if strings.Contains(credString, "\nregion =") || strings.HasPrefix(credString, "region =") {
-		// Region already set
-		return nil
-	}
-
-	var region string
-	var bucket string
-
-	// Get region from BSL config if available
-	if bslConfig != nil && bslConfig[Region] != "" {
-		region = bslConfig[Region]
-	} else {
-		// Try to discover region from bucket
-		if bslSpec.Velero != nil && bslSpec.Velero.ObjectStorage != nil {
-			bucket = bslSpec.Velero.ObjectStorage.Bucket
-		} else if bslSpec.CloudStorage != nil {
-			// For CloudStorage, get bucket from the CloudStorage resource
-			cloudStorage := &oadpv1alpha1.CloudStorage{}
-			err := r.Get(r.Context, client.ObjectKey{Namespace: secret.Namespace, Name: bslSpec.CloudStorage.CloudStorageRef.Name}, cloudStorage)
-			if err == nil {
-				bucket = cloudStorage.Spec.Name
-			}
-		}
-
-		if bucket != "" && !strings.Contains(bucket, "/") {
-			// Try to discover region
-			discoveredRegion, err := aws.GetBucketRegion(bucket)
-			if err == nil && discoveredRegion != "" {
-				region = discoveredRegion
-			}
-		}
-	}
-
-	if region == "" {
-		// No region found, skip patching
-		return nil
-	}
-
-	// Add region to credentials
-	updatedCreds := credString
-	if strings.HasSuffix(credString, "\n") {
-		updatedCreds = credString + "region = " + region
-	} else {
-		updatedCreds = credString + "\nregion = " + region
-	}
-
-	// Update secret using the "credentials" key (as used by AWS STS secrets)
-	secretCopy := secret.DeepCopy()
-	secretCopy.Data["credentials"] = []byte(updatedCreds)
-
-	if err := r.Patch(r.Context, secretCopy, client.MergeFrom(secret)); err != nil {
-		return fmt.Errorf("failed to patch AWS secret with region: %w", err)
-	}
-
-	r.Log.Info("Patched AWS secret with region", "secret", secret.Name, "region", region)
-	return nil
-}
-
-// patchAzureSecretWithResourceGroup patches Azure secret with resource group from BSL config
-func (r *DataProtectionApplicationReconciler) patchAzureSecretWithResourceGroup(secret *corev1.Secret, bslConfig map[string]string) error {
-	// Check if this is an STS secret by looking for STS-specific key
-	// Azure STS secrets created by stsflow use "azurekey" key
-	azureKeyData, exists := secret.Data["azurekey"]
-	if !exists {
-		// Not an STS secret, skip
-		return nil
-	}
-
-	azureKeyString := string(azureKeyData)
-	// Check if this is an STS secret by looking for AZURE_CLIENT_ID without AZURE_CLIENT_SECRET
-	hasClientID := strings.Contains(azureKeyString, "AZURE_CLIENT_ID")
-	hasClientSecret := strings.Contains(azureKeyString, "AZURE_CLIENT_SECRET")
-	isSTS := hasClientID && !hasClientSecret
-	if !isSTS {
-		// Not an STS secret, skip patching
-		return nil
-	}
-
-	// Check if resource group is already in the credentials
-	if strings.Contains(azureKeyString, "AZURE_RESOURCE_GROUP=") {
-		// Resource group already set
-		return nil
-	}
-
-	// Get resource group from BSL config
-	if bslConfig == nil || bslConfig[ResourceGroup] == "" {
-		// No resource group in BSL config
-		return nil
-	}
-
-	resourceGroup := bslConfig[ResourceGroup]
-
-	// Add resource group to credentials
-	updatedCreds := azureKeyString
-	if !strings.HasSuffix(azureKeyString, "\n") {
-		updatedCreds = azureKeyString + "\n"
-	}
-	updatedCreds = updatedCreds + "AZURE_RESOURCE_GROUP=" + resourceGroup + "\n"
-
-	// Update secret using the "azurekey" key (as used by Azure STS secrets)
-	secretCopy := secret.DeepCopy()
-	secretCopy.Data["azurekey"] = []byte(updatedCreds)
-
-	if err := r.Patch(r.Context, secretCopy, client.MergeFrom(secret)); err != nil {
-		return fmt.Errorf("failed to patch Azure secret with resource group: %w", err)
-	}
-
-	r.Log.Info("Patched Azure secret with resource group", "secret", secret.Name, "resourceGroup", resourceGroup)
-	return nil
-}
@@ -0,0 +1,226 @@
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+const (
+	// backupLocationsMinRequeueInterval and backupLocationsMaxRequeueInterval bound the backoff of the reconciles
+	// while backup storage locations are unavailable
+	backupLocationsMinRequeueInterval = 30 * time.Second
+	backupLocationsMaxRequeueInterval = 10 * time.Minute
+	backupLocationProbeTimeout        = 10 * time.Second
+	// maxClockSkew is the clock skew tolerated by S3 request signatures
+	maxClockSkew = 15 * time.Minute
+)
+
+// backupLocationFailurePatterns maps substrings of the messages reported by Velero for unavailable
+// BackupStorageLocations to failure reasons. They are checked in order, as a clock skew or a missing
+// bucket is also reported with an authorization error code by some providers.
+var backupLocationFailurePatterns = []struct {
+	reason   string
+	patterns []string
+}{
+	{oadpv1alpha1.BackupLocationFailureReasonClockSkew, []string{"RequestTimeTooSkewed", "clock skew", "Signature expired", "RequestExpired"}},
+	{oadpv1alpha1.BackupLocationFailureReasonBucketNotFound, []string{"NoSuchBucket", "ContainerNotFound", "bucket does not exist", "bucket doesn't exist"}},
+	{oadpv1alpha1.BackupLocationFailureReasonTLS, []string{"x509:", "tls:", "certificate"}},
+	{oadpv1alpha1.BackupLocationFailureReasonDNS, []string{"no such host", "server misbehaving"}},
+	{oadpv1alpha1.BackupLocationFailureReasonAuthentication, []string{
+		"InvalidAccessKeyId", "SignatureDoesNotMatch", "AccessDenied", "ExpiredToken", "InvalidToken", "WebIdentityErr",
+		"NoCredentialProviders", "AuthorizationFailed", "AuthenticationFailed", "AuthorizationPermissionMismatch",
+		"invalid_grant", "Unauthorized", "403",
+	}},
+	{oadpv1alpha1.BackupLocationFailureReasonNetwork, []string{"connection refused", "connection reset", "no route to host", "i/o timeout", "deadline exceeded"}},
+}
+
+// classifyBackupStorageLocationMessage returns the failure reason of an unavailable BackupStorageLocation
+// from the message reported by Velero
+func classifyBackupStorageLocationMessage(message string) string {
+	for _, failure := range backupLocationFailurePatterns {
+		for _, pattern := range failure.patterns {
+			if strings.Contains(message, pattern) {
+				return failure.reason
+			}
+		}
+	}
+	return oadpv1alpha1.BackupLocationFailureReasonUnknown
+}
+
+// getBackupStorageLocationFailure returns the failure reason and probe message of the unavailable BSL.
+// The reason is classified from the Velero message, or from the probe when the message is not known.
+// previous is the status of the BSL in the DPA: the BSL is only probed again once Velero validated it again.
+func (r *DataProtectionApplicationReconciler) getBackupStorageLocationFailure(bsl *velerov1.BackupStorageLocation, previous *oadpv1alpha1.BackupStorageLocationStatus) (string, string) {
+	reason := classifyBackupStorageLocationMessage(bsl.Status.Message)
+	if r.dpa.Spec.BackupLocationProbe == nil || !r.dpa.Spec.BackupLocationProbe.Enable {
+		return reason, ""
+	}
+	if previous != nil && previous.Phase == velerov1.BackupStorageLocationPhaseUnavailable && previous.ProbeMessage != "" &&
+		previous.LastValidationTime.Equal(bsl.Status.LastValidationTime) {
+		if reason == oadpv1alpha1.BackupLocationFailureReasonUnknown && previous.FailureReason != "" {
+			reason = previous.FailureReason
+		}
+		return reason, previous.ProbeMessage
+	}
+	probeReason, probeMessage := r.probeBackupStorageLocation(bsl)
+	if reason == oadpv1alpha1.BackupLocationFailureReasonUnknown && probeReason != "" {
+		reason = probeReason
+	}
+	return reason, probeMessage
+}
+
+// probeBackupStorageLocation checks the endpoint of the BSL from the operator, like a DataProtectionTest.
+// It sends an anonymous request to the bucket and classifies DNS, TLS and network errors, a missing bucket
+// and a clock skew with the endpoint. It returns an empty reason when the probe does not find a failure.
+func (r *DataProtectionApplicationReconciler) probeBackupStorageLocation(bsl *velerov1.BackupStorageLocation) (string, string) {
+	endpoint := getBackupStorageLocationEndpoint(&bsl.Spec)
+	if endpoint == "" || bsl.Spec.ObjectStorage == nil {
+		return "", fmt.Sprintf("probe not supported for provider %s", bsl.Spec.Provider)
+	}
+	url := strings.TrimSuffix(endpoint, "/") + "/" + bsl.Spec.ObjectStorage.Bucket
+
+	ctx, cancel := context.WithTimeout(r.Context, backupLocationProbeTimeout)
+	defer cancel()
+	httpClient, err := r.getBackupStorageLocationProbeClient(ctx, bsl)
+	if err != nil {
+		return "", fmt.Sprintf("unable to probe %s: %v", url, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return "", fmt.Sprintf("unable to probe %s: %v", url, err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return classifyProbeError(err), fmt.Sprintf("request to %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		if skew := time.Since(date); skew > maxClockSkew || skew < -maxClockSkew {
+			return oadpv1alpha1.BackupLocationFailureReasonClockSkew,
+				fmt.Sprintf("clock of the endpoint %s differs by %s from the cluster clock", endpoint, skew.Round(time.Second))
+		}
+	}
+	// anonymous requests to private Azure containers also return 404
+	if resp.StatusCode == http.StatusNotFound && !strings.HasSuffix(bsl.Spec.Provider, AzureProvider) {
+		return oadpv1alpha1.BackupLocationFailureReasonBucketNotFound, fmt.Sprintf("bucket %s not found at %s", bsl.Spec.ObjectStorage.Bucket, endpoint)
+	}
+	return "", fmt.Sprintf("endpoint %s is reachable, anonymous request to the bucket returned %s", endpoint, resp.Status)
+}
+
+// getBackupStorageLocationProbeClient returns an HTTP client trusting the trusted CA bundle of the namespace
+// and the CA certificate of the BSL, not following redirects
+func (r *DataProtectionApplicationReconciler) getBackupStorageLocationProbeClient(ctx context.Context, bsl *velerov1.BackupStorageLocation) (*http.Client, error) {
+	trustedClient, err := trustedHTTPClient(ctx, r.Client, r.dpa.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if trustedTransport, ok := trustedClient.Transport.(*http.Transport); ok {
+		transport = trustedTransport.Clone()
+	}
+	if caCert := bsl.Spec.ObjectStorage.CACert; len(caCert) > 0 {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		rootCAs := transport.TLSClientConfig.RootCAs
+		if rootCAs == nil {
+			if rootCAs, err = x509.SystemCertPool(); err != nil {
+				rootCAs = x509.NewCertPool()
+			}
+		}
+		if !rootCAs.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificates found in the caCert of BackupStorageLocation %s", bsl.Name)
+		}
+		transport.TLSClientConfig.RootCAs = rootCAs
+	}
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}, nil
+}
+
+// getBackupStorageLocationEndpoint returns the object storage endpoint of the BSL, empty if it is not known
+func getBackupStorageLocationEndpoint(bslSpec *velerov1.BackupStorageLocationSpec) string {
+	switch strings.TrimPrefix(bslSpec.Provider, veleroIOPrefix) {
+	case AWSProvider:
+		if s3Url := bslSpec.Config[S3URL]; s3Url != "" {
+			return s3Url
+		}
+		region := bslSpec.Config[Region]
+		if region == "" {
+			region = "us-east-1"
+		}
+		return fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	case GCPProvider:
+		return "https://storage.googleapis.com"
+	case AzureProvider:
+		if storageAccount := bslSpec.Config[StorageAccount]; storageAccount != "" {
+			return fmt.Sprintf("https://%s.blob.core.windows.net", storageAccount)
+		}
+	}
+	return ""
+}
+
+// classifyProbeError returns the failure reason of a failed probe request
+func classifyProbeError(err error) string {
+	var dnsError *net.DNSError
+	var unknownAuthorityError x509.UnknownAuthorityError
+	var hostnameError x509.HostnameError
+	var certificateInvalidError x509.CertificateInvalidError
+	var certificateVerificationError *tls.CertificateVerificationError
+	var recordHeaderError tls.RecordHeaderError
+	switch {
+	case errors.As(err, &dnsError):
+		return oadpv1alpha1.BackupLocationFailureReasonDNS
+	case errors.As(err, &unknownAuthorityError), errors.As(err, &hostnameError), errors.As(err, &certificateInvalidError),
+		errors.As(err, &certificateVerificationError), errors.As(err, &recordHeaderError):
+		return oadpv1alpha1.BackupLocationFailureReasonTLS
+	}
+	return oadpv1alpha1.BackupLocationFailureReasonNetwork
+}
+
+// recordBackupStorageLocationEvent emits an event on the DPA when a BSL becomes unavailable, fails for another
+// reason, or becomes available again
+func (r *DataProtectionApplicationReconciler) recordBackupStorageLocationEvent(previous *oadpv1alpha1.BackupStorageLocationStatus, current oadpv1alpha1.BackupStorageLocationStatus) {
+	wasUnavailable := previous != nil && previous.Phase == velerov1.BackupStorageLocationPhaseUnavailable
+	switch current.Phase {
+	case velerov1.BackupStorageLocationPhaseUnavailable:
+		if wasUnavailable && previous.FailureReason == current.FailureReason {
+			return
+		}
+		r.EventRecorder.Event(r.dpa, corev1.EventTypeWarning, "BackupStorageLocationUnavailable",
+			fmt.Sprintf("BackupStorageLocation %s is unavailable (%s): %s", current.Name, current.FailureReason, current.Message))
+	case velerov1.BackupStorageLocationPhaseAvailable:
+		if wasUnavailable {
+			r.EventRecorder.Event(r.dpa, corev1.EventTypeNormal, "BackupStorageLocationAvailable",
+				fmt.Sprintf("BackupStorageLocation %s is available again", current.Name))
+		}
+	}
+}
+
+// getBackupLocationsRequeueInterval returns when to reconcile again while backup storage locations are unavailable,
+// 0 when they are available. The interval grows with the time the locations have been unavailable, from
+// backupLocationsMinRequeueInterval to backupLocationsMaxRequeueInterval, which doubles it on each requeue.
+func (r *DataProtectionApplicationReconciler) getBackupLocationsRequeueInterval() time.Duration {
+	condition := apimeta.FindStatusCondition(r.dpa.Status.Conditions, oadpv1alpha1.ConditionBackupLocationsAvailable)
+	if condition == nil || condition.Reason != oadpv1alpha1.ComponentReasonUnavailable {
+		return 0
+	}
+	return min(max(time.Since(condition.LastTransitionTime.Time), backupLocationsMinRequeueInterval), backupLocationsMaxRequeueInterval)
+}
@@ -0,0 +1,271 @@
+package controller
+
+import (
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func TestClassifyBackupStorageLocationMessage(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{
+			message: "BackupStorageLocation \"default\" is unavailable: rpc error: code = Unknown desc = NoSuchBucket: The specified bucket does not exist",
+			want:    oadpv1alpha1.BackupLocationFailureReasonBucketNotFound,
+		},
+		{
+			message: "rpc error: code = Unknown desc = InvalidAccessKeyId: The AWS Access Key Id you provided does not exist in our records. status code: 403",
+			want:    oadpv1alpha1.BackupLocationFailureReasonAuthentication,
+		},
+		{
+			message: "rpc error: code = Unknown desc = RequestTimeTooSkewed: The difference between the request time and the current time is too large. status code: 403",
+			want:    oadpv1alpha1.BackupLocationFailureReasonClockSkew,
+		},
+		{
+			message: "Get \"https://minio.example.com/velero\": tls: failed to verify certificate: x509: certificate signed by unknown authority",
+			want:    oadpv1alpha1.BackupLocationFailureReasonTLS,
+		},
+		{
+			message: "dial tcp: lookup minio.example.com on 172.30.0.10:53: no such host",
+			want:    oadpv1alpha1.BackupLocationFailureReasonDNS,
+		},
+		{
+			message: "dial tcp 10.0.0.5:9000: connect: connection refused",
+			want:    oadpv1alpha1.BackupLocationFailureReasonNetwork,
+		},
+		{
+			message: "BackupStorageLocation is unavailable",
+			want:    oadpv1alpha1.BackupLocationFailureReasonUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := classifyBackupStorageLocationMessage(tt.message); got != tt.want {
+				t.Errorf("classifyBackupStorageLocationMessage() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_probeBackupStorageLocation(t *testing.T) {
+	handler := func(status int, date time.Time) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			if !date.IsZero() {
+				w.Header().Set("Date", date.UTC().Format(http.TimeFormat))
+			}
+			w.WriteHeader(status)
+		}
+	}
+	closedServer := httptest.NewServer(handler(http.StatusOK, time.Time{}))
+	closedServer.Close()
+	tlsServer := httptest.NewUnstartedServer(handler(http.StatusForbidden, time.Time{}))
+	// the untrusted certificate case logs a handshake error
+	tlsServer.Config.ErrorLog = log.New(io.Discard, "", 0)
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+	tlsCACert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw})
+
+	tests := []struct {
+		name        string
+		server      *httptest.Server
+		url         string
+		caCert      []byte
+		wantReason  string
+		wantMessage string
+	}{
+		{
+			name:        "missing bucket",
+			server:      httptest.NewServer(handler(http.StatusNotFound, time.Time{})),
+			wantReason:  oadpv1alpha1.BackupLocationFailureReasonBucketNotFound,
+			wantMessage: "bucket velero not found",
+		},
+		{
+			name:        "reachable bucket",
+			server:      httptest.NewServer(handler(http.StatusForbidden, time.Time{})),
+			wantMessage: "returned 403 Forbidden",
+		},
+		{
+			name:        "clock skew",
+			server:      httptest.NewServer(handler(http.StatusForbidden, time.Now().Add(-time.Hour))),
+			wantReason:  oadpv1alpha1.BackupLocationFailureReasonClockSkew,
+			wantMessage: "differs by 1h0m",
+		},
+		{
+			name:        "untrusted certificate",
+			url:         tlsServer.URL,
+			wantReason:  oadpv1alpha1.BackupLocationFailureReasonTLS,
+			wantMessage: "certificate",
+		},
+		{
+			name:        "certificate trusted with the caCert of the BSL",
+			url:         tlsServer.URL,
+			caCert:      tlsCACert,
+			wantMessage: "returned 403 Forbidden",
+		},
+		{
+			name:        "connection refused",
+			url:         closedServer.URL,
+			wantReason:  oadpv1alpha1.BackupLocationFailureReasonNetwork,
+			wantMessage: "connection refused",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := tt.url
+			if tt.server != nil {
+				defer tt.server.Close()
+				url = tt.server.URL
+			}
+			dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{})
+			fakeClient, err := getFakeClientFromObjects(dpa)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:  fakeClient,
+				Scheme:  fakeClient.Scheme(),
+				Log:     logr.Discard(),
+				Context: newContextForTest(),
+				dpa:     dpa,
+			}
+			bsl := &velerov1.BackupStorageLocation{
+				ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: dpa.Namespace},
+				Spec: velerov1.BackupStorageLocationSpec{
+					Provider: AWSProvider,
+					Config:   map[string]string{S3URL: url},
+					StorageType: velerov1.StorageType{ObjectStorage: &velerov1.ObjectStorageLocation{
+						Bucket: "velero",
+						CACert: tt.caCert,
+					}},
+				},
+			}
+			gotReason, gotMessage := r.probeBackupStorageLocation(bsl)
+			if gotReason != tt.wantReason || !strings.Contains(gotMessage, tt.wantMessage) {
+				t.Errorf("probeBackupStorageLocation() = %q, %q, want %q, message containing %q", gotReason, gotMessage, tt.wantReason, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_updateBackupLocationsStatus_Events(t *testing.T) {
+	tests := []struct {
+		name              string
+		previousPhase     velerov1.BackupStorageLocationPhase
+		phase             velerov1.BackupStorageLocationPhase
+		message           string
+		wantFailureReason string
+		wantEvent         string
+		wantRequeue       bool
+	}{
+		{
+			name:              "BSL becomes unavailable",
+			previousPhase:     velerov1.BackupStorageLocationPhaseAvailable,
+			phase:             velerov1.BackupStorageLocationPhaseUnavailable,
+			message:           "rpc error: code = Unknown desc = NoSuchBucket: The specified bucket does not exist",
+			wantFailureReason: oadpv1alpha1.BackupLocationFailureReasonBucketNotFound,
+			wantEvent:         "Warning BackupStorageLocationUnavailable BackupStorageLocation test-dpa-1 is unavailable (BucketNotFound)",
+			wantRequeue:       true,
+		},
+		{
+			name:              "BSL still unavailable for the same reason",
+			previousPhase:     velerov1.BackupStorageLocationPhaseUnavailable,
+			phase:             velerov1.BackupStorageLocationPhaseUnavailable,
+			message:           "InvalidAccessKeyId",
+			wantFailureReason: oadpv1alpha1.BackupLocationFailureReasonAuthentication,
+			wantRequeue:       true,
+		},
+		{
+			name:          "BSL becomes available again",
+			previousPhase: velerov1.BackupStorageLocationPhaseUnavailable,
+			phase:         velerov1.BackupStorageLocationPhaseAvailable,
+			wantEvent:     "Normal BackupStorageLocationAvailable BackupStorageLocation test-dpa-1 is available again",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := createTestStatusDPA(false, false)
+			dpa.Status.BackupLocations = []oadpv1alpha1.BackupStorageLocationStatus{{
+				Name:          "test-dpa-1",
+				Phase:         tt.previousPhase,
+				FailureReason: oadpv1alpha1.BackupLocationFailureReasonAuthentication,
+			}}
+			bsl := createTestStatusBSL(dpa, "test-dpa-1", tt.phase)
+			bsl.Status.Message = tt.message
+			fakeClient, err := getFakeClientFromObjects(dpa, bsl)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			recorder := record.NewFakeRecorder(10)
+			r := &DataProtectionApplicationReconciler{
+				Client:         fakeClient,
+				Scheme:         fakeClient.Scheme(),
+				Log:            logr.Discard(),
+				Context:        newContextForTest(),
+				NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+				EventRecorder:  recorder,
+				dpa:            dpa,
+			}
+			if err := r.updateBackupLocationsStatus(); err != nil {
+				t.Fatalf("updateBackupLocationsStatus() error = %v", err)
+			}
+
+			if got := dpa.Status.BackupLocations[0].FailureReason; got != tt.wantFailureReason {
+				t.Errorf("expected failure reason %q, got %q", tt.wantFailureReason, got)
+			}
+			gotEvent := ""
+			select {
+			case gotEvent = <-recorder.Events:
+			default:
+			}
+			if !strings.HasPrefix(gotEvent, tt.wantEvent) || (tt.wantEvent == "") != (gotEvent == "") {
+				t.Errorf("expected event %q, got %q", tt.wantEvent, gotEvent)
+			}
+			if got := r.getBackupLocationsRequeueInterval(); (got > 0) != tt.wantRequeue {
+				t.Errorf("expected requeue %v, got interval %s", tt.wantRequeue, got)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_getBackupLocationsRequeueInterval(t *testing.T) {
+	tests := []struct {
+		name             string
+		unavailableSince time.Duration
+		want             time.Duration
+	}{
+		{name: "just unavailable", unavailableSince: time.Second, want: backupLocationsMinRequeueInterval},
+		{name: "unavailable for two minutes", unavailableSince: 2 * time.Minute, want: 2 * time.Minute},
+		{name: "unavailable for a day", unavailableSince: 24 * time.Hour, want: backupLocationsMaxRequeueInterval},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{})
+			apimeta.SetStatusCondition(&dpa.Status.Conditions, metav1.Condition{
+				Type:               oadpv1alpha1.ConditionBackupLocationsAvailable,
+				Status:             metav1.ConditionFalse,
+				Reason:             oadpv1alpha1.ComponentReasonUnavailable,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-tt.unavailableSince)),
+			})
+			r := &DataProtectionApplicationReconciler{dpa: dpa}
+			if got := r.getBackupLocationsRequeueInterval(); got.Round(time.Second) != tt.want {
+				t.Errorf("getBackupLocationsRequeueInterval() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+const (
+	// defaultBackupLocationFailoverWindow is the unavailability window of spec.backupLocationFailover when not set
+	defaultBackupLocationFailoverWindow = 10 * time.Minute
+	// maxBackupLocationSwitches is the number of switches kept in status.backupLocationFailover
+	maxBackupLocationSwitches = 10
+)
+
+// validateBackupLocationFailover checks the primary and secondary of spec.backupLocationFailover are
+// different named backup locations of the spec, and the primary is the default one
+func validateBackupLocationFailover(dpa *oadpv1alpha1.DataProtectionApplication) error {
+	failover := dpa.Spec.BackupLocationFailover
+	if failover == nil {
+		return nil
+	}
+	if failover.Primary == "" || failover.Secondary == "" {
+		return errors.New("backupLocationFailover primary and secondary must be set")
+	}
+	if failover.Primary == failover.Secondary {
+		return fmt.Errorf("backupLocationFailover primary and secondary must be different backup locations, got %s", failover.Primary)
+	}
+	if failover.UnavailabilityWindow != nil && failover.UnavailabilityWindow.Duration <= 0 {
+		return fmt.Errorf("backupLocationFailover unavailabilityWindow must be positive, got %s", failover.UnavailabilityWindow.Duration)
+	}
+	for _, name := range []string{failover.Primary, failover.Secondary} {
+		i := slices.IndexFunc(dpa.Spec.BackupLocations, func(bslSpec oadpv1alpha1.BackupLocation) bool { return bslSpec.Name == name })
+		if i < 0 {
+			return fmt.Errorf("backupLocationFailover backup location %s is not a named backup location of the DPA", name)
+		}
+		bslSpec := dpa.Spec.BackupLocations[i]
+		isDefault := (bslSpec.Velero != nil && bslSpec.Velero.Default) || (bslSpec.CloudStorage != nil && bslSpec.CloudStorage.Default)
+		if name == failover.Primary && !isDefault {
+			return fmt.Errorf("backupLocationFailover primary %s must be set as default", name)
+		}
+	}
+	return nil
+}
+
+// getBackupLocationFailoverWindow returns the unavailability window of the failover
+func getBackupLocationFailoverWindow(failover *oadpv1alpha1.BackupLocationFailover) time.Duration {
+	if failover.UnavailabilityWindow != nil {
+		return failover.UnavailabilityWindow.Duration
+	}
+	return defaultBackupLocationFailoverWindow
+}
+
+// ReconcileBackupLocationFailover tracks the phase of the primary BackupStorageLocation of spec.backupLocationFailover
+// and switches the active location of status.backupLocationFailover, which ReconcileBackupStorageLocations sets as
+// the default. It fails over to the secondary once the primary is unavailable for the unavailability window, and
+// fails back once the primary is available again for the unavailability window, when failback is enabled.
+func (r *DataProtectionApplicationReconciler) ReconcileBackupLocationFailover(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	failover := dpa.Spec.BackupLocationFailover
+	if failover == nil {
+		dpa.Status.BackupLocationFailover = nil
+		return true, nil
+	}
+	status := dpa.Status.BackupLocationFailover
+	if status == nil || (status.Active != failover.Primary && status.Active != failover.Secondary) {
+		status = &oadpv1alpha1.BackupLocationFailoverStatus{Active: failover.Primary}
+		dpa.Status.BackupLocationFailover = status
+	}
+
+	primaryPhase, err := r.getBackupStorageLocationPhase(failover.Primary)
+	if err != nil {
+		return false, err
+	}
+	if status.PrimaryPhase != primaryPhase || status.PrimaryPhaseSince == nil {
+		status.PrimaryPhase = primaryPhase
+		status.PrimaryPhaseSince = &metav1.Time{Time: time.Now()}
+	}
+	window := getBackupLocationFailoverWindow(failover)
+	if time.Since(status.PrimaryPhaseSince.Time) < window {
+		return true, nil
+	}
+
+	switch {
+	case status.Active == failover.Primary && primaryPhase == velerov1.BackupStorageLocationPhaseUnavailable:
+		secondaryPhase, err := r.getBackupStorageLocationPhase(failover.Secondary)
+		if err != nil {
+			return false, err
+		}
+		if secondaryPhase == velerov1.BackupStorageLocationPhaseUnavailable {
+			log.Info("not failing over to the unavailable secondary backup storage location", "primary", failover.Primary, "secondary", failover.Secondary)
+			return true, nil
+		}
+		return true, r.switchBackupLocation(failover.Primary, failover.Secondary, oadpv1alpha1.BackupLocationSwitchReasonFailover)
+	case status.Active == failover.Secondary && failover.Failback && primaryPhase == velerov1.BackupStorageLocationPhaseAvailable:
+		return true, r.switchBackupLocation(failover.Secondary, failover.Primary, oadpv1alpha1.BackupLocationSwitchReasonFailback)
+	}
+	return true, nil
+}
+
+// getBackupStorageLocationPhase returns the phase of the BackupStorageLocation of the DPA namespace, empty if it does not exist
+func (r *DataProtectionApplicationReconciler) getBackupStorageLocationPhase(name string) (velerov1.BackupStorageLocationPhase, error) {
+	bsl := &velerov1.BackupStorageLocation{}
+	if err := r.Get(r.Context, client.ObjectKey{Namespace: r.dpa.Namespace, Name: name}, bsl); err != nil {
+		if k8serror.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return bsl.Status.Phase, nil
+}
+
+// switchBackupLocation sets to as the active backup location of the failover, repoints the Schedules
+// and records the switch in the DPA status and events. On failover, the Schedules using from are repointed to to
+// and annotated with from. On failback, only the Schedules annotated with to are repointed.
+func (r *DataProtectionApplicationReconciler) switchBackupLocation(from, to, reason string) error {
+	schedules := &velerov1.ScheduleList{}
+	if err := r.List(r.Context, schedules, client.InNamespace(r.dpa.Namespace)); err != nil {
+		return err
+	}
+	repointed := []string{}
+	for i := range schedules.Items {
+		schedule := &schedules.Items[i]
+		if schedule.Spec.Template.StorageLocation != from {
+			continue
+		}
+		patch := client.MergeFrom(schedule.DeepCopy())
+		if reason == oadpv1alpha1.BackupLocationSwitchReasonFailover {
+			if schedule.Annotations == nil {
+				schedule.Annotations = map[string]string{}
+			}
+			schedule.Annotations[common.FailoverFromAnnotation] = from
+		} else {
+			if schedule.Annotations[common.FailoverFromAnnotation] != to {
+				continue
+			}
+			delete(schedule.Annotations, common.FailoverFromAnnotation)
+		}
+		schedule.Spec.Template.StorageLocation = to
+		if err := r.Patch(r.Context, schedule, patch); err != nil {
+			return fmt.Errorf("unable to repoint Schedule %s from backup storage location %s to %s: %w", schedule.Name, from, to, err)
+		}
+		repointed = append(repointed, schedule.Name)
+	}
+
+	status := r.dpa.Status.BackupLocationFailover
+	status.Active = to
+	status.Switches = append(status.Switches, oadpv1alpha1.BackupLocationSwitch{
+		From:      from,
+		To:        to,
+		Reason:    reason,
+		Time:      metav1.Now(),
+		Schedules: repointed,
+	})
+	if len(status.Switches) > maxBackupLocationSwitches {
+		status.Switches = status.Switches[len(status.Switches)-maxBackupLocationSwitches:]
+	}
+
+	eventType := corev1.EventTypeWarning
+	if reason == oadpv1alpha1.BackupLocationSwitchReasonFailback {
+		eventType = corev1.EventTypeNormal
+	}
+	r.EventRecorder.Event(r.dpa, eventType, "BackupLocation"+reason,
+		fmt.Sprintf("default backup storage location switched from %s to %s, primary %s since %s, %d Schedules repointed",
+			from, to, status.PrimaryPhase, status.PrimaryPhaseSince.UTC().Format(time.RFC3339), len(repointed)),
+	)
+	return nil
+}
+
+// getBackupLocationFailoverRequeueInterval returns when the failover or failback of spec.backupLocationFailover
+// is due, 0 when no switch is pending
+func (r *DataProtectionApplicationReconciler) getBackupLocationFailoverRequeueInterval() time.Duration {
+	failover := r.dpa.Spec.BackupLocationFailover
+	status := r.dpa.Status.BackupLocationFailover
+	if failover == nil || status == nil || status.PrimaryPhaseSince == nil {
+		return 0
+	}
+	pending := (status.Active == failover.Primary && status.PrimaryPhase == velerov1.BackupStorageLocationPhaseUnavailable) ||
+		(status.Active == failover.Secondary && failover.Failback && status.PrimaryPhase == velerov1.BackupStorageLocationPhaseAvailable)
+	if !pending {
+		return 0
+	}
+	if remaining := getBackupLocationFailoverWindow(failover) - time.Since(status.PrimaryPhaseSince.Time); remaining > 0 {
+		return remaining
+	}
+	// the failover waits for the secondary to be available
+	return backupLocationsMinRequeueInterval
+}
@@ -0,0 +1,239 @@
+package controller
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func failoverBackupLocation(name, region string, isDefault bool) oadpv1alpha1.BackupLocation {
+	return oadpv1alpha1.BackupLocation{
+		Name: name,
+		Velero: &velerov1.BackupStorageLocationSpec{
+			Provider: AWSProvider,
+			Default:  isDefault,
+			Config:   map[string]string{Region: region},
+			Credential: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "cloud-credentials"},
+				Key:                  "cloud",
+			},
+			StorageType: velerov1.StorageType{ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "bucket-" + region, Prefix: "velero"}},
+		},
+	}
+}
+
+func TestValidateBackupLocationFailover(t *testing.T) {
+	locations := []oadpv1alpha1.BackupLocation{
+		failoverBackupLocation("east", "us-east-1", true),
+		failoverBackupLocation("west", "us-west-2", false),
+	}
+	tests := []struct {
+		name     string
+		failover *oadpv1alpha1.BackupLocationFailover
+		wantErr  bool
+	}{
+		{name: "no failover"},
+		{name: "valid failover", failover: &oadpv1alpha1.BackupLocationFailover{Primary: "east", Secondary: "west"}},
+		{name: "same primary and secondary", failover: &oadpv1alpha1.BackupLocationFailover{Primary: "east", Secondary: "east"}, wantErr: true},
+		{name: "unknown secondary", failover: &oadpv1alpha1.BackupLocationFailover{Primary: "east", Secondary: "north"}, wantErr: true},
+		{name: "primary not default", failover: &oadpv1alpha1.BackupLocationFailover{Primary: "west", Secondary: "east"}, wantErr: true},
+		{
+			name:     "negative window",
+			failover: &oadpv1alpha1.BackupLocationFailover{Primary: "east", Secondary: "west", UnavailabilityWindow: &metav1.Duration{Duration: -time.Minute}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{
+				BackupLocations:        locations,
+				BackupLocationFailover: tt.failover,
+			})
+			if err := validateBackupLocationFailover(dpa); (err != nil) != tt.wantErr {
+				t.Errorf("validateBackupLocationFailover() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_ReconcileBackupLocationFailover(t *testing.T) {
+	longAgo := &metav1.Time{Time: time.Now().Add(-time.Hour)}
+	bsl := func(name string, phase velerov1.BackupStorageLocationPhase) *velerov1.BackupStorageLocation {
+		return &velerov1.BackupStorageLocation{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns"},
+			Status:     velerov1.BackupStorageLocationStatus{Phase: phase},
+		}
+	}
+	schedule := func(name, storageLocation, failoverFrom string) *velerov1.Schedule {
+		schedule := &velerov1.Schedule{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns"},
+			Spec:       velerov1.ScheduleSpec{Template: velerov1.BackupSpec{StorageLocation: storageLocation}},
+		}
+		if failoverFrom != "" {
+			schedule.Annotations = map[string]string{common.FailoverFromAnnotation: failoverFrom}
+		}
+		return schedule
+	}
+	tests := []struct {
+		name              string
+		failback          bool
+		status            *oadpv1alpha1.BackupLocationFailoverStatus
+		objects           []client.Object
+		wantActive        string
+		wantSchedules     map[string]string
+		wantEvent         string
+		wantRequeue       bool
+		wantDefaultBSL    string
+		wantSwitchesCount int
+	}{
+		{
+			name:           "primary just became unavailable",
+			status:         &oadpv1alpha1.BackupLocationFailoverStatus{Active: "east", PrimaryPhase: velerov1.BackupStorageLocationPhaseAvailable, PrimaryPhaseSince: longAgo},
+			objects:        []client.Object{bsl("east", velerov1.BackupStorageLocationPhaseUnavailable), bsl("west", velerov1.BackupStorageLocationPhaseAvailable)},
+			wantActive:     "east",
+			wantRequeue:    true,
+			wantDefaultBSL: "east",
+		},
+		{
+			name:   "primary unavailable for the window fails over",
+			status: &oadpv1alpha1.BackupLocationFailoverStatus{Active: "east", PrimaryPhase: velerov1.BackupStorageLocationPhaseUnavailable, PrimaryPhaseSince: longAgo},
+			objects: []client.Object{
+				bsl("east", velerov1.BackupStorageLocationPhaseUnavailable), bsl("west", velerov1.BackupStorageLocationPhaseAvailable),
+				schedule("daily", "east", ""), schedule("weekly", "other", ""), schedule("default", "", ""),
+			},
+			wantActive:        "west",
+			wantSchedules:     map[string]string{"daily": "west", "weekly": "other", "default": ""},
+			wantEvent:         "Warning BackupLocationFailover default backup storage location switched from east to west",
+			wantDefaultBSL:    "west",
+			wantSwitchesCount: 1,
+		},
+		{
+			name:           "secondary unavailable",
+			status:         &oadpv1alpha1.BackupLocationFailoverStatus{Active: "east", PrimaryPhase: velerov1.BackupStorageLocationPhaseUnavailable, PrimaryPhaseSince: longAgo},
+			objects:        []client.Object{bsl("east", velerov1.BackupStorageLocationPhaseUnavailable), bsl("west", velerov1.BackupStorageLocationPhaseUnavailable)},
+			wantActive:     "east",
+			wantRequeue:    true,
+			wantDefaultBSL: "east",
+		},
+		{
+			name:     "primary available for the window fails back",
+			failback: true,
+			status: &oadpv1alpha1.BackupLocationFailoverStatus{
+				Active: "west", PrimaryPhase: velerov1.BackupStorageLocationPhaseAvailable, PrimaryPhaseSince: longAgo,
+				Switches: []oadpv1alpha1.BackupLocationSwitch{{From: "east", To: "west", Reason: oadpv1alpha1.BackupLocationSwitchReasonFailover}},
+			},
+			objects: []client.Object{
+				bsl("east", velerov1.BackupStorageLocationPhaseAvailable), bsl("west", velerov1.BackupStorageLocationPhaseAvailable),
+				schedule("daily", "west", "east"), schedule("replica", "west", ""),
+			},
+			wantActive:        "east",
+			wantSchedules:     map[string]string{"daily": "east", "replica": "west"},
+			wantEvent:         "Normal BackupLocationFailback default backup storage location switched from west to east",
+			wantDefaultBSL:    "east",
+			wantSwitchesCount: 2,
+		},
+		{
+			name: "no failback without failback enabled",
+			status: &oadpv1alpha1.BackupLocationFailoverStatus{
+				Active: "west", PrimaryPhase: velerov1.BackupStorageLocationPhaseAvailable, PrimaryPhaseSince: longAgo,
+				Switches: []oadpv1alpha1.BackupLocationSwitch{{From: "east", To: "west", Reason: oadpv1alpha1.BackupLocationSwitchReasonFailover}},
+			},
+			objects:           []client.Object{bsl("east", velerov1.BackupStorageLocationPhaseAvailable), bsl("west", velerov1.BackupStorageLocationPhaseAvailable)},
+			wantActive:        "west",
+			wantDefaultBSL:    "west",
+			wantSwitchesCount: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{
+				BackupLocations: []oadpv1alpha1.BackupLocation{
+					failoverBackupLocation("east", "us-east-1", true),
+					failoverBackupLocation("west", "us-west-2", false),
+				},
+				BackupLocationFailover: &oadpv1alpha1.BackupLocationFailover{Primary: "east", Secondary: "west", Failback: tt.failback},
+			})
+			dpa.UID = "test-uid"
+			dpa.Status.BackupLocationFailover = tt.status
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials", Namespace: "test-ns"},
+				Data:       map[string][]byte{"cloud": []byte("[default]\naws_access_key_id=test-key\naws_secret_access_key=test-secret")},
+			}
+			fakeClient, err := getFakeClientFromObjects(append(tt.objects, dpa, secret)...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			recorder := record.NewFakeRecorder(10)
+			r := &DataProtectionApplicationReconciler{
+				Client:         fakeClient,
+				Scheme:         fakeClient.Scheme(),
+				Log:            logr.Discard(),
+				Context:        newContextForTest(),
+				NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+				EventRecorder:  recorder,
+				dpa:            dpa,
+			}
+			if _, err := r.ReconcileBackupLocationFailover(r.Log); err != nil {
+				t.Fatalf("ReconcileBackupLocationFailover() error = %v", err)
+			}
+			status := dpa.Status.BackupLocationFailover
+			if status.Active != tt.wantActive {
+				t.Errorf("expected active backup location %s, got %s", tt.wantActive, status.Active)
+			}
+			if len(status.Switches) != tt.wantSwitchesCount {
+				t.Errorf("expected %d switches, got %v", tt.wantSwitchesCount, status.Switches)
+			}
+			if got := r.getBackupLocationFailoverRequeueInterval(); (got > 0) != tt.wantRequeue {
+				t.Errorf("expected requeue %v, got interval %s", tt.wantRequeue, got)
+			}
+			gotEvent := ""
+			select {
+			case gotEvent = <-recorder.Events:
+			default:
+			}
+			if !strings.HasPrefix(gotEvent, tt.wantEvent) || (tt.wantEvent == "") != (gotEvent == "") {
+				t.Errorf("expected event %q, got %q", tt.wantEvent, gotEvent)
+			}
+
+			gotSchedules := map[string]string{}
+			schedules := &velerov1.ScheduleList{}
+			if err := fakeClient.List(r.Context, schedules, client.InNamespace("test-ns")); err != nil {
+				t.Fatal(err)
+			}
+			for _, schedule := range schedules.Items {
+				gotSchedules[schedule.Name] = schedule.Spec.Template.StorageLocation
+				if _, annotated := schedule.Annotations[common.FailoverFromAnnotation]; annotated != (schedule.Spec.Template.StorageLocation == "west" && schedule.Name == "daily") {
+					t.Errorf("unexpected failover annotation on Schedule %s: %v", schedule.Name, schedule.Annotations)
+				}
+			}
+			if len(tt.wantSchedules) > 0 && !reflect.DeepEqual(gotSchedules, tt.wantSchedules) {
+				t.Errorf("expected schedule storage locations %v, got %v", tt.wantSchedules, gotSchedules)
+			}
+
+			if _, err := r.ReconcileBackupStorageLocations(r.Log); err != nil {
+				t.Fatalf("ReconcileBackupStorageLocations() error = %v", err)
+			}
+			bsls := &velerov1.BackupStorageLocationList{}
+			if err := fakeClient.List(r.Context, bsls, client.InNamespace("test-ns")); err != nil {
+				t.Fatal(err)
+			}
+			for _, bsl := range bsls.Items {
+				if bsl.Spec.Default != (bsl.Name == tt.wantDefaultBSL) {
+					t.Errorf("expected default backup storage location %s, got %s default %v", tt.wantDefaultBSL, bsl.Name, bsl.Spec.Default)
+				}
+			}
+		})
+	}
+}
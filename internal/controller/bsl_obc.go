@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+const (
+	// objectBucketClaimBoundPhase is the phase of an ObjectBucketClaim whose bucket is provisioned
+	objectBucketClaimBoundPhase = "Bound"
+	// keys of the ConfigMap and the Secret of a bound ObjectBucketClaim, named after the claim
+	objectBucketClaimHostKey      = "BUCKET_HOST"
+	objectBucketClaimPortKey      = "BUCKET_PORT"
+	objectBucketClaimNameKey      = "BUCKET_NAME"
+	objectBucketClaimRegionKey    = "BUCKET_REGION"
+	objectBucketClaimAccessKeyKey = "AWS_ACCESS_KEY_ID"
+	objectBucketClaimSecretKeyKey = "AWS_SECRET_ACCESS_KEY"
+	// objectBucketClaimCredentialsKey is the key of the Velero credentials rendered from the Secret of the claim
+	objectBucketClaimCredentialsKey = "cloud"
+	// objectBucketClaimDefaultRegion is the region of the claims whose ConfigMap has none, S3 path style requires a region
+	objectBucketClaimDefaultRegion = "us-east-1"
+	// serviceCAConfigMap holds the service CA of the cluster, injected in every namespace
+	serviceCAConfigMap = "openshift-service-ca.crt"
+	serviceCAKey       = "service-ca.crt"
+	// objectBucketClaimRequeueInterval is the interval the DPA is reconciled at while ObjectBucketClaims are pending
+	objectBucketClaimRequeueInterval = time.Minute
+)
+
+var objectBucketClaimGVK = schema.GroupVersionKind{Group: "objectbucket.io", Version: "v1alpha1", Kind: "ObjectBucketClaim"}
+
+// ReconcileObjectBucketClaimLocations resolves the backup locations of ObjectBucketClaims into velero backup
+// locations, so they are validated and reconciled as such. The access keys of the claim are rendered into a
+// credentials secret owned by the DPA, updated when the claim rotates them. The DPA is only changed in memory.
+// Claims that are not bound, or whose ConfigMap or Secret is missing, are reported in the ObjectBucketClaimsBound
+// condition without blocking the other locations: the BSL of a claim bound before is kept as is, the location
+// of a claim never bound is skipped until it is bound.
+func (r *DataProtectionApplicationReconciler) ReconcileObjectBucketClaimLocations(log logr.Logger) (bool, error) {
+	credentialsSecretNames := []string{}
+	locations := []oadpv1alpha1.BackupLocation{}
+	pending := []string{}
+	hasClaims := false
+	r.pendingBackupLocations = nil
+	for i := range r.dpa.Spec.BackupLocations {
+		location := r.dpa.Spec.BackupLocations[i]
+		if location.ObjectBucketClaim == nil {
+			locations = append(locations, location)
+			continue
+		}
+		hasClaims = true
+		if location.Velero != nil || location.CloudStorage != nil {
+			return false, fmt.Errorf("DPA spec.backupLocations[%d] cannot have objectBucketClaim with velero or bucket", i)
+		}
+		if location.Profile != "" {
+			return false, fmt.Errorf("DPA spec.backupLocations[%d] cannot have objectBucketClaim with profile", i)
+		}
+		name := location.ObjectBucketClaim.ObjectBucketClaimRef.Name
+		bslSpec, pendingReason, err := r.getObjectBucketClaimLocation(name)
+		if err != nil {
+			return false, err
+		}
+		if len(pendingReason) > 0 {
+			log.Info("ObjectBucketClaim location is pending", "objectBucketClaim", name, "reason", pendingReason)
+			pending = append(pending, fmt.Sprintf("%s: %s", name, pendingReason))
+			bsl, err := r.getObjectBucketClaimBackupStorageLocation(name)
+			if err != nil {
+				return false, err
+			}
+			if bsl == nil {
+				r.pendingBackupLocations = append(r.pendingBackupLocations, location)
+				continue
+			}
+			location.Velero = bsl.Spec.DeepCopy()
+			locations = append(locations, location)
+			credentialsSecretNames = append(credentialsSecretNames, bsl.Spec.Credential.Name)
+			continue
+		}
+		obcLocation := location.ObjectBucketClaim
+		bslSpec.Default = obcLocation.Default
+		bslSpec.BackupSyncPeriod = obcLocation.BackupSyncPeriod
+		bslSpec.ObjectStorage.Prefix = obcLocation.Prefix
+		if len(obcLocation.CACert) > 0 {
+			bslSpec.ObjectStorage.CACert = obcLocation.CACert
+		}
+		for key, value := range obcLocation.Config {
+			bslSpec.Config[key] = value
+		}
+		location.Velero = bslSpec
+		locations = append(locations, location)
+		credentialsSecretNames = append(credentialsSecretNames, bslSpec.Credential.Name)
+	}
+	r.dpa.Spec.BackupLocations = locations
+	if err := r.deleteStaleLocationCredentialsSecrets(obcCredentialsComponent, credentialsSecretNames); err != nil {
+		return false, err
+	}
+
+	if !hasClaims {
+		apimeta.RemoveStatusCondition(&r.dpa.Status.Conditions, oadpv1alpha1.ConditionObjectBucketClaimsBound)
+		return true, nil
+	}
+	condition := metav1.Condition{
+		Type:    oadpv1alpha1.ConditionObjectBucketClaimsBound,
+		Status:  metav1.ConditionTrue,
+		Reason:  oadpv1alpha1.ObjectBucketClaimsReasonBound,
+		Message: "the ObjectBucketClaims of the backup locations are bound",
+	}
+	if len(pending) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = oadpv1alpha1.ObjectBucketClaimsReasonPending
+		condition.Message = "backup locations wait for their ObjectBucketClaims, " + strings.Join(pending, "; ")
+	}
+	apimeta.SetStatusCondition(&r.dpa.Status.Conditions, condition)
+	return true, nil
+}
+
+// getObjectBucketClaimBackupStorageLocation returns the BSL of the DPA created for the ObjectBucketClaim name,
+// nil if there is none
+func (r *DataProtectionApplicationReconciler) getObjectBucketClaimBackupStorageLocation(name string) (*velerov1.BackupStorageLocation, error) {
+	bsls := &velerov1.BackupStorageLocationList{}
+	if err := r.List(r.Context, bsls, client.InNamespace(r.dpa.Namespace)); err != nil {
+		return nil, err
+	}
+	secretName := getLocationCredentialsSecretName(name, obcCredentialsComponent)
+	for i := range bsls.Items {
+		bsl := &bsls.Items[i]
+		if metav1.IsControlledBy(bsl, r.dpa) && bsl.Spec.Credential != nil && bsl.Spec.Credential.Name == secretName {
+			return bsl, nil
+		}
+	}
+	return nil, nil
+}
+
+// getObjectBucketClaimLocation returns the velero backup location of the bound ObjectBucketClaim name, and renders
+// the access keys of the claim into the credentials secret the location uses. It returns the reason the location
+// is pending instead when the claim is not bound, or its ConfigMap or Secret is missing or incomplete.
+func (r *DataProtectionApplicationReconciler) getObjectBucketClaimLocation(name string) (*velerov1.BackupStorageLocationSpec, string, error) {
+	obc := &unstructured.Unstructured{}
+	obc.SetGroupVersionKind(objectBucketClaimGVK)
+	if err := r.Get(r.Context, client.ObjectKey{Namespace: r.dpa.Namespace, Name: name}, obc); err != nil {
+		if apimeta.IsNoMatchError(err) {
+			return nil, "", fmt.Errorf("ObjectBucketClaim %s cannot be used, the ObjectBucketClaim API is not available on the cluster", name)
+		}
+		if k8serror.IsNotFound(err) {
+			return nil, "ObjectBucketClaim not found", nil
+		}
+		return nil, "", fmt.Errorf("unable to get ObjectBucketClaim %s: %w", name, err)
+	}
+	if phase, _, _ := unstructured.NestedString(obc.Object, "status", "phase"); phase != objectBucketClaimBoundPhase {
+		return nil, fmt.Sprintf("not bound yet, phase %q", phase), nil
+	}
+
+	// the ConfigMap and the Secret of the claim have the name of the claim
+	configMap := &corev1.ConfigMap{}
+	if err := r.Get(r.Context, client.ObjectKey{Namespace: r.dpa.Namespace, Name: name}, configMap); err != nil {
+		if k8serror.IsNotFound(err) {
+			return nil, fmt.Sprintf("ConfigMap %s not found", name), nil
+		}
+		return nil, "", fmt.Errorf("unable to get ConfigMap %s of ObjectBucketClaim %s: %w", name, name, err)
+	}
+	host, bucket := configMap.Data[objectBucketClaimHostKey], configMap.Data[objectBucketClaimNameKey]
+	if host == "" || bucket == "" {
+		return nil, fmt.Sprintf("ConfigMap %s has no %s or %s", name, objectBucketClaimHostKey, objectBucketClaimNameKey), nil
+	}
+	secret := &corev1.Secret{}
+	if err := r.Get(r.Context, client.ObjectKey{Namespace: r.dpa.Namespace, Name: name}, secret); err != nil {
+		if k8serror.IsNotFound(err) {
+			return nil, fmt.Sprintf("Secret %s not found", name), nil
+		}
+		return nil, "", fmt.Errorf("unable to get Secret %s of ObjectBucketClaim %s: %w", name, name, err)
+	}
+	accessKey, secretKey := string(secret.Data[objectBucketClaimAccessKeyKey]), string(secret.Data[objectBucketClaimSecretKeyKey])
+	if accessKey == "" || secretKey == "" {
+		return nil, fmt.Sprintf("Secret %s has no %s or %s", name, objectBucketClaimAccessKeyKey, objectBucketClaimSecretKeyKey), nil
+	}
+	// the dataprotectionapplication.name label of the claim Secret triggers a reconcile when the keys are rotated
+	if err := r.UpdateCredentialsSecretLabels(name, r.dpa.Name); err != nil {
+		return nil, "", err
+	}
+	credential, err := r.writeLocationCredentialsSecret(name, obcCredentialsComponent, name, objectBucketClaimCredentialsKey,
+		[]byte(fmt.Sprintf("[default]\naws_access_key_id=%s\naws_secret_access_key=%s\n", accessKey, secretKey)))
+	if err != nil {
+		return nil, "", err
+	}
+
+	region := configMap.Data[objectBucketClaimRegionKey]
+	if region == "" {
+		region = objectBucketClaimDefaultRegion
+	}
+	s3Url := &url.URL{Scheme: "http", Host: host}
+	if port := configMap.Data[objectBucketClaimPortKey]; port == "443" {
+		s3Url.Scheme = "https"
+	} else if port != "" && port != "80" {
+		s3Url.Host = net.JoinHostPort(host, port)
+	}
+	bslSpec := &velerov1.BackupStorageLocationSpec{
+		Provider: AWSProvider,
+		Config: map[string]string{
+			Region:            region,
+			S3URL:             s3Url.String(),
+			S3ForcePathStyle:  "true",
+			checksumAlgorithm: "",
+		},
+		Credential: credential,
+		StorageType: velerov1.StorageType{
+			ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: bucket},
+		},
+	}
+	// the bucket endpoint of the claim is a service of the cluster, served with a certificate of the service CA
+	if s3Url.Scheme == "https" {
+		serviceCA := &corev1.ConfigMap{}
+		if err := r.Get(r.Context, client.ObjectKey{Namespace: r.dpa.Namespace, Name: serviceCAConfigMap}, serviceCA); err == nil {
+			bslSpec.ObjectStorage.CACert = []byte(serviceCA.Data[serviceCAKey])
+		} else if !k8serror.IsNotFound(err) {
+			return nil, "", err
+		}
+	}
+	return bslSpec, "", nil
+}
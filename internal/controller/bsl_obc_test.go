@@ -0,0 +1,291 @@
+package controller
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func createTestObjectBucketClaim(name, phase string) *unstructured.Unstructured {
+	obc := &unstructured.Unstructured{}
+	obc.SetGroupVersionKind(objectBucketClaimGVK)
+	obc.SetName(name)
+	obc.SetNamespace("test-ns")
+	if phase != "" {
+		_ = unstructured.SetNestedField(obc.Object, phase, "status", "phase")
+	}
+	return obc
+}
+
+func TestDPAReconciler_ReconcileObjectBucketClaimLocations(t *testing.T) {
+	obcConfigMap := func(port, region string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "backups", Namespace: "test-ns"},
+			Data: map[string]string{
+				objectBucketClaimHostKey:   "s3.openshift-storage.svc",
+				objectBucketClaimPortKey:   port,
+				objectBucketClaimNameKey:   "backups-0123",
+				objectBucketClaimRegionKey: region,
+			},
+		}
+	}
+	obcSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "backups", Namespace: "test-ns"},
+		Data: map[string][]byte{
+			objectBucketClaimAccessKeyKey: []byte("test-key"),
+			objectBucketClaimSecretKeyKey: []byte("test-secret"),
+		},
+	}
+	serviceCA := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: serviceCAConfigMap, Namespace: "test-ns"},
+		Data:       map[string]string{serviceCAKey: "service-ca"},
+	}
+	staleSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "removed-obc-credentials",
+			Namespace: "test-ns",
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": common.OADPOperator,
+				"app.kubernetes.io/component":  obcCredentialsComponent,
+			},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: oadpv1alpha1.GroupVersion.String(),
+				Kind:       "DataProtectionApplication",
+				Name:       testDpaName,
+				UID:        "test-uid",
+				Controller: ptr.To(true),
+			}},
+		},
+	}
+	credential := &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "backups-obc-credentials"},
+		Key:                  objectBucketClaimCredentialsKey,
+	}
+	pendingBSL := &velerov1.BackupStorageLocation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "obc-location",
+			Namespace: "test-ns",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: oadpv1alpha1.GroupVersion.String(),
+				Kind:       "DataProtectionApplication",
+				Name:       testDpaName,
+				UID:        "test-uid",
+				Controller: ptr.To(true),
+			}},
+		},
+		Spec: velerov1.BackupStorageLocationSpec{
+			Provider:    AWSProvider,
+			Default:     true,
+			Config:      map[string]string{Region: objectBucketClaimDefaultRegion, S3URL: "https://s3.openshift-storage.svc", S3ForcePathStyle: "true"},
+			Credential:  credential,
+			StorageType: velerov1.StorageType{ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "backups-0123", Prefix: "velero"}},
+		},
+	}
+	obcCredentialsSecret := staleSecret.DeepCopy()
+	obcCredentialsSecret.Name = credential.Name
+	obcCredentialsSecret.Data = map[string][]byte{credential.Key: []byte("[default]\naws_access_key_id=test-key\naws_secret_access_key=test-secret\n")}
+	tests := []struct {
+		name        string
+		location    oadpv1alpha1.BackupLocation
+		objects     []client.Object
+		want        *velerov1.BackupStorageLocationSpec
+		wantPending string
+		wantSkipped bool
+		wantErr     bool
+	}{
+		{
+			name: "bound claim served with the service CA",
+			location: oadpv1alpha1.BackupLocation{ObjectBucketClaim: &oadpv1alpha1.ObjectBucketClaimLocation{
+				ObjectBucketClaimRef: corev1.LocalObjectReference{Name: "backups"},
+				Default:              true,
+				Prefix:               "velero",
+			}},
+			objects: []client.Object{createTestObjectBucketClaim("backups", "Bound"), obcConfigMap("443", ""), obcSecret, serviceCA, staleSecret},
+			want: &velerov1.BackupStorageLocationSpec{
+				Provider: AWSProvider,
+				Default:  true,
+				Config: map[string]string{
+					Region:            objectBucketClaimDefaultRegion,
+					S3URL:             "https://s3.openshift-storage.svc",
+					S3ForcePathStyle:  "true",
+					checksumAlgorithm: "",
+				},
+				Credential: credential,
+				StorageType: velerov1.StorageType{
+					ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "backups-0123", Prefix: "velero", CACert: []byte("service-ca")},
+				},
+			},
+		},
+		{
+			name: "bound claim over http with config",
+			location: oadpv1alpha1.BackupLocation{ObjectBucketClaim: &oadpv1alpha1.ObjectBucketClaimLocation{
+				ObjectBucketClaimRef: corev1.LocalObjectReference{Name: "backups"},
+				Default:              true,
+				Prefix:               "velero",
+				Config:               map[string]string{checksumAlgorithm: "CRC32"},
+			}},
+			objects: []client.Object{createTestObjectBucketClaim("backups", "Bound"), obcConfigMap("8080", "eu-west-1"), obcSecret, serviceCA},
+			want: &velerov1.BackupStorageLocationSpec{
+				Provider: AWSProvider,
+				Default:  true,
+				Config: map[string]string{
+					Region:            "eu-west-1",
+					S3URL:             "http://s3.openshift-storage.svc:8080",
+					S3ForcePathStyle:  "true",
+					checksumAlgorithm: "CRC32",
+				},
+				Credential: credential,
+				StorageType: velerov1.StorageType{
+					ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "backups-0123", Prefix: "velero"},
+				},
+			},
+		},
+		{
+			name: "claim not bound is skipped",
+			location: oadpv1alpha1.BackupLocation{ObjectBucketClaim: &oadpv1alpha1.ObjectBucketClaimLocation{
+				ObjectBucketClaimRef: corev1.LocalObjectReference{Name: "backups"},
+				Default:              true,
+			}},
+			objects:     []client.Object{createTestObjectBucketClaim("backups", "Pending"), staleSecret},
+			wantPending: `backups: not bound yet, phase "Pending"`,
+			wantSkipped: true,
+		},
+		{
+			name: "claim not found is skipped",
+			location: oadpv1alpha1.BackupLocation{ObjectBucketClaim: &oadpv1alpha1.ObjectBucketClaimLocation{
+				ObjectBucketClaimRef: corev1.LocalObjectReference{Name: "backups"},
+				Default:              true,
+			}},
+			wantPending: "backups: ObjectBucketClaim not found",
+			wantSkipped: true,
+		},
+		{
+			name: "claim without ConfigMap is skipped",
+			location: oadpv1alpha1.BackupLocation{ObjectBucketClaim: &oadpv1alpha1.ObjectBucketClaimLocation{
+				ObjectBucketClaimRef: corev1.LocalObjectReference{Name: "backups"},
+				Default:              true,
+			}},
+			objects:     []client.Object{createTestObjectBucketClaim("backups", "Bound"), obcSecret},
+			wantPending: "backups: ConfigMap backups not found",
+			wantSkipped: true,
+		},
+		{
+			name: "claim without access keys keeps its backup storage location",
+			location: oadpv1alpha1.BackupLocation{ObjectBucketClaim: &oadpv1alpha1.ObjectBucketClaimLocation{
+				ObjectBucketClaimRef: corev1.LocalObjectReference{Name: "backups"},
+				Default:              true,
+				Prefix:               "velero",
+			}},
+			objects: []client.Object{
+				createTestObjectBucketClaim("backups", "Bound"), obcConfigMap("443", ""),
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "backups", Namespace: "test-ns"}},
+				pendingBSL, obcCredentialsSecret, staleSecret,
+			},
+			want:        &pendingBSL.Spec,
+			wantPending: "backups: Secret backups has no AWS_ACCESS_KEY_ID or AWS_SECRET_ACCESS_KEY",
+		},
+		{
+			name: "claim with velero",
+			location: oadpv1alpha1.BackupLocation{
+				ObjectBucketClaim: &oadpv1alpha1.ObjectBucketClaimLocation{ObjectBucketClaimRef: corev1.LocalObjectReference{Name: "backups"}},
+				Velero:            &velerov1.BackupStorageLocationSpec{Provider: AWSProvider},
+			},
+			objects: []client.Object{createTestObjectBucketClaim("backups", "Bound"), obcConfigMap("443", ""), obcSecret},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{
+				Configuration: &oadpv1alpha1.ApplicationConfig{
+					Velero: &oadpv1alpha1.VeleroConfig{DefaultPlugins: []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginAWS}},
+				},
+				BackupLocations: []oadpv1alpha1.BackupLocation{tt.location},
+			})
+			dpa.UID = "test-uid"
+			fakeClient, err := getFakeClientFromObjects(append(tt.objects, dpa)...)
+			if err != nil {
+				t.Fatalf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:         fakeClient,
+				Scheme:         fakeClient.Scheme(),
+				Log:            logr.Discard(),
+				Context:        newContextForTest(),
+				NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+				EventRecorder:  record.NewFakeRecorder(10),
+				dpa:            dpa,
+			}
+			_, err = r.ReconcileObjectBucketClaimLocations(r.Log)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReconcileObjectBucketClaimLocations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			condition := apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionObjectBucketClaimsBound)
+			if condition == nil {
+				t.Fatalf("expected %s condition", oadpv1alpha1.ConditionObjectBucketClaimsBound)
+			}
+			if got := condition.Status == metav1.ConditionFalse; got != (len(tt.wantPending) > 0) || !strings.HasSuffix(condition.Message, tt.wantPending) {
+				t.Errorf("expected pending claims %q, got condition %s %q", tt.wantPending, condition.Status, condition.Message)
+			}
+			// pending locations do not block the validation of the others
+			if _, err := r.ValidateBackupStorageLocations(); err != nil {
+				t.Errorf("ValidateBackupStorageLocations() error = %v", err)
+			}
+			if tt.wantSkipped {
+				if len(dpa.Spec.BackupLocations) != 0 || len(r.pendingBackupLocations) != 1 {
+					t.Errorf("expected the pending location to be skipped, got %v", dpa.Spec.BackupLocations)
+				}
+				return
+			}
+			if got := dpa.Spec.BackupLocations[0].Velero; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected backup location %#v, got %#v", tt.want, got)
+			}
+			if len(tt.wantPending) > 0 {
+				if err := fakeClient.Get(r.Context, client.ObjectKeyFromObject(obcCredentialsSecret), &corev1.Secret{}); err != nil {
+					t.Errorf("expected the credentials secret of the kept location, got %v", err)
+				}
+				return
+			}
+
+			secret := &corev1.Secret{}
+			if err := fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: credential.Name}, secret); err != nil {
+				t.Fatalf("expected credentials secret %s: %v", credential.Name, err)
+			}
+			if got, want := string(secret.Data[credential.Key]), "[default]\naws_access_key_id=test-key\naws_secret_access_key=test-secret\n"; got != want {
+				t.Errorf("expected credentials %q, got %q", want, got)
+			}
+			if !metav1.IsControlledBy(secret, dpa) {
+				t.Errorf("expected credentials secret %s to be owned by the DPA", secret.Name)
+			}
+			claimSecret := &corev1.Secret{}
+			if err := fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: "backups"}, claimSecret); err != nil {
+				t.Fatal(err)
+			}
+			if claimSecret.Labels["dataprotectionapplication.name"] != dpa.Name {
+				t.Errorf("expected the claim secret to be labelled with the DPA, got %v", claimSecret.Labels)
+			}
+			err = fakeClient.Get(r.Context, client.ObjectKeyFromObject(staleSecret), &corev1.Secret{})
+			if !k8serror.IsNotFound(err) {
+				t.Errorf("expected stale credentials secret to be deleted, got %v", err)
+			}
+		})
+	}
+}
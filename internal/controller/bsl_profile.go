@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	routev1 "github.com/openshift/api/route/v1"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+const (
+	// noobaaS3RouteNamespace and noobaaS3RouteName are the S3 route of the OpenShift Data Foundation Multicloud Object Gateway
+	noobaaS3RouteNamespace = "openshift-storage"
+	noobaaS3RouteName      = "s3"
+	// defaultIngressCertConfigMap holds the CA of the default ingress certificate, published by the ingress operator
+	defaultIngressCertNamespace = "openshift-config-managed"
+	defaultIngressCertConfigMap = "default-ingress-cert"
+	defaultIngressCertKey       = "ca-bundle.crt"
+	// backupLocationProfileRegion is replaced by the region of the config in the values of backupLocationProfileConfigs
+	backupLocationProfileRegion = "{region}"
+)
+
+// backupLocationProfileConfigs are the velero config values of the backup location profiles. The vendors do not
+// all support the CRC32 checksum the AWS plugin sends by default, so the checksum is disabled.
+var backupLocationProfileConfigs = map[oadpv1alpha1.BackupLocationProfile]map[string]string{
+	oadpv1alpha1.BackupLocationProfileODF: {
+		Region:            "noobaa",
+		S3ForcePathStyle:  "true",
+		checksumAlgorithm: "",
+	},
+	oadpv1alpha1.BackupLocationProfileMinIO: {
+		Region:            "minio",
+		S3ForcePathStyle:  "true",
+		checksumAlgorithm: "",
+	},
+	oadpv1alpha1.BackupLocationProfileIBMCOS: {
+		S3URL:             "https://s3." + backupLocationProfileRegion + ".cloud-object-storage.appdomain.cloud",
+		S3ForcePathStyle:  "true",
+		checksumAlgorithm: "",
+	},
+	oadpv1alpha1.BackupLocationProfileWasabi: {
+		S3URL:             "https://s3." + backupLocationProfileRegion + ".wasabisys.com",
+		S3ForcePathStyle:  "true",
+		checksumAlgorithm: "",
+	},
+}
+
+// applyBackupLocationProfiles expands the profile of the backup locations into their velero config, keeping the
+// values set in the config. The DPA is only changed in memory, so the BSLs, the registry and the location
+// credentials are all rendered with the preset values.
+func (r *DataProtectionApplicationReconciler) applyBackupLocationProfiles() error {
+	for i := range r.dpa.Spec.BackupLocations {
+		location := &r.dpa.Spec.BackupLocations[i]
+		if location.Profile == "" {
+			continue
+		}
+		preset, ok := backupLocationProfileConfigs[location.Profile]
+		if !ok {
+			return fmt.Errorf("DPA spec.backupLocations[%d].profile %s is invalid", i, location.Profile)
+		}
+		if location.Velero == nil {
+			return fmt.Errorf("DPA spec.backupLocations[%d].profile %s requires velero to be set", i, location.Profile)
+		}
+		bslSpec := location.Velero.DeepCopy()
+		if bslSpec.Provider == "" {
+			bslSpec.Provider = AWSProvider
+		}
+		if strings.TrimPrefix(bslSpec.Provider, veleroIOPrefix) != AWSProvider {
+			return fmt.Errorf("DPA spec.backupLocations[%d].profile %s requires provider %s, got %s", i, location.Profile, AWSProvider, bslSpec.Provider)
+		}
+		if bslSpec.Config == nil {
+			bslSpec.Config = map[string]string{}
+		}
+		for key, value := range preset {
+			if _, set := bslSpec.Config[key]; set {
+				continue
+			}
+			if strings.Contains(value, backupLocationProfileRegion) {
+				if bslSpec.Config[Region] == "" {
+					continue
+				}
+				value = strings.ReplaceAll(value, backupLocationProfileRegion, bslSpec.Config[Region])
+			}
+			bslSpec.Config[key] = value
+		}
+		if location.Profile == oadpv1alpha1.BackupLocationProfileODF {
+			if err := r.discoverNooBaaS3Endpoint(bslSpec); err != nil {
+				return err
+			}
+		}
+		location.Velero = bslSpec
+	}
+	return nil
+}
+
+// discoverNooBaaS3Endpoint sets the s3Url of an odf backup location to the NooBaa S3 route when it is not set, and
+// its caCert to the CA of the route certificate. A missing route is reported by validateAWSBackupStorageLocation.
+func (r *DataProtectionApplicationReconciler) discoverNooBaaS3Endpoint(bslSpec *velerov1.BackupStorageLocationSpec) error {
+	if bslSpec.Config[S3URL] != "" {
+		return nil
+	}
+	route := &routev1.Route{}
+	if err := r.ClusterWideClient.Get(r.Context, types.NamespacedName{Namespace: noobaaS3RouteNamespace, Name: noobaaS3RouteName}, route); err != nil {
+		if k8serror.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+			return nil
+		}
+		return fmt.Errorf("unable to get the NooBaa S3 route %s/%s: %w", noobaaS3RouteNamespace, noobaaS3RouteName, err)
+	}
+	if route.Spec.TLS == nil {
+		bslSpec.Config[S3URL] = (&url.URL{Scheme: "http", Host: route.Spec.Host}).String()
+		return nil
+	}
+	bslSpec.Config[S3URL] = (&url.URL{Scheme: "https", Host: route.Spec.Host}).String()
+	if bslSpec.ObjectStorage == nil || len(bslSpec.ObjectStorage.CACert) > 0 || bslSpec.Config[InsecureSkipTLSVerify] == "true" {
+		return nil
+	}
+	// the route is served with its own certificate, or the default ingress certificate
+	if route.Spec.TLS.CACertificate != "" {
+		bslSpec.ObjectStorage.CACert = []byte(route.Spec.TLS.CACertificate)
+		return nil
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := r.ClusterWideClient.Get(r.Context, types.NamespacedName{Namespace: defaultIngressCertNamespace, Name: defaultIngressCertConfigMap}, configMap); err != nil {
+		if k8serror.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("unable to get the default ingress CA %s/%s: %w", defaultIngressCertNamespace, defaultIngressCertConfigMap, err)
+	}
+	if ca := configMap.Data[defaultIngressCertKey]; ca != "" {
+		bslSpec.ObjectStorage.CACert = []byte(ca)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	routev1 "github.com/openshift/api/route/v1"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func TestDPAReconciler_applyBackupLocationProfiles(t *testing.T) {
+	noobaaRoute := func(tls *routev1.TLSConfig) *routev1.Route {
+		return &routev1.Route{
+			ObjectMeta: metav1.ObjectMeta{Name: noobaaS3RouteName, Namespace: noobaaS3RouteNamespace},
+			Spec:       routev1.RouteSpec{Host: "s3-openshift-storage.apps.example.com", TLS: tls},
+		}
+	}
+	ingressCA := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: defaultIngressCertConfigMap, Namespace: defaultIngressCertNamespace},
+		Data:       map[string]string{defaultIngressCertKey: "ingress-ca"},
+	}
+	tests := []struct {
+		name       string
+		profile    oadpv1alpha1.BackupLocationProfile
+		provider   string
+		config     map[string]string
+		objects    []client.Object
+		wantConfig map[string]string
+		wantCACert string
+		wantErr    bool
+	}{
+		{
+			name:       "minio",
+			profile:    oadpv1alpha1.BackupLocationProfileMinIO,
+			config:     map[string]string{S3URL: "http://minio.minio.svc:9000"},
+			wantConfig: map[string]string{S3URL: "http://minio.minio.svc:9000", Region: "minio", S3ForcePathStyle: "true", checksumAlgorithm: ""},
+		},
+		{
+			name:    "minio without s3Url",
+			profile: oadpv1alpha1.BackupLocationProfileMinIO,
+			wantErr: true,
+		},
+		{
+			name:    "minio with s3Url without scheme",
+			profile: oadpv1alpha1.BackupLocationProfileMinIO,
+			config:  map[string]string{S3URL: "minio.minio.svc:9000"},
+			wantErr: true,
+		},
+		{
+			name:    "ibm cos endpoint of the region",
+			profile: oadpv1alpha1.BackupLocationProfileIBMCOS,
+			config:  map[string]string{Region: "us-south"},
+			wantConfig: map[string]string{
+				Region:            "us-south",
+				S3URL:             "https://s3.us-south.cloud-object-storage.appdomain.cloud",
+				S3ForcePathStyle:  "true",
+				checksumAlgorithm: "",
+			},
+		},
+		{
+			name:    "ibm cos without region",
+			profile: oadpv1alpha1.BackupLocationProfileIBMCOS,
+			wantErr: true,
+		},
+		{
+			name:    "wasabi keeps the values of the config",
+			profile: oadpv1alpha1.BackupLocationProfileWasabi,
+			config:  map[string]string{Region: "eu-central-1", S3ForcePathStyle: "false", checksumAlgorithm: "CRC32"},
+			wantConfig: map[string]string{
+				Region:            "eu-central-1",
+				S3URL:             "https://s3.eu-central-1.wasabisys.com",
+				S3ForcePathStyle:  "false",
+				checksumAlgorithm: "CRC32",
+			},
+		},
+		{
+			name:     "profile of another provider",
+			profile:  oadpv1alpha1.BackupLocationProfileMinIO,
+			provider: GCPProvider,
+			config:   map[string]string{S3URL: "http://minio.minio.svc:9000"},
+			wantErr:  true,
+		},
+		{
+			name:       "odf with the default ingress certificate",
+			profile:    oadpv1alpha1.BackupLocationProfileODF,
+			objects:    []client.Object{noobaaRoute(&routev1.TLSConfig{Termination: routev1.TLSTerminationReencrypt}), ingressCA},
+			wantConfig: map[string]string{S3URL: "https://s3-openshift-storage.apps.example.com", Region: "noobaa", S3ForcePathStyle: "true", checksumAlgorithm: ""},
+			wantCACert: "ingress-ca",
+		},
+		{
+			name:       "odf with the route certificate",
+			profile:    oadpv1alpha1.BackupLocationProfileODF,
+			objects:    []client.Object{noobaaRoute(&routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, CACertificate: "route-ca"}), ingressCA},
+			wantConfig: map[string]string{S3URL: "https://s3-openshift-storage.apps.example.com", Region: "noobaa", S3ForcePathStyle: "true", checksumAlgorithm: ""},
+			wantCACert: "route-ca",
+		},
+		{
+			name:    "odf skipping TLS verification",
+			profile: oadpv1alpha1.BackupLocationProfileODF,
+			config:  map[string]string{InsecureSkipTLSVerify: "true"},
+			objects: []client.Object{noobaaRoute(&routev1.TLSConfig{Termination: routev1.TLSTerminationReencrypt}), ingressCA},
+			wantConfig: map[string]string{
+				S3URL:                 "https://s3-openshift-storage.apps.example.com",
+				Region:                "noobaa",
+				S3ForcePathStyle:      "true",
+				checksumAlgorithm:     "",
+				InsecureSkipTLSVerify: "true",
+			},
+		},
+		{
+			name:       "odf with s3Url",
+			profile:    oadpv1alpha1.BackupLocationProfileODF,
+			config:     map[string]string{S3URL: "https://s3.openshift-storage.svc"},
+			wantConfig: map[string]string{S3URL: "https://s3.openshift-storage.svc", Region: "noobaa", S3ForcePathStyle: "true", checksumAlgorithm: ""},
+		},
+		{
+			name:    "odf without the NooBaa route",
+			profile: oadpv1alpha1.BackupLocationProfileODF,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := AWSProvider
+			if tt.provider != "" {
+				provider = tt.provider
+			}
+			dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{
+				Configuration: &oadpv1alpha1.ApplicationConfig{
+					Velero: &oadpv1alpha1.VeleroConfig{DefaultPlugins: []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginAWS}},
+				},
+				BackupLocations: []oadpv1alpha1.BackupLocation{{
+					Profile: tt.profile,
+					Velero: &velerov1.BackupStorageLocationSpec{
+						Provider: provider,
+						Default:  true,
+						Config:   tt.config,
+						Credential: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "cloud-credentials"},
+							Key:                  "cloud",
+						},
+						StorageType: velerov1.StorageType{ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "velero", Prefix: "velero"}},
+					},
+				}},
+			})
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials", Namespace: dpa.Namespace},
+				Data:       map[string][]byte{"cloud": []byte("[default]\naws_access_key_id=test-key\naws_secret_access_key=test-secret")},
+			}
+			fakeClient, err := getFakeClientFromObjects(append(tt.objects, dpa, secret)...)
+			if err != nil {
+				t.Fatalf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:            fakeClient,
+				ClusterWideClient: fakeClient,
+				Scheme:            fakeClient.Scheme(),
+				Log:               logr.Discard(),
+				Context:           newContextForTest(),
+				NamespacedName:    types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+				EventRecorder:     record.NewFakeRecorder(10),
+				dpa:               dpa,
+			}
+			_, err = r.ValidateBackupStorageLocations()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateBackupStorageLocations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			bslSpec := dpa.Spec.BackupLocations[0].Velero
+			if !reflect.DeepEqual(bslSpec.Config, tt.wantConfig) {
+				t.Errorf("expected config %v, got %v", tt.wantConfig, bslSpec.Config)
+			}
+			if string(bslSpec.ObjectStorage.CACert) != tt.wantCACert {
+				t.Errorf("expected caCert %q, got %q", tt.wantCACert, bslSpec.ObjectStorage.CACert)
+			}
+		})
+	}
+}
@@ -4,14 +4,16 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/go-logr/logr"
 	"github.com/google/go-cmp/cmp"
 	configv1 "github.com/openshift/api/config/v1"
-	"github.com/stretchr/testify/assert"
+	routev1 "github.com/openshift/api/route/v1"
 	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
 	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -44,6 +46,11 @@ func getSchemeForFakeClient() (*runtime.Scheme, error) {
 		return nil, err
 	}
 
+	err = routev1.AddToScheme(scheme.Scheme)
+	if err != nil {
+		return nil, err
+	}
+
 	return scheme.Scheme, nil
 }
 
@@ -1608,7 +1615,7 @@ func TestDPAReconciler_updateBSLFromSpec(t *testing.T) {
 					},
 					Config: map[string]string{
 						Region:            "test-region",
-						checksumAlgorithm: "",
+						checksumAlgorithm: "CRC32",
 					},
 					Credential: &corev1.SecretKeySelector{
 						LocalObjectReference: corev1.LocalObjectReference{
@@ -1698,7 +1705,7 @@ func TestDPAReconciler_updateBSLFromSpec(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name: "checksumAlgorithm config is not specified by the user, add it as an empty string for BSL config",
+			name: "checksumAlgorithm config is not specified by the user, add the default of the AWS S3 endpoint for BSL config",
 			bsl: &velerov1.BackupStorageLocation{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "foo-1",
@@ -1768,7 +1775,7 @@ func TestDPAReconciler_updateBSLFromSpec(t *testing.T) {
 					},
 					Config: map[string]string{
 						Region:            "test-region",
-						checksumAlgorithm: "",
+						checksumAlgorithm: "CRC32",
 					},
 					Credential: &corev1.SecretKeySelector{
 						LocalObjectReference: corev1.LocalObjectReference{
@@ -1781,6 +1788,83 @@ func TestDPAReconciler_updateBSLFromSpec(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "checksumAlgorithm config is not specified by the user and the S3 vendor is not detected, keep the checksumAlgorithm of the BSL",
+			bsl: &velerov1.BackupStorageLocation{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo-1",
+					Namespace: "bar",
+				},
+				Spec: velerov1.BackupStorageLocationSpec{
+					Config: map[string]string{
+						checksumAlgorithm: "CRC32",
+					},
+				},
+			},
+			dpa: &oadpv1alpha1.DataProtectionApplication{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "bar",
+				},
+				Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+					BackupLocations: []oadpv1alpha1.BackupLocation{
+						{
+							Velero: &velerov1.BackupStorageLocationSpec{
+								Provider: "aws",
+								StorageType: velerov1.StorageType{
+									ObjectStorage: &velerov1.ObjectStorageLocation{
+										Bucket: "test-aws-bucket",
+										Prefix: "velero",
+									},
+								},
+								Config: map[string]string{
+									Region: "test-region",
+									S3URL:  "https://s3.example.com",
+								},
+								Default: true,
+							},
+						},
+					},
+				},
+			},
+			wantBSL: &velerov1.BackupStorageLocation{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo-1",
+					Namespace: "bar",
+					Labels: map[string]string{
+						"app.kubernetes.io/name":       "oadp-operator-velero",
+						"app.kubernetes.io/instance":   "foo" + "-1",
+						"app.kubernetes.io/managed-by": "oadp-operator",
+						"app.kubernetes.io/component":  "bsl",
+						oadpv1alpha1.OadpOperatorLabel: "True",
+						common.RegistryDeploymentLabel: "True",
+					},
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion:         oadpv1alpha1.SchemeBuilder.GroupVersion.String(),
+						Kind:               "DataProtectionApplication",
+						Name:               "foo",
+						Controller:         pointer.BoolPtr(true),
+						BlockOwnerDeletion: pointer.BoolPtr(true),
+					}},
+				},
+				Spec: velerov1.BackupStorageLocationSpec{
+					Provider: "aws",
+					StorageType: velerov1.StorageType{
+						ObjectStorage: &velerov1.ObjectStorageLocation{
+							Bucket: "test-aws-bucket",
+							Prefix: "velero",
+						},
+					},
+					Config: map[string]string{
+						Region:            "test-region",
+						S3URL:             "https://s3.example.com",
+						checksumAlgorithm: "CRC32",
+					},
+					Default: true,
+				},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -2317,7 +2401,7 @@ func TestDPAReconciler_ReconcileBackupStorageLocations(t *testing.T) {
 					Provider: "aws",
 					Config: map[string]string{
 						Region:            "us-east-1",
-						checksumAlgorithm: "",
+						checksumAlgorithm: "CRC32",
 					},
 					StorageType: velerov1.StorageType{
 						ObjectStorage: &velerov1.ObjectStorageLocation{
@@ -2459,7 +2543,7 @@ func TestDPAReconciler_ReconcileBackupStorageLocations(t *testing.T) {
 					Provider: "aws",
 					Config: map[string]string{
 						Region:            "us-east-1",
-						checksumAlgorithm: "",
+						checksumAlgorithm: "CRC32",
 					},
 					StorageType: velerov1.StorageType{
 						ObjectStorage: &velerov1.ObjectStorageLocation{
@@ -2597,549 +2681,343 @@ func TestDPAReconciler_ReconcileBackupStorageLocations(t *testing.T) {
 	}
 }
 
-func TestPatchSecretsForBSL(t *testing.T) {
+func TestDPAReconciler_ReconcileBackupStorageLocations_Deletion(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials", Namespace: "test-ns"},
+		Data:       map[string][]byte{"credentials": {}},
+	}
+	dpaBSL := func(name string) *velerov1.BackupStorageLocation {
+		return &velerov1.BackupStorageLocation{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "test-ns",
+				Labels: map[string]string{
+					"app.kubernetes.io/name":       common.OADPOperatorVelero,
+					"app.kubernetes.io/managed-by": common.OADPOperator,
+					"app.kubernetes.io/component":  "bsl",
+				},
+			},
+			Spec: velerov1.BackupStorageLocationSpec{Provider: "aws"},
+		}
+	}
+	backup := func(name, bslName string) *velerov1.Backup {
+		return &velerov1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "test-ns",
+				Labels:    map[string]string{velerov1.StorageLocationLabel: bslName},
+			},
+			Spec: velerov1.BackupSpec{StorageLocation: bslName},
+		}
+	}
+	schedule := &velerov1.Schedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "daily", Namespace: "test-ns"},
+		Spec:       velerov1.ScheduleSpec{Template: velerov1.BackupSpec{StorageLocation: "old"}},
+	}
 	tests := []struct {
-		name          string
-		bsl           *velerov1.BackupStorageLocation
-		bslSpec       oadpv1alpha1.BackupLocation
-		secret        *corev1.Secret
-		cloudStorage  *oadpv1alpha1.CloudStorage
-		expectError   bool
-		errorContains string
-		verifyFunc    func(t *testing.T, secret *corev1.Secret)
+		name            string
+		annotations     map[string]string
+		location        oadpv1alpha1.BackupLocation
+		objects         []client.Object
+		wantBSLs        []string
+		wantBlocked     string
+		wantBackupBSL   string
+		wantScheduleBSL string
 	}{
 		{
-			name: "AWS provider with known bucket openshift-velero-plugin-s3-auto-region-test-1",
-			bsl: &velerov1.BackupStorageLocation{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-bsl",
-					Namespace: "test-ns",
-				},
-			},
-			bslSpec: oadpv1alpha1.BackupLocation{
-				Velero: &velerov1.BackupStorageLocationSpec{
-					Provider: "aws",
-					Config: map[string]string{
-						"region": "us-east-1",
-						"bucket": "openshift-velero-plugin-s3-auto-region-test-1",
-					},
-					Credential: &corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: "aws-secret",
-						},
-						Key: "credentials",
-					},
-				},
-			},
-			secret: &corev1.Secret{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "aws-secret",
-					Namespace: "test-ns",
-					Labels: map[string]string{
-						"oadp.openshift.io/secret-type": "sts-credentials",
-					},
-				},
-				Data: map[string][]byte{
-					"credentials": []byte(`[default]
-role_arn = arn:aws:iam::123456789012:role/test-role
-web_identity_token_file = /var/run/secrets/openshift/serviceaccount/token`),
-				},
-			},
-			verifyFunc: func(t *testing.T, secret *corev1.Secret) {
-				assert.Contains(t, string(secret.Data["credentials"]), "region = us-east-1")
-			},
+			name:     "unreferenced BSL removed from the spec is deleted",
+			location: oadpv1alpha1.BackupLocation{Name: "new"},
+			objects:  []client.Object{dpaBSL("old"), backup("other", "new")},
+			wantBSLs: []string{"new"},
 		},
 		{
-			name: "AWS provider with known bucket openshift-velero-plugin-s3-auto-region-test-2",
-			bsl: &velerov1.BackupStorageLocation{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-bsl",
-					Namespace: "test-ns",
-				},
-			},
-			bslSpec: oadpv1alpha1.BackupLocation{
-				Velero: &velerov1.BackupStorageLocationSpec{
-					Provider: "aws",
-					Config: map[string]string{
-						"region": "us-west-1",
-						"bucket": "openshift-velero-plugin-s3-auto-region-test-2",
-					},
-					Credential: &corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: "aws-secret",
-						},
-						Key: "credentials",
-					},
-				},
-			},
-			secret: &corev1.Secret{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "aws-secret",
-					Namespace: "test-ns",
-					Labels: map[string]string{
-						"oadp.openshift.io/secret-type": "sts-credentials",
-					},
-				},
-				Data: map[string][]byte{
-					"credentials": []byte(`[default]
-role_arn = arn:aws:iam::123456789012:role/test-role
-web_identity_token_file = /var/run/secrets/openshift/serviceaccount/token`),
-				},
-			},
-			verifyFunc: func(t *testing.T, secret *corev1.Secret) {
-				assert.Contains(t, string(secret.Data["credentials"]), "region = us-west-1")
-			},
+			name:            "BSL referenced by Backups and Schedules is not deleted",
+			location:        oadpv1alpha1.BackupLocation{Name: "new"},
+			objects:         []client.Object{dpaBSL("old"), backup("backup", "old"), schedule},
+			wantBSLs:        []string{"new", "old"},
+			wantBlocked:     "BackupStorageLocations removed from the DPA spec are not deleted as they are still referenced: old (1 Backups, 1 Schedules).",
+			wantBackupBSL:   "old",
+			wantScheduleBSL: "old",
 		},
 		{
-			name: "AWS provider with known bucket openshift-velero-plugin-s3-auto-region-test-3",
-			bsl: &velerov1.BackupStorageLocation{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-bsl",
-					Namespace: "test-ns",
-				},
-			},
-			bslSpec: oadpv1alpha1.BackupLocation{
-				Velero: &velerov1.BackupStorageLocationSpec{
-					Provider: "aws",
-					Config: map[string]string{
-						"region": "eu-central-1",
-						"bucket": "openshift-velero-plugin-s3-auto-region-test-3",
-					},
-					Credential: &corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: "aws-secret",
-						},
-						Key: "credentials",
-					},
-				},
-			},
-			secret: &corev1.Secret{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "aws-secret",
-					Namespace: "test-ns",
-					Labels: map[string]string{
-						"oadp.openshift.io/secret-type": "sts-credentials",
-					},
-				},
-				Data: map[string][]byte{
-					"credentials": []byte(`[default]
-role_arn = arn:aws:iam::123456789012:role/test-role
-web_identity_token_file = /var/run/secrets/openshift/serviceaccount/token`),
-				},
-			},
-			verifyFunc: func(t *testing.T, secret *corev1.Secret) {
-				assert.Contains(t, string(secret.Data["credentials"]), "region = eu-central-1")
-			},
+			name:            "referenced BSL is deleted with the force annotation",
+			annotations:     map[string]string{common.ForceBackupLocationDeletionAnnotation: "true"},
+			location:        oadpv1alpha1.BackupLocation{Name: "new"},
+			objects:         []client.Object{dpaBSL("old"), backup("backup", "old"), schedule},
+			wantBSLs:        []string{"new"},
+			wantBackupBSL:   "old",
+			wantScheduleBSL: "old",
 		},
 		{
-			name: "Azure provider with resource group patching",
-			bsl: &velerov1.BackupStorageLocation{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-bsl",
-					Namespace: "test-ns",
-				},
-			},
-			bslSpec: oadpv1alpha1.BackupLocation{
-				Velero: &velerov1.BackupStorageLocationSpec{
-					Provider: "azure",
-					Config: map[string]string{
-						"resourceGroup": "test-rg",
-					},
-					Credential: &corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: "azure-secret",
-						},
-						Key: "azurekey",
-					},
-				},
-			},
-			secret: &corev1.Secret{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "azure-secret",
-					Namespace: "test-ns",
-					Labels: map[string]string{
-						"oadp.openshift.io/secret-type": "sts-credentials",
-					},
+			name:            "renamed BSL references are rewritten before deletion",
+			location:        oadpv1alpha1.BackupLocation{Name: "new", PreviousName: "old"},
+			objects:         []client.Object{dpaBSL("old"), backup("backup", "old"), schedule},
+			wantBSLs:        []string{"new"},
+			wantBackupBSL:   "new",
+			wantScheduleBSL: "new",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location := tt.location
+			location.Velero = &velerov1.BackupStorageLocationSpec{
+				Provider: "aws",
+				Default:  true,
+				Config:   map[string]string{Region: "us-east-1"},
+				StorageType: velerov1.StorageType{
+					ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "test-bucket", Prefix: "velero"},
 				},
-				Data: map[string][]byte{
-					"azurekey": []byte(`AZURE_CLIENT_ID=test-client
-AZURE_TENANT_ID=test-tenant`),
+			}
+			dpa := &oadpv1alpha1.DataProtectionApplication{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: "test-ns", Annotations: tt.annotations},
+				Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+					BackupLocations: []oadpv1alpha1.BackupLocation{location},
 				},
-			},
-			verifyFunc: func(t *testing.T, secret *corev1.Secret) {
-				assert.Contains(t, string(secret.Data["azurekey"]), "AZURE_RESOURCE_GROUP=test-rg")
-			},
+			}
+			fakeClient, err := getFakeClientFromObjects(append([]client.Object{dpa, secret}, tt.objects...)...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:         fakeClient,
+				Scheme:         fakeClient.Scheme(),
+				Log:            logr.Discard(),
+				Context:        newContextForTest(),
+				NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+				EventRecorder:  record.NewFakeRecorder(10),
+				dpa:            dpa,
+			}
+			if _, err := r.ReconcileBackupStorageLocations(r.Log); err != nil {
+				t.Fatalf("ReconcileBackupStorageLocations() error = %v", err)
+			}
+
+			bsls := &velerov1.BackupStorageLocationList{}
+			if err := fakeClient.List(r.Context, bsls, client.InNamespace("test-ns")); err != nil {
+				t.Fatal(err)
+			}
+			gotBSLs := []string{}
+			for _, bsl := range bsls.Items {
+				gotBSLs = append(gotBSLs, bsl.Name)
+			}
+			if !reflect.DeepEqual(gotBSLs, tt.wantBSLs) {
+				t.Errorf("expected BSLs %v, got %v", tt.wantBSLs, gotBSLs)
+			}
+
+			condition := apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionBackupLocationDeletionBlocked)
+			if tt.wantBlocked == "" && condition != nil {
+				t.Errorf("expected no %s condition, got %v", oadpv1alpha1.ConditionBackupLocationDeletionBlocked, condition)
+			}
+			if tt.wantBlocked != "" && (condition == nil || !strings.HasPrefix(condition.Message, tt.wantBlocked)) {
+				t.Errorf("expected %s condition with message %q, got %v", oadpv1alpha1.ConditionBackupLocationDeletionBlocked, tt.wantBlocked, condition)
+			}
+
+			if tt.wantBackupBSL != "" {
+				gotBackup := &velerov1.Backup{}
+				if err := fakeClient.Get(r.Context, types.NamespacedName{Namespace: "test-ns", Name: "backup"}, gotBackup); err != nil {
+					t.Fatal(err)
+				}
+				if gotBackup.Spec.StorageLocation != tt.wantBackupBSL || gotBackup.Labels[velerov1.StorageLocationLabel] != tt.wantBackupBSL {
+					t.Errorf("expected backup storage location %s, got %s with label %s", tt.wantBackupBSL, gotBackup.Spec.StorageLocation, gotBackup.Labels[velerov1.StorageLocationLabel])
+				}
+			}
+			if tt.wantScheduleBSL != "" {
+				gotSchedule := &velerov1.Schedule{}
+				if err := fakeClient.Get(r.Context, types.NamespacedName{Namespace: "test-ns", Name: "daily"}, gotSchedule); err != nil {
+					t.Fatal(err)
+				}
+				if gotSchedule.Spec.Template.StorageLocation != tt.wantScheduleBSL {
+					t.Errorf("expected schedule storage location %s, got %s", tt.wantScheduleBSL, gotSchedule.Spec.Template.StorageLocation)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateBackupLocationRenames(t *testing.T) {
+	tests := []struct {
+		name      string
+		locations []oadpv1alpha1.BackupLocation
+		wantErr   string
+	}{
+		{
+			name:      "rename",
+			locations: []oadpv1alpha1.BackupLocation{{Name: "new", PreviousName: "old"}, {}},
 		},
 		{
-			name: "GCP provider - no patching needed",
-			bsl: &velerov1.BackupStorageLocation{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-bsl",
-					Namespace: "test-ns",
-				},
-			},
-			bslSpec: oadpv1alpha1.BackupLocation{
-				Velero: &velerov1.BackupStorageLocationSpec{
-					Provider: "gcp",
-					Credential: &corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: "gcp-secret",
-						},
-						Key: "cloud",
-					},
-				},
-			},
-			secret: &corev1.Secret{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "gcp-secret",
-					Namespace: "test-ns",
-				},
-				Data: map[string][]byte{
-					"cloud": []byte(`{"type":"service_account"}`),
-				},
-			},
-			verifyFunc: func(t *testing.T, secret *corev1.Secret) {
-				// Should remain unchanged
-				assert.Equal(t, `{"type":"service_account"}`, string(secret.Data["cloud"]))
-			},
+			name:      "rename of an unnamed location",
+			locations: []oadpv1alpha1.BackupLocation{{PreviousName: "old"}},
 		},
 		{
-			name: "CloudStorage AWS provider",
-			bsl: &velerov1.BackupStorageLocation{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-bsl",
-					Namespace: "test-ns",
-				},
-			},
-			bslSpec: oadpv1alpha1.BackupLocation{
-				CloudStorage: &oadpv1alpha1.CloudStorageLocation{
-					CloudStorageRef: corev1.LocalObjectReference{
-						Name: "test-cloudstorage",
-					},
-					Config: map[string]string{
-						"region": "eu-west-1",
-					},
-					Credential: &corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: "aws-secret",
-						},
-						Key: "credentials",
-					},
-				},
-			},
-			cloudStorage: &oadpv1alpha1.CloudStorage{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-cloudstorage",
-					Namespace: "test-ns",
-				},
-				Spec: oadpv1alpha1.CloudStorageSpec{
-					Provider: oadpv1alpha1.AWSBucketProvider,
-				},
-			},
-			secret: &corev1.Secret{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "aws-secret",
-					Namespace: "test-ns",
-					Labels: map[string]string{
-						"oadp.openshift.io/secret-type": "sts-credentials",
-					},
-				},
-				Data: map[string][]byte{
-					"credentials": []byte(`[default]
-role_arn = arn:aws:iam::123456789012:role/test-role
-web_identity_token_file = /var/run/secrets/openshift/serviceaccount/token`),
-				},
-			},
-			verifyFunc: func(t *testing.T, secret *corev1.Secret) {
-				assert.Contains(t, string(secret.Data["credentials"]), "region = eu-west-1")
-			},
+			name:      "previousName of a location of the spec",
+			locations: []oadpv1alpha1.BackupLocation{{Name: "new", PreviousName: "test-dpa-1"}, {}},
+			wantErr:   "previousName test-dpa-1 of backup location new is the name of a backup location of the DPA",
 		},
 		{
-			name: "No secret name - uses default cloud-credentials",
-			bsl: &velerov1.BackupStorageLocation{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-bsl",
-					Namespace: "test-ns",
-				},
-			},
-			bslSpec: oadpv1alpha1.BackupLocation{
-				Velero: &velerov1.BackupStorageLocationSpec{
-					Provider: "aws",
-				},
-			},
-			secret: &corev1.Secret{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "cloud-credentials",
-					Namespace: "test-ns",
-				},
-				Data: map[string][]byte{
-					"cloud": []byte(`[default]
-aws_access_key_id=test-key
-aws_secret_access_key=test-secret`),
-				},
+			name:      "previousName set twice",
+			locations: []oadpv1alpha1.BackupLocation{{Name: "a", PreviousName: "old"}, {Name: "b", PreviousName: "old"}},
+			wantErr:   "previousName old is set on more than one backup location",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := &oadpv1alpha1.DataProtectionApplication{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: "test-ns"},
+				Spec:       oadpv1alpha1.DataProtectionApplicationSpec{BackupLocations: tt.locations},
+			}
+			fakeClient, err := getFakeClientFromObjects(dpa)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{Client: fakeClient, Context: newContextForTest(), dpa: dpa}
+			names, _, err := r.getBackupLocationNames()
+			if err != nil {
+				t.Fatalf("getBackupLocationNames() error = %v", err)
+			}
+			err = validateBackupLocationRenames(dpa, names)
+			if (err != nil) != (tt.wantErr != "") || (err != nil && err.Error() != tt.wantErr) {
+				t.Errorf("validateBackupLocationRenames() error = %v, wantErr %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_getBackupLocationNames(t *testing.T) {
+	location := func(bucket string) oadpv1alpha1.BackupLocation {
+		return oadpv1alpha1.BackupLocation{Velero: &velerov1.BackupStorageLocationSpec{
+			Provider: "aws",
+			StorageType: velerov1.StorageType{
+				ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: bucket, Prefix: "velero"},
 			},
-			expectError: false,
-			verifyFunc: func(t *testing.T, secret *corev1.Secret) {
-				// Should not be patched since it doesn't have the STS label
-				assert.NotContains(t, string(secret.Data["cloud"]), "region")
+		}}
+	}
+	named := func(name, bucket string) oadpv1alpha1.BackupLocation {
+		bslSpec := location(bucket)
+		bslSpec.Name = name
+		return bslSpec
+	}
+	onEndpoint := func(bucket, s3Url string) oadpv1alpha1.BackupLocation {
+		bslSpec := location(bucket)
+		bslSpec.Velero.Config = map[string]string{S3URL: s3Url, Region: "minio"}
+		return bslSpec
+	}
+	newDpa := func(locations []oadpv1alpha1.BackupLocation, recorded []oadpv1alpha1.BackupLocationName) *oadpv1alpha1.DataProtectionApplication {
+		return &oadpv1alpha1.DataProtectionApplication{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: "test-ns", UID: "test-uid"},
+			Spec:       oadpv1alpha1.DataProtectionApplicationSpec{BackupLocations: locations},
+			Status:     oadpv1alpha1.DataProtectionApplicationStatus{BackupLocationNames: recorded},
+		}
+	}
+	existingBSL := func(dpa *oadpv1alpha1.DataProtectionApplication, name string, bslSpec oadpv1alpha1.BackupLocation) client.Object {
+		bsl := &velerov1.BackupStorageLocation{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns"},
+			Spec:       *bslSpec.Velero,
+		}
+		bsl.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(dpa, oadpv1alpha1.GroupVersion.WithKind("DataProtectionApplication"))})
+		return bsl
+	}
+	tests := []struct {
+		name          string
+		dpa           *oadpv1alpha1.DataProtectionApplication
+		objects       func(dpa *oadpv1alpha1.DataProtectionApplication) []client.Object
+		wantNames     []string
+		wantGenerated []oadpv1alpha1.BackupLocationName
+		wantErr       string
+	}{
+		{
+			name:      "locations without name are numbered",
+			dpa:       newDpa([]oadpv1alpha1.BackupLocation{location("a"), location("b")}, nil),
+			wantNames: []string{"test-dpa-1", "test-dpa-2"},
+			wantGenerated: []oadpv1alpha1.BackupLocationName{
+				{Name: "test-dpa-1", Storage: "aws:a/velero"},
+				{Name: "test-dpa-2", Storage: "aws:b/velero"},
 			},
 		},
 		{
-			name: "Secret not found",
-			bsl: &velerov1.BackupStorageLocation{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-bsl",
-					Namespace: "test-ns",
-				},
-			},
-			bslSpec: oadpv1alpha1.BackupLocation{
-				Velero: &velerov1.BackupStorageLocationSpec{
-					Provider: "aws",
-					Credential: &corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: "non-existent-secret",
-						},
-						Key: "cloud",
-					},
-				},
+			name: "location inserted first keeps the recorded names",
+			dpa: newDpa([]oadpv1alpha1.BackupLocation{location("c"), location("a"), location("b")}, []oadpv1alpha1.BackupLocationName{
+				{Name: "test-dpa-1", Storage: "aws:a/velero"},
+				{Name: "test-dpa-2", Storage: "aws:b/velero"},
+			}),
+			wantNames: []string{"test-dpa-3", "test-dpa-1", "test-dpa-2"},
+			wantGenerated: []oadpv1alpha1.BackupLocationName{
+				{Name: "test-dpa-3", Storage: "aws:c/velero"},
+				{Name: "test-dpa-1", Storage: "aws:a/velero"},
+				{Name: "test-dpa-2", Storage: "aws:b/velero"},
 			},
-			expectError:   true,
-			errorContains: "failed to get secret",
 		},
 		{
-			name: "CloudStorage not found",
-			bsl: &velerov1.BackupStorageLocation{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-bsl",
-					Namespace: "test-ns",
-				},
+			name: "existing BSLs with the same storage are adopted",
+			dpa:  newDpa([]oadpv1alpha1.BackupLocation{location("new"), location("b")}, nil),
+			objects: func(dpa *oadpv1alpha1.DataProtectionApplication) []client.Object {
+				return []client.Object{existingBSL(dpa, "test-dpa-1", location("b"))}
 			},
-			bslSpec: oadpv1alpha1.BackupLocation{
-				CloudStorage: &oadpv1alpha1.CloudStorageLocation{
-					CloudStorageRef: corev1.LocalObjectReference{
-						Name: "non-existent-cloudstorage",
-					},
-				},
+			wantNames: []string{"test-dpa-2", "test-dpa-1"},
+			wantGenerated: []oadpv1alpha1.BackupLocationName{
+				{Name: "test-dpa-2", Storage: "aws:new/velero"},
+				{Name: "test-dpa-1", Storage: "aws:b/velero"},
 			},
-			expectError:   true,
-			errorContains: "not found",
 		},
 		{
-			name: "AWS provider with STS credentials - should be patched",
-			bsl: &velerov1.BackupStorageLocation{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-bsl",
-					Namespace: "test-ns",
-				},
-			},
-			bslSpec: oadpv1alpha1.BackupLocation{
-				Velero: &velerov1.BackupStorageLocationSpec{
-					Provider: "aws",
-					Config: map[string]string{
-						"region": "us-east-1",
-					},
-					Credential: &corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: "aws-sts-secret",
-						},
-						Key: "credentials",
-					},
-					StorageType: velerov1.StorageType{
-						ObjectStorage: &velerov1.ObjectStorageLocation{
-							Bucket: "test-bucket",
-						},
-					},
-				},
-			},
-			secret: &corev1.Secret{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "aws-sts-secret",
-					Namespace: "test-ns",
-					Labels: map[string]string{
-						"oadp.openshift.io/secret-type": "sts-credentials",
-					},
-				},
-				Data: map[string][]byte{
-					"credentials": []byte(`[default]
-role_arn = arn:aws:iam::123456789012:role/test-role
-web_identity_token_file = /var/run/secrets/openshift/serviceaccount/token`),
-				},
-			},
-			verifyFunc: func(t *testing.T, secret *corev1.Secret) {
-				assert.Contains(t, string(secret.Data["credentials"]), "region = us-east-1")
-			},
+			name:          "names of the spec are not generated",
+			dpa:           newDpa([]oadpv1alpha1.BackupLocation{location("a"), named("test-dpa-1", "b")}, nil),
+			wantNames:     []string{"test-dpa-2", "test-dpa-1"},
+			wantGenerated: []oadpv1alpha1.BackupLocationName{{Name: "test-dpa-2", Storage: "aws:a/velero"}},
 		},
 		{
-			name: "AWS provider without STS label - should NOT be patched",
-			bsl: &velerov1.BackupStorageLocation{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-bsl",
-					Namespace: "test-ns",
-				},
-			},
-			bslSpec: oadpv1alpha1.BackupLocation{
-				Velero: &velerov1.BackupStorageLocationSpec{
-					Provider: "aws",
-					Config: map[string]string{
-						"region": "us-east-1",
-					},
-					Credential: &corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: "aws-regular-secret",
-						},
-						Key: "credentials",
-					},
-					StorageType: velerov1.StorageType{
-						ObjectStorage: &velerov1.ObjectStorageLocation{
-							Bucket: "test-bucket",
-						},
-					},
-				},
-			},
-			secret: &corev1.Secret{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "aws-regular-secret",
-					Namespace: "test-ns",
-				},
-				Data: map[string][]byte{
-					"credentials": []byte(`[default]
-aws_access_key_id=test-key
-aws_secret_access_key=test-secret`),
-				},
-			},
-			verifyFunc: func(t *testing.T, secret *corev1.Secret) {
-				// Should NOT contain region since it's not an STS secret
-				assert.NotContains(t, string(secret.Data["credentials"]), "region = ")
-			},
+			name:      "named locations only",
+			dpa:       newDpa([]oadpv1alpha1.BackupLocation{named("primary", "a")}, nil),
+			wantNames: []string{"primary"},
 		},
 		{
-			name: "Azure provider with STS credentials - should be patched",
-			bsl: &velerov1.BackupStorageLocation{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-bsl",
-					Namespace: "test-ns",
-				},
-			},
-			bslSpec: oadpv1alpha1.BackupLocation{
-				Velero: &velerov1.BackupStorageLocationSpec{
-					Provider: "azure",
-					Config: map[string]string{
-						"resourceGroup": "test-rg",
-					},
-					Credential: &corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: "azure-sts-secret",
-						},
-						Key: "azurekey",
-					},
-				},
-			},
-			secret: &corev1.Secret{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "azure-sts-secret",
-					Namespace: "test-ns",
-					Labels: map[string]string{
-						"oadp.openshift.io/secret-type": "sts-credentials",
-					},
-				},
-				Data: map[string][]byte{
-					"azurekey": []byte(`AZURE_CLIENT_ID=test-client
-AZURE_TENANT_ID=test-tenant`),
-				},
-			},
-			verifyFunc: func(t *testing.T, secret *corev1.Secret) {
-				assert.Contains(t, string(secret.Data["azurekey"]), "AZURE_RESOURCE_GROUP=test-rg")
+			name:      "locations without name with the same bucket on two endpoints",
+			dpa:       newDpa([]oadpv1alpha1.BackupLocation{onEndpoint("velero", "https://minio-a.example.com"), onEndpoint("velero", "https://minio-b.example.com")}, nil),
+			wantNames: []string{"test-dpa-1", "test-dpa-2"},
+			wantGenerated: []oadpv1alpha1.BackupLocationName{
+				{Name: "test-dpa-1", Storage: "aws:velero/velero,s3Url=https://minio-a.example.com,region=minio"},
+				{Name: "test-dpa-2", Storage: "aws:velero/velero,s3Url=https://minio-b.example.com,region=minio"},
 			},
 		},
 		{
-			name: "Azure provider without STS label - should NOT be patched",
-			bsl: &velerov1.BackupStorageLocation{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-bsl",
-					Namespace: "test-ns",
-				},
-			},
-			bslSpec: oadpv1alpha1.BackupLocation{
-				Velero: &velerov1.BackupStorageLocationSpec{
-					Provider: "azure",
-					Config: map[string]string{
-						"resourceGroup": "test-rg",
-					},
-					Credential: &corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: "azure-regular-secret",
-						},
-						Key: "azurekey",
-					},
-				},
+			name: "existing BSL of the same bucket on the other endpoint is not adopted",
+			dpa: newDpa([]oadpv1alpha1.BackupLocation{onEndpoint("velero", "https://minio-a.example.com"), onEndpoint("velero", "https://minio-b.example.com")}, []oadpv1alpha1.BackupLocationName{
+				// recorded without the endpoint by a previous version of the operator
+				{Name: "test-dpa-1", Storage: "aws:velero/velero"},
+			}),
+			objects: func(dpa *oadpv1alpha1.DataProtectionApplication) []client.Object {
+				return []client.Object{existingBSL(dpa, "test-dpa-1", onEndpoint("velero", "https://minio-b.example.com"))}
 			},
-			secret: &corev1.Secret{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "azure-regular-secret",
-					Namespace: "test-ns",
-				},
-				Data: map[string][]byte{
-					"azurekey": []byte(`AZURE_CLIENT_ID=test-client
-AZURE_CLIENT_SECRET=test-secret
-AZURE_TENANT_ID=test-tenant`),
-				},
-			},
-			verifyFunc: func(t *testing.T, secret *corev1.Secret) {
-				// Should NOT contain resource group since it's not an STS secret
-				assert.NotContains(t, string(secret.Data["azurekey"]), "AZURE_RESOURCE_GROUP=")
+			wantNames: []string{"test-dpa-2", "test-dpa-1"},
+			wantGenerated: []oadpv1alpha1.BackupLocationName{
+				{Name: "test-dpa-2", Storage: "aws:velero/velero,s3Url=https://minio-a.example.com,region=minio"},
+				{Name: "test-dpa-1", Storage: "aws:velero/velero,s3Url=https://minio-b.example.com,region=minio"},
 			},
 		},
+		{
+			name:    "locations without name with the same storage",
+			dpa:     newDpa([]oadpv1alpha1.BackupLocation{location("a"), location("a")}, nil),
+			wantErr: "backup locations 1 and 2 use the same storage aws:a/velero, set their name",
+		},
 	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create fake client with objects
-			objs := []client.Object{}
-			if tt.secret != nil {
-				objs = append(objs, tt.secret)
-			}
-			if tt.cloudStorage != nil {
-				objs = append(objs, tt.cloudStorage)
+			objects := []client.Object{tt.dpa}
+			if tt.objects != nil {
+				objects = append(objects, tt.objects(tt.dpa)...)
 			}
-			schemeForFakeClient, err := getSchemeForFakeClient()
+			fakeClient, err := getFakeClientFromObjects(objects...)
 			if err != nil {
-				t.Error(err)
+				t.Errorf("error in creating fake client, likely programmer error")
 			}
-			fakeClient := fake.NewClientBuilder().
-				WithScheme(schemeForFakeClient).
-				WithObjects(objs...).
-				Build()
-
-			r := &DataProtectionApplicationReconciler{
-				Client:  fakeClient,
-				Scheme:  schemeForFakeClient,
-				Context: context.Background(),
-				Log:     logr.Discard(),
+			r := &DataProtectionApplicationReconciler{Client: fakeClient, Context: newContextForTest(), dpa: tt.dpa}
+			names, generated, err := r.getBackupLocationNames()
+			if (err != nil) != (tt.wantErr != "") || (err != nil && err.Error() != tt.wantErr) {
+				t.Fatalf("getBackupLocationNames() error = %v, wantErr %q", err, tt.wantErr)
 			}
-
-			// Call the function
-			err = r.patchSecretsForBSL(tt.bsl, tt.bslSpec)
-
-			// Check error
-			if tt.expectError {
-				assert.Error(t, err)
-				if err != nil && tt.errorContains != "" {
-					assert.Contains(t, err.Error(), tt.errorContains)
-				}
-			} else {
-				assert.NoError(t, err)
-
-				// Verify secret if needed
-				if tt.verifyFunc != nil && tt.secret != nil {
-					// Get the updated secret
-					updatedSecret := &corev1.Secret{}
-					err := fakeClient.Get(context.Background(), client.ObjectKey{
-						Name:      tt.secret.Name,
-						Namespace: tt.secret.Namespace,
-					}, updatedSecret)
-					assert.NoError(t, err)
-					tt.verifyFunc(t, updatedSecret)
-				}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("expected names %v, got %v", tt.wantNames, names)
+			}
+			if !reflect.DeepEqual(generated, tt.wantGenerated) {
+				t.Errorf("expected generated names %v, got %v", tt.wantGenerated, generated)
 			}
 		})
 	}
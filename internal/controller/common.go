@@ -4,6 +4,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
 
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
 	"github.com/openshift/oadp-operator/pkg/common"
 )
 
@@ -66,3 +67,41 @@ func setPodTemplateSpecDefaults(template *corev1.PodTemplateSpec) {
 		}
 	}
 }
+
+// applyPodConfig sets the scheduling, annotations and pod security context from podConfig on a component pod template
+func applyPodConfig(template *corev1.PodTemplateSpec, podConfig *oadpv1alpha1.PodConfig) {
+	if podConfig == nil {
+		return
+	}
+	if len(podConfig.PriorityClassName) != 0 {
+		template.Spec.PriorityClassName = podConfig.PriorityClassName
+	}
+	if len(podConfig.TopologySpreadConstraints) != 0 {
+		template.Spec.TopologySpreadConstraints = podConfig.TopologySpreadConstraints
+	}
+	if podConfig.PodAntiAffinity != nil {
+		if template.Spec.Affinity == nil {
+			template.Spec.Affinity = &corev1.Affinity{}
+		}
+		template.Spec.Affinity.PodAntiAffinity = podConfig.PodAntiAffinity.DeepCopy()
+	}
+	if len(podConfig.Annotations) != 0 {
+		if template.Annotations == nil {
+			template.Annotations = make(map[string]string, len(podConfig.Annotations))
+		}
+		for key, value := range podConfig.Annotations {
+			template.Annotations[key] = value
+		}
+	}
+	if podConfig.PodSecurityContext != nil {
+		template.Spec.SecurityContext = podConfig.PodSecurityContext.DeepCopy()
+	}
+}
+
+// applyContainerPodConfig sets the container security context from podConfig on a component container
+func applyContainerPodConfig(container *corev1.Container, podConfig *oadpv1alpha1.PodConfig) {
+	if podConfig == nil || podConfig.ContainerSecurityContext == nil {
+		return
+	}
+	container.SecurityContext = podConfig.ContainerSecurityContext.DeepCopy()
+}
@@ -26,6 +26,8 @@ import (
 	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -35,6 +37,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
@@ -52,6 +55,20 @@ type DataProtectionApplicationReconciler struct {
 	EventRecorder     record.EventRecorder
 	dpa               *oadpv1alpha1.DataProtectionApplication
 	ClusterWideClient client.Client
+	deferredRollouts  []string
+	dryRun            bool
+	// inProgressOperations are the Backups and Restores in progress in the DPA namespace
+	inProgressOperations []string
+	// resolvedImages maps configured images to their digest pinned images, when spec.pinImageDigests is set
+	resolvedImages map[string]string
+	// imageLabels are the labels of the resolved images
+	imageLabels map[string]map[string]string
+	// unsupportedServerArgs are the server args overridden by the unsupported server args ConfigMaps
+	unsupportedServerArgs []oadpv1alpha1.UnsupportedServerArgsStatus
+	// pendingBackupLocations are the backup locations of ObjectBucketClaims not bound yet, skipped from the spec
+	pendingBackupLocations []oadpv1alpha1.BackupLocation
+	// s3Vendors are the vendors of the S3 endpoints of the AWS BSLs, by s3Url, detected by the validation
+	s3Vendors map[string]string
 }
 
 var debugMode = os.Getenv("DEBUG") == "true"
@@ -61,6 +78,9 @@ var debugMode = os.Getenv("DEBUG") == "true"
 //+kubebuilder:rbac:groups=oadp.openshift.io,resources=dataprotectionapplications/finalizers,verbs=update
 
 //+kubebuilder:rbac:groups=config.openshift.io,resources=infrastructures,verbs=get;list;watch
+//+kubebuilder:rbac:groups=config.openshift.io,resources=proxies,verbs=get;list;watch
+//+kubebuilder:rbac:groups=config.openshift.io,resources=imagedigestmirrorsets;imagetagmirrorsets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=operator.openshift.io,resources=imagecontentsourcepolicies,verbs=get;list;watch
 //+kubebuilder:rbac:groups=cloudcredential.openshift.io,resources=credentialsrequests,verbs=get;create;update
 //+kubebuilder:rbac:groups=oadp.openshift.io,resources=*,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=corev1;coordination.k8s.io,resources=secrets,verbs=get;list;watch;create;update;patch;delete
@@ -70,8 +90,12 @@ var debugMode = os.Getenv("DEBUG") == "true"
 //+kubebuilder:rbac:groups="",resources=secrets;configmaps;pods;services;serviceaccounts;endpoints;persistentvolumeclaims;events,verbs=get;list;watch;create;update;patch;delete;deletecollection
 //+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;update;patch
 //+kubebuilder:rbac:groups=apps,resources=deployments;daemonsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=route.openshift.io,resources=routes,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=objectbucket.io,resources=objectbucketclaims,verbs=get;list;watch
+//+kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingadmissionpolicies;validatingadmissionpolicybindings,verbs=get;create;update;patch
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors;prometheusrules,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main Kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -83,6 +107,12 @@ func (r *DataProtectionApplicationReconciler) Reconcile(ctx context.Context, req
 	r.Context = ctx
 	r.NamespacedName = req.NamespacedName
 	r.dpa = &oadpv1alpha1.DataProtectionApplication{}
+	r.deferredRollouts = nil
+	r.inProgressOperations = nil
+	r.resolvedImages = nil
+	r.imageLabels = nil
+	r.unsupportedServerArgs = nil
+	r.s3Vendors = nil
 
 	if err := r.Get(ctx, req.NamespacedName, r.dpa); err != nil {
 		logger.Error(err, "unable to fetch DataProtectionApplication CR")
@@ -92,10 +122,35 @@ func (r *DataProtectionApplicationReconciler) Reconcile(ctx context.Context, req
 	// set client to pkg/client for use in non-reconcile functions
 	oadpclient.SetClient(r.Client)
 
+	if r.isPaused() {
+		if apimeta.SetStatusCondition(&r.dpa.Status.Conditions,
+			metav1.Condition{
+				Type:    oadpv1alpha1.ConditionPaused,
+				Status:  metav1.ConditionTrue,
+				Reason:  oadpv1alpha1.PausedReason,
+				Message: oadpv1alpha1.PausedMessage,
+			},
+		) {
+			return result, r.Client.Status().Update(ctx, r.dpa)
+		}
+		return result, nil
+	}
+	apimeta.RemoveStatusCondition(&r.dpa.Status.Conditions, oadpv1alpha1.ConditionPaused)
+
+	if r.isDryRun() {
+		_, err := r.ReconcileDryRunConfigMap(logger)
+		return result, err
+	}
+
 	_, err := ReconcileBatch(r.Log,
+		r.ReconcileObjectBucketClaimLocations,
 		r.ValidateDataProtectionCR,
+		r.ReconcileInProgressOperations,
 		r.ReconcileFsRestoreHelperConfig,
+		r.ReconcileBackupLocationFailover,
 		r.ReconcileBackupStorageLocations,
+		r.ReconcileBackupSchedules,
+		r.ReconcileRestoreOnlyPolicy,
 		r.ReconcileRegistrySecrets,
 		r.ReconcileRegistries,
 		r.ReconcileRegistrySVCs,
@@ -104,13 +159,20 @@ func (r *DataProtectionApplicationReconciler) Reconcile(ctx context.Context, req
 		r.LabelVSLSecrets,
 		r.ReconcileVolumeSnapshotLocations,
 		r.ReconcileAzureWorkloadIdentitySecret,
+		r.ReconcileTrustedCABundleConfigMap,
+		r.ReconcileImageDigests,
+		r.ValidatePluginCompatibility,
 		r.ReconcileVeleroDeployment,
 		r.ReconcileNodeAgentConfigMap,
 		r.ReconcileBackupRepositoryConfigMap,
 		r.ReconcileRepositoryMaintenanceConfigMap,
 		r.ReconcileNodeAgentDaemonset,
+		r.ReconcileUnsupportedServerArgsStatus,
 		r.ReconcileVeleroMetricsSVC,
 		r.ReconcileNonAdminController,
+		r.ReconcileMonitoring,
+		r.ReconcileNetworkPolicies,
+		r.ReconcilePodDisruptionBudgets,
 	)
 
 	if err != nil {
@@ -133,12 +195,49 @@ func (r *DataProtectionApplicationReconciler) Reconcile(ctx context.Context, req
 			},
 		)
 	}
+	result.RequeueAfter = r.updateRolloutDeferredCondition()
+	if len(r.inProgressOperations) > 0 && (result.RequeueAfter == 0 || result.RequeueAfter > operationsRequeueInterval) {
+		// check again for completed operations to apply deferred rollouts and release the Velero PodDisruptionBudget
+		result.RequeueAfter = operationsRequeueInterval
+	}
+	if apimeta.IsStatusConditionTrue(r.dpa.Status.Conditions, oadpv1alpha1.ConditionBackupLocationDeletionBlocked) &&
+		(result.RequeueAfter == 0 || result.RequeueAfter > operationsRequeueInterval) {
+		// delete the blocked BackupStorageLocations once their Backups and Schedules are deleted
+		result.RequeueAfter = operationsRequeueInterval
+	}
+	if len(r.pendingBackupLocations) > 0 && (result.RequeueAfter == 0 || result.RequeueAfter > objectBucketClaimRequeueInterval) {
+		// create the backup locations of the ObjectBucketClaims once bound, their events are not watched
+		result.RequeueAfter = objectBucketClaimRequeueInterval
+	}
+	if len(r.dpa.Spec.Schedules) > 0 && (result.RequeueAfter == 0 || result.RequeueAfter > backupScheduleRequeueInterval) {
+		// prune the Backups of spec.schedules beyond their retention
+		result.RequeueAfter = backupScheduleRequeueInterval
+	}
+	if r.isMonitoringEnabled() && (result.RequeueAfter == 0 || result.RequeueAfter > monitoringRequeueInterval) {
+		// refresh the backup storage location and backup repository metrics of the operator
+		result.RequeueAfter = monitoringRequeueInterval
+	}
+	if statusErr := r.UpdateComponentStatus(r.Log); statusErr != nil {
+		logger.Error(statusErr, "unable to update DPA component status")
+	}
+	if interval := r.getBackupLocationsRequeueInterval(); interval > 0 && (result.RequeueAfter == 0 || result.RequeueAfter > interval) {
+		// reapply the unavailable backup storage locations and their credentials, with backoff
+		result.RequeueAfter = interval
+	}
+	if interval := r.getBackupLocationFailoverRequeueInterval(); interval > 0 && (result.RequeueAfter == 0 || result.RequeueAfter > interval) {
+		// switch the default backup storage location once the unavailability window elapsed
+		result.RequeueAfter = interval
+	}
+	if interval := r.getSnapshotLocationCheckInterval(); interval > 0 && len(r.dpa.Status.SnapshotLocations) > 0 && (result.RequeueAfter == 0 || result.RequeueAfter > interval) {
+		// recheck the snapshot permissions of the volume snapshot locations
+		result.RequeueAfter = interval
+	}
 	statusErr := r.Client.Status().Update(ctx, r.dpa)
 	if err == nil { // Don't mask previous error
 		err = statusErr
 	}
 
-	return ctrl.Result{}, err
+	return result, err
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -148,12 +247,17 @@ func (r *DataProtectionApplicationReconciler) SetupWithManager(mgr ctrl.Manager)
 		Owns(&appsv1.Deployment{}).
 		Owns(&velerov1.BackupStorageLocation{}).
 		Owns(&velerov1.VolumeSnapshotLocation{}).
+		Owns(&velerov1.Schedule{}).
 		Owns(&appsv1.DaemonSet{}).
 		Owns(&security.SecurityContextConstraints{}).
 		Owns(&corev1.Service{}).
 		Owns(&routev1.Route{}).
 		Owns(&corev1.ConfigMap{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
+		Owns(&networkingv1.NetworkPolicy{}).
 		Watches(&corev1.Secret{}, &labelHandler{}).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.configMapRequests)).
+		Watches(&velerov1.BackupStorageLocation{}, handler.EnqueueRequestsFromMapFunc(r.networkPolicyBackupStorageLocationRequests)).
 		WithEventFilter(veleroPredicate(r.Scheme)).
 		Complete(r)
 }
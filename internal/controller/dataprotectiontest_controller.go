@@ -19,7 +19,6 @@ package controller
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -230,36 +229,15 @@ func (r *DataProtectionTestReconciler) determineVendor(ctx context.Context, dpt
 		return nil
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s3Url, nil)
+	httpClient, err := trustedHTTPClient(ctx, r.Client, r.NamespacedName.Namespace)
 	if err != nil {
-		return fmt.Errorf("failed to create HEAD request: %w", err)
+		return fmt.Errorf("failed to load trusted CA bundle: %w", err)
 	}
-
-	resp, err := http.DefaultClient.Do(req)
+	vendor, err := detectS3Vendor(ctx, httpClient, s3Url)
 	if err != nil {
-		return fmt.Errorf("HEAD request to %s failed: %w", s3Url, err)
-	}
-	defer resp.Body.Close()
-
-	server := strings.ToLower(resp.Header.Get("Server"))
-	xAmzReqID := resp.Header.Get("x-amz-request-id")
-	minioRegion := resp.Header.Get("x-minio-region")
-	rgwReqID := resp.Header.Get("x-rgw-request-id")
-
-	switch {
-	case strings.Contains(server, "amazon") || (xAmzReqID != "" && minioRegion == ""):
-		dpt.Status.S3Vendor = "AWS"
-	case strings.Contains(server, "minio") || minioRegion != "":
-		dpt.Status.S3Vendor = "MinIO"
-	case strings.Contains(server, "ceph") || rgwReqID != "":
-		dpt.Status.S3Vendor = "Ceph"
-	default:
-		if server != "" {
-			dpt.Status.S3Vendor = server
-		} else {
-			dpt.Status.S3Vendor = "Unknown"
-		}
+		return err
 	}
+	dpt.Status.S3Vendor = vendor
 
 	r.Log.Info("Detected S3 vendor", "vendor", dpt.Status.S3Vendor)
 	return nil
@@ -340,8 +318,13 @@ func (r *DataProtectionTestReconciler) initializeAWSProvider(ctx context.Context
 		s3Url = ""
 	}
 
+	httpClient, err := trustedHTTPClient(ctx, r.Client, r.NamespacedName.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trusted CA bundle: %w", err)
+	}
+
 	// Initialize the AWS provider
-	awsProvider := cloudprovider.NewAWSProvider(region, s3Url, accessKey, secretKey)
+	awsProvider := cloudprovider.NewAWSProvider(region, s3Url, accessKey, secretKey, httpClient)
 	if awsProvider == nil {
 		return nil, fmt.Errorf("failed to create AWS provider")
 	}
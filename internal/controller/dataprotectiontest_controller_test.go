@@ -18,6 +18,7 @@ package controller
 
 import (
 	"context"
+	"encoding/pem"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -32,10 +33,12 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
 	"github.com/openshift/oadp-operator/pkg/cloudprovider"
+	"github.com/openshift/oadp-operator/pkg/common"
 )
 
 type mockProvider struct {
@@ -136,7 +139,7 @@ func TestDetermineVendor(t *testing.T) {
 				},
 			}
 
-			reconciler := &DataProtectionTestReconciler{}
+			reconciler := &DataProtectionTestReconciler{Client: fake.NewClientBuilder().Build()}
 
 			err := reconciler.determineVendor(context.Background(), dpt, dpt.Spec.BackupLocationSpec)
 			require.NoError(t, err)
@@ -145,6 +148,62 @@ func TestDetermineVendor(t *testing.T) {
 	}
 }
 
+func TestDetermineVendorWithTrustedCABundle(t *testing.T) {
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "Ceph")
+	}))
+	defer testServer.Close()
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: testServer.Certificate().Raw})
+
+	tests := []struct {
+		name           string
+		objects        []client.Object
+		expectedVendor string
+		wantErr        bool
+	}{
+		{
+			name:    "server CA not trusted",
+			wantErr: true,
+		},
+		{
+			name: "server CA in trusted CA bundle",
+			objects: []client.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: common.TrustedCABundleConfigMapName, Namespace: "test-ns"},
+					Data:       map[string]string{common.TrustedCABundleKey: string(caBundle)},
+				},
+			},
+			expectedVendor: "Ceph",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dpt := &oadpv1alpha1.DataProtectionTest{
+				Spec: oadpv1alpha1.DataProtectionTestSpec{
+					BackupLocationSpec: &velerov1.BackupStorageLocationSpec{
+						Provider: "aws",
+						Config: map[string]string{
+							"s3Url": testServer.URL,
+						},
+					},
+				},
+			}
+			reconciler := &DataProtectionTestReconciler{
+				Client:         fake.NewClientBuilder().WithObjects(tc.objects...).Build(),
+				NamespacedName: types.NamespacedName{Namespace: "test-ns"},
+			}
+
+			err := reconciler.determineVendor(context.Background(), dpt, dpt.Spec.BackupLocationSpec)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedVendor, dpt.Status.S3Vendor)
+		})
+	}
+}
+
 func TestResolveBackupLocation(t *testing.T) {
 	scheme := runtime.NewScheme()
 	require.NoError(t, oadpv1alpha1.AddToScheme(scheme))
@@ -0,0 +1,186 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// desiredStateHashAnnotation records the hash of the state the operator last applied to an object. Changes made
+// to the object while its desired state is unchanged are out of band, unlike the changes of the desired state
+// from the DPA, deferred rollouts, failovers, the cluster proxy or an operator upgrade.
+const desiredStateHashAnnotation = "oadp.openshift.io/desired-state-hash"
+
+var driftCorrectedTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "oadp_operator_drift_corrected_total",
+		Help: "Number of times the operator reverted out of band changes to an object it manages",
+	},
+	[]string{"kind", "namespace", "name"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(driftCorrectedTotal)
+}
+
+// withDriftDetection wraps the mutate function of a CreateOrPatch call so fields changed out of band
+// on an existing obj are reported before being overwritten
+func (r *DataProtectionApplicationReconciler) withDriftDetection(obj client.Object, mutate controllerutil.MutateFn) controllerutil.MutateFn {
+	return func() error {
+		live := obj.DeepCopyObject().(client.Object)
+		if err := mutate(); err != nil {
+			return err
+		}
+		hash, err := desiredStateHash(obj)
+		if err != nil {
+			return err
+		}
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[desiredStateHashAnnotation] = hash
+		obj.SetAnnotations(annotations)
+
+		if creationTimestamp := live.GetCreationTimestamp(); creationTimestamp.IsZero() || live.GetAnnotations()[desiredStateHashAnnotation] != hash {
+			return nil
+		}
+		paths, err := driftedFields(live, obj)
+		if err != nil || len(paths) == 0 {
+			return err
+		}
+		r.reportDrift(obj, paths)
+		return nil
+	}
+}
+
+// desiredStateHash returns the hash of the content of obj compared for drift, without its desiredStateHashAnnotation
+func desiredStateHash(obj client.Object) (string, error) {
+	content, err := driftContent(obj)
+	if err != nil {
+		return "", err
+	}
+	metadata := content["metadata"].(map[string]interface{})
+	if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+		delete(annotations, desiredStateHashAnnotation)
+		if len(annotations) == 0 {
+			delete(metadata, "annotations")
+		}
+	}
+	// maps are marshalled with sorted keys, so equal contents have equal hashes
+	out, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(out)), nil
+}
+
+// reportDrift emits a DriftCorrected event on obj and counts it in the drift metric
+func (r *DataProtectionApplicationReconciler) reportDrift(obj client.Object, paths []string) {
+	if r.dryRun {
+		return
+	}
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if gvk, err := apiutil.GVKForObject(obj, r.Scheme); err == nil {
+		kind = gvk.Kind
+	}
+	driftCorrectedTotal.WithLabelValues(kind, obj.GetNamespace(), obj.GetName()).Inc()
+	r.EventRecorder.Event(obj,
+		corev1.EventTypeWarning,
+		"DriftCorrected",
+		fmt.Sprintf("reverted out of band changes to %s %s/%s: %s", kind, obj.GetNamespace(), obj.GetName(), strings.Join(paths, ", ")),
+	)
+}
+
+// driftedFields returns the field paths set by the operator in desired that have a different value in live.
+// Fields the operator does not set are ignored, as they are defaulted by the API server.
+func driftedFields(live client.Object, desired client.Object) ([]string, error) {
+	liveContent, err := driftContent(live)
+	if err != nil {
+		return nil, err
+	}
+	desiredContent, err := driftContent(desired)
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	collectDriftedFields("", liveContent, desiredContent, &paths)
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// driftContent returns the spec-like content of obj along with the labels and annotations,
+// the only metadata set from the DPA
+func driftContent(obj client.Object) (map[string]interface{}, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	delete(content, "status")
+	delete(content, "apiVersion")
+	delete(content, "kind")
+	metadata := map[string]interface{}{}
+	if labels := obj.GetLabels(); labels != nil {
+		metadata["labels"] = stringMapContent(labels)
+	}
+	if annotations := obj.GetAnnotations(); annotations != nil {
+		metadata["annotations"] = stringMapContent(annotations)
+	}
+	content["metadata"] = metadata
+	return content, nil
+}
+
+func stringMapContent(values map[string]string) map[string]interface{} {
+	content := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		content[key] = value
+	}
+	return content
+}
+
+// collectDriftedFields walks desired and appends the path of every value that differs in live
+func collectDriftedFields(path string, live interface{}, desired interface{}, paths *[]string) {
+	switch desiredValue := desired.(type) {
+	case map[string]interface{}:
+		liveValue, ok := live.(map[string]interface{})
+		if !ok {
+			if len(desiredValue) > 0 {
+				*paths = append(*paths, path)
+			}
+			return
+		}
+		for key, value := range desiredValue {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			collectDriftedFields(fieldPath, liveValue[key], value, paths)
+		}
+	case []interface{}:
+		liveValue, ok := live.([]interface{})
+		if !ok || len(liveValue) != len(desiredValue) {
+			if ok || len(desiredValue) > 0 {
+				*paths = append(*paths, path)
+			}
+			return
+		}
+		for i, value := range desiredValue {
+			collectDriftedFields(fmt.Sprintf("%s[%d]", path, i), liveValue[i], value, paths)
+		}
+	default:
+		if !reflect.DeepEqual(live, desired) {
+			*paths = append(*paths, path)
+		}
+	}
+}
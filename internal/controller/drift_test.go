@@ -0,0 +1,314 @@
+package controller
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func TestDriftedFields(t *testing.T) {
+	deployment := func(image string, labels map[string]string, policy corev1.TerminationMessagePolicy) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: common.Velero, Namespace: "test-ns", Labels: labels, ResourceVersion: "1"},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: common.Velero, Image: image, TerminationMessagePolicy: policy}},
+					},
+				},
+			},
+			Status: appsv1.DeploymentStatus{ReadyReplicas: 1},
+		}
+	}
+	tests := []struct {
+		name    string
+		live    *appsv1.Deployment
+		desired *appsv1.Deployment
+		want    []string
+	}{
+		{
+			name:    "no drift",
+			live:    deployment("velero:1", map[string]string{"app": "velero"}, ""),
+			desired: deployment("velero:1", map[string]string{"app": "velero"}, ""),
+			want:    []string{},
+		},
+		{
+			name:    "image and label changed by hand",
+			live:    deployment("velero:debug", map[string]string{"app": "other"}, ""),
+			desired: deployment("velero:1", map[string]string{"app": "velero"}, ""),
+			want:    []string{"metadata.labels.app", "spec.template.spec.containers[0].image"},
+		},
+		{
+			name:    "field defaulted by the API server is not drift",
+			live:    deployment("velero:1", nil, corev1.TerminationMessageReadFile),
+			desired: deployment("velero:1", nil, ""),
+			want:    []string{},
+		},
+		{
+			name: "container added by hand",
+			live: func() *appsv1.Deployment {
+				d := deployment("velero:1", nil, "")
+				d.Spec.Template.Spec.Containers = append(d.Spec.Template.Spec.Containers, corev1.Container{Name: "debug"})
+				return d
+			}(),
+			desired: deployment("velero:1", nil, ""),
+			want:    []string{"spec.template.spec.containers"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := driftedFields(tt.live, tt.desired)
+			if err != nil {
+				t.Fatalf("driftedFields() unexpected error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("driftedFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_ReconcileVeleroDeploymentDrift(t *testing.T) {
+	closedWindowNow := func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+	openWindowNow := func() time.Time { return time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name          string
+		editImage     bool
+		bumpDPA       bool
+		changeDesired bool
+		deferRollout  bool
+		wantDrift     bool
+		wantEventPath string
+	}{
+		{
+			name:          "image changed by hand is reported",
+			editImage:     true,
+			wantDrift:     true,
+			wantEventPath: "spec.template.spec.containers[0].image",
+		},
+		{
+			name:          "image changed by hand while the DPA changed is reported",
+			editImage:     true,
+			bumpDPA:       true,
+			wantDrift:     true,
+			wantEventPath: "spec.template.spec.containers[0].image",
+		},
+		{
+			name:          "desired Deployment changed in between is not drift",
+			editImage:     true,
+			changeDesired: true,
+		},
+		{
+			name: "unchanged Deployment is not drift",
+		},
+		{
+			name:    "DPA changed without Deployment change is not drift",
+			bumpDPA: true,
+		},
+		{
+			name:          "rollout deferred to the maintenance window is not drift",
+			changeDesired: true,
+			deferRollout:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() { timeNow = time.Now }()
+			timeNow = openWindowNow
+			dpa := createTestDryRunDPA()
+			dpa.Annotations = nil
+			dpa.Generation = 1
+			dpa.Spec.MaintenanceWindow = &oadpv1alpha1.MaintenanceWindow{StartHour: 2, DurationHours: 2}
+			fakeClient, err := getFakeClientFromObjects(dpa, testGenericInfrastructure)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			eventRecorder := record.NewFakeRecorder(10)
+			r := &DataProtectionApplicationReconciler{
+				Client:  fakeClient,
+				Scheme:  fakeClient.Scheme(),
+				Log:     logr.Discard(),
+				Context: newContextForTest(),
+				NamespacedName: types.NamespacedName{
+					Namespace: dpa.Namespace,
+					Name:      dpa.Name,
+				},
+				EventRecorder: eventRecorder,
+				dpa:           dpa,
+			}
+			if _, err := r.ReconcileVeleroDeployment(r.Log); err != nil {
+				t.Fatalf("ReconcileVeleroDeployment() unexpected error = %v", err)
+			}
+
+			key := types.NamespacedName{Namespace: dpa.Namespace, Name: common.Velero}
+			deployment := &appsv1.Deployment{}
+			if err := fakeClient.Get(r.Context, key, deployment); err != nil {
+				t.Fatalf("unable to get velero deployment: %v", err)
+			}
+			if _, found := deployment.Annotations[desiredStateHashAnnotation]; !found {
+				t.Errorf("expected %s annotation on the deployment, got %v", desiredStateHashAnnotation, deployment.Annotations)
+			}
+			if _, found := deployment.Spec.Template.Annotations[desiredStateHashAnnotation]; found {
+				t.Errorf("expected no %s annotation in the pod template, got %v", desiredStateHashAnnotation, deployment.Spec.Template.Annotations)
+			}
+			template := deployment.Spec.Template.DeepCopy()
+			deployment.CreationTimestamp = metav1.Now()
+			if tt.editImage {
+				deployment.Spec.Template.Spec.Containers[0].Image = "quay.io/konveyor/velero:debug"
+			}
+			if err := fakeClient.Update(r.Context, deployment); err != nil {
+				t.Fatalf("unable to update velero deployment: %v", err)
+			}
+			if tt.bumpDPA {
+				r.dpa.Generation++
+			}
+			if tt.changeDesired {
+				r.dpa.Generation++
+				r.dpa.Spec.PodAnnotations = map[string]string{"example.com/owner": "backup-team"}
+			}
+
+			before := testutil.ToFloat64(driftCorrectedTotal.WithLabelValues("Deployment", dpa.Namespace, common.Velero))
+			for len(eventRecorder.Events) > 0 {
+				<-eventRecorder.Events
+			}
+			if tt.deferRollout {
+				timeNow = closedWindowNow
+				if _, err := r.ReconcileVeleroDeployment(r.Log); err != nil {
+					t.Fatalf("ReconcileVeleroDeployment() unexpected error = %v", err)
+				}
+				if len(r.deferredRollouts) == 0 {
+					t.Fatalf("expected the rollout to be deferred")
+				}
+				timeNow = openWindowNow
+			}
+			if _, err := r.ReconcileVeleroDeployment(r.Log); err != nil {
+				t.Fatalf("ReconcileVeleroDeployment() unexpected error = %v", err)
+			}
+			after := testutil.ToFloat64(driftCorrectedTotal.WithLabelValues("Deployment", dpa.Namespace, common.Velero))
+
+			driftEvent := ""
+			for len(eventRecorder.Events) > 0 {
+				if event := <-eventRecorder.Events; strings.Contains(event, "DriftCorrected") {
+					driftEvent = event
+				}
+			}
+			if err := fakeClient.Get(r.Context, key, deployment); err != nil {
+				t.Fatalf("unable to get velero deployment: %v", err)
+			}
+			if tt.changeDesired {
+				if deployment.Spec.Template.Annotations["example.com/owner"] != "backup-team" {
+					t.Errorf("expected the desired pod template to be applied, got annotations %v", deployment.Spec.Template.Annotations)
+				}
+			} else if !reflect.DeepEqual(deployment.Spec.Template, *template) {
+				t.Errorf("expected pod template to be unchanged, got diff %s", cmp.Diff(*template, deployment.Spec.Template))
+			}
+			if tt.wantDrift {
+				if !strings.HasSuffix(driftEvent, ": "+tt.wantEventPath) {
+					t.Errorf("expected DriftCorrected event listing only %s, got %q", tt.wantEventPath, driftEvent)
+				}
+				if after != before+1 {
+					t.Errorf("expected drift metric to be incremented, got %v -> %v", before, after)
+				}
+			} else {
+				if driftEvent != "" {
+					t.Errorf("expected no DriftCorrected event, got %q", driftEvent)
+				}
+				if after != before {
+					t.Errorf("expected drift metric to be unchanged, got %v -> %v", before, after)
+				}
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_ReconcileBackupStorageLocationsDrift(t *testing.T) {
+	dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{
+		BackupLocations: []oadpv1alpha1.BackupLocation{
+			failoverBackupLocation("east", "us-east-1", true),
+			failoverBackupLocation("west", "us-west-2", false),
+		},
+		BackupLocationFailover: &oadpv1alpha1.BackupLocationFailover{Primary: "east", Secondary: "west"},
+	})
+	dpa.UID = "test-uid"
+	dpa.Status.BackupLocationFailover = &oadpv1alpha1.BackupLocationFailoverStatus{Active: "east"}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials", Namespace: "test-ns"},
+		Data:       map[string][]byte{"cloud": []byte("[default]\naws_access_key_id=test-key\naws_secret_access_key=test-secret")},
+	}
+	fakeClient, err := getFakeClientFromObjects(dpa, secret)
+	if err != nil {
+		t.Fatalf("error in creating fake client, likely programmer error")
+	}
+	eventRecorder := record.NewFakeRecorder(20)
+	r := &DataProtectionApplicationReconciler{
+		Client:         fakeClient,
+		Scheme:         fakeClient.Scheme(),
+		Log:            logr.Discard(),
+		Context:        newContextForTest(),
+		NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+		EventRecorder:  eventRecorder,
+		dpa:            dpa,
+	}
+	// reconcile returns the DriftCorrected events of a reconcile of the BackupStorageLocations
+	reconcile := func() []string {
+		for len(eventRecorder.Events) > 0 {
+			<-eventRecorder.Events
+		}
+		if _, err := r.ReconcileBackupStorageLocations(r.Log); err != nil {
+			t.Fatalf("ReconcileBackupStorageLocations() error = %v", err)
+		}
+		events := []string{}
+		for len(eventRecorder.Events) > 0 {
+			if event := <-eventRecorder.Events; strings.Contains(event, "DriftCorrected") {
+				events = append(events, event)
+			}
+		}
+		return events
+	}
+	// edit applies change to the live BackupStorageLocation name, as made by hand
+	edit := func(name string, change func(*velerov1.BackupStorageLocation)) {
+		bsl := &velerov1.BackupStorageLocation{}
+		if err := fakeClient.Get(r.Context, types.NamespacedName{Namespace: "test-ns", Name: name}, bsl); err != nil {
+			t.Fatalf("unable to get BackupStorageLocation %s: %v", name, err)
+		}
+		change(bsl)
+		if err := fakeClient.Update(r.Context, bsl); err != nil {
+			t.Fatalf("unable to update BackupStorageLocation %s: %v", name, err)
+		}
+	}
+
+	reconcile()
+	for _, name := range []string{"east", "west"} {
+		edit(name, func(bsl *velerov1.BackupStorageLocation) { bsl.CreationTimestamp = metav1.Now() })
+	}
+
+	// the failover switches the default backup storage location
+	dpa.Status.BackupLocationFailover.Active = "west"
+	if events := reconcile(); len(events) > 0 {
+		t.Errorf("expected no DriftCorrected event on failover, got %v", events)
+	}
+	west := &velerov1.BackupStorageLocation{}
+	if err := fakeClient.Get(r.Context, types.NamespacedName{Namespace: "test-ns", Name: "west"}, west); err != nil || !west.Spec.Default {
+		t.Errorf("expected west to be the default backup storage location, got %v, %v", west.Spec.Default, err)
+	}
+
+	// the prefix changed by hand is reverted and reported
+	edit("east", func(bsl *velerov1.BackupStorageLocation) { bsl.Spec.ObjectStorage.Prefix = "other" })
+	if events := reconcile(); len(events) != 1 || !strings.HasSuffix(events[0], ": spec.objectStorage.prefix") {
+		t.Errorf("expected a DriftCorrected event for spec.objectStorage.prefix, got %v", events)
+	}
+}
@@ -0,0 +1,330 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/pmezard/go-difflib/difflib"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	oadpclient "github.com/openshift/oadp-operator/pkg/client"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+const (
+	dryRunConfigMapSuffix = "-dry-run"
+	dryRunManifestsKey    = "manifests.yaml"
+	dryRunDiffKey         = "diff"
+	// dryRunRedacted replaces the values of the Secrets in the DryRun output
+	dryRunRedacted = "<redacted>"
+)
+
+// DryRunResult holds the objects a reconcile would write and how they differ from the live cluster
+type DryRunResult struct {
+	// Manifests is a multi-document YAML stream of every object the reconcile would create or update
+	Manifests string
+	// Diff is a unified diff of every written or deleted object against its live counterpart
+	Diff string
+}
+
+// isDryRun returns true when the DPA asks to be rendered instead of applied
+func (r *DataProtectionApplicationReconciler) isDryRun() bool {
+	return r.dpa.GetAnnotations()[common.DryRunAnnotation] == "true"
+}
+
+// DryRun runs the Velero, NodeAgent, BSL, VSL and ConfigMap reconcilers for the DPA against an
+// in-memory copy of their writes, leaving the cluster untouched.
+func (r *DataProtectionApplicationReconciler) DryRun(log logr.Logger) (*DryRunResult, error) {
+	dryRunClient := newDryRunClient(r.Client)
+	dryRun := *r
+	dryRun.Client = dryRunClient
+	dryRun.dryRun = true
+	dryRun.EventRecorder = &record.FakeRecorder{}
+	dryRun.dpa = r.dpa.DeepCopy()
+
+	_, err := ReconcileBatch(log,
+		dryRun.ReconcileObjectBucketClaimLocations,
+		dryRun.ValidateDataProtectionCR,
+		dryRun.ReconcileFsRestoreHelperConfig,
+		dryRun.ReconcileBackupStorageLocations,
+		dryRun.ReconcileVolumeSnapshotLocations,
+		dryRun.ReconcileTrustedCABundleConfigMap,
+		dryRun.ReconcileImageDigests,
+		dryRun.ValidatePluginCompatibility,
+		dryRun.ReconcileVeleroDeployment,
+		dryRun.ReconcileNodeAgentConfigMap,
+		dryRun.ReconcileBackupRepositoryConfigMap,
+		dryRun.ReconcileRepositoryMaintenanceConfigMap,
+		dryRun.ReconcileNodeAgentDaemonset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return dryRunClient.result(r.Context)
+}
+
+// RenderDataProtectionApplication runs DryRun for dpa, reading the cluster state through c
+func RenderDataProtectionApplication(ctx context.Context, c client.Client, clusterWideClient client.Client, dpa *oadpv1alpha1.DataProtectionApplication, log logr.Logger) (*DryRunResult, error) {
+	oadpclient.SetClient(c)
+	r := &DataProtectionApplicationReconciler{
+		Client:            c,
+		Scheme:            c.Scheme(),
+		Log:               log,
+		Context:           ctx,
+		NamespacedName:    client.ObjectKeyFromObject(dpa),
+		EventRecorder:     &record.FakeRecorder{},
+		dpa:               dpa,
+		ClusterWideClient: clusterWideClient,
+	}
+	return r.DryRun(log)
+}
+
+// ReconcileDryRunConfigMap stores the DryRun output of the DPA in the <dpa name>-dry-run ConfigMap
+func (r *DataProtectionApplicationReconciler) ReconcileDryRunConfigMap(log logr.Logger) (bool, error) {
+	result, err := r.DryRun(log)
+	if err != nil {
+		r.EventRecorder.Event(r.dpa, corev1.EventTypeWarning, "DryRunFailed", fmt.Sprintf("could not render DPA: %v", err))
+		return false, err
+	}
+
+	configMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.dpa.Name + dryRunConfigMapSuffix,
+			Namespace: r.NamespacedName.Namespace,
+		},
+	}
+	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, &configMap, func() error {
+		configMap.Labels = getDpaAppLabels(r.dpa)
+		configMap.Data = map[string]string{
+			dryRunManifestsKey: result.Manifests,
+			dryRunDiffKey:      result.Diff,
+		}
+		return controllerutil.SetControllerReference(r.dpa, &configMap, r.Scheme)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if op == controllerutil.OperationResultCreated || op == controllerutil.OperationResultUpdated {
+		r.EventRecorder.Event(r.dpa,
+			corev1.EventTypeNormal,
+			"DryRunRendered",
+			fmt.Sprintf("DPA rendered into config map %s/%s", configMap.Namespace, configMap.Name),
+		)
+	}
+	return true, nil
+}
+
+type dryRunKey struct {
+	gvk schema.GroupVersionKind
+	types.NamespacedName
+}
+
+// dryRunClient serves reads from the wrapped client and records writes in memory. Objects written
+// during the dry run are read back from memory so later reconcile steps see earlier ones.
+type dryRunClient struct {
+	client.Client
+	objects map[dryRunKey]client.Object
+	deleted map[dryRunKey]client.Object
+	order   []dryRunKey
+}
+
+func newDryRunClient(c client.Client) *dryRunClient {
+	return &dryRunClient{
+		Client:  c,
+		objects: map[dryRunKey]client.Object{},
+		deleted: map[dryRunKey]client.Object{},
+	}
+}
+
+func (c *dryRunClient) keyFor(obj client.Object) (dryRunKey, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return dryRunKey{}, err
+	}
+	return dryRunKey{gvk: gvk, NamespacedName: client.ObjectKeyFromObject(obj)}, nil
+}
+
+func (c *dryRunClient) track(key dryRunKey) {
+	if _, ok := c.objects[key]; ok {
+		return
+	}
+	if _, ok := c.deleted[key]; ok {
+		return
+	}
+	c.order = append(c.order, key)
+}
+
+func (c *dryRunClient) record(obj client.Object) error {
+	key, err := c.keyFor(obj)
+	if err != nil {
+		return err
+	}
+	c.track(key)
+	stored := obj.DeepCopyObject().(client.Object)
+	stored.GetObjectKind().SetGroupVersionKind(key.gvk)
+	c.objects[key] = stored
+	delete(c.deleted, key)
+	return nil
+}
+
+func (c *dryRunClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return err
+	}
+	recordKey := dryRunKey{gvk: gvk, NamespacedName: key}
+	if stored, ok := c.objects[recordKey]; ok {
+		dst := reflect.ValueOf(obj)
+		src := reflect.ValueOf(stored.DeepCopyObject())
+		if dst.Type() != src.Type() {
+			return fmt.Errorf("cannot read %s %s into %T", gvk.Kind, key, obj)
+		}
+		dst.Elem().Set(src.Elem())
+		return nil
+	}
+	if _, ok := c.deleted[recordKey]; ok {
+		return errors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: strings.ToLower(gvk.Kind)}, key.Name)
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func (c *dryRunClient) Create(_ context.Context, obj client.Object, _ ...client.CreateOption) error {
+	return c.record(obj)
+}
+
+func (c *dryRunClient) Update(_ context.Context, obj client.Object, _ ...client.UpdateOption) error {
+	return c.record(obj)
+}
+
+func (c *dryRunClient) Patch(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+	return c.record(obj)
+}
+
+func (c *dryRunClient) Delete(_ context.Context, obj client.Object, _ ...client.DeleteOption) error {
+	key, err := c.keyFor(obj)
+	if err != nil {
+		return err
+	}
+	c.track(key)
+	delete(c.objects, key)
+	c.deleted[key] = obj.DeepCopyObject().(client.Object)
+	return nil
+}
+
+func (c *dryRunClient) DeleteAllOf(_ context.Context, _ client.Object, _ ...client.DeleteAllOfOption) error {
+	return nil
+}
+
+func (c *dryRunClient) Status() client.SubResourceWriter {
+	return &dryRunStatusWriter{client: c}
+}
+
+// dryRunStatusWriter records status writes the same way as object writes
+type dryRunStatusWriter struct {
+	client *dryRunClient
+}
+
+func (w *dryRunStatusWriter) Create(_ context.Context, obj client.Object, _ client.Object, _ ...client.SubResourceCreateOption) error {
+	return w.client.record(obj)
+}
+
+func (w *dryRunStatusWriter) Update(_ context.Context, obj client.Object, _ ...client.SubResourceUpdateOption) error {
+	return w.client.record(obj)
+}
+
+func (w *dryRunStatusWriter) Patch(_ context.Context, obj client.Object, _ client.Patch, _ ...client.SubResourcePatchOption) error {
+	return w.client.record(obj)
+}
+
+// result renders the recorded writes and diffs them against the objects in the wrapped client
+func (c *dryRunClient) result(ctx context.Context) (*DryRunResult, error) {
+	var manifests, diff strings.Builder
+	for _, key := range c.order {
+		desired := c.objects[key]
+
+		live, err := c.Scheme().New(key.gvk)
+		if err != nil {
+			return nil, err
+		}
+		liveObj, ok := live.(client.Object)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a client.Object", key.gvk)
+		}
+		liveYAML := ""
+		if err := c.Client.Get(ctx, key.NamespacedName, liveObj); err == nil {
+			liveObj.GetObjectKind().SetGroupVersionKind(key.gvk)
+			if liveYAML, err = dryRunYAML(liveObj); err != nil {
+				return nil, err
+			}
+		} else if !errors.IsNotFound(err) {
+			return nil, err
+		}
+
+		desiredYAML := ""
+		if desired != nil {
+			if desiredYAML, err = dryRunYAML(desired); err != nil {
+				return nil, err
+			}
+			manifests.WriteString("---\n")
+			manifests.WriteString(desiredYAML)
+		}
+
+		name := fmt.Sprintf("%s/%s/%s", key.gvk.Kind, key.Namespace, key.Name)
+		objectDiff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(liveYAML),
+			B:        difflib.SplitLines(desiredYAML),
+			FromFile: "live/" + name,
+			ToFile:   "rendered/" + name,
+			Context:  3,
+		})
+		if err != nil {
+			return nil, err
+		}
+		diff.WriteString(objectDiff)
+	}
+	return &DryRunResult{Manifests: manifests.String(), Diff: diff.String()}, nil
+}
+
+// dryRunYAML marshals obj without the server populated fields that would only add noise to a diff. The values of
+// Secrets are redacted, the output is readable by anyone allowed to read the dry run ConfigMap.
+func dryRunYAML(obj client.Object) (string, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return "", err
+	}
+	delete(content, "status")
+	if _, isSecret := obj.(*corev1.Secret); isSecret {
+		for _, field := range []string{"data", "stringData"} {
+			if values, ok := content[field].(map[string]interface{}); ok {
+				for key := range values {
+					values[key] = dryRunRedacted
+				}
+			}
+		}
+	}
+	if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+		for _, field := range []string{"managedFields", "resourceVersion", "uid", "generation", "creationTimestamp"} {
+			delete(metadata, field)
+		}
+	}
+	out, err := yaml.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func createTestDryRunDPA() *oadpv1alpha1.DataProtectionApplication {
+	return &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-dpa",
+			Namespace:   "test-ns",
+			Annotations: map[string]string{common.DryRunAnnotation: "true"},
+		},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{
+					NoDefaultBackupLocation: true,
+					DefaultPlugins:          []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginOpenShift},
+				},
+				NodeAgent: &oadpv1alpha1.NodeAgentConfig{
+					NodeAgentCommonFields: oadpv1alpha1.NodeAgentCommonFields{
+						Enable: ptr.To(true),
+					},
+					UploaderType: "kopia",
+				},
+			},
+			BackupImages: ptr.To(false),
+		},
+	}
+}
+
+func TestDPAReconciler_DryRun(t *testing.T) {
+	tests := []struct {
+		name            string
+		objects         []client.Object
+		backupLocations []oadpv1alpha1.BackupLocation
+		wantManifests   []string
+		wantDiff        []string
+		wantLiveImages  map[string]string
+		// secrets must not be in the manifests or the diff, neither in clear nor base64 encoded
+		secrets []string
+	}{
+		{
+			name: "nothing deployed yet",
+			wantManifests: []string{
+				"kind: Deployment\n",
+				"kind: DaemonSet\n",
+				"name: " + common.Velero + "\n",
+				"name: " + common.NodeAgent + "\n",
+			},
+			wantDiff: []string{
+				"--- live/Deployment/test-ns/" + common.Velero,
+				"+++ rendered/DaemonSet/test-ns/" + common.NodeAgent,
+				"+kind: Deployment",
+			},
+		},
+		{
+			name: "velero deployed with an old image",
+			objects: []client.Object{
+				&appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{Name: common.Velero, Namespace: "test-ns"},
+					Spec: appsv1.DeploymentSpec{
+						Template: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{{Name: common.Velero, Image: "quay.io/konveyor/velero:old"}},
+							},
+						},
+					},
+				},
+			},
+			wantManifests: []string{"kind: Deployment\n"},
+			wantDiff: []string{
+				"image: quay.io/konveyor/velero:old",
+				"image: " + getVeleroImage(createTestDryRunDPA()),
+			},
+			wantLiveImages: map[string]string{common.Velero: "quay.io/konveyor/velero:old"},
+		},
+		{
+			name: "backup locations with credentials",
+			objects: []client.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials", Namespace: "test-ns"},
+					Data: map[string][]byte{
+						"cloud": []byte("[default]\naws_access_key_id=user-key\naws_secret_access_key=user-secret\n"),
+					},
+				},
+				createTestObjectBucketClaim("backups", "Bound"),
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "backups", Namespace: "test-ns"},
+					Data: map[string]string{
+						objectBucketClaimHostKey: "s3.openshift-storage.svc",
+						objectBucketClaimPortKey: "443",
+						objectBucketClaimNameKey: "backups-0123",
+					},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "backups", Namespace: "test-ns"},
+					Data: map[string][]byte{
+						objectBucketClaimAccessKeyKey: []byte("obc-key"),
+						objectBucketClaimSecretKeyKey: []byte("obc-secret"),
+					},
+				},
+			},
+			backupLocations: []oadpv1alpha1.BackupLocation{
+				{
+					Name: "aws",
+					Velero: &velerov1.BackupStorageLocationSpec{
+						Provider: AWSProvider,
+						Default:  true,
+						Config:   map[string]string{Region: "us-east-1"},
+						StorageType: velerov1.StorageType{
+							ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "velero", Prefix: "velero"},
+						},
+						Credential: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "cloud-credentials"},
+							Key:                  "cloud",
+						},
+					},
+				},
+				{
+					Name: "obc",
+					ObjectBucketClaim: &oadpv1alpha1.ObjectBucketClaimLocation{
+						ObjectBucketClaimRef: corev1.LocalObjectReference{Name: "backups"},
+						Prefix:               "velero",
+					},
+				},
+			},
+			wantManifests: []string{
+				"kind: BackupStorageLocation\n",
+				"name: backups-obc-credentials\n",
+				dryRunRedacted,
+			},
+			secrets: []string{"user-key", "user-secret", "obc-key", "obc-secret"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := createTestDryRunDPA()
+			if tt.backupLocations != nil {
+				dpa.Spec.Configuration.Velero.NoDefaultBackupLocation = false
+				dpa.Spec.Configuration.Velero.DefaultPlugins = append(dpa.Spec.Configuration.Velero.DefaultPlugins, oadpv1alpha1.DefaultPluginAWS)
+				dpa.Spec.BackupLocations = tt.backupLocations
+			}
+			fakeClient, err := getFakeClientFromObjects(append(tt.objects, dpa, testGenericInfrastructure)...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:  fakeClient,
+				Scheme:  fakeClient.Scheme(),
+				Log:     logr.Discard(),
+				Context: newContextForTest(),
+				NamespacedName: types.NamespacedName{
+					Namespace: dpa.Namespace,
+					Name:      dpa.Name,
+				},
+				EventRecorder: record.NewFakeRecorder(10),
+				dpa:           dpa,
+			}
+			if cont, err := r.ReconcileDryRunConfigMap(r.Log); !cont || err != nil {
+				t.Fatalf("ReconcileDryRunConfigMap() = %v, %v", cont, err)
+			}
+
+			configMap := &corev1.ConfigMap{}
+			if err := fakeClient.Get(r.Context, types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name + dryRunConfigMapSuffix}, configMap); err != nil {
+				t.Fatalf("expected dry run config map: %v", err)
+			}
+			for _, want := range tt.wantManifests {
+				if !strings.Contains(configMap.Data[dryRunManifestsKey], want) {
+					t.Errorf("expected manifests to contain %q, got:\n%s", want, configMap.Data[dryRunManifestsKey])
+				}
+			}
+			for _, want := range tt.wantDiff {
+				if !strings.Contains(configMap.Data[dryRunDiffKey], want) {
+					t.Errorf("expected diff to contain %q, got:\n%s", want, configMap.Data[dryRunDiffKey])
+				}
+			}
+			for _, secret := range tt.secrets {
+				encoded := base64.StdEncoding.EncodeToString([]byte(secret))
+				for key, data := range configMap.Data {
+					if strings.Contains(data, secret) || strings.Contains(data, encoded) {
+						t.Errorf("expected %s to redact secret %q, got:\n%s", key, secret, data)
+					}
+				}
+			}
+
+			// the cluster must be left untouched by the dry run
+			deployment := &appsv1.Deployment{}
+			err = fakeClient.Get(r.Context, types.NamespacedName{Namespace: dpa.Namespace, Name: common.Velero}, deployment)
+			if image, deployed := tt.wantLiveImages[common.Velero]; deployed {
+				if err != nil || deployment.Spec.Template.Spec.Containers[0].Image != image {
+					t.Errorf("expected live velero deployment with image %s, got %v, %v", image, deployment.Spec.Template.Spec.Containers, err)
+				}
+			} else if !errors.IsNotFound(err) {
+				t.Errorf("expected velero deployment not to be created, got %v", err)
+			}
+			if err := fakeClient.Get(r.Context, types.NamespacedName{Namespace: dpa.Namespace, Name: common.NodeAgent}, &appsv1.DaemonSet{}); !errors.IsNotFound(err) {
+				t.Errorf("expected node agent daemonset not to be created, got %v", err)
+			}
+		})
+	}
+}
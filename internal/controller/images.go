@@ -0,0 +1,292 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/credentials"
+	"github.com/openshift/oadp-operator/pkg/image"
+)
+
+const (
+	// clusterPullSecretNamespace and clusterPullSecretName are the OpenShift global pull secret,
+	// used to authenticate to the registries and mirrors
+	clusterPullSecretNamespace = "openshift-config"
+	clusterPullSecretName      = "pull-secret"
+
+	// resolved digests are cached so every reconcile does not query the registries,
+	// failures are retried sooner
+	imageDigestCacheTTL        = time.Hour
+	imageDigestFailureCacheTTL = 5 * time.Minute
+	imageResolveTimeout        = 30 * time.Second
+)
+
+type imageDigestCacheEntry struct {
+	result  image.Result
+	labels  map[string]string
+	err     error
+	expires time.Time
+}
+
+// imageDigestCache caches resolved images and image labels by image and mirror rules
+var imageDigestCache = struct {
+	sync.Mutex
+	entries map[string]imageDigestCacheEntry
+}{entries: map[string]imageDigestCacheEntry{}}
+
+// managedImage is an image deployed by the operator
+type managedImage struct {
+	component string
+	image     string
+}
+
+// getManagedImages returns the images of the Velero server, its plugins and the non-admin controller
+func (r *DataProtectionApplicationReconciler) getManagedImages() []managedImage {
+	dpa := r.dpa
+	images := []managedImage{{component: "velero", image: getVeleroImage(dpa)}}
+	if dpa.Spec.Configuration != nil && dpa.Spec.Configuration.Velero != nil {
+		for _, plugin := range dpa.Spec.Configuration.Velero.DefaultPlugins {
+			if pluginImage := credentials.GetPluginImage(plugin, dpa); len(pluginImage) != 0 {
+				images = append(images, managedImage{component: "plugin/" + string(plugin), image: pluginImage})
+			}
+		}
+		for _, plugin := range dpa.Spec.Configuration.Velero.CustomPlugins {
+			images = append(images, managedImage{component: "plugin/" + plugin.Name, image: plugin.Image})
+		}
+	}
+	if r.checkNonAdminEnabled() {
+		images = append(images, managedImage{component: nonAdminObjectName, image: r.getNonAdminImage()})
+	}
+	return images
+}
+
+// ReconcileImageDigests resolves the managed images to digests through the cluster image mirrors
+// when spec.pinImageDigests is set. Resolved images are recorded in the DPA status and deployed by digest,
+// images that cannot be resolved are deployed as configured and reported by the ImagesResolved condition.
+// The labels of resolved images are read as well.
+func (r *DataProtectionApplicationReconciler) ReconcileImageDigests(log logr.Logger) (bool, error) {
+	r.resolvedImages = nil
+	r.imageLabels = nil
+	if !r.dpa.Spec.PinImageDigests {
+		r.dpa.Status.Images = nil
+		apimeta.RemoveStatusCondition(&r.dpa.Status.Conditions, oadpv1alpha1.ConditionImagesResolved)
+		return true, nil
+	}
+
+	mirrors, err := r.getImageMirrorRules()
+	if err != nil {
+		return false, err
+	}
+	registryCredentials, err := r.getClusterPullSecretCredentials()
+	if err != nil {
+		return false, err
+	}
+	httpClient, err := trustedHTTPClient(r.Context, r.Client, r.dpa.Namespace)
+	if err != nil {
+		return false, err
+	}
+	resolver := &image.Resolver{HTTPClient: httpClient, Mirrors: mirrors, Credentials: registryCredentials}
+
+	r.resolvedImages = map[string]string{}
+	r.imageLabels = map[string]map[string]string{}
+	statuses := []oadpv1alpha1.ImageStatus{}
+	unreachable := []string{}
+	for _, managed := range r.getManagedImages() {
+		status := oadpv1alpha1.ImageStatus{Component: managed.component, Image: managed.image}
+		result, err := resolveImageDigest(r.Context, resolver, managed.image)
+		if err != nil {
+			log.Info("unable to resolve image digest", "component", managed.component, "image", managed.image, "error", err.Error())
+			status.Message = err.Error()
+			unreachable = append(unreachable, fmt.Sprintf("%s (%s)", managed.component, managed.image))
+		} else {
+			status.ResolvedImage = result.Image
+			status.ResolvedFrom = result.From
+			r.resolvedImages[managed.image] = result.Image
+			// labels declare the plugin compatibility, see ValidatePluginCompatibility
+			labels, err := readImageLabels(r.Context, resolver, managed.image)
+			if err != nil {
+				log.Info("unable to read image labels", "component", managed.component, "image", managed.image, "error", err.Error())
+			} else {
+				r.imageLabels[managed.image] = labels
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	r.dpa.Status.Images = statuses
+
+	condition := metav1.Condition{
+		Type:    oadpv1alpha1.ConditionImagesResolved,
+		Status:  metav1.ConditionTrue,
+		Reason:  oadpv1alpha1.ImagesReasonResolved,
+		Message: "all managed images were resolved to digests",
+	}
+	if len(unreachable) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = oadpv1alpha1.ImagesReasonUnreachable
+		condition.Message = "images not reachable through the configured mirrors, deployed without digest: " + strings.Join(unreachable, ", ")
+	}
+	apimeta.SetStatusCondition(&r.dpa.Status.Conditions, condition)
+	return true, nil
+}
+
+// resolveImageDigest resolves imageName, using the cached result when it has not expired
+func resolveImageDigest(ctx context.Context, resolver *image.Resolver, imageName string) (image.Result, error) {
+	entry := cachedImageLookup(fmt.Sprintf("digest|%s|%v", imageName, resolver.Mirrors), func() imageDigestCacheEntry {
+		ctx, cancel := context.WithTimeout(ctx, imageResolveTimeout)
+		defer cancel()
+		result, err := resolver.Resolve(ctx, imageName)
+		return imageDigestCacheEntry{result: result, err: err}
+	})
+	return entry.result, entry.err
+}
+
+// readImageLabels reads the labels of imageName, using the cached result when it has not expired
+func readImageLabels(ctx context.Context, resolver *image.Resolver, imageName string) (map[string]string, error) {
+	entry := cachedImageLookup(fmt.Sprintf("labels|%s|%v", imageName, resolver.Mirrors), func() imageDigestCacheEntry {
+		ctx, cancel := context.WithTimeout(ctx, imageResolveTimeout)
+		defer cancel()
+		labels, err := resolver.Labels(ctx, imageName)
+		return imageDigestCacheEntry{labels: labels, err: err}
+	})
+	return entry.labels, entry.err
+}
+
+func cachedImageLookup(key string, lookup func() imageDigestCacheEntry) imageDigestCacheEntry {
+	imageDigestCache.Lock()
+	entry, found := imageDigestCache.entries[key]
+	imageDigestCache.Unlock()
+	if found && time.Now().Before(entry.expires) {
+		return entry
+	}
+
+	entry = lookup()
+	entry.expires = time.Now().Add(imageDigestCacheTTL)
+	if entry.err != nil {
+		entry.expires = time.Now().Add(imageDigestFailureCacheTTL)
+	}
+	imageDigestCache.Lock()
+	imageDigestCache.entries[key] = entry
+	imageDigestCache.Unlock()
+	return entry
+}
+
+// getImageMirrorRules returns the mirror rules of the cluster ImageDigestMirrorSets, ImageTagMirrorSets
+// and ImageContentSourcePolicies. Kinds not served by the cluster are ignored.
+func (r *DataProtectionApplicationReconciler) getImageMirrorRules() ([]image.MirrorRule, error) {
+	rules := []image.MirrorRule{}
+
+	digestMirrorSets := &configv1.ImageDigestMirrorSetList{}
+	if err := r.listClusterImageMirrors(digestMirrorSets); err != nil {
+		return nil, err
+	}
+	for _, mirrorSet := range digestMirrorSets.Items {
+		for _, digestMirror := range mirrorSet.Spec.ImageDigestMirrors {
+			rules = append(rules, image.MirrorRule{
+				Source:             digestMirror.Source,
+				Mirrors:            imageMirrorStrings(digestMirror.Mirrors),
+				NeverContactSource: digestMirror.MirrorSourcePolicy == configv1.NeverContactSource,
+			})
+		}
+	}
+
+	tagMirrorSets := &configv1.ImageTagMirrorSetList{}
+	if err := r.listClusterImageMirrors(tagMirrorSets); err != nil {
+		return nil, err
+	}
+	for _, mirrorSet := range tagMirrorSets.Items {
+		for _, tagMirror := range mirrorSet.Spec.ImageTagMirrors {
+			rules = append(rules, image.MirrorRule{
+				Source:             tagMirror.Source,
+				Mirrors:            imageMirrorStrings(tagMirror.Mirrors),
+				NeverContactSource: tagMirror.MirrorSourcePolicy == configv1.NeverContactSource,
+				ForTags:            true,
+			})
+		}
+	}
+
+	contentSourcePolicies := &operatorv1alpha1.ImageContentSourcePolicyList{}
+	if err := r.listClusterImageMirrors(contentSourcePolicies); err != nil {
+		return nil, err
+	}
+	for _, policy := range contentSourcePolicies.Items {
+		for _, digestMirror := range policy.Spec.RepositoryDigestMirrors {
+			rules = append(rules, image.MirrorRule{Source: digestMirror.Source, Mirrors: digestMirror.Mirrors})
+		}
+	}
+
+	// most specific sources first, as the container runtime does
+	sort.SliceStable(rules, func(i, j int) bool {
+		return len(rules[i].Source) > len(rules[j].Source)
+	})
+	return rules, nil
+}
+
+func (r *DataProtectionApplicationReconciler) listClusterImageMirrors(list client.ObjectList) error {
+	if err := r.ClusterWideClient.List(r.Context, list); err != nil {
+		if apimeta.IsNoMatchError(err) || runtime.IsNotRegisteredError(err) || k8serror.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func imageMirrorStrings(mirrors []configv1.ImageMirror) []string {
+	result := make([]string, 0, len(mirrors))
+	for _, mirror := range mirrors {
+		result = append(result, string(mirror))
+	}
+	return result
+}
+
+// getClusterPullSecretCredentials returns the registry credentials of the OpenShift global pull secret,
+// none if the cluster does not have one
+func (r *DataProtectionApplicationReconciler) getClusterPullSecretCredentials() (map[string]image.Credential, error) {
+	secret := &corev1.Secret{}
+	err := r.ClusterWideClient.Get(r.Context, types.NamespacedName{Namespace: clusterPullSecretNamespace, Name: clusterPullSecretName}, secret)
+	if err != nil {
+		if k8serror.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return image.ParsePullSecret(secret.Data[corev1.DockerConfigJsonKey])
+}
+
+// pinImages replaces the images of a workload pod template by their resolved digests,
+// keeping images that were not resolved as configured
+func (r *DataProtectionApplicationReconciler) pinImages(template *corev1.PodTemplateSpec) {
+	if len(r.resolvedImages) == 0 {
+		return
+	}
+	pin := func(containers []corev1.Container) {
+		for i := range containers {
+			pinned, ok := r.resolvedImages[containers[i].Image]
+			if !ok {
+				continue
+			}
+			containers[i].Image = pinned
+			if r.dpa.Spec.ImagePullPolicy == nil {
+				containers[i].ImagePullPolicy = corev1.PullIfNotPresent
+			}
+		}
+	}
+	pin(template.Spec.InitContainers)
+	pin(template.Spec.Containers)
+}
@@ -0,0 +1,211 @@
+package controller
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func TestDPAReconciler_ReconcileImageDigests(t *testing.T) {
+	const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	registry := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/v2/oadp/velero/manifests/v1.0" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", digest)
+	}))
+	defer registry.Close()
+	mirror := strings.TrimPrefix(registry.URL, "https://")
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: registry.Certificate().Raw})
+	auth := base64.StdEncoding.EncodeToString([]byte("user:secret"))
+
+	clusterObjects := []client.Object{
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: common.TrustedCABundleConfigMapName, Namespace: "test-ns"},
+			Data:       map[string]string{common.TrustedCABundleKey: string(caBundle)},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: clusterPullSecretName, Namespace: clusterPullSecretNamespace},
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{"` + mirror + `":{"auth":"` + auth + `"}}}`)},
+		},
+		&configv1.ImageTagMirrorSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "oadp"},
+			Spec: configv1.ImageTagMirrorSetSpec{
+				ImageTagMirrors: []configv1.ImageTagMirrors{{
+					Source:             "quay.invalid/konveyor",
+					Mirrors:            []configv1.ImageMirror{configv1.ImageMirror(mirror + "/oadp")},
+					MirrorSourcePolicy: configv1.NeverContactSource,
+				}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		pinImageDigests  bool
+		objects          []client.Object
+		wantImages       []oadpv1alpha1.ImageStatus
+		wantCondition    *metav1.Condition
+		wantPinnedVelero string
+	}{
+		{
+			name: "pinning disabled, status cleared",
+		},
+		{
+			name:            "velero image resolved through mirror, plugin image unreachable",
+			pinImageDigests: true,
+			objects:         clusterObjects,
+			wantImages: []oadpv1alpha1.ImageStatus{
+				{
+					Component:     "velero",
+					Image:         "quay.invalid/konveyor/velero:v1.0",
+					ResolvedImage: "quay.invalid/konveyor/velero@" + digest,
+					ResolvedFrom:  mirror + "/oadp/velero",
+				},
+				{
+					Component: "plugin/openshift",
+					Image:     "quay.invalid/konveyor/openshift-velero-plugin:v1.0",
+				},
+			},
+			wantCondition: &metav1.Condition{
+				Type:    oadpv1alpha1.ConditionImagesResolved,
+				Status:  metav1.ConditionFalse,
+				Reason:  oadpv1alpha1.ImagesReasonUnreachable,
+				Message: "images not reachable through the configured mirrors, deployed without digest: plugin/openshift (quay.invalid/konveyor/openshift-velero-plugin:v1.0)",
+			},
+			wantPinnedVelero: "quay.invalid/konveyor/velero@" + digest,
+		},
+		{
+			name:            "no mirrors or credentials, images unreachable",
+			pinImageDigests: true,
+			objects:         clusterObjects[:1],
+			wantImages: []oadpv1alpha1.ImageStatus{
+				{Component: "velero", Image: "quay.invalid/konveyor/velero:v1.0"},
+				{Component: "plugin/openshift", Image: "quay.invalid/konveyor/openshift-velero-plugin:v1.0"},
+			},
+			wantCondition: &metav1.Condition{
+				Type:    oadpv1alpha1.ConditionImagesResolved,
+				Status:  metav1.ConditionFalse,
+				Reason:  oadpv1alpha1.ImagesReasonUnreachable,
+				Message: "images not reachable through the configured mirrors, deployed without digest: velero (quay.invalid/konveyor/velero:v1.0), plugin/openshift (quay.invalid/konveyor/openshift-velero-plugin:v1.0)",
+			},
+			wantPinnedVelero: "quay.invalid/konveyor/velero:v1.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imageDigestCache.entries = map[string]imageDigestCacheEntry{}
+			dpa := createTestDryRunDPA()
+			dpa.Spec.PinImageDigests = tt.pinImageDigests
+			dpa.Spec.UnsupportedOverrides = map[oadpv1alpha1.UnsupportedImageKey]string{
+				oadpv1alpha1.VeleroImageKey:          "quay.invalid/konveyor/velero:v1.0",
+				oadpv1alpha1.OpenShiftPluginImageKey: "quay.invalid/konveyor/openshift-velero-plugin:v1.0",
+			}
+			dpa.Status.Images = []oadpv1alpha1.ImageStatus{{Component: "velero", Image: "old"}}
+			fakeClient, err := getFakeClientFromObjects(append(tt.objects, dpa)...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:            fakeClient,
+				ClusterWideClient: fakeClient,
+				Scheme:            fakeClient.Scheme(),
+				Log:               logr.Discard(),
+				Context:           newContextForTest(),
+				dpa:               dpa,
+			}
+			if _, err := r.ReconcileImageDigests(r.Log); err != nil {
+				t.Fatalf("ReconcileImageDigests() unexpected error = %v", err)
+			}
+			for i := range dpa.Status.Images {
+				dpa.Status.Images[i].Message = ""
+			}
+			if !reflect.DeepEqual(dpa.Status.Images, tt.wantImages) {
+				t.Errorf("expected images status %v, got %v", tt.wantImages, dpa.Status.Images)
+			}
+			condition := apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionImagesResolved)
+			if (condition == nil) != (tt.wantCondition == nil) {
+				t.Fatalf("expected condition %v, got %v", tt.wantCondition, condition)
+			}
+			if condition != nil && (condition.Status != tt.wantCondition.Status || condition.Reason != tt.wantCondition.Reason || condition.Message != tt.wantCondition.Message) {
+				t.Errorf("expected condition %v, got %v", tt.wantCondition, condition)
+			}
+
+			if len(tt.wantPinnedVelero) == 0 {
+				return
+			}
+			template := &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: common.Velero, Image: "quay.invalid/konveyor/velero:v1.0", ImagePullPolicy: corev1.PullAlways}},
+				},
+			}
+			r.pinImages(template)
+			if template.Spec.Containers[0].Image != tt.wantPinnedVelero {
+				t.Errorf("expected velero image %s, got %s", tt.wantPinnedVelero, template.Spec.Containers[0].Image)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_pinImages(t *testing.T) {
+	pinned := "quay.io/konveyor/velero@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	tests := []struct {
+		name            string
+		imagePullPolicy *corev1.PullPolicy
+		wantPolicy      corev1.PullPolicy
+	}{
+		{
+			name:       "resolved image pulled if not present",
+			wantPolicy: corev1.PullIfNotPresent,
+		},
+		{
+			name:            "imagePullPolicy override kept",
+			imagePullPolicy: ptr.To(corev1.PullAlways),
+			wantPolicy:      corev1.PullAlways,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := createTestDryRunDPA()
+			dpa.Spec.ImagePullPolicy = tt.imagePullPolicy
+			r := &DataProtectionApplicationReconciler{
+				dpa:            dpa,
+				resolvedImages: map[string]string{"quay.io/konveyor/velero:latest": pinned},
+			}
+			template := &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{Name: "custom", Image: "quay.io/example/custom:latest", ImagePullPolicy: corev1.PullAlways}},
+					Containers:     []corev1.Container{{Name: common.Velero, Image: "quay.io/konveyor/velero:latest", ImagePullPolicy: corev1.PullAlways}},
+				},
+			}
+			r.pinImages(template)
+			if template.Spec.Containers[0].Image != pinned || template.Spec.Containers[0].ImagePullPolicy != tt.wantPolicy {
+				t.Errorf("expected velero container %s with %s, got %s with %s", pinned, tt.wantPolicy, template.Spec.Containers[0].Image, template.Spec.Containers[0].ImagePullPolicy)
+			}
+			if template.Spec.InitContainers[0].Image != "quay.io/example/custom:latest" || template.Spec.InitContainers[0].ImagePullPolicy != corev1.PullAlways {
+				t.Errorf("expected unresolved init container unchanged, got %v", template.Spec.InitContainers[0])
+			}
+		})
+	}
+}
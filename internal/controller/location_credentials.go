@@ -0,0 +1,254 @@
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+	"github.com/openshift/oadp-operator/pkg/credentials"
+	"github.com/openshift/oadp-operator/pkg/storage/aws"
+)
+
+// components of the credentials secrets rendered for the BSLs, VSLs and ObjectBucketClaims
+const (
+	bslCredentialsComponent = "bsl-credentials"
+	vslCredentialsComponent = "vsl-credentials"
+	obcCredentialsComponent = "obc-credentials"
+)
+
+// getLocationCredentialsSecretName returns the name of the credentials secret rendered for the location locationName
+func getLocationCredentialsSecretName(locationName, component string) string {
+	return locationName + "-" + component
+}
+
+// renderLocationCredentials returns the credentials of a location completed with the location config
+// the provider does not read from the location, or nil when the credentials are used as is:
+//   - AWS STS credentials without region get the region of the config, or of the bucket,
+//   - Azure workload identity credentials without resource group get the resource group of the config.
+func renderLocationCredentials(provider string, credentials []byte, config map[string]string, bucket string) []byte {
+	credString := string(credentials)
+	switch strings.TrimPrefix(provider, veleroIOPrefix) {
+	case AWSProvider:
+		// STS credentials created by stsflow set role_arn and web_identity_token_file
+		if !strings.Contains(credString, "role_arn") || !strings.Contains(credString, "web_identity_token_file") {
+			return nil
+		}
+		// Look for region as a separate config line (not part of another word like "role_region")
+		if strings.Contains(credString, "\nregion =") || strings.HasPrefix(credString, "region =") {
+			return nil
+		}
+		region := config[Region]
+		if region == "" && bucket != "" && !strings.Contains(bucket, "/") {
+			if discoveredRegion, err := aws.GetBucketRegion(bucket); err == nil {
+				region = discoveredRegion
+			}
+		}
+		if region == "" {
+			return nil
+		}
+		if !strings.HasSuffix(credString, "\n") {
+			credString += "\n"
+		}
+		return []byte(credString + "region = " + region)
+	case AzureProvider:
+		// workload identity credentials set AZURE_CLIENT_ID without AZURE_CLIENT_SECRET
+		if !strings.Contains(credString, "AZURE_CLIENT_ID") || strings.Contains(credString, "AZURE_CLIENT_SECRET") {
+			return nil
+		}
+		if strings.Contains(credString, "AZURE_RESOURCE_GROUP=") || config[ResourceGroup] == "" {
+			return nil
+		}
+		if !strings.HasSuffix(credString, "\n") {
+			credString += "\n"
+		}
+		return []byte(credString + "AZURE_RESOURCE_GROUP=" + config[ResourceGroup] + "\n")
+	}
+	return nil
+}
+
+// reconcileBackupLocationCredentials renders the credentials of the backup location for its BSL bslName,
+// and returns the credential to set in the BSL spec, nil to keep the credential of the backup location
+func (r *DataProtectionApplicationReconciler) reconcileBackupLocationCredentials(bslName string, bslSpec oadpv1alpha1.BackupLocation) (*corev1.SecretKeySelector, error) {
+	if bslSpec.Velero != nil {
+		secretName, secretKey, _ := r.getSecretNameAndKey(bslSpec.Velero.Config, bslSpec.Velero.Credential, oadpv1alpha1.DefaultPlugin(bslSpec.Velero.Provider))
+		bucket := ""
+		if bslSpec.Velero.ObjectStorage != nil {
+			bucket = bslSpec.Velero.ObjectStorage.Bucket
+		}
+		return r.reconcileLocationCredentialsSecret(bslName, bslCredentialsComponent, bslSpec.Velero.Provider, secretName, secretKey, bslSpec.Velero.Config, bucket)
+	}
+	if bslSpec.CloudStorage != nil {
+		bucket := &oadpv1alpha1.CloudStorage{}
+		if err := r.Get(r.Context, client.ObjectKey{Namespace: r.dpa.Namespace, Name: bslSpec.CloudStorage.CloudStorageRef.Name}, bucket); err != nil {
+			return nil, err
+		}
+		provider := ""
+		switch bucket.Spec.Provider {
+		case oadpv1alpha1.AWSBucketProvider:
+			provider = AWSProvider
+		case oadpv1alpha1.AzureBucketProvider:
+			provider = AzureProvider
+		case oadpv1alpha1.GCPBucketProvider:
+			provider = GCPProvider
+		}
+		secretName, secretKey, _ := r.getSecretNameAndKeyFromCloudStorage(bslSpec.CloudStorage)
+		return r.reconcileLocationCredentialsSecret(bslName, bslCredentialsComponent, provider, secretName, secretKey, bslSpec.CloudStorage.Config, bucket.Spec.Name)
+	}
+	return nil, nil
+}
+
+// reconcileLocationCredentialsSecret renders the key secretKey of the user secret secretName for the location locationName
+// into a secret owned by the DPA, and returns the credential referencing it. The user secret is not modified, so
+// locations sharing a secret each get their own config. It returns nil when the user secret is used as is.
+// component is bslCredentialsComponent or vslCredentialsComponent.
+func (r *DataProtectionApplicationReconciler) reconcileLocationCredentialsSecret(locationName, component, provider, secretName, secretKey string, config map[string]string, bucket string) (*corev1.SecretKeySelector, error) {
+	if secretName == "" || secretKey == "" || config[CredentialsFileKey] != "" {
+		return nil, nil
+	}
+	userSecret := &corev1.Secret{}
+	if err := r.Get(r.Context, client.ObjectKey{Namespace: r.dpa.Namespace, Name: secretName}, userSecret); err != nil {
+		// a missing secret is reported by the validation of the locations, and by Velero in the location status
+		if k8serror.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get secret %s: %w", secretName, err)
+	}
+	credentials := renderLocationCredentials(provider, userSecret.Data[secretKey], config, bucket)
+	if credentials == nil {
+		return nil, nil
+	}
+	return r.writeLocationCredentialsSecret(locationName, component, secretName, secretKey, credentials)
+}
+
+// writeLocationCredentialsSecret writes the credentials rendered from the secret secretName for the location
+// locationName into the key secretKey of a secret owned by the DPA, and returns the credential referencing it
+func (r *DataProtectionApplicationReconciler) writeLocationCredentialsSecret(locationName, component, secretName, secretKey string, credentials []byte) (*corev1.SecretKeySelector, error) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getLocationCredentialsSecretName(locationName, component),
+			Namespace: r.dpa.Namespace,
+		},
+	}
+	// do not take over a user secret with the same name
+	if err := r.Get(r.Context, client.ObjectKeyFromObject(secret), secret); err == nil {
+		if !metav1.IsControlledBy(secret, r.dpa) {
+			return nil, fmt.Errorf("secret %s for the credentials of %s already exists and is not owned by the DPA", secret.Name, locationName)
+		}
+	} else if !k8serror.IsNotFound(err) {
+		return nil, err
+	}
+	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, secret, func() error {
+		// the dataprotectionapplication.name label triggers a reconcile when the secret is changed, see labelHandler
+		secret.Labels = common.AppendTTMapAsCopy(secret.Labels, map[string]string{
+			"app.kubernetes.io/name":         common.OADPOperatorVelero,
+			"app.kubernetes.io/instance":     locationName,
+			"app.kubernetes.io/managed-by":   common.OADPOperator,
+			"app.kubernetes.io/component":    component,
+			oadpv1alpha1.OadpOperatorLabel:   "True",
+			"dataprotectionapplication.name": r.dpa.Name,
+		})
+		secret.Data = map[string][]byte{secretKey: credentials}
+		return controllerutil.SetControllerReference(r.dpa, secret, r.Scheme)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if op == controllerutil.OperationResultCreated || op == controllerutil.OperationResultUpdated {
+		r.EventRecorder.Event(secret,
+			corev1.EventTypeNormal,
+			"LocationCredentialsReconciled",
+			fmt.Sprintf("performed %s on credentials secret %s/%s of %s from secret %s", op, secret.Namespace, secret.Name, locationName, secretName),
+		)
+	}
+	return &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: secret.Name},
+		Key:                  secretKey,
+	}, nil
+}
+
+// getDefaultBackupLocationIndex returns the index of the default backup location of the spec. The only backup
+// location is the default one, -1 if there is none.
+func getDefaultBackupLocationIndex(dpa *oadpv1alpha1.DataProtectionApplication) int {
+	for i, bslSpec := range dpa.Spec.BackupLocations {
+		if (bslSpec.Velero != nil && bslSpec.Velero.Default) || (bslSpec.CloudStorage != nil && bslSpec.CloudStorage.Default) {
+			return i
+		}
+	}
+	if len(dpa.Spec.BackupLocations) == 1 {
+		return 0
+	}
+	return -1
+}
+
+// useDefaultBackupLocationCredentials replaces the default secret of a provider in the volumes of the Velero or
+// NodeAgent pod with the credentials rendered for the default backup location, when the default location uses
+// the default secret of the provider. The default credentials of the pods then get the region or resource group
+// of the default location, as its BSL does, without modifying the default secret.
+func (r *DataProtectionApplicationReconciler) useDefaultBackupLocationCredentials(volumes []corev1.Volume) error {
+	dpa := r.dpa
+	index := getDefaultBackupLocationIndex(dpa)
+	if index < 0 || dpa.Spec.BackupLocations[index].Velero == nil {
+		return nil
+	}
+	bslSpec := dpa.Spec.BackupLocations[index].Velero
+	providerFields, ok := credentials.PluginSpecificFields[oadpv1alpha1.DefaultPlugin(strings.TrimPrefix(bslSpec.Provider, veleroIOPrefix))]
+	if !ok || !providerFields.IsCloudProvider || bslSpec.Config[CredentialsFileKey] != "" ||
+		(bslSpec.Credential != nil && (bslSpec.Credential.Name != providerFields.SecretName || bslSpec.Credential.Key != providerFields.PluginSecretKey)) {
+		return nil
+	}
+	volumeIndex := slices.IndexFunc(volumes, func(volume corev1.Volume) bool {
+		return volume.Secret != nil && volume.Secret.SecretName == providerFields.SecretName
+	})
+	if volumeIndex < 0 {
+		return nil
+	}
+	names, _, err := r.getBackupLocationNames()
+	if err != nil {
+		return err
+	}
+	secret := &corev1.Secret{}
+	if err := r.Get(r.Context, client.ObjectKey{Namespace: dpa.Namespace, Name: getLocationCredentialsSecretName(names[index], bslCredentialsComponent)}, secret); err != nil {
+		if k8serror.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if metav1.IsControlledBy(secret, dpa) {
+		volumes[volumeIndex].Secret.SecretName = secret.Name
+	}
+	return nil
+}
+
+// deleteStaleLocationCredentialsSecrets deletes the component credentials secrets owned by the DPA
+// that are not in secretNames, the secrets of removed locations or of locations using their user secret again
+func (r *DataProtectionApplicationReconciler) deleteStaleLocationCredentialsSecrets(component string, secretNames []string) error {
+	secrets := corev1.SecretList{}
+	if err := r.List(r.Context, &secrets, client.InNamespace(r.dpa.Namespace), client.MatchingLabels{
+		"app.kubernetes.io/managed-by": common.OADPOperator,
+		"app.kubernetes.io/component":  component,
+	}); err != nil {
+		return err
+	}
+	for _, secret := range secrets.Items {
+		if slices.Contains(secretNames, secret.Name) || !metav1.IsControlledBy(&secret, r.dpa) {
+			continue
+		}
+		if err := r.Delete(r.Context, &secret); err != nil && !k8serror.IsNotFound(err) {
+			return err
+		}
+		r.EventRecorder.Event(&secret,
+			corev1.EventTypeNormal,
+			"LocationCredentialsDeleted",
+			fmt.Sprintf("deleted credentials secret %s/%s no longer used by a location", secret.Namespace, secret.Name),
+		)
+	}
+	return nil
+}
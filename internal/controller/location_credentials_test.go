@@ -0,0 +1,588 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+const (
+	testAWSSTSCredentials = `[default]
+role_arn = arn:aws:iam::123456789012:role/test-role
+web_identity_token_file = /var/run/secrets/openshift/serviceaccount/token`
+	testAzureWorkloadIdentityCredentials = `AZURE_CLIENT_ID=test-client
+AZURE_TENANT_ID=test-tenant`
+)
+
+func TestRenderLocationCredentials(t *testing.T) {
+	tests := []struct {
+		name        string
+		provider    string
+		credentials string
+		config      map[string]string
+		want        string
+	}{
+		{
+			name:        "AWS STS credentials get the region of the config",
+			provider:    AWSProvider,
+			credentials: testAWSSTSCredentials,
+			config:      map[string]string{Region: "us-east-1"},
+			want:        testAWSSTSCredentials + "\nregion = us-east-1",
+		},
+		{
+			name:        "AWS STS credentials with velero.io prefix",
+			provider:    "velero.io/aws",
+			credentials: testAWSSTSCredentials + "\n",
+			config:      map[string]string{Region: "eu-west-1"},
+			want:        testAWSSTSCredentials + "\nregion = eu-west-1",
+		},
+		{
+			name:        "AWS STS credentials with a region are used as is",
+			provider:    AWSProvider,
+			credentials: testAWSSTSCredentials + "\nregion = us-west-2",
+			config:      map[string]string{Region: "us-east-1"},
+		},
+		{
+			name:        "AWS access key credentials are used as is",
+			provider:    AWSProvider,
+			credentials: "[default]\naws_access_key_id=test-key\naws_secret_access_key=test-secret",
+			config:      map[string]string{Region: "us-east-1"},
+		},
+		{
+			name:        "AWS STS credentials without region in the config nor bucket are used as is",
+			provider:    AWSProvider,
+			credentials: testAWSSTSCredentials,
+		},
+		{
+			name:        "Azure workload identity credentials get the resource group of the config",
+			provider:    AzureProvider,
+			credentials: testAzureWorkloadIdentityCredentials,
+			config:      map[string]string{ResourceGroup: "test-rg"},
+			want:        testAzureWorkloadIdentityCredentials + "\nAZURE_RESOURCE_GROUP=test-rg\n",
+		},
+		{
+			name:        "Azure client secret credentials are used as is",
+			provider:    AzureProvider,
+			credentials: testAzureWorkloadIdentityCredentials + "\nAZURE_CLIENT_SECRET=test-secret",
+			config:      map[string]string{ResourceGroup: "test-rg"},
+		},
+		{
+			name:        "Azure workload identity credentials with a resource group are used as is",
+			provider:    AzureProvider,
+			credentials: testAzureWorkloadIdentityCredentials + "\nAZURE_RESOURCE_GROUP=other-rg",
+			config:      map[string]string{ResourceGroup: "test-rg"},
+		},
+		{
+			name:        "GCP credentials are used as is",
+			provider:    GCPProvider,
+			credentials: `{"type":"service_account"}`,
+			config:      map[string]string{Region: "us-east-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderLocationCredentials(tt.provider, []byte(tt.credentials), tt.config, "")
+			if string(got) != tt.want || (got == nil) != (tt.want == "") {
+				t.Errorf("renderLocationCredentials() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_ReconcileLocationCredentials(t *testing.T) {
+	awsSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials", Namespace: "test-ns"},
+		Data:       map[string][]byte{"credentials": []byte(testAWSSTSCredentials)},
+	}
+	azureSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials-azure", Namespace: "test-ns"},
+		Data:       map[string][]byte{"azurekey": []byte(testAzureWorkloadIdentityCredentials)},
+	}
+	awsLocation := func(name, region string) oadpv1alpha1.BackupLocation {
+		return oadpv1alpha1.BackupLocation{
+			Name: name,
+			Velero: &velerov1.BackupStorageLocationSpec{
+				Provider: AWSProvider,
+				Config:   map[string]string{Region: region},
+				Credential: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: awsSecret.Name},
+					Key:                  "credentials",
+				},
+				StorageType: velerov1.StorageType{ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "bucket-" + name}},
+			},
+		}
+	}
+	credentialsDpa := func(backupLocations []oadpv1alpha1.BackupLocation, snapshotLocations []oadpv1alpha1.SnapshotLocation) *oadpv1alpha1.DataProtectionApplication {
+		dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{
+			BackupLocations:   backupLocations,
+			SnapshotLocations: snapshotLocations,
+		})
+		dpa.UID = "test-uid"
+		return dpa
+	}
+	staleSecret := func(dpa *oadpv1alpha1.DataProtectionApplication, name, component string) *corev1.Secret {
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "test-ns",
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": common.OADPOperator,
+				"app.kubernetes.io/component":  component,
+			},
+		}}
+		secret.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(dpa, oadpv1alpha1.GroupVersion.WithKind("DataProtectionApplication"))})
+		return secret
+	}
+	tests := []struct {
+		name            string
+		dpa             *oadpv1alpha1.DataProtectionApplication
+		objects         func(dpa *oadpv1alpha1.DataProtectionApplication) []client.Object
+		wantCredentials map[string]*corev1.SecretKeySelector
+		wantSecrets     map[string]string
+		wantErr         bool
+	}{
+		{
+			name: "BSLs sharing an STS secret get their own region",
+			dpa:  credentialsDpa([]oadpv1alpha1.BackupLocation{awsLocation("east", "us-east-1"), awsLocation("west", "us-west-2")}, nil),
+			wantCredentials: map[string]*corev1.SecretKeySelector{
+				"east": {LocalObjectReference: corev1.LocalObjectReference{Name: "east-bsl-credentials"}, Key: "credentials"},
+				"west": {LocalObjectReference: corev1.LocalObjectReference{Name: "west-bsl-credentials"}, Key: "credentials"},
+			},
+			wantSecrets: map[string]string{
+				"east-bsl-credentials": testAWSSTSCredentials + "\nregion = us-east-1",
+				"west-bsl-credentials": testAWSSTSCredentials + "\nregion = us-west-2",
+			},
+		},
+		{
+			name: "VSL gets the resource group of its config and stale secrets are deleted",
+			dpa: credentialsDpa(nil, []oadpv1alpha1.SnapshotLocation{{
+				Name: "snapshots",
+				Velero: &velerov1.VolumeSnapshotLocationSpec{
+					Provider: AzureProvider,
+					Config:   map[string]string{ResourceGroup: "test-rg"},
+					Credential: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: azureSecret.Name},
+						Key:                  "azurekey",
+					},
+				},
+			}}),
+			objects: func(dpa *oadpv1alpha1.DataProtectionApplication) []client.Object {
+				return []client.Object{
+					staleSecret(dpa, "removed-bsl-credentials", bslCredentialsComponent),
+					staleSecret(dpa, "removed-vsl-credentials", vslCredentialsComponent),
+				}
+			},
+			wantCredentials: map[string]*corev1.SecretKeySelector{
+				"snapshots": {LocalObjectReference: corev1.LocalObjectReference{Name: "snapshots-vsl-credentials"}, Key: "azurekey"},
+			},
+			wantSecrets: map[string]string{
+				"snapshots-vsl-credentials": testAzureWorkloadIdentityCredentials + "\nAZURE_RESOURCE_GROUP=test-rg\n",
+			},
+		},
+		{
+			name: "user secret with the name of the rendered secret is not taken over",
+			dpa:  credentialsDpa([]oadpv1alpha1.BackupLocation{awsLocation("east", "us-east-1")}, nil),
+			objects: func(dpa *oadpv1alpha1.DataProtectionApplication) []client.Object {
+				return []client.Object{&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "east-bsl-credentials", Namespace: "test-ns"}}}
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := []client.Object{tt.dpa, awsSecret.DeepCopy(), azureSecret.DeepCopy()}
+			if tt.objects != nil {
+				objects = append(objects, tt.objects(tt.dpa)...)
+			}
+			fakeClient, err := getFakeClientFromObjects(objects...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:         fakeClient,
+				Scheme:         fakeClient.Scheme(),
+				Log:            logr.Discard(),
+				Context:        newContextForTest(),
+				NamespacedName: types.NamespacedName{Namespace: tt.dpa.Namespace, Name: tt.dpa.Name},
+				EventRecorder:  record.NewFakeRecorder(10),
+				dpa:            tt.dpa,
+			}
+			_, err = r.ReconcileBackupStorageLocations(r.Log)
+			if err == nil {
+				_, err = r.ReconcileVolumeSnapshotLocations(r.Log)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reconcile locations error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			for name, want := range tt.wantCredentials {
+				var got *corev1.SecretKeySelector
+				bsl := &velerov1.BackupStorageLocation{}
+				vsl := &velerov1.VolumeSnapshotLocation{}
+				if err := fakeClient.Get(r.Context, types.NamespacedName{Namespace: "test-ns", Name: name}, bsl); err == nil {
+					got = bsl.Spec.Credential
+				} else if err := fakeClient.Get(r.Context, types.NamespacedName{Namespace: "test-ns", Name: name}, vsl); err == nil {
+					got = vsl.Spec.Credential
+				} else {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("expected credential of %s %#v, got %#v", name, want, got)
+				}
+			}
+
+			secrets := &corev1.SecretList{}
+			if err := fakeClient.List(r.Context, secrets, client.InNamespace("test-ns")); err != nil {
+				t.Fatal(err)
+			}
+			gotSecrets := map[string]string{}
+			for _, secret := range secrets.Items {
+				switch secret.Name {
+				case awsSecret.Name, azureSecret.Name:
+					// user secrets are not modified
+					want := awsSecret
+					if secret.Name == azureSecret.Name {
+						want = azureSecret
+					}
+					if !reflect.DeepEqual(secret.Data, want.Data) {
+						t.Errorf("expected secret %s to be unchanged, got %q", secret.Name, secret.Data)
+					}
+					continue
+				}
+				if !metav1.IsControlledBy(&secret, tt.dpa) {
+					t.Errorf("expected secret %s to be owned by the DPA", secret.Name)
+				}
+				for _, data := range secret.Data {
+					gotSecrets[secret.Name] = string(data)
+				}
+			}
+			if !reflect.DeepEqual(gotSecrets, tt.wantSecrets) {
+				t.Errorf("expected rendered secrets %v, got %v", tt.wantSecrets, gotSecrets)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_reconcileBackupLocationCredentials(t *testing.T) {
+	const awsAccessKeyCredentials = `[default]
+aws_access_key_id=test-key
+aws_secret_access_key=test-secret`
+	const azureClientSecretCredentials = `AZURE_CLIENT_ID=test-client
+AZURE_CLIENT_SECRET=test-secret
+AZURE_TENANT_ID=test-tenant`
+	secret := func(name, key, data string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns"},
+			Data:       map[string][]byte{key: []byte(data)},
+		}
+	}
+	credential := func(name, key string) *corev1.SecretKeySelector {
+		return &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: key}
+	}
+	tests := []struct {
+		name           string
+		bslSpec        oadpv1alpha1.BackupLocation
+		objects        []client.Object
+		wantCredential *corev1.SecretKeySelector
+		wantSecret     string
+		wantErr        bool
+	}{
+		{
+			name: "AWS STS credentials get the region of the config",
+			bslSpec: oadpv1alpha1.BackupLocation{Velero: &velerov1.BackupStorageLocationSpec{
+				Provider:   AWSProvider,
+				Config:     map[string]string{Region: "us-east-1"},
+				Credential: credential("aws-secret", "credentials"),
+				StorageType: velerov1.StorageType{
+					ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "test-bucket"},
+				},
+			}},
+			objects:        []client.Object{secret("aws-secret", "credentials", testAWSSTSCredentials)},
+			wantCredential: credential("test-bsl-bsl-credentials", "credentials"),
+			wantSecret:     testAWSSTSCredentials + "\nregion = us-east-1",
+		},
+		{
+			name: "AWS STS credentials in another region",
+			bslSpec: oadpv1alpha1.BackupLocation{Velero: &velerov1.BackupStorageLocationSpec{
+				Provider:   AWSProvider,
+				Config:     map[string]string{Region: "eu-central-1"},
+				Credential: credential("aws-secret", "credentials"),
+			}},
+			objects:        []client.Object{secret("aws-secret", "credentials", testAWSSTSCredentials)},
+			wantCredential: credential("test-bsl-bsl-credentials", "credentials"),
+			wantSecret:     testAWSSTSCredentials + "\nregion = eu-central-1",
+		},
+		{
+			name: "AWS STS credentials with region are used as is",
+			bslSpec: oadpv1alpha1.BackupLocation{Velero: &velerov1.BackupStorageLocationSpec{
+				Provider:   AWSProvider,
+				Config:     map[string]string{Region: "us-east-1"},
+				Credential: credential("aws-secret", "credentials"),
+			}},
+			objects: []client.Object{secret("aws-secret", "credentials", testAWSSTSCredentials+"\nregion = us-west-1")},
+		},
+		{
+			name: "AWS access keys are used as is",
+			bslSpec: oadpv1alpha1.BackupLocation{Velero: &velerov1.BackupStorageLocationSpec{
+				Provider:   AWSProvider,
+				Config:     map[string]string{Region: "us-east-1"},
+				Credential: credential("aws-secret", "credentials"),
+			}},
+			objects: []client.Object{secret("aws-secret", "credentials", awsAccessKeyCredentials)},
+		},
+		{
+			name: "default cloud-credentials without credential",
+			bslSpec: oadpv1alpha1.BackupLocation{Velero: &velerov1.BackupStorageLocationSpec{
+				Provider: AWSProvider,
+				Config:   map[string]string{Region: "us-west-2"},
+			}},
+			objects:        []client.Object{secret("cloud-credentials", "cloud", testAWSSTSCredentials)},
+			wantCredential: credential("test-bsl-bsl-credentials", "cloud"),
+			wantSecret:     testAWSSTSCredentials + "\nregion = us-west-2",
+		},
+		{
+			name: "Azure workload identity credentials get the resource group of the config",
+			bslSpec: oadpv1alpha1.BackupLocation{Velero: &velerov1.BackupStorageLocationSpec{
+				Provider:   AzureProvider,
+				Config:     map[string]string{ResourceGroup: "test-rg"},
+				Credential: credential("azure-secret", "azurekey"),
+			}},
+			objects:        []client.Object{secret("azure-secret", "azurekey", testAzureWorkloadIdentityCredentials)},
+			wantCredential: credential("test-bsl-bsl-credentials", "azurekey"),
+			wantSecret:     testAzureWorkloadIdentityCredentials + "\nAZURE_RESOURCE_GROUP=test-rg\n",
+		},
+		{
+			name: "Azure client secret credentials are used as is",
+			bslSpec: oadpv1alpha1.BackupLocation{Velero: &velerov1.BackupStorageLocationSpec{
+				Provider:   AzureProvider,
+				Config:     map[string]string{ResourceGroup: "test-rg"},
+				Credential: credential("azure-secret", "azurekey"),
+			}},
+			objects: []client.Object{secret("azure-secret", "azurekey", azureClientSecretCredentials)},
+		},
+		{
+			name: "GCP credentials are used as is",
+			bslSpec: oadpv1alpha1.BackupLocation{Velero: &velerov1.BackupStorageLocationSpec{
+				Provider:   GCPProvider,
+				Credential: credential("gcp-secret", "cloud"),
+			}},
+			objects: []client.Object{secret("gcp-secret", "cloud", `{"type":"service_account"}`)},
+		},
+		{
+			name: "CloudStorage AWS STS credentials get the region of the config",
+			bslSpec: oadpv1alpha1.BackupLocation{CloudStorage: &oadpv1alpha1.CloudStorageLocation{
+				CloudStorageRef: corev1.LocalObjectReference{Name: "test-cloudstorage"},
+				Config:          map[string]string{Region: "eu-west-1"},
+				Credential:      credential("aws-secret", "credentials"),
+			}},
+			objects: []client.Object{
+				secret("aws-secret", "credentials", testAWSSTSCredentials),
+				&oadpv1alpha1.CloudStorage{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-cloudstorage", Namespace: "test-ns"},
+					Spec:       oadpv1alpha1.CloudStorageSpec{Provider: oadpv1alpha1.AWSBucketProvider},
+				},
+			},
+			wantCredential: credential("test-bsl-bsl-credentials", "credentials"),
+			wantSecret:     testAWSSTSCredentials + "\nregion = eu-west-1",
+		},
+		{
+			name: "missing secret is left to the validation of the location",
+			bslSpec: oadpv1alpha1.BackupLocation{Velero: &velerov1.BackupStorageLocationSpec{
+				Provider:   AWSProvider,
+				Credential: credential("non-existent-secret", "cloud"),
+			}},
+		},
+		{
+			name: "CloudStorage not found",
+			bslSpec: oadpv1alpha1.BackupLocation{CloudStorage: &oadpv1alpha1.CloudStorageLocation{
+				CloudStorageRef: corev1.LocalObjectReference{Name: "non-existent-cloudstorage"},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{})
+			dpa.UID = "test-uid"
+			fakeClient, err := getFakeClientFromObjects(append(tt.objects, dpa)...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:        fakeClient,
+				Scheme:        fakeClient.Scheme(),
+				Log:           logr.Discard(),
+				Context:       newContextForTest(),
+				EventRecorder: record.NewFakeRecorder(10),
+				dpa:           dpa,
+			}
+			got, err := r.reconcileBackupLocationCredentials("test-bsl", tt.bslSpec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reconcileBackupLocationCredentials() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.wantCredential) {
+				t.Errorf("expected credential %#v, got %#v", tt.wantCredential, got)
+			}
+			rendered := &corev1.Secret{}
+			err = fakeClient.Get(r.Context, types.NamespacedName{Namespace: "test-ns", Name: "test-bsl-bsl-credentials"}, rendered)
+			if len(tt.wantSecret) == 0 {
+				if err == nil {
+					t.Errorf("expected no rendered secret, got %q", rendered.Data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(rendered.Data[tt.wantCredential.Key]); got != tt.wantSecret {
+				t.Errorf("expected rendered credentials %q, got %q", tt.wantSecret, got)
+			}
+		})
+	}
+}
+
+func TestGetDefaultBackupLocationIndex(t *testing.T) {
+	tests := []struct {
+		name      string
+		locations []oadpv1alpha1.BackupLocation
+		want      int
+	}{
+		{
+			name: "default location is not first",
+			locations: []oadpv1alpha1.BackupLocation{
+				{Velero: &velerov1.BackupStorageLocationSpec{}},
+				{Velero: &velerov1.BackupStorageLocationSpec{Default: true}},
+			},
+			want: 1,
+		},
+		{
+			name: "default cloud storage location",
+			locations: []oadpv1alpha1.BackupLocation{
+				{Velero: &velerov1.BackupStorageLocationSpec{}},
+				{CloudStorage: &oadpv1alpha1.CloudStorageLocation{Default: true}},
+			},
+			want: 1,
+		},
+		{
+			name:      "only location",
+			locations: []oadpv1alpha1.BackupLocation{{Velero: &velerov1.BackupStorageLocationSpec{}}},
+			want:      0,
+		},
+		{
+			name: "no default location",
+			locations: []oadpv1alpha1.BackupLocation{
+				{Velero: &velerov1.BackupStorageLocationSpec{}},
+				{Velero: &velerov1.BackupStorageLocationSpec{}},
+			},
+			want: -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := &oadpv1alpha1.DataProtectionApplication{Spec: oadpv1alpha1.DataProtectionApplicationSpec{BackupLocations: tt.locations}}
+			if got := getDefaultBackupLocationIndex(dpa); got != tt.want {
+				t.Errorf("getDefaultBackupLocationIndex() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_useDefaultBackupLocationCredentials(t *testing.T) {
+	location := func(name string, isDefault bool, credential *corev1.SecretKeySelector) oadpv1alpha1.BackupLocation {
+		return oadpv1alpha1.BackupLocation{
+			Name: name,
+			Velero: &velerov1.BackupStorageLocationSpec{
+				Provider:   AWSProvider,
+				Default:    isDefault,
+				Credential: credential,
+			},
+		}
+	}
+	renderedSecret := func(dpa *oadpv1alpha1.DataProtectionApplication, name string) *corev1.Secret {
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns"}}
+		secret.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(dpa, oadpv1alpha1.GroupVersion.WithKind("DataProtectionApplication"))})
+		return secret
+	}
+	tests := []struct {
+		name       string
+		locations  []oadpv1alpha1.BackupLocation
+		objects    func(dpa *oadpv1alpha1.DataProtectionApplication) []client.Object
+		wantSecret string
+	}{
+		{
+			name:      "default location, not first, with rendered credentials",
+			locations: []oadpv1alpha1.BackupLocation{location("east", false, nil), location("west", true, nil)},
+			objects: func(dpa *oadpv1alpha1.DataProtectionApplication) []client.Object {
+				return []client.Object{renderedSecret(dpa, "east-bsl-credentials"), renderedSecret(dpa, "west-bsl-credentials")}
+			},
+			wantSecret: "west-bsl-credentials",
+		},
+		{
+			name:       "default location without rendered credentials",
+			locations:  []oadpv1alpha1.BackupLocation{location("east", true, nil)},
+			wantSecret: "cloud-credentials",
+		},
+		{
+			name: "default location with its own secret",
+			locations: []oadpv1alpha1.BackupLocation{location("east", true, &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "east-secret"},
+				Key:                  "cloud",
+			})},
+			objects: func(dpa *oadpv1alpha1.DataProtectionApplication) []client.Object {
+				return []client.Object{renderedSecret(dpa, "east-bsl-credentials")}
+			},
+			wantSecret: "cloud-credentials",
+		},
+		{
+			name:      "user secret with the name of the rendered secret",
+			locations: []oadpv1alpha1.BackupLocation{location("east", true, nil)},
+			objects: func(dpa *oadpv1alpha1.DataProtectionApplication) []client.Object {
+				return []client.Object{&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "east-bsl-credentials", Namespace: "test-ns"}}}
+			},
+			wantSecret: "cloud-credentials",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{BackupLocations: tt.locations})
+			dpa.UID = "test-uid"
+			objects := []client.Object{dpa}
+			if tt.objects != nil {
+				objects = append(objects, tt.objects(dpa)...)
+			}
+			fakeClient, err := getFakeClientFromObjects(objects...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:  fakeClient,
+				Scheme:  fakeClient.Scheme(),
+				Log:     logr.Discard(),
+				Context: newContextForTest(),
+				dpa:     dpa,
+			}
+			volumes := []corev1.Volume{
+				{Name: "plugins", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+				{Name: "cloud-credentials", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "cloud-credentials"}}},
+			}
+			if err := r.useDefaultBackupLocationCredentials(volumes); err != nil {
+				t.Fatalf("useDefaultBackupLocationCredentials() error = %v", err)
+			}
+			if got := volumes[1].Secret.SecretName; got != tt.wantSecret {
+				t.Errorf("expected default credentials volume of secret %s, got %s", tt.wantSecret, got)
+			}
+		})
+	}
+}
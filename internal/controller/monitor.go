@@ -2,18 +2,74 @@ package controller
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
+	monitor "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
 	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+	veleroserver "github.com/openshift/oadp-operator/pkg/velero/server"
+)
+
+const (
+	veleroMetricsServiceName    = "openshift-adp-velero-metrics-svc"
+	nodeAgentMetricsServiceName = "openshift-adp-node-agent-metrics-svc"
+	nonAdminMetricsServiceName  = "openshift-adp-non-admin-metrics-svc"
+	operatorMetricsServiceName  = "openshift-adp-operator-metrics-svc"
+	oadpAlertsRuleName          = "openshift-adp-alerts"
+
+	metricsServicePortName = "monitoring"
+	defaultMetricsPort     = 8085
+	// nonAdminMetricsPort is the metrics port of the non-admin controller, set when monitoring is enabled
+	nonAdminMetricsPort = 8080
+	// operatorMetricsPort is the default metrics port of the operator
+	operatorMetricsPort = 8080
+
+	// monitoringRequeueInterval is how often the DPA is reconciled when monitoring is enabled,
+	// to keep the backup storage location and backup repository metrics of the operator up to date
+	monitoringRequeueInterval = 5 * time.Minute
 )
 
+// operatorPodLabels are the labels of the operator pods, as set by the operator bundle
+var operatorPodLabels = map[string]string{"control-plane": "controller-manager"}
+
+var (
+	backupStorageLocationAvailable = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "oadp_backup_storage_location_available",
+			Help: "1 if the backup storage location created by the DPA is available, 0 if it is unavailable",
+		},
+		[]string{"backup_storage_location"},
+	)
+	backupRepositoryMaintenanceFailed = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "oadp_backup_repository_maintenance_failed",
+			Help: "1 if the last maintenance of the backup repository failed, 0 if it succeeded",
+		},
+		[]string{"backup_repository"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(backupStorageLocationAvailable, backupRepositoryMaintenanceFailed)
+}
+
 func (r *DataProtectionApplicationReconciler) ReconcileVeleroMetricsSVC(log logr.Logger) (bool, error) {
 	svc := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "openshift-adp-velero-metrics-svc",
+			Name:      veleroMetricsServiceName,
 			Namespace: r.NamespacedName.Namespace,
 		},
 	}
@@ -51,13 +107,17 @@ func (r *DataProtectionApplicationReconciler) updateVeleroMetricsSVC(svc *corev1
 	svc.Spec.Selector = getDpaAppLabels(r.dpa)
 
 	svc.Spec.Type = corev1.ServiceTypeClusterIP
+	metricsPort, err := getVeleroMetricsPort(r.dpa)
+	if err != nil {
+		return err
+	}
 	svc.Spec.Ports = []corev1.ServicePort{
 		{
 			Protocol: corev1.ProtocolTCP,
-			Name:     "monitoring",
-			Port:     int32(8085),
+			Name:     metricsServicePortName,
+			Port:     int32(metricsPort),
 			TargetPort: intstr.IntOrString{
-				IntVal: int32(8085),
+				IntVal: int32(metricsPort),
 			},
 		},
 	}
@@ -65,3 +125,340 @@ func (r *DataProtectionApplicationReconciler) updateVeleroMetricsSVC(svc *corev1
 	svc.Labels = getDpaAppLabels(r.dpa)
 	return nil
 }
+
+// getVeleroMetricsPort returns the metrics port of the Velero server
+func getVeleroMetricsPort(dpa *oadpv1alpha1.DataProtectionApplication) (int, error) {
+	if dpa.Spec.Configuration != nil && dpa.Spec.Configuration.Velero != nil &&
+		dpa.Spec.Configuration.Velero.Args != nil && dpa.Spec.Configuration.Velero.Args.MetricsAddress != "" {
+		return veleroserver.GetMetricsPort(dpa.Spec.Configuration.Velero.Args.MetricsAddress)
+	}
+	return defaultMetricsPort, nil
+}
+
+// getNodeAgentMetricsPort returns the metrics port of the NodeAgent server
+func getNodeAgentMetricsPort(dpa *oadpv1alpha1.DataProtectionApplication) (int, error) {
+	if dpa.Spec.Configuration != nil && dpa.Spec.Configuration.NodeAgent != nil &&
+		dpa.Spec.Configuration.NodeAgent.Args != nil && dpa.Spec.Configuration.NodeAgent.Args.MetricsAddress != "" {
+		return veleroserver.GetMetricsPort(dpa.Spec.Configuration.NodeAgent.Args.MetricsAddress)
+	}
+	return defaultMetricsPort, nil
+}
+
+func (r *DataProtectionApplicationReconciler) isMonitoringEnabled() bool {
+	return r.dpa.Spec.Monitoring != nil && r.dpa.Spec.Monitoring.Enable
+}
+
+// monitoredComponent is a component whose metrics are scraped when monitoring is enabled
+type monitoredComponent struct {
+	// serviceName is the metrics Service of the component, also the name of its ServiceMonitor
+	serviceName string
+	selector    map[string]string
+	port        int
+	enabled     bool
+}
+
+// getMonitoredComponents returns the components with a metrics Service and ServiceMonitor.
+// The Velero metrics Service is reconciled by ReconcileVeleroMetricsSVC.
+func (r *DataProtectionApplicationReconciler) getMonitoredComponents() ([]monitoredComponent, error) {
+	dpa := r.dpa
+	nodeAgentEnabled := dpa.Spec.Configuration != nil && isNodeAgentEnabled(dpa)
+	nodeAgentPort, err := getNodeAgentMetricsPort(dpa)
+	if err != nil {
+		return nil, err
+	}
+	return []monitoredComponent{
+		{serviceName: veleroMetricsServiceName, enabled: true},
+		{serviceName: nodeAgentMetricsServiceName, selector: nodeAgentMatchLabels, port: nodeAgentPort, enabled: nodeAgentEnabled},
+		{serviceName: nonAdminMetricsServiceName, selector: controlPlaneLabel, port: nonAdminMetricsPort, enabled: r.checkNonAdminEnabled()},
+		{serviceName: operatorMetricsServiceName, selector: operatorPodLabels, port: operatorMetricsPort, enabled: true},
+	}, nil
+}
+
+// ReconcileMonitoring creates the metrics Services and ServiceMonitors of the OADP components and the OADP alerts
+// PrometheusRule when spec.monitoring is enabled, and deletes them otherwise.
+// The operator exports the availability of backup storage locations and the result of the last backup repository
+// maintenances, which Velero does not, so they can be alerted on.
+func (r *DataProtectionApplicationReconciler) ReconcileMonitoring(log logr.Logger) (bool, error) {
+	components, err := r.getMonitoredComponents()
+	if err != nil {
+		return false, err
+	}
+	enabled := r.isMonitoringEnabled()
+	for _, component := range components {
+		if !enabled || !component.enabled {
+			if err := r.deleteMonitoringObject(&monitor.ServiceMonitor{}, component.serviceName); err != nil {
+				return false, err
+			}
+			if component.serviceName != veleroMetricsServiceName {
+				if err := r.deleteMonitoringObject(&corev1.Service{}, component.serviceName); err != nil {
+					return false, err
+				}
+			}
+			continue
+		}
+		if component.serviceName != veleroMetricsServiceName {
+			if err := r.reconcileMetricsService(component); err != nil {
+				return false, err
+			}
+		}
+		if err := r.reconcileServiceMonitor(component.serviceName); err != nil {
+			return false, err
+		}
+	}
+
+	if !enabled || (r.dpa.Spec.Monitoring.Alerts != nil && r.dpa.Spec.Monitoring.Alerts.Disable) {
+		if err := r.deleteMonitoringObject(&monitor.PrometheusRule{}, oadpAlertsRuleName); err != nil {
+			return false, err
+		}
+	} else if err := r.reconcileAlertsPrometheusRule(); err != nil {
+		return false, err
+	}
+
+	backupRepositoryMaintenanceFailed.Reset()
+	if enabled {
+		if err := r.updateBackupRepositoryMaintenanceMetrics(); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (r *DataProtectionApplicationReconciler) reconcileMetricsService(component monitoredComponent) error {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      component.serviceName,
+			Namespace: r.dpa.Namespace,
+		},
+	}
+	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, svc, r.withDriftDetection(svc, func() error {
+		svc.Labels = common.AppendTTMapAsCopy(svc.Labels, getDpaAppLabels(r.dpa))
+		svc.Spec.Selector = component.selector
+		svc.Spec.Type = corev1.ServiceTypeClusterIP
+		svc.Spec.Ports = []corev1.ServicePort{
+			{
+				Protocol:   corev1.ProtocolTCP,
+				Name:       metricsServicePortName,
+				Port:       int32(component.port),
+				TargetPort: intstr.FromInt32(int32(component.port)),
+			},
+		}
+		return controllerutil.SetControllerReference(r.dpa, svc, r.Scheme)
+	}))
+	if err != nil {
+		return err
+	}
+	if op == controllerutil.OperationResultCreated || op == controllerutil.OperationResultUpdated {
+		r.EventRecorder.Event(svc,
+			corev1.EventTypeNormal,
+			"MetricsServiceReconciled",
+			fmt.Sprintf("performed %s on metrics service %s/%s", op, svc.Namespace, svc.Name),
+		)
+	}
+	return nil
+}
+
+func (r *DataProtectionApplicationReconciler) reconcileServiceMonitor(serviceName string) error {
+	serviceMonitor := &monitor.ServiceMonitor{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceName,
+			Namespace: r.dpa.Namespace,
+		},
+	}
+	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, serviceMonitor, r.withDriftDetection(serviceMonitor, func() error {
+		serviceMonitor.Labels = common.AppendTTMapAsCopy(serviceMonitor.Labels, getDpaAppLabels(r.dpa))
+		// the metrics Services carry the DPA app labels, the service name tells them apart
+		serviceMonitor.Spec.Selector = metav1.LabelSelector{MatchLabels: getDpaAppLabels(r.dpa)}
+		serviceMonitor.Spec.Selector.MatchExpressions = nil
+		serviceMonitor.Spec.Endpoints = []monitor.Endpoint{
+			{
+				Port:     metricsServicePortName,
+				Path:     "/metrics",
+				Interval: r.dpa.Spec.Monitoring.ScrapeInterval,
+				RelabelConfigs: []*monitor.RelabelConfig{
+					{
+						SourceLabels: []string{"__meta_kubernetes_service_name"},
+						Regex:        serviceName,
+						Action:       "keep",
+					},
+				},
+			},
+		}
+		return controllerutil.SetControllerReference(r.dpa, serviceMonitor, r.Scheme)
+	}))
+	if err != nil {
+		return monitoringAPIError(err)
+	}
+	if op == controllerutil.OperationResultCreated || op == controllerutil.OperationResultUpdated {
+		r.EventRecorder.Event(serviceMonitor,
+			corev1.EventTypeNormal,
+			"ServiceMonitorReconciled",
+			fmt.Sprintf("performed %s on service monitor %s/%s", op, serviceMonitor.Namespace, serviceMonitor.Name),
+		)
+	}
+	return nil
+}
+
+func (r *DataProtectionApplicationReconciler) reconcileAlertsPrometheusRule() error {
+	rule := &monitor.PrometheusRule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      oadpAlertsRuleName,
+			Namespace: r.dpa.Namespace,
+		},
+	}
+	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, rule, r.withDriftDetection(rule, func() error {
+		rule.Labels = common.AppendTTMapAsCopy(rule.Labels, getDpaAppLabels(r.dpa))
+		rule.Spec.Groups = []monitor.RuleGroup{getAlertsRuleGroup(r.dpa.Namespace, r.dpa.Spec.Monitoring.Alerts)}
+		return controllerutil.SetControllerReference(r.dpa, rule, r.Scheme)
+	}))
+	if err != nil {
+		return monitoringAPIError(err)
+	}
+	if op == controllerutil.OperationResultCreated || op == controllerutil.OperationResultUpdated {
+		r.EventRecorder.Event(rule,
+			corev1.EventTypeNormal,
+			"PrometheusRuleReconciled",
+			fmt.Sprintf("performed %s on prometheus rule %s/%s", op, rule.Namespace, rule.Name),
+		)
+	}
+	return nil
+}
+
+// getAlertsRuleGroup returns the OADP alerts of the DPA namespace, with the thresholds of alerts or their defaults
+func getAlertsRuleGroup(namespace string, alerts *oadpv1alpha1.MonitoringAlerts) monitor.RuleGroup {
+	if alerts == nil {
+		alerts = &oadpv1alpha1.MonitoringAlerts{}
+	}
+	count := func(value *int32) int32 {
+		if value == nil {
+			return 1
+		}
+		return *value
+	}
+	duration := func(value *metav1.Duration, defaultValue time.Duration) string {
+		if value == nil {
+			return model.Duration(defaultValue).String()
+		}
+		return model.Duration(value.Duration).String()
+	}
+	failuresWindow := duration(alerts.FailuresWindow, time.Hour)
+	scheduleStaleAfter := time.Duration(25) * time.Hour
+	if alerts.ScheduleStaleAfter != nil {
+		scheduleStaleAfter = alerts.ScheduleStaleAfter.Duration
+	}
+	selector := fmt.Sprintf(`namespace=%q`, namespace)
+
+	return monitor.RuleGroup{
+		Name: "oadp.rules",
+		Rules: []monitor.Rule{
+			{
+				Alert:  "OADPBackupFailed",
+				Expr:   intstr.FromString(fmt.Sprintf(`sum by (schedule) (increase(velero_backup_failure_total{%s}[%s])) >= %d`, selector, failuresWindow, count(alerts.FailedBackups))),
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary":     "OADP backups failed",
+					"description": fmt.Sprintf("{{ $value }} backups of schedule {{ $labels.schedule }} failed in namespace %s in the last %s.", namespace, failuresWindow),
+				},
+			},
+			{
+				Alert:  "OADPBackupPartiallyFailed",
+				Expr:   intstr.FromString(fmt.Sprintf(`sum by (schedule) (increase(velero_backup_partial_failure_total{%s}[%s])) >= %d`, selector, failuresWindow, count(alerts.PartiallyFailedBackups))),
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary":     "OADP backups partially failed",
+					"description": fmt.Sprintf("{{ $value }} backups of schedule {{ $labels.schedule }} partially failed in namespace %s in the last %s.", namespace, failuresWindow),
+				},
+			},
+			{
+				Alert:  "OADPBackupStorageLocationUnavailable",
+				Expr:   intstr.FromString(fmt.Sprintf(`oadp_backup_storage_location_available{%s} == 0`, selector)),
+				For:    duration(alerts.BackupLocationUnavailableFor, 15*time.Minute),
+				Labels: map[string]string{"severity": "critical"},
+				Annotations: map[string]string{
+					"summary":     "OADP backup storage location unavailable",
+					"description": fmt.Sprintf("Backup storage location {{ $labels.backup_storage_location }} in namespace %s is unavailable, backups to it fail.", namespace),
+				},
+			},
+			{
+				Alert:  "OADPScheduleStale",
+				Expr:   intstr.FromString(fmt.Sprintf(`time() - velero_backup_last_successful_timestamp{%s,schedule!=""} > %d`, selector, int64(scheduleStaleAfter.Seconds()))),
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary":     "OADP schedule has no recent successful backup",
+					"description": fmt.Sprintf("Schedule {{ $labels.schedule }} in namespace %s has no successful backup in the last %s.", namespace, model.Duration(scheduleStaleAfter).String()),
+				},
+			},
+			{
+				Alert:  "OADPRepositoryMaintenanceFailed",
+				Expr:   intstr.FromString(fmt.Sprintf(`oadp_backup_repository_maintenance_failed{%s} == 1`, selector)),
+				For:    duration(alerts.RepositoryMaintenanceFailedFor, time.Hour),
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary":     "OADP backup repository maintenance failed",
+					"description": fmt.Sprintf("The last maintenance of backup repository {{ $labels.backup_repository }} in namespace %s failed.", namespace),
+				},
+			},
+		},
+	}
+}
+
+// updateBackupRepositoryMaintenanceMetrics exports the result of the last maintenance of the backup repositories
+func (r *DataProtectionApplicationReconciler) updateBackupRepositoryMaintenanceMetrics() error {
+	repositories := &velerov1.BackupRepositoryList{}
+	if err := r.List(r.Context, repositories, client.InNamespace(r.dpa.Namespace)); err != nil {
+		return err
+	}
+	for _, repository := range repositories.Items {
+		maintenances := repository.Status.RecentMaintenance
+		if len(maintenances) == 0 {
+			continue
+		}
+		failed := 0.0
+		if maintenances[len(maintenances)-1].Result == velerov1.BackupRepositoryMaintenanceFailed {
+			failed = 1
+		}
+		backupRepositoryMaintenanceFailed.WithLabelValues(repository.Name).Set(failed)
+	}
+	return nil
+}
+
+// deleteMonitoringObject deletes the monitoring object name of the DPA namespace if it is controlled by the DPA.
+// Monitoring objects whose API is not served by the cluster do not exist.
+func (r *DataProtectionApplicationReconciler) deleteMonitoringObject(obj client.Object, name string) error {
+	if err := r.Get(r.Context, client.ObjectKey{Namespace: r.dpa.Namespace, Name: name}, obj); err != nil {
+		if k8serror.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+	if !metav1.IsControlledBy(obj, r.dpa) {
+		return nil
+	}
+	if err := r.Delete(r.Context, obj); err != nil && !k8serror.IsNotFound(err) {
+		return err
+	}
+	r.EventRecorder.Event(r.dpa,
+		corev1.EventTypeNormal,
+		"MonitoringObjectDeleted",
+		fmt.Sprintf("deleted %T %s/%s", obj, r.dpa.Namespace, name),
+	)
+	return nil
+}
+
+// monitoringAPIError explains errors met when the Prometheus operator APIs are not served by the cluster
+func monitoringAPIError(err error) error {
+	if apimeta.IsNoMatchError(err) {
+		return fmt.Errorf("spec.monitoring is enabled but the Prometheus operator API %s is not available: %w", monitor.SchemeGroupVersion, err)
+	}
+	return err
+}
+
+// updateBackupStorageLocationMetric exports the availability of a backup storage location created by the DPA,
+// locations not validated yet are not exported
+func updateBackupStorageLocationMetric(bsl *velerov1.BackupStorageLocation) {
+	switch bsl.Status.Phase {
+	case velerov1.BackupStorageLocationPhaseAvailable:
+		backupStorageLocationAvailable.WithLabelValues(bsl.Name).Set(1)
+	case velerov1.BackupStorageLocationPhaseUnavailable:
+		backupStorageLocationAvailable.WithLabelValues(bsl.Name).Set(0)
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/google/go-cmp/cmp"
 	monitor "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -17,6 +19,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
@@ -172,3 +175,202 @@ func TestDPAReconciler_updateVeleroMetricsSVC(t *testing.T) {
 		})
 	}
 }
+
+func TestDPAReconciler_ReconcileMonitoring(t *testing.T) {
+	monitoredDpa := func(monitoring *oadpv1alpha1.Monitoring) *oadpv1alpha1.DataProtectionApplication {
+		dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero:    &oadpv1alpha1.VeleroConfig{},
+				NodeAgent: &oadpv1alpha1.NodeAgentConfig{NodeAgentCommonFields: oadpv1alpha1.NodeAgentCommonFields{Enable: ptr.To(true)}},
+			},
+			Monitoring: monitoring,
+		})
+		dpa.UID = "test-uid"
+		return dpa
+	}
+	ownedBy := func(dpa *oadpv1alpha1.DataProtectionApplication, obj client.Object) client.Object {
+		obj.SetNamespace(testNamespaceName)
+		obj.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(dpa, oadpv1alpha1.GroupVersion.WithKind("DataProtectionApplication"))})
+		return obj
+	}
+	tests := []struct {
+		name                string
+		dpa                 *oadpv1alpha1.DataProtectionApplication
+		objects             func(dpa *oadpv1alpha1.DataProtectionApplication) []client.Object
+		wantServiceMonitors []string
+		wantServices        []string
+		wantRule            bool
+		wantExprs           []string
+	}{
+		{
+			name: "monitoring disabled deletes owned monitoring objects",
+			dpa:  monitoredDpa(nil),
+			objects: func(dpa *oadpv1alpha1.DataProtectionApplication) []client.Object {
+				return []client.Object{
+					ownedBy(dpa, &monitor.ServiceMonitor{ObjectMeta: metav1.ObjectMeta{Name: veleroMetricsServiceName}}),
+					ownedBy(dpa, &monitor.ServiceMonitor{ObjectMeta: metav1.ObjectMeta{Name: nodeAgentMetricsServiceName}}),
+					ownedBy(dpa, &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: nodeAgentMetricsServiceName}}),
+					ownedBy(dpa, &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: veleroMetricsServiceName}}),
+					ownedBy(dpa, &monitor.PrometheusRule{ObjectMeta: metav1.ObjectMeta{Name: oadpAlertsRuleName}}),
+				}
+			},
+			wantServices: []string{veleroMetricsServiceName},
+		},
+		{
+			name: "monitoring disabled keeps monitoring objects not owned by the DPA",
+			dpa:  monitoredDpa(&oadpv1alpha1.Monitoring{Enable: false}),
+			objects: func(dpa *oadpv1alpha1.DataProtectionApplication) []client.Object {
+				return []client.Object{
+					&monitor.ServiceMonitor{ObjectMeta: metav1.ObjectMeta{Name: veleroMetricsServiceName, Namespace: testNamespaceName}},
+				}
+			},
+			wantServiceMonitors: []string{veleroMetricsServiceName},
+		},
+		{
+			name:                "monitoring enabled creates service monitors of enabled components and alerts",
+			dpa:                 monitoredDpa(&oadpv1alpha1.Monitoring{Enable: true, ScrapeInterval: "1m"}),
+			wantServiceMonitors: []string{nodeAgentMetricsServiceName, operatorMetricsServiceName, veleroMetricsServiceName},
+			wantServices:        []string{nodeAgentMetricsServiceName, operatorMetricsServiceName},
+			wantRule:            true,
+			wantExprs: []string{
+				`sum by (schedule) (increase(velero_backup_failure_total{namespace="test-ns"}[1h])) >= 1`,
+				`sum by (schedule) (increase(velero_backup_partial_failure_total{namespace="test-ns"}[1h])) >= 1`,
+				`oadp_backup_storage_location_available{namespace="test-ns"} == 0`,
+				`time() - velero_backup_last_successful_timestamp{namespace="test-ns",schedule!=""} > 90000`,
+				`oadp_backup_repository_maintenance_failed{namespace="test-ns"} == 1`,
+			},
+		},
+		{
+			name: "monitoring enabled with custom thresholds",
+			dpa: monitoredDpa(&oadpv1alpha1.Monitoring{Enable: true, Alerts: &oadpv1alpha1.MonitoringAlerts{
+				FailedBackups:      ptr.To(int32(3)),
+				FailuresWindow:     &metav1.Duration{Duration: 6 * time.Hour},
+				ScheduleStaleAfter: &metav1.Duration{Duration: 8 * time.Hour},
+			}}),
+			wantServiceMonitors: []string{nodeAgentMetricsServiceName, operatorMetricsServiceName, veleroMetricsServiceName},
+			wantServices:        []string{nodeAgentMetricsServiceName, operatorMetricsServiceName},
+			wantRule:            true,
+			wantExprs: []string{
+				`sum by (schedule) (increase(velero_backup_failure_total{namespace="test-ns"}[6h])) >= 3`,
+				`sum by (schedule) (increase(velero_backup_partial_failure_total{namespace="test-ns"}[6h])) >= 1`,
+				`oadp_backup_storage_location_available{namespace="test-ns"} == 0`,
+				`time() - velero_backup_last_successful_timestamp{namespace="test-ns",schedule!=""} > 28800`,
+				`oadp_backup_repository_maintenance_failed{namespace="test-ns"} == 1`,
+			},
+		},
+		{
+			name: "alerts disabled deletes the alerts rule",
+			dpa:  monitoredDpa(&oadpv1alpha1.Monitoring{Enable: true, Alerts: &oadpv1alpha1.MonitoringAlerts{Disable: true}}),
+			objects: func(dpa *oadpv1alpha1.DataProtectionApplication) []client.Object {
+				return []client.Object{ownedBy(dpa, &monitor.PrometheusRule{ObjectMeta: metav1.ObjectMeta{Name: oadpAlertsRuleName}})}
+			},
+			wantServiceMonitors: []string{nodeAgentMetricsServiceName, operatorMetricsServiceName, veleroMetricsServiceName},
+			wantServices:        []string{nodeAgentMetricsServiceName, operatorMetricsServiceName},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := []client.Object{tt.dpa}
+			if tt.objects != nil {
+				objects = append(objects, tt.objects(tt.dpa)...)
+			}
+			fakeClient, err := getFakeClientFromObjectsForMonitor(objects...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:        fakeClient,
+				Scheme:        fakeClient.Scheme(),
+				Log:           logr.Discard(),
+				Context:       newContextForTest(),
+				EventRecorder: record.NewFakeRecorder(20),
+				dpa:           tt.dpa,
+			}
+			if _, err := r.ReconcileMonitoring(r.Log); err != nil {
+				t.Fatalf("ReconcileMonitoring() error = %v", err)
+			}
+
+			serviceMonitors := &monitor.ServiceMonitorList{}
+			if err := fakeClient.List(r.Context, serviceMonitors, client.InNamespace(testNamespaceName)); err != nil {
+				t.Fatal(err)
+			}
+			gotServiceMonitors := []string{}
+			for _, serviceMonitor := range serviceMonitors.Items {
+				gotServiceMonitors = append(gotServiceMonitors, serviceMonitor.Name)
+				if tt.dpa.Spec.Monitoring != nil && tt.dpa.Spec.Monitoring.ScrapeInterval != "" &&
+					serviceMonitor.Spec.Endpoints[0].Interval != tt.dpa.Spec.Monitoring.ScrapeInterval {
+					t.Errorf("expected service monitor %s interval %s, got %s", serviceMonitor.Name, tt.dpa.Spec.Monitoring.ScrapeInterval, serviceMonitor.Spec.Endpoints[0].Interval)
+				}
+			}
+			if !reflect.DeepEqual(gotServiceMonitors, append([]string{}, tt.wantServiceMonitors...)) {
+				t.Errorf("expected service monitors %v, got %v", tt.wantServiceMonitors, gotServiceMonitors)
+			}
+
+			services := &corev1.ServiceList{}
+			if err := fakeClient.List(r.Context, services, client.InNamespace(testNamespaceName)); err != nil {
+				t.Fatal(err)
+			}
+			gotServices := []string{}
+			for _, service := range services.Items {
+				gotServices = append(gotServices, service.Name)
+			}
+			if !reflect.DeepEqual(gotServices, append([]string{}, tt.wantServices...)) {
+				t.Errorf("expected services %v, got %v", tt.wantServices, gotServices)
+			}
+
+			rule := &monitor.PrometheusRule{}
+			err = fakeClient.Get(r.Context, types.NamespacedName{Namespace: testNamespaceName, Name: oadpAlertsRuleName}, rule)
+			if (err == nil) != tt.wantRule {
+				t.Fatalf("expected alerts rule %v, got error %v", tt.wantRule, err)
+			}
+			if !tt.wantRule {
+				return
+			}
+			gotExprs := []string{}
+			for _, rule := range rule.Spec.Groups[0].Rules {
+				gotExprs = append(gotExprs, rule.Expr.String())
+			}
+			if !reflect.DeepEqual(gotExprs, tt.wantExprs) {
+				t.Errorf("expected alert expressions %v, got %v", tt.wantExprs, gotExprs)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_updateBackupRepositoryMaintenanceMetrics(t *testing.T) {
+	repository := func(name string, results ...velerov1.BackupRepositoryMaintenanceResult) *velerov1.BackupRepository {
+		repo := &velerov1.BackupRepository{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespaceName}}
+		for _, result := range results {
+			repo.Status.RecentMaintenance = append(repo.Status.RecentMaintenance, velerov1.BackupRepositoryMaintenanceStatus{Result: result})
+		}
+		return repo
+	}
+	dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{})
+	fakeClient, err := getFakeClientFromObjectsForMonitor(dpa,
+		repository("failed", velerov1.BackupRepositoryMaintenanceSucceeded, velerov1.BackupRepositoryMaintenanceFailed),
+		repository("recovered", velerov1.BackupRepositoryMaintenanceFailed, velerov1.BackupRepositoryMaintenanceSucceeded),
+		repository("never-maintained"),
+	)
+	if err != nil {
+		t.Errorf("error in creating fake client, likely programmer error")
+	}
+	r := &DataProtectionApplicationReconciler{
+		Client:  fakeClient,
+		Scheme:  fakeClient.Scheme(),
+		Log:     logr.Discard(),
+		Context: newContextForTest(),
+		dpa:     dpa,
+	}
+	backupRepositoryMaintenanceFailed.Reset()
+	if err := r.updateBackupRepositoryMaintenanceMetrics(); err != nil {
+		t.Fatalf("updateBackupRepositoryMaintenanceMetrics() error = %v", err)
+	}
+	if got := testutil.CollectAndCount(backupRepositoryMaintenanceFailed); got != 2 {
+		t.Errorf("expected 2 backup repository series, got %d", got)
+	}
+	for name, want := range map[string]float64{"failed": 1, "recovered": 0} {
+		if got := testutil.ToFloat64(backupRepositoryMaintenanceFailed.WithLabelValues(name)); got != want {
+			t.Errorf("expected backup repository %s maintenance failed %v, got %v", name, want, got)
+		}
+	}
+}
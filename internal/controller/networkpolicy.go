@@ -0,0 +1,487 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+const (
+	veleroNetworkPolicyName                = "openshift-adp-velero"
+	nodeAgentNetworkPolicyName             = "openshift-adp-node-agent"
+	dataMoverNetworkPolicyName             = "openshift-adp-data-mover"
+	repositoryMaintenanceNetworkPolicyName = "openshift-adp-repository-maintenance"
+	nonAdminNetworkPolicyName              = "openshift-adp-non-admin-controller"
+
+	defaultNetworkPolicyMonitoringNamespace = "openshift-user-workload-monitoring"
+	defaultNetworkPolicyDNSNamespace        = "openshift-dns"
+
+	// exposerPodGroupLabel labels the data mover pods created by Velero exposers
+	exposerPodGroupLabel = "velero.io/exposer-pod-group"
+	// repositoryNameLabel labels the repository maintenance job pods created by Velero
+	repositoryNameLabel = "velero.io/repo-name"
+
+	imageRegistryNamespace = "openshift-image-registry"
+	imageRegistryPort      = 5000
+
+	httpsPort = 443
+	httpPort  = 80
+)
+
+// dnsPorts are the ports of the cluster DNS service and of the OpenShift DNS pods
+var dnsPorts = []int32{53, 5353}
+
+// oadpNetworkPolicy is a NetworkPolicy of OADP pods
+type oadpNetworkPolicy struct {
+	name        string
+	podSelector metav1.LabelSelector
+	// metricsPort is the port allowed from the monitoring namespace, none if 0
+	metricsPort int
+	// storageEgress allows egress to the backup and snapshot location endpoints
+	storageEgress bool
+	// registryEgress allows egress to the OpenShift image registry, used by the OpenShift plugin
+	registryEgress bool
+	enabled        bool
+}
+
+func (r *DataProtectionApplicationReconciler) isNetworkPolicyEnabled() bool {
+	return r.dpa.Spec.NetworkPolicy != nil && r.dpa.Spec.NetworkPolicy.Enable
+}
+
+// getOADPNetworkPolicies returns the NetworkPolicies of the OADP pods
+func (r *DataProtectionApplicationReconciler) getOADPNetworkPolicies() ([]oadpNetworkPolicy, error) {
+	dpa := r.dpa
+	veleroMetricsPort, err := getVeleroMetricsPort(dpa)
+	if err != nil {
+		return nil, err
+	}
+	nodeAgentMetricsPort, err := getNodeAgentMetricsPort(dpa)
+	if err != nil {
+		return nil, err
+	}
+	nodeAgentEnabled := dpa.Spec.Configuration != nil && isNodeAgentEnabled(dpa)
+	openShiftPluginEnabled := dpa.Spec.Configuration != nil && dpa.Spec.Configuration.Velero != nil &&
+		slices.Contains(dpa.Spec.Configuration.Velero.DefaultPlugins, oadpv1alpha1.DefaultPluginOpenShift)
+	return []oadpNetworkPolicy{
+		{
+			name:           veleroNetworkPolicyName,
+			podSelector:    metav1.LabelSelector{MatchLabels: getDpaAppLabels(dpa)},
+			metricsPort:    veleroMetricsPort,
+			storageEgress:  true,
+			registryEgress: openShiftPluginEnabled,
+			enabled:        true,
+		},
+		{
+			name:          nodeAgentNetworkPolicyName,
+			podSelector:   metav1.LabelSelector{MatchLabels: nodeAgentMatchLabels},
+			metricsPort:   nodeAgentMetricsPort,
+			storageEgress: true,
+			enabled:       nodeAgentEnabled,
+		},
+		{
+			name: dataMoverNetworkPolicyName,
+			podSelector: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: exposerPodGroupLabel, Operator: metav1.LabelSelectorOpExists},
+			}},
+			storageEgress: true,
+			enabled:       nodeAgentEnabled,
+		},
+		{
+			name: repositoryMaintenanceNetworkPolicyName,
+			podSelector: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: repositoryNameLabel, Operator: metav1.LabelSelectorOpExists},
+			}},
+			storageEgress: true,
+			enabled:       true,
+		},
+		{
+			name:        nonAdminNetworkPolicyName,
+			podSelector: metav1.LabelSelector{MatchLabels: controlPlaneLabel},
+			metricsPort: nonAdminMetricsPort,
+			enabled:     r.checkNonAdminEnabled(),
+		},
+	}, nil
+}
+
+// ReconcileNetworkPolicies creates least-privilege NetworkPolicies for the OADP pods when spec.networkPolicy is
+// enabled, and deletes them otherwise. Egress is allowed to DNS, the API server and the endpoints of the backup and
+// snapshot locations of the namespace, ingress only to the metrics ports from the monitoring namespace.
+func (r *DataProtectionApplicationReconciler) ReconcileNetworkPolicies(log logr.Logger) (bool, error) {
+	policies, err := r.getOADPNetworkPolicies()
+	if err != nil {
+		return false, err
+	}
+	if !r.isNetworkPolicyEnabled() {
+		for _, policy := range policies {
+			if err := r.deleteNetworkPolicy(policy.name); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	apiServerEgress, err := r.getAPIServerEgressRule()
+	if err != nil {
+		return false, err
+	}
+	storageEgress, err := r.getStorageEgressRules()
+	if err != nil {
+		return false, err
+	}
+	for _, policy := range policies {
+		if !policy.enabled {
+			if err := r.deleteNetworkPolicy(policy.name); err != nil {
+				return false, err
+			}
+			continue
+		}
+		egress := []networkingv1.NetworkPolicyEgressRule{r.getDNSEgressRule(), apiServerEgress}
+		if policy.registryEgress {
+			egress = append(egress, networkingv1.NetworkPolicyEgressRule{
+				To:    []networkingv1.NetworkPolicyPeer{namespacePeer(imageRegistryNamespace)},
+				Ports: []networkingv1.NetworkPolicyPort{tcpPort(imageRegistryPort)},
+			})
+		}
+		if policy.storageEgress {
+			egress = append(egress, storageEgress...)
+		}
+		var ingress []networkingv1.NetworkPolicyIngressRule
+		if policy.metricsPort != 0 {
+			ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{
+				From:  []networkingv1.NetworkPolicyPeer{namespacePeer(r.getNetworkPolicyMonitoringNamespace())},
+				Ports: []networkingv1.NetworkPolicyPort{tcpPort(int32(policy.metricsPort))},
+			})
+		}
+		if err := r.reconcileNetworkPolicy(policy, ingress, egress); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (r *DataProtectionApplicationReconciler) reconcileNetworkPolicy(policy oadpNetworkPolicy, ingress []networkingv1.NetworkPolicyIngressRule, egress []networkingv1.NetworkPolicyEgressRule) error {
+	networkPolicy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      policy.name,
+			Namespace: r.dpa.Namespace,
+		},
+	}
+	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, networkPolicy, r.withDriftDetection(networkPolicy, func() error {
+		networkPolicy.Labels = common.AppendTTMapAsCopy(networkPolicy.Labels, getDpaAppLabels(r.dpa))
+		networkPolicy.Spec = networkingv1.NetworkPolicySpec{
+			PodSelector: policy.podSelector,
+			Ingress:     ingress,
+			Egress:      egress,
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		}
+		return controllerutil.SetControllerReference(r.dpa, networkPolicy, r.Scheme)
+	}))
+	if err != nil {
+		return err
+	}
+	if op == controllerutil.OperationResultCreated || op == controllerutil.OperationResultUpdated {
+		r.EventRecorder.Event(networkPolicy,
+			corev1.EventTypeNormal,
+			"NetworkPolicyReconciled",
+			fmt.Sprintf("performed %s on network policy %s/%s", op, networkPolicy.Namespace, networkPolicy.Name),
+		)
+	}
+	return nil
+}
+
+func (r *DataProtectionApplicationReconciler) deleteNetworkPolicy(name string) error {
+	networkPolicy := &networkingv1.NetworkPolicy{}
+	if err := r.Get(r.Context, client.ObjectKey{Namespace: r.dpa.Namespace, Name: name}, networkPolicy); err != nil {
+		if k8serror.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !metav1.IsControlledBy(networkPolicy, r.dpa) {
+		return nil
+	}
+	if err := r.Delete(r.Context, networkPolicy); err != nil && !k8serror.IsNotFound(err) {
+		return err
+	}
+	r.EventRecorder.Event(r.dpa,
+		corev1.EventTypeNormal,
+		"NetworkPolicyDeleted",
+		fmt.Sprintf("deleted network policy %s/%s", networkPolicy.Namespace, networkPolicy.Name),
+	)
+	return nil
+}
+
+func (r *DataProtectionApplicationReconciler) getNetworkPolicyMonitoringNamespace() string {
+	if r.dpa.Spec.NetworkPolicy.MonitoringNamespace != "" {
+		return r.dpa.Spec.NetworkPolicy.MonitoringNamespace
+	}
+	return defaultNetworkPolicyMonitoringNamespace
+}
+
+// getDNSEgressRule allows egress to the cluster DNS pods
+func (r *DataProtectionApplicationReconciler) getDNSEgressRule() networkingv1.NetworkPolicyEgressRule {
+	dnsNamespace := r.dpa.Spec.NetworkPolicy.DNSNamespace
+	if dnsNamespace == "" {
+		dnsNamespace = defaultNetworkPolicyDNSNamespace
+	}
+	rule := networkingv1.NetworkPolicyEgressRule{To: []networkingv1.NetworkPolicyPeer{namespacePeer(dnsNamespace)}}
+	for _, port := range dnsPorts {
+		rule.Ports = append(rule.Ports, tcpPort(port), networkingv1.NetworkPolicyPort{
+			Protocol: ptr.To(corev1.ProtocolUDP),
+			Port:     ptr.To(intstr.FromInt32(port)),
+		})
+	}
+	return rule
+}
+
+// getAPIServerEgressRule allows egress to the kubernetes Service and its endpoints, as network plugins evaluate
+// NetworkPolicies before or after the Service address translation
+func (r *DataProtectionApplicationReconciler) getAPIServerEgressRule() (networkingv1.NetworkPolicyEgressRule, error) {
+	key := types.NamespacedName{Namespace: metav1.NamespaceDefault, Name: "kubernetes"}
+	service := &corev1.Service{}
+	if err := r.ClusterWideClient.Get(r.Context, key, service); err != nil {
+		return networkingv1.NetworkPolicyEgressRule{}, fmt.Errorf("unable to get the API server Service for the NetworkPolicies: %w", err)
+	}
+	endpoints := &corev1.Endpoints{}
+	if err := r.ClusterWideClient.Get(r.Context, key, endpoints); err != nil {
+		return networkingv1.NetworkPolicyEgressRule{}, fmt.Errorf("unable to get the API server endpoints for the NetworkPolicies: %w", err)
+	}
+
+	ips := []string{}
+	ports := []int32{}
+	for _, ip := range service.Spec.ClusterIPs {
+		if net.ParseIP(ip) != nil {
+			ips = append(ips, ip)
+		}
+	}
+	for _, port := range service.Spec.Ports {
+		ports = append(ports, port.Port)
+	}
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			ips = append(ips, address.IP)
+		}
+		for _, port := range subset.Ports {
+			ports = append(ports, port.Port)
+		}
+	}
+	slices.Sort(ips)
+	slices.Sort(ports)
+
+	rule := networkingv1.NetworkPolicyEgressRule{}
+	for _, ip := range slices.Compact(ips) {
+		rule.To = append(rule.To, ipPeer(ip))
+	}
+	for _, port := range slices.Compact(ports) {
+		rule.Ports = append(rule.Ports, tcpPort(port))
+	}
+	return rule, nil
+}
+
+// getStorageEgressRules allows egress to the endpoints of the backup and snapshot locations of the namespace.
+// NetworkPolicies cannot select host names, so endpoints are allowed by port to any address unless they are
+// IP addresses. The endpoints of the cluster wide proxy are allowed as well.
+func (r *DataProtectionApplicationReconciler) getStorageEgressRules() ([]networkingv1.NetworkPolicyEgressRule, error) {
+	bsls := &velerov1.BackupStorageLocationList{}
+	if err := r.List(r.Context, bsls, client.InNamespace(r.dpa.Namespace)); err != nil {
+		return nil, err
+	}
+	vsls := &velerov1.VolumeSnapshotLocationList{}
+	if err := r.List(r.Context, vsls, client.InNamespace(r.dpa.Namespace)); err != nil {
+		return nil, err
+	}
+
+	anyAddressPorts := []int32{}
+	addressPorts := map[string][]int32{}
+	addEndpoint := func(ip string, port int32) {
+		if ip == "" {
+			anyAddressPorts = append(anyAddressPorts, port)
+		} else {
+			addressPorts[ip] = append(addressPorts[ip], port)
+		}
+	}
+	for _, bsl := range bsls.Items {
+		ip, port := getStorageEndpoint(bsl.Spec.Config[S3URL])
+		addEndpoint(ip, port)
+		targetPorts, err := r.getServiceTargetPorts(bsl.Spec.Config[S3URL], port)
+		if err != nil {
+			return nil, err
+		}
+		for _, targetPort := range targetPorts {
+			addEndpoint("", targetPort)
+		}
+	}
+	for range vsls.Items {
+		// snapshot APIs of the cloud providers
+		addEndpoint("", httpsPort)
+	}
+	// the pods are given the cluster wide proxy environment variables, so storage traffic may go through the proxy
+	clusterProxy, err := r.getClusterProxy()
+	if err != nil {
+		return nil, err
+	}
+	if clusterProxy != nil {
+		for _, proxyUrl := range []string{clusterProxy.Status.HTTPProxy, clusterProxy.Status.HTTPSProxy} {
+			if proxyUrl != "" {
+				addEndpoint(getStorageEndpoint(proxyUrl))
+			}
+		}
+	}
+
+	rules := []networkingv1.NetworkPolicyEgressRule{}
+	if len(anyAddressPorts) > 0 {
+		slices.Sort(anyAddressPorts)
+		rule := networkingv1.NetworkPolicyEgressRule{}
+		for _, port := range slices.Compact(anyAddressPorts) {
+			rule.Ports = append(rule.Ports, tcpPort(port))
+		}
+		rules = append(rules, rule)
+	}
+	ips := make([]string, 0, len(addressPorts))
+	for ip := range addressPorts {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	for _, ip := range ips {
+		ports := addressPorts[ip]
+		slices.Sort(ports)
+		rule := networkingv1.NetworkPolicyEgressRule{To: []networkingv1.NetworkPolicyPeer{ipPeer(ip)}}
+		for _, port := range slices.Compact(ports) {
+			rule.Ports = append(rule.Ports, tcpPort(port))
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Locations without s3Url use the HTTPS endpoints of their provider.
+// getServiceTargetPorts returns the target ports of the port of an in-cluster Service endpoint, as network
+// plugins evaluate NetworkPolicies before or after the Service address translation. s3Url host names other
+// than <service>.<namespace>.svc[.<cluster domain>] have no target ports.
+func (r *DataProtectionApplicationReconciler) getServiceTargetPorts(s3Url string, port int32) ([]int32, error) {
+	parsed, err := url.Parse(s3Url)
+	if s3Url == "" || err != nil {
+		return nil, nil
+	}
+	labels := strings.Split(parsed.Hostname(), ".")
+	if len(labels) < 3 || labels[2] != "svc" {
+		return nil, nil
+	}
+	key := types.NamespacedName{Namespace: labels[1], Name: labels[0]}
+	service := &corev1.Service{}
+	if err := r.ClusterWideClient.Get(r.Context, key, service); err != nil {
+		if k8serror.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get the storage Service %s for the NetworkPolicies: %w", key, err)
+	}
+
+	targetPorts := []int32{}
+	for _, servicePort := range service.Spec.Ports {
+		if servicePort.Port != port {
+			continue
+		}
+		if servicePort.TargetPort.Type == intstr.Int {
+			if servicePort.TargetPort.IntVal != 0 {
+				targetPorts = append(targetPorts, servicePort.TargetPort.IntVal)
+			}
+			continue
+		}
+		// named target ports are resolved by the endpoints of the Service
+		endpoints := &corev1.Endpoints{}
+		if err := r.ClusterWideClient.Get(r.Context, key, endpoints); err != nil {
+			if k8serror.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("unable to get the storage Service %s endpoints for the NetworkPolicies: %w", key, err)
+		}
+		for _, subset := range endpoints.Subsets {
+			for _, endpointPort := range subset.Ports {
+				if endpointPort.Name == servicePort.Name {
+					targetPorts = append(targetPorts, endpointPort.Port)
+				}
+			}
+		}
+	}
+	return targetPorts, nil
+}
+
+// getStorageEndpoint returns the IP address and port of an s3Url, the IP address is empty for host names.
+func getStorageEndpoint(s3Url string) (string, int32) {
+	parsed, err := url.Parse(s3Url)
+	if s3Url == "" || err != nil || parsed.Host == "" {
+		return "", httpsPort
+	}
+	port := int32(httpsPort)
+	if parsed.Scheme == "http" {
+		port = httpPort
+	}
+	if parsed.Port() != "" {
+		if parsedPort, err := strconv.ParseInt(parsed.Port(), 10, 32); err == nil {
+			port = int32(parsedPort)
+		}
+	}
+	if ip := net.ParseIP(parsed.Hostname()); ip != nil {
+		return ip.String(), port
+	}
+	return "", port
+}
+
+func namespacePeer(namespace string) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{corev1.LabelMetadataName: namespace}},
+	}
+}
+
+func ipPeer(ip string) networkingv1.NetworkPolicyPeer {
+	prefix := "/32"
+	if net.ParseIP(ip).To4() == nil {
+		prefix = "/128"
+	}
+	return networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: ip + prefix}}
+}
+
+func tcpPort(port int32) networkingv1.NetworkPolicyPort {
+	return networkingv1.NetworkPolicyPort{
+		Protocol: ptr.To(corev1.ProtocolTCP),
+		Port:     ptr.To(intstr.FromInt32(port)),
+	}
+}
+
+// networkPolicyBackupStorageLocationRequests maps a BackupStorageLocation to the DPAs of its namespace with
+// NetworkPolicies enabled, so egress follows the backup storage locations created by users
+func (r *DataProtectionApplicationReconciler) networkPolicyBackupStorageLocationRequests(ctx context.Context, object client.Object) []reconcile.Request {
+	dpaList := &oadpv1alpha1.DataProtectionApplicationList{}
+	if err := r.List(ctx, dpaList, client.InNamespace(object.GetNamespace())); err != nil {
+		r.Log.Error(err, "unable to list DataProtectionApplications for BackupStorageLocation", "backupStorageLocation", object.GetName())
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, dpa := range dpaList.Items {
+		if dpa.Spec.NetworkPolicy != nil && dpa.Spec.NetworkPolicy.Enable {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name}})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,281 @@
+package controller
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func TestDPAReconciler_ReconcileNetworkPolicies(t *testing.T) {
+	apiServer := []client.Object{
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "kubernetes", Namespace: metav1.NamespaceDefault},
+			Spec: corev1.ServiceSpec{
+				ClusterIPs: []string{"172.30.0.1"},
+				Ports:      []corev1.ServicePort{{Name: "https", Port: 443}},
+			},
+		},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "kubernetes", Namespace: metav1.NamespaceDefault},
+			Subsets: []corev1.EndpointSubset{{
+				Addresses: []corev1.EndpointAddress{{IP: "10.0.0.2"}, {IP: "10.0.0.1"}},
+				Ports:     []corev1.EndpointPort{{Name: "https", Port: 6443}},
+			}},
+		},
+	}
+	bsl := func(name, s3Url string) *velerov1.BackupStorageLocation {
+		return &velerov1.BackupStorageLocation{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespaceName},
+			Spec: velerov1.BackupStorageLocationSpec{
+				Provider: "aws",
+				Config:   map[string]string{S3URL: s3Url},
+			},
+		}
+	}
+	networkPolicyDpa := func(networkPolicy *oadpv1alpha1.NetworkPolicyConfig, nodeAgent bool) *oadpv1alpha1.DataProtectionApplication {
+		dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{
+					DefaultPlugins: []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginOpenShift, oadpv1alpha1.DefaultPluginAWS},
+				},
+				NodeAgent: &oadpv1alpha1.NodeAgentConfig{NodeAgentCommonFields: oadpv1alpha1.NodeAgentCommonFields{Enable: ptr.To(nodeAgent)}},
+			},
+			NetworkPolicy: networkPolicy,
+		})
+		dpa.UID = "test-uid"
+		return dpa
+	}
+	tests := []struct {
+		name        string
+		dpa         *oadpv1alpha1.DataProtectionApplication
+		objects     func(dpa *oadpv1alpha1.DataProtectionApplication) []client.Object
+		wantNames   []string
+		wantVelero  *networkingv1.NetworkPolicySpec
+		wantStorage []networkingv1.NetworkPolicyEgressRule
+	}{
+		{
+			name: "disabled deletes the network policies owned by the DPA",
+			dpa:  networkPolicyDpa(nil, true),
+			objects: func(dpa *oadpv1alpha1.DataProtectionApplication) []client.Object {
+				owned := &networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: veleroNetworkPolicyName, Namespace: testNamespaceName}}
+				owned.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(dpa, oadpv1alpha1.GroupVersion.WithKind("DataProtectionApplication"))})
+				return []client.Object{
+					owned,
+					&networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: nodeAgentNetworkPolicyName, Namespace: testNamespaceName}},
+				}
+			},
+			wantNames: []string{nodeAgentNetworkPolicyName},
+		},
+		{
+			name: "enabled without node agent",
+			dpa:  networkPolicyDpa(&oadpv1alpha1.NetworkPolicyConfig{Enable: true}, false),
+			objects: func(dpa *oadpv1alpha1.DataProtectionApplication) []client.Object {
+				return []client.Object{bsl("default", "")}
+			},
+			wantNames: []string{veleroNetworkPolicyName, repositoryMaintenanceNetworkPolicyName},
+			wantVelero: &networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: getDpaAppLabels(createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{}))},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{
+					From:  []networkingv1.NetworkPolicyPeer{namespacePeer(defaultNetworkPolicyMonitoringNamespace)},
+					Ports: []networkingv1.NetworkPolicyPort{tcpPort(8085)},
+				}},
+				Egress: []networkingv1.NetworkPolicyEgressRule{
+					{
+						To: []networkingv1.NetworkPolicyPeer{namespacePeer(defaultNetworkPolicyDNSNamespace)},
+						Ports: []networkingv1.NetworkPolicyPort{
+							tcpPort(53), {Protocol: ptr.To(corev1.ProtocolUDP), Port: tcpPort(53).Port},
+							tcpPort(5353), {Protocol: ptr.To(corev1.ProtocolUDP), Port: tcpPort(5353).Port},
+						},
+					},
+					{
+						To:    []networkingv1.NetworkPolicyPeer{ipPeer("10.0.0.1"), ipPeer("10.0.0.2"), ipPeer("172.30.0.1")},
+						Ports: []networkingv1.NetworkPolicyPort{tcpPort(443), tcpPort(6443)},
+					},
+					{
+						To:    []networkingv1.NetworkPolicyPeer{namespacePeer(imageRegistryNamespace)},
+						Ports: []networkingv1.NetworkPolicyPort{tcpPort(imageRegistryPort)},
+					},
+					{Ports: []networkingv1.NetworkPolicyPort{tcpPort(443)}},
+				},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			},
+		},
+		{
+			name: "enabled follows the backup storage locations endpoints",
+			dpa: networkPolicyDpa(&oadpv1alpha1.NetworkPolicyConfig{
+				Enable:              true,
+				MonitoringNamespace: "openshift-monitoring",
+			}, true),
+			objects: func(dpa *oadpv1alpha1.DataProtectionApplication) []client.Object {
+				return []client.Object{
+					bsl("minio", "http://minio.minio.svc:9000"),
+					bsl("ip", "https://192.168.1.10:8443"),
+					bsl("ip-other-port", "http://192.168.1.10"),
+					bsl("aws", ""),
+				}
+			},
+			wantNames: []string{
+				dataMoverNetworkPolicyName,
+				nodeAgentNetworkPolicyName,
+				veleroNetworkPolicyName,
+				repositoryMaintenanceNetworkPolicyName,
+			},
+			wantStorage: []networkingv1.NetworkPolicyEgressRule{
+				{Ports: []networkingv1.NetworkPolicyPort{tcpPort(443), tcpPort(9000)}},
+				{
+					To:    []networkingv1.NetworkPolicyPeer{ipPeer("192.168.1.10")},
+					Ports: []networkingv1.NetworkPolicyPort{tcpPort(80), tcpPort(8443)},
+				},
+			},
+		},
+		{
+			name: "enabled follows the cluster proxy and the target ports of in-cluster storage Services",
+			dpa: networkPolicyDpa(&oadpv1alpha1.NetworkPolicyConfig{
+				Enable:              true,
+				MonitoringNamespace: "openshift-monitoring",
+			}, true),
+			objects: func(dpa *oadpv1alpha1.DataProtectionApplication) []client.Object {
+				return []client.Object{
+					bsl("noobaa", "https://s3.openshift-storage.svc"),
+					bsl("noobaa-http", "http://s3.openshift-storage.svc.cluster.local"),
+					&corev1.Service{
+						ObjectMeta: metav1.ObjectMeta{Name: "s3", Namespace: "openshift-storage"},
+						Spec: corev1.ServiceSpec{
+							Ports: []corev1.ServicePort{
+								{Name: "s3", Port: 80, TargetPort: intstr.FromInt32(6001)},
+								{Name: "s3-https", Port: 443, TargetPort: intstr.FromString("s3-https")},
+							},
+						},
+					},
+					&corev1.Endpoints{
+						ObjectMeta: metav1.ObjectMeta{Name: "s3", Namespace: "openshift-storage"},
+						Subsets: []corev1.EndpointSubset{{
+							Addresses: []corev1.EndpointAddress{{IP: "10.128.0.20"}},
+							Ports:     []corev1.EndpointPort{{Name: "s3", Port: 6001}, {Name: "s3-https", Port: 6443}},
+						}},
+					},
+					&configv1.Proxy{
+						ObjectMeta: metav1.ObjectMeta{Name: Cluster},
+						Status: configv1.ProxyStatus{
+							HTTPProxy:  "http://proxy.example.com:3128",
+							HTTPSProxy: "http://10.0.0.50:8080",
+						},
+					},
+				}
+			},
+			wantNames: []string{
+				dataMoverNetworkPolicyName,
+				nodeAgentNetworkPolicyName,
+				veleroNetworkPolicyName,
+				repositoryMaintenanceNetworkPolicyName,
+			},
+			wantStorage: []networkingv1.NetworkPolicyEgressRule{
+				{Ports: []networkingv1.NetworkPolicyPort{tcpPort(80), tcpPort(443), tcpPort(3128), tcpPort(6001), tcpPort(6443)}},
+				{
+					To:    []networkingv1.NetworkPolicyPeer{ipPeer("10.0.0.50")},
+					Ports: []networkingv1.NetworkPolicyPort{tcpPort(8080)},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := append([]client.Object{tt.dpa}, apiServer...)
+			if tt.objects != nil {
+				objects = append(objects, tt.objects(tt.dpa)...)
+			}
+			fakeClient, err := getFakeClientFromObjects(objects...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:            fakeClient,
+				ClusterWideClient: fakeClient,
+				Scheme:            fakeClient.Scheme(),
+				Log:               logr.Discard(),
+				Context:           newContextForTest(),
+				EventRecorder:     record.NewFakeRecorder(10),
+				dpa:               tt.dpa,
+			}
+			if _, err := r.ReconcileNetworkPolicies(r.Log); err != nil {
+				t.Fatalf("ReconcileNetworkPolicies() error = %v", err)
+			}
+
+			policies := &networkingv1.NetworkPolicyList{}
+			if err := fakeClient.List(r.Context, policies, client.InNamespace(testNamespaceName)); err != nil {
+				t.Fatal(err)
+			}
+			gotNames := []string{}
+			for _, policy := range policies.Items {
+				gotNames = append(gotNames, policy.Name)
+			}
+			wantNames := append([]string{}, tt.wantNames...)
+			slices.Sort(gotNames)
+			slices.Sort(wantNames)
+			if !reflect.DeepEqual(gotNames, wantNames) {
+				t.Errorf("expected network policies %v, got %v", wantNames, gotNames)
+			}
+
+			if tt.wantVelero != nil {
+				velero := &networkingv1.NetworkPolicy{}
+				if err := fakeClient.Get(r.Context, types.NamespacedName{Namespace: testNamespaceName, Name: veleroNetworkPolicyName}, velero); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(velero.Spec, *tt.wantVelero) {
+					t.Errorf("expected velero network policy %#v, got %#v", *tt.wantVelero, velero.Spec)
+				}
+			}
+			if tt.wantStorage != nil {
+				nodeAgent := &networkingv1.NetworkPolicy{}
+				if err := fakeClient.Get(r.Context, types.NamespacedName{Namespace: testNamespaceName, Name: nodeAgentNetworkPolicyName}, nodeAgent); err != nil {
+					t.Fatal(err)
+				}
+				if got := nodeAgent.Spec.Egress[2:]; !reflect.DeepEqual(got, tt.wantStorage) {
+					t.Errorf("expected node agent storage egress %#v, got %#v", tt.wantStorage, got)
+				}
+				wantIngress := []networkingv1.NetworkPolicyPeer{namespacePeer(tt.dpa.Spec.NetworkPolicy.MonitoringNamespace)}
+				if !reflect.DeepEqual(nodeAgent.Spec.Ingress[0].From, wantIngress) {
+					t.Errorf("expected node agent ingress from %#v, got %#v", wantIngress, nodeAgent.Spec.Ingress[0].From)
+				}
+			}
+		})
+	}
+}
+
+func TestGetStorageEndpoint(t *testing.T) {
+	tests := []struct {
+		s3Url    string
+		wantIP   string
+		wantPort int32
+	}{
+		{s3Url: "", wantPort: 443},
+		{s3Url: "https://s3.us-east-1.amazonaws.com", wantPort: 443},
+		{s3Url: "http://minio.minio.svc:9000", wantPort: 9000},
+		{s3Url: "http://minio.minio.svc", wantPort: 80},
+		{s3Url: "https://10.0.0.5:8443", wantIP: "10.0.0.5", wantPort: 8443},
+		{s3Url: "https://[fd00::5]", wantIP: "fd00::5", wantPort: 443},
+		{s3Url: "not a url", wantPort: 443},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s3Url, func(t *testing.T) {
+			ip, port := getStorageEndpoint(tt.s3Url)
+			if ip != tt.wantIP || port != tt.wantPort {
+				t.Errorf("getStorageEndpoint() = %q, %d, want %q, %d", ip, port, tt.wantIP, tt.wantPort)
+			}
+		})
+	}
+}
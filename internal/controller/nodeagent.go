@@ -26,6 +26,7 @@ import (
 	"github.com/openshift/oadp-operator/pkg/common"
 	"github.com/openshift/oadp-operator/pkg/credentials"
 	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
+	veleroserver "github.com/openshift/oadp-operator/pkg/velero/server"
 )
 
 const (
@@ -106,6 +107,21 @@ func isNodeAgentEnabled(dpa *oadpv1alpha1.DataProtectionApplication) bool {
 	return false
 }
 
+// getNodeAgentConfigMapSettings returns the node-agent ConfigMap settings, including the
+// data path concurrency set in the node-agent server args
+func getNodeAgentConfigMapSettings(nodeAgent *oadpv1alpha1.NodeAgentConfig) oadpv1alpha1.NodeAgentConfigMapSettings {
+	settings := nodeAgent.NodeAgentConfigMapSettings
+	if nodeAgent.Args != nil && nodeAgent.Args.DataPathConcurrency != nil {
+		loadConcurrency := oadpv1alpha1.LoadConcurrency{}
+		if settings.LoadConcurrency != nil {
+			loadConcurrency = *settings.LoadConcurrency
+		}
+		loadConcurrency.GlobalConfig = *nodeAgent.Args.DataPathConcurrency
+		settings.LoadConcurrency = &loadConcurrency
+	}
+	return settings
+}
+
 // isNodeAgentCMRequired checks if at least one required field is present in NodeAgentConfigMapSettings or PodConfig.
 func isNodeAgentCMRequired(config oadpv1alpha1.NodeAgentConfigMapSettings) bool {
 	return config.LoadConcurrency != nil ||
@@ -123,7 +139,7 @@ func (r *DataProtectionApplicationReconciler) updateNodeAgentCM(cm *corev1.Confi
 	}
 
 	// Convert NodeAgentConfigMapSettings to a generic map
-	configNodeAgentJSON, err := json.Marshal(r.dpa.Spec.Configuration.NodeAgent.NodeAgentConfigMapSettings)
+	configNodeAgentJSON, err := json.Marshal(getNodeAgentConfigMapSettings(r.dpa.Spec.Configuration.NodeAgent))
 	if err != nil {
 		return fmt.Errorf("failed to serialize node agent config: %w", err)
 	}
@@ -156,7 +172,7 @@ func (r *DataProtectionApplicationReconciler) ReconcileNodeAgentConfigMap(log lo
 		},
 	}
 
-	if !isNodeAgentEnabled(dpa) || !isNodeAgentCMRequired(dpa.Spec.Configuration.NodeAgent.NodeAgentConfigMapSettings) {
+	if !isNodeAgentEnabled(dpa) || !isNodeAgentCMRequired(getNodeAgentConfigMapSettings(dpa.Spec.Configuration.NodeAgent)) {
 		err := r.Get(r.Context, cmName, &configMap)
 		if err != nil && !errors.IsNotFound(err) {
 			return false, err
@@ -175,9 +191,9 @@ func (r *DataProtectionApplicationReconciler) ReconcileNodeAgentConfigMap(log lo
 		return true, nil
 	}
 
-	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, &configMap, func() error {
+	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, &configMap, r.withDriftDetection(&configMap, func() error {
 		return r.updateNodeAgentCM(&configMap)
-	})
+	}))
 	if err != nil {
 		return false, fmt.Errorf("failed to create or patch config map: %w", err)
 	}
@@ -221,7 +237,7 @@ func (r *DataProtectionApplicationReconciler) ReconcileNodeAgentDaemonset(log lo
 		return true, nil
 	}
 
-	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, ds, func() error {
+	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, ds, r.withDriftDetection(ds, func() error {
 		// Deployment selector is immutable so we set this value only if
 		// a new object is going to be created
 		if ds.ObjectMeta.CreationTimestamp.IsZero() {
@@ -243,6 +259,11 @@ func (r *DataProtectionApplicationReconciler) ReconcileNodeAgentDaemonset(log lo
 			}
 		}
 
+		var currentTemplate *corev1.PodTemplateSpec
+		if !ds.ObjectMeta.CreationTimestamp.IsZero() {
+			currentTemplate = ds.Spec.Template.DeepCopy()
+		}
+
 		if _, err := r.buildNodeAgentDaemonset(ds); err != nil {
 			return err
 		}
@@ -250,16 +271,16 @@ func (r *DataProtectionApplicationReconciler) ReconcileNodeAgentDaemonset(log lo
 			return err
 		}
 		if dpa.Spec.Configuration.NodeAgent.NodeAgentConfigMapSettings.LoadAffinityConfig != nil {
-			veleroAffinityStruct := make([]*kube.LoadAffinity, len(dpa.Spec.Configuration.NodeAgent.NodeAgentConfigMapSettings.LoadAffinityConfig))
-
-			for i, aff := range dpa.Spec.Configuration.NodeAgent.NodeAgentConfigMapSettings.LoadAffinityConfig {
-				veleroAffinityStruct[i] = (*kube.LoadAffinity)(aff)
-			}
-			affinity := kube.ToSystemAffinity(veleroAffinityStruct)
-			ds.Spec.Template.Spec.Affinity = affinity
+			ds.Spec.Template.Spec.Affinity = getNodeAgentLoadAffinity(dpa.Spec.Configuration.NodeAgent.NodeAgentConfigMapSettings.LoadAffinityConfig, ds.Spec.Template.Spec.Affinity)
 		}
+		// data mover pods inherit the NodeAgent env and volumes
+		if err := r.appendClusterProxyAndTrustedCA(&ds.Spec.Template); err != nil {
+			return err
+		}
+		r.pinImages(&ds.Spec.Template)
+		r.deferRollout(common.NodeAgent, currentTemplate, &ds.Spec.Template)
 		return nil
-	})
+	}))
 
 	if err != nil {
 		if errors.IsInvalid(err) {
@@ -346,10 +367,10 @@ func (r *DataProtectionApplicationReconciler) buildNodeAgentDaemonset(ds *appsv1
 	ds.Spec = installDs.Spec
 	ds.Name = dsName
 
-	return r.customizeNodeAgentDaemonset(ds)
+	return r.customizeNodeAgentDaemonset(ds, configMapName)
 }
 
-func (r *DataProtectionApplicationReconciler) customizeNodeAgentDaemonset(ds *appsv1.DaemonSet) (*appsv1.DaemonSet, error) {
+func (r *DataProtectionApplicationReconciler) customizeNodeAgentDaemonset(ds *appsv1.DaemonSet, configMapName string) (*appsv1.DaemonSet, error) {
 	dpa := r.dpa
 
 	// customize specs
@@ -435,6 +456,17 @@ func (r *DataProtectionApplicationReconciler) customizeNodeAgentDaemonset(ds *ap
 		return nil, fmt.Errorf("error checking platform type: %s", err)
 	}
 
+	podsHostPath := getFsPvHostPath(platformType)
+	pluginsHostPath := getPluginsHostPath(platformType)
+	if serverArgs := dpa.Spec.Configuration.NodeAgent.Args; serverArgs != nil {
+		if serverArgs.HostPodsPath != "" {
+			podsHostPath = serverArgs.HostPodsPath
+		}
+		if serverArgs.HostPluginsPath != "" {
+			pluginsHostPath = serverArgs.HostPluginsPath
+		}
+	}
+
 	// Remove HostPods and HostPlugins volumes if not running in privileged mode.
 	// Note: This code may be removed in the future once the following upstream issue is resolved:
 	// https://github.com/vmware-tanzu/velero/issues/8185
@@ -444,9 +476,9 @@ func (r *DataProtectionApplicationReconciler) customizeNodeAgentDaemonset(ds *ap
 			if vol.HostPath != nil {
 				switch vol.Name {
 				case HostPods:
-					vol.HostPath.Path = getFsPvHostPath(platformType)
+					vol.HostPath.Path = podsHostPath
 				case HostPlugins:
-					vol.HostPath.Path = getPluginsHostPath(platformType)
+					vol.HostPath.Path = pluginsHostPath
 				}
 			}
 			// privileged mode: append host-path and plugins host-path volumes
@@ -473,6 +505,7 @@ func (r *DataProtectionApplicationReconciler) customizeNodeAgentDaemonset(ds *ap
 				return nil, fmt.Errorf("NodeAgent daemonset template custom label: %s", err)
 			}
 		}
+		applyPodConfig(&ds.Spec.Template, dpa.Spec.Configuration.NodeAgent.PodConfig)
 	}
 
 	// fetch nodeAgent container in order to customize it
@@ -492,7 +525,7 @@ func (r *DataProtectionApplicationReconciler) customizeNodeAgentDaemonset(ds *ap
 				// update nodeAgent plugins volume mount host path only if privileged
 				for v, volumeMount := range nodeAgentContainer.VolumeMounts {
 					if volumeMount.Name == HostPlugins {
-						nodeAgentContainer.VolumeMounts[v].MountPath = getPluginsHostPath(platformType)
+						nodeAgentContainer.VolumeMounts[v].MountPath = pluginsHostPath
 					}
 				}
 			} else {
@@ -509,6 +542,7 @@ func (r *DataProtectionApplicationReconciler) customizeNodeAgentDaemonset(ds *ap
 					Drop: []corev1.Capability{"ALL"},
 				}
 			}
+			applyContainerPodConfig(nodeAgentContainer, dpa.Spec.Configuration.NodeAgent.PodConfig)
 
 			nodeAgentContainer.VolumeMounts = append(nodeAgentContainer.VolumeMounts,
 				// append certs volume mount
@@ -575,16 +609,36 @@ func (r *DataProtectionApplicationReconciler) customizeNodeAgentDaemonset(ds *ap
 			nodeAgentContainer.ImagePullPolicy = imagePullPolicy
 			setContainerDefaults(nodeAgentContainer)
 
-			// append data mover prepare timeout and resource timeout to nodeAgent container args
-			if dpa.Spec.Configuration.NodeAgent.DataMoverPrepareTimeout != nil {
-				nodeAgentContainer.Args = append(nodeAgentContainer.Args, fmt.Sprintf("--data-mover-prepare-timeout=%s", dpa.Spec.Configuration.NodeAgent.DataMoverPrepareTimeout.Duration))
-			}
-			if dpa.Spec.Configuration.NodeAgent.ResourceTimeout != nil {
-				nodeAgentContainer.Args = append(nodeAgentContainer.Args, fmt.Sprintf("--resource-timeout=%s", dpa.Spec.Configuration.NodeAgent.ResourceTimeout.Duration))
-			}
+			if serverArgs := dpa.Spec.Configuration.NodeAgent.Args; serverArgs != nil {
+				// recreate node-agent server args from scratch
+				nodeAgentContainer.Args, err = veleroserver.GetNodeAgentArgs(dpa, configMapName)
+				if err != nil {
+					return nil, err
+				}
+				// if metrics address is set, change metrics port
+				if serverArgs.MetricsAddress != "" {
+					metricsPort, err := veleroserver.GetMetricsPort(serverArgs.MetricsAddress)
+					if err != nil {
+						return nil, err
+					}
+					for i := range nodeAgentContainer.Ports {
+						if nodeAgentContainer.Ports[i].Name == "metrics" {
+							nodeAgentContainer.Ports[i].ContainerPort = int32(metricsPort)
+						}
+					}
+				}
+			} else {
+				// append data mover prepare timeout and resource timeout to nodeAgent container args
+				if dpa.Spec.Configuration.NodeAgent.DataMoverPrepareTimeout != nil {
+					nodeAgentContainer.Args = append(nodeAgentContainer.Args, fmt.Sprintf("--data-mover-prepare-timeout=%s", dpa.Spec.Configuration.NodeAgent.DataMoverPrepareTimeout.Duration))
+				}
+				if dpa.Spec.Configuration.NodeAgent.ResourceTimeout != nil {
+					nodeAgentContainer.Args = append(nodeAgentContainer.Args, fmt.Sprintf("--resource-timeout=%s", dpa.Spec.Configuration.NodeAgent.ResourceTimeout.Duration))
+				}
 
-			if dpa.Spec.LogFormat != "" {
-				nodeAgentContainer.Args = append(nodeAgentContainer.Args, fmt.Sprintf("--log-format=%s", dpa.Spec.LogFormat))
+				if dpa.Spec.LogFormat != "" {
+					nodeAgentContainer.Args = append(nodeAgentContainer.Args, fmt.Sprintf("--log-format=%s", dpa.Spec.LogFormat))
+				}
 			}
 
 			// Apply unsupported server args from the specified ConfigMap.
@@ -592,11 +646,9 @@ func (r *DataProtectionApplicationReconciler) customizeNodeAgentDaemonset(ds *ap
 			// If the ConfigMap exists and is not empty, its key-value pairs will be used as the new CLI arguments.
 			if configMapName, ok := dpa.Annotations[common.UnsupportedNodeAgentServerArgsAnnotation]; ok {
 				if configMapName != "" {
-					unsupportedServerArgsCM := corev1.ConfigMap{}
-					if err := r.Get(r.Context, types.NamespacedName{Namespace: dpa.Namespace, Name: configMapName}, &unsupportedServerArgsCM); err != nil {
+					if err := r.applyUnsupportedServerArgs(nodeAgentContainer, configMapName, common.NodeAgent); err != nil {
 						return nil, err
 					}
-					common.ApplyUnsupportedServerArgsOverride(nodeAgentContainer, unsupportedServerArgsCM, common.NodeAgent)
 				}
 			}
 
@@ -616,6 +668,9 @@ func (r *DataProtectionApplicationReconciler) customizeNodeAgentDaemonset(ds *ap
 	}
 
 	credentials.AppendCloudProviderVolumes(dpa, ds, providerNeedsDefaultCreds)
+	if err := r.useDefaultBackupLocationCredentials(ds.Spec.Template.Spec.Volumes); err != nil {
+		return nil, err
+	}
 
 	setPodTemplateSpecDefaults(&ds.Spec.Template)
 	if ds.Spec.UpdateStrategy.Type == appsv1.RollingUpdateDaemonSetStrategyType {
@@ -629,6 +684,9 @@ func (r *DataProtectionApplicationReconciler) customizeNodeAgentDaemonset(ds *ap
 				IntVal: 0,
 			},
 		}
+		if dpa.Spec.Availability != nil && dpa.Spec.Availability.NodeAgentMaxUnavailable != nil {
+			ds.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable = ptr.To(*dpa.Spec.Availability.NodeAgentMaxUnavailable)
+		}
 	}
 	if ds.Spec.RevisionHistoryLimit == nil {
 		ds.Spec.RevisionHistoryLimit = ptr.To(int32(10))
@@ -675,3 +733,20 @@ func (r *DataProtectionApplicationReconciler) getPlatformType() (string, error)
 	}
 	return "", nil
 }
+
+// getNodeAgentLoadAffinity returns the NodeAgent affinity of the LoadAffinityConfig, keeping the pod anti-affinity
+// from PodConfig even when the LoadAffinityConfig has no node selector
+func getNodeAgentLoadAffinity(loadAffinityConfig []*oadpv1alpha1.LoadAffinity, podConfigAffinity *corev1.Affinity) *corev1.Affinity {
+	veleroAffinityStruct := make([]*kube.LoadAffinity, len(loadAffinityConfig))
+	for i, aff := range loadAffinityConfig {
+		veleroAffinityStruct[i] = (*kube.LoadAffinity)(aff)
+	}
+	affinity := kube.ToSystemAffinity(veleroAffinityStruct)
+	if podConfigAffinity != nil && podConfigAffinity.PodAntiAffinity != nil {
+		if affinity == nil {
+			affinity = &corev1.Affinity{}
+		}
+		affinity.PodAntiAffinity = podConfigAffinity.PodAntiAffinity
+	}
+	return affinity
+}
@@ -255,9 +255,22 @@ type TestBuiltNodeAgentDaemonSetOptions struct {
 	toleration              []corev1.Toleration
 	nodeSelector            map[string]string
 	disableFsBackup         *bool
+	priorityClass           string
+	podAntiAffinity         *corev1.PodAntiAffinity
+	containerSecurity       *corev1.SecurityContext
+	maxUnavailable          *intstr.IntOrString
+	metricsPort             *int32
+	podsHostPath            string
+	pluginsHostPath         string
 }
 
 func createTestBuiltNodeAgentDaemonSet(options TestBuiltNodeAgentDaemonSetOptions) *appsv1.DaemonSet {
+	if len(options.podsHostPath) == 0 {
+		options.podsHostPath = GenericPVHostPath
+	}
+	if len(options.pluginsHostPath) == 0 {
+		options.pluginsHostPath = GenericPluginsHostPath
+	}
 
 	containerVolumeMounts := []corev1.VolumeMount{}
 	podVolumes := []corev1.Volume{}
@@ -268,7 +281,7 @@ func createTestBuiltNodeAgentDaemonSet(options TestBuiltNodeAgentDaemonSetOption
 			Name: HostPods,
 			VolumeSource: corev1.VolumeSource{
 				HostPath: &corev1.HostPathVolumeSource{
-					Path: "/var/lib/kubelet/pods",
+					Path: options.podsHostPath,
 					Type: ptr.To(corev1.HostPathUnset),
 				},
 			},
@@ -276,7 +289,7 @@ func createTestBuiltNodeAgentDaemonSet(options TestBuiltNodeAgentDaemonSetOption
 			Name: HostPlugins,
 			VolumeSource: corev1.VolumeSource{
 				HostPath: &corev1.HostPathVolumeSource{
-					Path: "/var/lib/kubelet/plugins",
+					Path: options.pluginsHostPath,
 					Type: ptr.To(corev1.HostPathUnset),
 				},
 			},
@@ -290,7 +303,7 @@ func createTestBuiltNodeAgentDaemonSet(options TestBuiltNodeAgentDaemonSetOption
 			},
 			corev1.VolumeMount{
 				Name:             HostPlugins,
-				MountPath:        "/var/lib/kubelet/plugins",
+				MountPath:        options.pluginsHostPath,
 				MountPropagation: &mountPropagationToHostContainer,
 			},
 		)
@@ -555,6 +568,25 @@ func createTestBuiltNodeAgentDaemonSet(options TestBuiltNodeAgentDaemonSetOption
 		testBuiltNodeAgentDaemonSet.Spec.Template.Spec.DNSConfig = options.dnsConfig
 	}
 
+	if options.maxUnavailable != nil {
+		testBuiltNodeAgentDaemonSet.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable = options.maxUnavailable
+	}
+
+	if len(options.priorityClass) > 0 {
+		testBuiltNodeAgentDaemonSet.Spec.Template.Spec.PriorityClassName = options.priorityClass
+	}
+
+	if options.podAntiAffinity != nil {
+		if testBuiltNodeAgentDaemonSet.Spec.Template.Spec.Affinity == nil {
+			testBuiltNodeAgentDaemonSet.Spec.Template.Spec.Affinity = &corev1.Affinity{}
+		}
+		testBuiltNodeAgentDaemonSet.Spec.Template.Spec.Affinity.PodAntiAffinity = options.podAntiAffinity
+	}
+
+	if options.containerSecurity != nil {
+		testBuiltNodeAgentDaemonSet.Spec.Template.Spec.Containers[0].SecurityContext = options.containerSecurity
+	}
+
 	if options.dataMoverPrepareTimeout != nil {
 		testBuiltNodeAgentDaemonSet.Spec.Template.Spec.Containers[0].Args = append(testBuiltNodeAgentDaemonSet.Spec.Template.Spec.Containers[0].Args, fmt.Sprintf("--data-mover-prepare-timeout=%s", *options.dataMoverPrepareTimeout))
 	}
@@ -567,6 +599,10 @@ func createTestBuiltNodeAgentDaemonSet(options TestBuiltNodeAgentDaemonSetOption
 		testBuiltNodeAgentDaemonSet.Spec.Template.Spec.Containers[0].Args = append(testBuiltNodeAgentDaemonSet.Spec.Template.Spec.Containers[0].Args, fmt.Sprintf("--log-format=%s", *options.logFormat))
 	}
 
+	if options.metricsPort != nil {
+		testBuiltNodeAgentDaemonSet.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort = *options.metricsPort
+	}
+
 	return testBuiltNodeAgentDaemonSet
 }
 
@@ -604,6 +640,65 @@ func TestDPAReconciler_buildNodeAgentDaemonset(t *testing.T) {
 			nodeAgentDaemonSet:     testNodeAgentDaemonSet.DeepCopy(),
 			wantNodeAgentDaemonSet: createTestBuiltNodeAgentDaemonSet(TestBuiltNodeAgentDaemonSetOptions{}),
 		},
+		{
+			name: "valid DPA CR with availability nodeAgentMaxUnavailable, NodeAgent DaemonSet is built with rolling update maxUnavailable",
+			dpa: createTestDpaWith(
+				nil,
+				oadpv1alpha1.DataProtectionApplicationSpec{
+					Configuration: &oadpv1alpha1.ApplicationConfig{
+						Velero: &oadpv1alpha1.VeleroConfig{},
+						NodeAgent: &oadpv1alpha1.NodeAgentConfig{
+							NodeAgentCommonFields: oadpv1alpha1.NodeAgentCommonFields{},
+							UploaderType:          "kopia",
+						},
+					},
+					Availability: &oadpv1alpha1.Availability{
+						NodeAgentMaxUnavailable: ptr.To(intstr.FromString("10%")),
+					},
+				},
+			),
+			clientObjects:      []client.Object{testGenericInfrastructure},
+			nodeAgentDaemonSet: testNodeAgentDaemonSet.DeepCopy(),
+			wantNodeAgentDaemonSet: createTestBuiltNodeAgentDaemonSet(TestBuiltNodeAgentDaemonSetOptions{
+				maxUnavailable: ptr.To(intstr.FromString("10%")),
+			}),
+		},
+		{
+			name: "valid DPA CR with PodConfig scheduling and security options, NodeAgent DaemonSet is built with them",
+			dpa: createTestDpaWith(
+				nil,
+				oadpv1alpha1.DataProtectionApplicationSpec{
+					Configuration: &oadpv1alpha1.ApplicationConfig{
+						Velero: &oadpv1alpha1.VeleroConfig{},
+						NodeAgent: &oadpv1alpha1.NodeAgentConfig{
+							NodeAgentCommonFields: oadpv1alpha1.NodeAgentCommonFields{
+								PodConfig: &oadpv1alpha1.PodConfig{
+									PriorityClassName: "system-node-critical",
+									PodAntiAffinity: &corev1.PodAntiAffinity{
+										PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+											{Weight: 1, PodAffinityTerm: corev1.PodAffinityTerm{TopologyKey: "kubernetes.io/hostname"}},
+										},
+									},
+									ContainerSecurityContext: &corev1.SecurityContext{Privileged: ptr.To(true)},
+								},
+							},
+							UploaderType: "kopia",
+						},
+					},
+				},
+			),
+			clientObjects:      []client.Object{testGenericInfrastructure},
+			nodeAgentDaemonSet: testNodeAgentDaemonSet.DeepCopy(),
+			wantNodeAgentDaemonSet: createTestBuiltNodeAgentDaemonSet(TestBuiltNodeAgentDaemonSetOptions{
+				priorityClass: "system-node-critical",
+				podAntiAffinity: &corev1.PodAntiAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+						{Weight: 1, PodAffinityTerm: corev1.PodAffinityTerm{TopologyKey: "kubernetes.io/hostname"}},
+					},
+				},
+				containerSecurity: &corev1.SecurityContext{Privileged: ptr.To(true)},
+			}),
+		},
 		{
 			name: "valid DPA CR with PodConfig Env, NodeAgent DaemonSet is built with Container Env",
 			dpa: createTestDpaWith(
@@ -801,6 +896,63 @@ func TestDPAReconciler_buildNodeAgentDaemonset(t *testing.T) {
 				resourceTimeout:         ptr.To("10m0s"),
 			}),
 		},
+		{
+			name: "valid DPA CR with NodeAgent Server Args, NodeAgent DaemonSet is built with NodeAgent Server Args",
+			dpa: createTestDpaWith(
+				nil,
+				oadpv1alpha1.DataProtectionApplicationSpec{
+					LogFormat: "json",
+					Configuration: &oadpv1alpha1.ApplicationConfig{
+						Velero: &oadpv1alpha1.VeleroConfig{},
+						NodeAgent: &oadpv1alpha1.NodeAgentConfig{
+							ResourceTimeout: &metav1.Duration{Duration: 10 * time.Minute},
+							UploaderType:    "kopia",
+							Args: &oadpv1alpha1.NodeAgentServerArgs{
+								LogLevel:                "debug",
+								MetricsAddress:          ":9085",
+								DataMoverPrepareTimeout: ptr.To(time.Hour),
+								HostPodsPath:            "/var/data/kubelet/pods",
+								HostPluginsPath:         "/var/data/kubelet/plugins",
+							},
+						},
+					},
+				},
+			),
+			clientObjects:      []client.Object{testGenericInfrastructure},
+			nodeAgentDaemonSet: testNodeAgentDaemonSet.DeepCopy(),
+			wantNodeAgentDaemonSet: createTestBuiltNodeAgentDaemonSet(TestBuiltNodeAgentDaemonSetOptions{
+				args: []string{
+					"--log-level=debug",
+					"--log-format=json",
+					"--metrics-address=:9085",
+					"--data-mover-prepare-timeout=1h0m0s",
+					"--resource-timeout=10m0s",
+				},
+				metricsPort:     ptr.To(int32(9085)),
+				podsHostPath:    "/var/data/kubelet/pods",
+				pluginsHostPath: "/var/data/kubelet/plugins",
+			}),
+		},
+		{
+			name: "valid DPA CR with invalid NodeAgent Server Args metrics address, error is returned",
+			dpa: createTestDpaWith(
+				nil,
+				oadpv1alpha1.DataProtectionApplicationSpec{
+					Configuration: &oadpv1alpha1.ApplicationConfig{
+						Velero: &oadpv1alpha1.VeleroConfig{},
+						NodeAgent: &oadpv1alpha1.NodeAgentConfig{
+							UploaderType: "kopia",
+							Args: &oadpv1alpha1.NodeAgentServerArgs{
+								MetricsAddress: "9085",
+							},
+						},
+					},
+				},
+			),
+			clientObjects:      []client.Object{testGenericInfrastructure},
+			nodeAgentDaemonSet: testNodeAgentDaemonSet.DeepCopy(),
+			errorMessage:       "invalid metrics address \"9085\": address 9085: missing port in address",
+		},
 		{
 			name: "valid DPA CR with Unsupported NodeAgent Server Args, NodeAgent DaemonSet is built with Unsupported NodeAgent Server Args",
 			dpa: createTestDpaWith(
@@ -1768,6 +1920,59 @@ func TestDPAReconciler_updateNodeAgentCM(t *testing.T) {
 				}`,
 			}),
 		},
+		{
+			name: "Given DPA CR instance with NodeAgent Server Args data path concurrency, appropriate NodeAgent config cm is created with LoadConcurrency",
+			nodeAgentConfigMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      common.NodeAgentConfigMapPrefix + testCmName,
+					Namespace: testCmNs,
+				},
+			},
+			dpa: &oadpv1alpha1.DataProtectionApplication{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testCmName,
+					Namespace: testCmNs,
+				},
+				Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+					Configuration: &oadpv1alpha1.ApplicationConfig{
+						Velero: &oadpv1alpha1.VeleroConfig{},
+						NodeAgent: &oadpv1alpha1.NodeAgentConfig{
+							NodeAgentConfigMapSettings: oadpv1alpha1.NodeAgentConfigMapSettings{
+								LoadConcurrency: &oadpv1alpha1.LoadConcurrency{
+									PerNodeConfig: []oadpv1alpha1.RuledConfigs{
+										{
+											NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"label.io/size": "large"}},
+											Number:       4,
+										},
+									},
+								},
+							},
+							Args: &oadpv1alpha1.NodeAgentServerArgs{
+								DataPathConcurrency: ptr.To(2),
+							},
+						},
+					},
+				},
+			},
+			wantErr: false,
+			wantNodeAgentConfigMap: createTestBuiltNodeAgentCM(map[string]string{
+				"node-agent-config": `{
+					"loadConcurrency": {
+						"globalConfig": 2,
+						"perNodeConfig": [
+							{
+								"nodeSelector": {
+									"matchLabels": {
+										"label.io/size": "large"
+									}
+								},
+								"number": 4
+							}
+						]
+					}
+				}`,
+			}),
+		},
 	}
 
 	for _, tt := range tests {
@@ -1978,6 +2183,62 @@ func Test_getPluginsHostPath(t *testing.T) {
 	}
 }
 
+func Test_getNodeAgentLoadAffinity(t *testing.T) {
+	podAntiAffinity := &corev1.PodAntiAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+			{Weight: 1, PodAffinityTerm: corev1.PodAffinityTerm{TopologyKey: "kubernetes.io/hostname"}},
+		},
+	}
+	nodeAffinity := &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+				MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "node-role.kubernetes.io/worker", Values: []string{""}, Operator: corev1.NodeSelectorOpIn},
+				},
+			}},
+		},
+	}
+	workers := []*oadpv1alpha1.LoadAffinity{
+		{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"node-role.kubernetes.io/worker": ""}}},
+	}
+	tests := []struct {
+		name               string
+		loadAffinityConfig []*oadpv1alpha1.LoadAffinity
+		podConfigAffinity  *corev1.Affinity
+		want               *corev1.Affinity
+	}{
+		{
+			name:               "node selector without PodConfig",
+			loadAffinityConfig: workers,
+			want:               &corev1.Affinity{NodeAffinity: nodeAffinity},
+		},
+		{
+			name:               "node selector keeps the PodConfig pod anti-affinity",
+			loadAffinityConfig: workers,
+			podConfigAffinity:  &corev1.Affinity{PodAntiAffinity: podAntiAffinity},
+			want:               &corev1.Affinity{NodeAffinity: nodeAffinity, PodAntiAffinity: podAntiAffinity},
+		},
+		{
+			name:               "no node selector keeps the PodConfig pod anti-affinity",
+			loadAffinityConfig: []*oadpv1alpha1.LoadAffinity{},
+			podConfigAffinity:  &corev1.Affinity{PodAntiAffinity: podAntiAffinity},
+			want:               &corev1.Affinity{PodAntiAffinity: podAntiAffinity},
+		},
+		{
+			name:               "no node selector without PodConfig",
+			loadAffinityConfig: []*oadpv1alpha1.LoadAffinity{},
+			want:               nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getNodeAgentLoadAffinity(tt.loadAffinityConfig, tt.podConfigAffinity); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getNodeAgentLoadAffinity() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestDPAReconciler_buildNodeAgentDaemonsetWithAzureWorkloadIdentity(t *testing.T) {
 	tests := []struct {
 		name               string
@@ -99,10 +99,19 @@ func (r *DataProtectionApplicationReconciler) ReconcileNonAdminController(log lo
 		r.Client,
 		nonAdminDeployment,
 		func() error {
+			var currentTemplate *corev1.PodTemplateSpec
+			if !nonAdminDeployment.CreationTimestamp.IsZero() {
+				currentTemplate = nonAdminDeployment.Spec.Template.DeepCopy()
+			}
 			err := r.buildNonAdminDeployment(nonAdminDeployment)
 			if err != nil {
 				return err
 			}
+			if err := r.appendClusterProxyAndTrustedCA(&nonAdminDeployment.Spec.Template); err != nil {
+				return err
+			}
+			r.pinImages(&nonAdminDeployment.Spec.Template)
+			r.deferRollout(nonAdminObjectName, currentTemplate, &nonAdminDeployment.Spec.Template)
 
 			// Setting controller owner reference on the non admin controller deployment
 			return controllerutil.SetControllerReference(r.dpa, nonAdminDeployment, r.Scheme)
@@ -178,6 +187,14 @@ func ensureRequiredSpecs(deploymentObject *appsv1.Deployment, dpa *oadpv1alpha1.
 		})
 	}
 
+	var podConfig *oadpv1alpha1.PodConfig
+	if dpa.Spec.NonAdmin != nil {
+		podConfig = dpa.Spec.NonAdmin.PodConfig
+	}
+	if podConfig != nil && podConfig.Env != nil {
+		envVars = common.AppendUniqueEnvVars(envVars, podConfig.Env)
+	}
+
 	if len(dpaResourceVersion) == 0 ||
 		!reflect.DeepEqual(dpa.Spec.NonAdmin, previousNonAdminConfiguration) ||
 		(dpa.Spec.Configuration.Velero.Args != nil &&
@@ -197,21 +214,19 @@ func ensureRequiredSpecs(deploymentObject *appsv1.Deployment, dpa *oadpv1alpha1.
 		MatchLabels: controlPlaneLabel,
 	}
 
-	templateObjectLabels := deploymentObject.Spec.Template.GetLabels()
-	if templateObjectLabels == nil {
-		deploymentObject.Spec.Template.SetLabels(controlPlaneLabel)
-	} else {
-		templateObjectLabels[controlPlaneKey] = controlPlaneLabel[controlPlaneKey]
-		deploymentObject.Spec.Template.SetLabels(templateObjectLabels)
-	}
-
-	templateObjectAnnotations := deploymentObject.Spec.Template.GetAnnotations()
-	if templateObjectAnnotations == nil {
-		deploymentObject.Spec.Template.SetAnnotations(podAnnotations)
-	} else {
-		templateObjectAnnotations[dpaResourceVersionAnnotation] = podAnnotations[dpaResourceVersionAnnotation]
-		deploymentObject.Spec.Template.SetAnnotations(templateObjectAnnotations)
+	// the Deployment is updated in place, so the template labels and annotations are rebuilt
+	// to drop the ones removed from podConfig
+	templateLabels := maps.Clone(controlPlaneLabel)
+	// add custom pod labels
+	if podConfig != nil && podConfig.Labels != nil {
+		labels, err := common.AppendUniqueKeyTOfTMaps(templateLabels, podConfig.Labels)
+		if err != nil {
+			return fmt.Errorf("non admin deployment template custom label: %v", err)
+		}
+		templateLabels = labels
 	}
+	deploymentObject.Spec.Template.SetLabels(templateLabels)
+	deploymentObject.Spec.Template.SetAnnotations(podAnnotations)
 
 	nonAdminContainerFound := false
 	if len(deploymentObject.Spec.Template.Spec.Containers) == 0 {
@@ -239,6 +254,44 @@ func ensureRequiredSpecs(deploymentObject *appsv1.Deployment, dpa *oadpv1alpha1.
 	if !nonAdminContainerFound {
 		return fmt.Errorf("could not find Non admin container in Deployment")
 	}
+	for index, container := range deploymentObject.Spec.Template.Spec.Containers {
+		if container.Name == nonAdminObjectName {
+			deploymentObject.Spec.Template.Spec.Containers[index].Args = nil
+			if dpa.Spec.Monitoring != nil && dpa.Spec.Monitoring.Enable {
+				deploymentObject.Spec.Template.Spec.Containers[index].Args = []string{fmt.Sprintf("--metrics-bind-address=:%d", nonAdminMetricsPort)}
+			}
+		}
+	}
+	// clear the fields set from podConfig, applyPodConfig only sets the ones in podConfig
+	template := &deploymentObject.Spec.Template
+	template.Spec.Tolerations = nil
+	template.Spec.NodeSelector = nil
+	template.Spec.PriorityClassName = ""
+	template.Spec.TopologySpreadConstraints = nil
+	template.Spec.SecurityContext = &corev1.PodSecurityContext{}
+	if template.Spec.Affinity != nil {
+		template.Spec.Affinity.PodAntiAffinity = nil
+		if reflect.DeepEqual(*template.Spec.Affinity, corev1.Affinity{}) {
+			template.Spec.Affinity = nil
+		}
+	}
+	for index, container := range template.Spec.Containers {
+		if container.Name == nonAdminObjectName {
+			template.Spec.Containers[index].Resources = corev1.ResourceRequirements{}
+			template.Spec.Containers[index].SecurityContext = nil
+		}
+	}
+	if podConfig != nil {
+		template.Spec.Tolerations = podConfig.Tolerations
+		template.Spec.NodeSelector = podConfig.NodeSelector
+		for index, container := range template.Spec.Containers {
+			if container.Name == nonAdminObjectName {
+				template.Spec.Containers[index].Resources = podConfig.ResourceAllocations
+				applyContainerPodConfig(&template.Spec.Containers[index], podConfig)
+			}
+		}
+		applyPodConfig(template, podConfig)
+	}
 	deploymentObject.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyAlways
 	deploymentObject.Spec.Template.Spec.ServiceAccountName = nonAdminObjectName
 	return nil
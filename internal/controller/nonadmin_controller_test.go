@@ -3,6 +3,7 @@ package controller
 import (
 	"context"
 	"os"
+	"reflect"
 	"strconv"
 	"testing"
 
@@ -440,6 +441,170 @@ func TestEnsureRequiredSpecs(t *testing.T) {
 	}
 }
 
+func TestEnsureRequiredSpecsPodConfig(t *testing.T) {
+	deployment := createTestDeployment("test-ensure-spec-pod-config")
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			ResourceVersion: "123456789",
+		},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{},
+			},
+			NonAdmin: &oadpv1alpha1.NonAdmin{
+				Enable: ptr.To(true),
+				PodConfig: &oadpv1alpha1.PodConfig{
+					NodeSelector:      map[string]string{"node-role.kubernetes.io/infra": ""},
+					PriorityClassName: "system-cluster-critical",
+					Annotations:       map[string]string{"team": "backup"},
+					Labels:            map[string]string{"tier": "control"},
+					PodSecurityContext: &corev1.PodSecurityContext{
+						RunAsNonRoot: ptr.To(true),
+					},
+					ContainerSecurityContext: &corev1.SecurityContext{
+						ReadOnlyRootFilesystem: ptr.To(true),
+					},
+				},
+			},
+		},
+	}
+	err := ensureRequiredSpecs(deployment, dpa, defaultNonAdminImage, corev1.PullAlways)
+	if err != nil {
+		t.Errorf("ensureRequiredSpecs() errored out: %v", err)
+	}
+	podSpec := deployment.Spec.Template.Spec
+	if podSpec.PriorityClassName != "system-cluster-critical" {
+		t.Errorf("Deployment has wrong PriorityClassName: %v", podSpec.PriorityClassName)
+	}
+	if !reflect.DeepEqual(podSpec.NodeSelector, dpa.Spec.NonAdmin.PodConfig.NodeSelector) {
+		t.Errorf("Deployment has wrong NodeSelector: %v", podSpec.NodeSelector)
+	}
+	if podSpec.SecurityContext == nil || !ptr.Deref(podSpec.SecurityContext.RunAsNonRoot, false) {
+		t.Errorf("Deployment has wrong pod SecurityContext: %v", podSpec.SecurityContext)
+	}
+	if podSpec.Containers[0].SecurityContext == nil || !ptr.Deref(podSpec.Containers[0].SecurityContext.ReadOnlyRootFilesystem, false) {
+		t.Errorf("Deployment has wrong container SecurityContext: %v", podSpec.Containers[0].SecurityContext)
+	}
+	if deployment.Spec.Template.Annotations["team"] != "backup" || len(deployment.Spec.Template.Annotations[dpaResourceVersionAnnotation]) == 0 {
+		t.Errorf("Deployment has wrong Annotations: %v", deployment.Spec.Template.Annotations)
+	}
+	if deployment.Spec.Template.Labels["tier"] != "control" || deployment.Spec.Template.Labels[controlPlaneKey] != controlPlaneLabel[controlPlaneKey] {
+		t.Errorf("Deployment has wrong Labels: %v", deployment.Spec.Template.Labels)
+	}
+}
+
+func TestEnsureRequiredSpecsPodConfigRemoved(t *testing.T) {
+	podConfig := func() *oadpv1alpha1.PodConfig {
+		return &oadpv1alpha1.PodConfig{
+			NodeSelector:      map[string]string{"node-role.kubernetes.io/infra": ""},
+			Tolerations:       []corev1.Toleration{{Key: "infra", Operator: corev1.TolerationOpExists}},
+			PriorityClassName: "system-cluster-critical",
+			TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{
+				MaxSkew:           1,
+				TopologyKey:       "topology.kubernetes.io/zone",
+				WhenUnsatisfiable: corev1.ScheduleAnyway,
+			}},
+			PodAntiAffinity: &corev1.PodAntiAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+					Weight:          100,
+					PodAffinityTerm: corev1.PodAffinityTerm{TopologyKey: "kubernetes.io/hostname"},
+				}},
+			},
+			Annotations:              map[string]string{"team": "backup"},
+			Labels:                   map[string]string{"tier": "control"},
+			PodSecurityContext:       &corev1.PodSecurityContext{RunAsNonRoot: ptr.To(true)},
+			ContainerSecurityContext: &corev1.SecurityContext{ReadOnlyRootFilesystem: ptr.To(true)},
+		}
+	}
+	tests := []struct {
+		name    string
+		remove  func(*oadpv1alpha1.PodConfig)
+		removed func(corev1.PodTemplateSpec) bool
+	}{
+		{
+			name:    "nodeSelector",
+			remove:  func(podConfig *oadpv1alpha1.PodConfig) { podConfig.NodeSelector = nil },
+			removed: func(template corev1.PodTemplateSpec) bool { return len(template.Spec.NodeSelector) == 0 },
+		},
+		{
+			name:    "tolerations",
+			remove:  func(podConfig *oadpv1alpha1.PodConfig) { podConfig.Tolerations = nil },
+			removed: func(template corev1.PodTemplateSpec) bool { return len(template.Spec.Tolerations) == 0 },
+		},
+		{
+			name:    "priorityClassName",
+			remove:  func(podConfig *oadpv1alpha1.PodConfig) { podConfig.PriorityClassName = "" },
+			removed: func(template corev1.PodTemplateSpec) bool { return template.Spec.PriorityClassName == "" },
+		},
+		{
+			name:    "topologySpreadConstraints",
+			remove:  func(podConfig *oadpv1alpha1.PodConfig) { podConfig.TopologySpreadConstraints = nil },
+			removed: func(template corev1.PodTemplateSpec) bool { return len(template.Spec.TopologySpreadConstraints) == 0 },
+		},
+		{
+			name:    "podAntiAffinity",
+			remove:  func(podConfig *oadpv1alpha1.PodConfig) { podConfig.PodAntiAffinity = nil },
+			removed: func(template corev1.PodTemplateSpec) bool { return template.Spec.Affinity == nil },
+		},
+		{
+			name:    "annotations",
+			remove:  func(podConfig *oadpv1alpha1.PodConfig) { podConfig.Annotations = nil },
+			removed: func(template corev1.PodTemplateSpec) bool { _, found := template.Annotations["team"]; return !found },
+		},
+		{
+			name:    "labels",
+			remove:  func(podConfig *oadpv1alpha1.PodConfig) { podConfig.Labels = nil },
+			removed: func(template corev1.PodTemplateSpec) bool { _, found := template.Labels["tier"]; return !found },
+		},
+		{
+			name:   "podSecurityContext",
+			remove: func(podConfig *oadpv1alpha1.PodConfig) { podConfig.PodSecurityContext = nil },
+			removed: func(template corev1.PodTemplateSpec) bool {
+				return reflect.DeepEqual(template.Spec.SecurityContext, &corev1.PodSecurityContext{})
+			},
+		},
+		{
+			name:    "containerSecurityContext",
+			remove:  func(podConfig *oadpv1alpha1.PodConfig) { podConfig.ContainerSecurityContext = nil },
+			removed: func(template corev1.PodTemplateSpec) bool { return template.Spec.Containers[0].SecurityContext == nil },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployment := createTestDeployment("test-ensure-spec-pod-config-removed")
+			dpa := &oadpv1alpha1.DataProtectionApplication{
+				ObjectMeta: metav1.ObjectMeta{ResourceVersion: "123456789"},
+				Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+					Configuration: &oadpv1alpha1.ApplicationConfig{Velero: &oadpv1alpha1.VeleroConfig{}},
+					NonAdmin:      &oadpv1alpha1.NonAdmin{Enable: ptr.To(true), PodConfig: podConfig()},
+				},
+			}
+			if err := ensureRequiredSpecs(deployment, dpa, defaultNonAdminImage, corev1.PullAlways); err != nil {
+				t.Fatalf("ensureRequiredSpecs() errored out: %v", err)
+			}
+			if tt.removed(deployment.Spec.Template) {
+				t.Fatalf("expected %s to be set from podConfig, got %#v", tt.name, deployment.Spec.Template)
+			}
+
+			tt.remove(dpa.Spec.NonAdmin.PodConfig)
+			if err := ensureRequiredSpecs(deployment, dpa, defaultNonAdminImage, corev1.PullAlways); err != nil {
+				t.Fatalf("ensureRequiredSpecs() errored out: %v", err)
+			}
+			if !tt.removed(deployment.Spec.Template) {
+				t.Errorf("expected %s removed from podConfig to be cleared, got %#v", tt.name, deployment.Spec.Template)
+			}
+
+			dpa.Spec.NonAdmin.PodConfig = nil
+			if err := ensureRequiredSpecs(deployment, dpa, defaultNonAdminImage, corev1.PullAlways); err != nil {
+				t.Fatalf("ensureRequiredSpecs() errored out: %v", err)
+			}
+			if !tt.removed(deployment.Spec.Template) {
+				t.Errorf("expected %s to be cleared without podConfig, got %#v", tt.name, deployment.Spec.Template)
+			}
+		})
+	}
+}
+
 func TestDPAReconcilerCheckNonAdminEnabled(t *testing.T) {
 	tests := []struct {
 		name   string
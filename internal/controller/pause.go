@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+// timeNow is replaced in tests to check maintenance window handling
+var timeNow = time.Now
+
+// isPaused returns true if reconcile of the DPA is paused through spec.paused or the paused annotation
+func (r *DataProtectionApplicationReconciler) isPaused() bool {
+	return r.dpa.Spec.Paused || r.dpa.GetAnnotations()[common.PausedAnnotation] == "true"
+}
+
+// maintenanceWindowOpen returns true if disruptive rollouts are allowed at the given time.
+// Rollouts are always allowed when no maintenance window is configured.
+func maintenanceWindowOpen(window *oadpv1alpha1.MaintenanceWindow, now time.Time) bool {
+	if window == nil {
+		return true
+	}
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), int(window.StartHour), 0, 0, 0, time.UTC)
+	if start.After(now) {
+		start = start.AddDate(0, 0, -1)
+	}
+	return now.Before(start.Add(time.Duration(window.DurationHours) * time.Hour))
+}
+
+// untilMaintenanceWindow returns how long until the maintenance window opens, zero if it is open
+func untilMaintenanceWindow(window *oadpv1alpha1.MaintenanceWindow, now time.Time) time.Duration {
+	if maintenanceWindowOpen(window, now) {
+		return 0
+	}
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), int(window.StartHour), 0, 0, 0, time.UTC)
+	if !start.After(now) {
+		start = start.AddDate(0, 0, 1)
+	}
+	return start.Sub(now)
+}
+
+// deferRollout keeps the current pod template of an existing workload when the desired one would
+// restart its pods outside the DPA maintenance window, or while Backups or Restores are in progress.
+// It returns true if the rollout was deferred.
+func (r *DataProtectionApplicationReconciler) deferRollout(component string, current *corev1.PodTemplateSpec, desired *corev1.PodTemplateSpec) bool {
+	if current == nil || equality.Semantic.DeepEqual(current, desired) {
+		return false
+	}
+	if maintenanceWindowOpen(r.dpa.Spec.MaintenanceWindow, timeNow()) && len(r.inProgressOperations) == 0 {
+		return false
+	}
+	*desired = *current
+	r.deferredRollouts = append(r.deferredRollouts, component)
+	return true
+}
+
+// updateRolloutDeferredCondition reports rollouts deferred during this reconcile and returns when
+// the DPA should be reconciled again to apply them
+func (r *DataProtectionApplicationReconciler) updateRolloutDeferredCondition() time.Duration {
+	if len(r.deferredRollouts) == 0 {
+		apimeta.RemoveStatusCondition(&r.dpa.Status.Conditions, oadpv1alpha1.ConditionRolloutDeferred)
+		return 0
+	}
+	window := r.dpa.Spec.MaintenanceWindow
+	if !maintenanceWindowOpen(window, timeNow()) {
+		apimeta.SetStatusCondition(&r.dpa.Status.Conditions,
+			metav1.Condition{
+				Type:   oadpv1alpha1.ConditionRolloutDeferred,
+				Status: metav1.ConditionTrue,
+				Reason: oadpv1alpha1.RolloutDeferredReason,
+				Message: fmt.Sprintf("rollout of %s deferred to the maintenance window starting at %02d:00 UTC",
+					strings.Join(r.deferredRollouts, ", "), window.StartHour),
+			},
+		)
+		return untilMaintenanceWindow(window, timeNow())
+	}
+	apimeta.SetStatusCondition(&r.dpa.Status.Conditions,
+		metav1.Condition{
+			Type:   oadpv1alpha1.ConditionRolloutDeferred,
+			Status: metav1.ConditionTrue,
+			Reason: oadpv1alpha1.RolloutDeferredOperationsReason,
+			Message: fmt.Sprintf("rollout of %s deferred until in progress operations complete: %s",
+				strings.Join(r.deferredRollouts, ", "), strings.Join(r.inProgressOperations, ", ")),
+		},
+	)
+	return operationsRequeueInterval
+}
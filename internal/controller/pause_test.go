@@ -0,0 +1,254 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func TestMaintenanceWindowOpen(t *testing.T) {
+	tests := []struct {
+		name      string
+		window    *oadpv1alpha1.MaintenanceWindow
+		now       time.Time
+		wantOpen  bool
+		wantUntil time.Duration
+	}{
+		{
+			name:     "no maintenance window",
+			now:      time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+			wantOpen: true,
+		},
+		{
+			name:     "inside window",
+			window:   &oadpv1alpha1.MaintenanceWindow{StartHour: 2, DurationHours: 4},
+			now:      time.Date(2024, 1, 1, 3, 30, 0, 0, time.UTC),
+			wantOpen: true,
+		},
+		{
+			name:      "before window",
+			window:    &oadpv1alpha1.MaintenanceWindow{StartHour: 2, DurationHours: 4},
+			now:       time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC),
+			wantUntil: time.Hour,
+		},
+		{
+			name:      "after window",
+			window:    &oadpv1alpha1.MaintenanceWindow{StartHour: 2, DurationHours: 4},
+			now:       time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC),
+			wantUntil: 20 * time.Hour,
+		},
+		{
+			name:     "window crossing midnight, after midnight",
+			window:   &oadpv1alpha1.MaintenanceWindow{StartHour: 22, DurationHours: 4},
+			now:      time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC),
+			wantOpen: true,
+		},
+		{
+			name:      "window crossing midnight, closed",
+			window:    &oadpv1alpha1.MaintenanceWindow{StartHour: 22, DurationHours: 4},
+			now:       time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC),
+			wantUntil: 20 * time.Hour,
+		},
+		{
+			name:     "window in other time zone",
+			window:   &oadpv1alpha1.MaintenanceWindow{StartHour: 2, DurationHours: 1},
+			now:      time.Date(2024, 1, 1, 4, 30, 0, 0, time.FixedZone("UTC+2", 2*60*60)),
+			wantOpen: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maintenanceWindowOpen(tt.window, tt.now); got != tt.wantOpen {
+				t.Errorf("maintenanceWindowOpen() = %v, want %v", got, tt.wantOpen)
+			}
+			if got := untilMaintenanceWindow(tt.window, tt.now); got != tt.wantUntil {
+				t.Errorf("untilMaintenanceWindow() = %v, want %v", got, tt.wantUntil)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_ReconcilePaused(t *testing.T) {
+	tests := []struct {
+		name       string
+		dpa        *oadpv1alpha1.DataProtectionApplication
+		wantPaused bool
+	}{
+		{
+			name: "paused through spec",
+			dpa: func() *oadpv1alpha1.DataProtectionApplication {
+				dpa := createTestDryRunDPA()
+				dpa.Annotations = nil
+				dpa.Spec.Paused = true
+				return dpa
+			}(),
+			wantPaused: true,
+		},
+		{
+			name: "paused through annotation",
+			dpa: func() *oadpv1alpha1.DataProtectionApplication {
+				dpa := createTestDryRunDPA()
+				dpa.Annotations = map[string]string{common.PausedAnnotation: "true"}
+				return dpa
+			}(),
+			wantPaused: true,
+		},
+		{
+			name: "not paused",
+			dpa: func() *oadpv1alpha1.DataProtectionApplication {
+				dpa := createTestDryRunDPA()
+				dpa.Annotations = map[string]string{common.PausedAnnotation: "false"}
+				apimeta.SetStatusCondition(&dpa.Status.Conditions, metav1.Condition{
+					Type:   oadpv1alpha1.ConditionPaused,
+					Status: metav1.ConditionTrue,
+					Reason: oadpv1alpha1.PausedReason,
+				})
+				return dpa
+			}(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schemeForFakeClient, err := getSchemeForFakeClient()
+			if err != nil {
+				t.Errorf("error in getting scheme for fake client, likely programmer error")
+			}
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(schemeForFakeClient).
+				WithObjects(tt.dpa, testGenericInfrastructure).
+				WithStatusSubresource(&oadpv1alpha1.DataProtectionApplication{}).
+				Build()
+			r := &DataProtectionApplicationReconciler{
+				Client:        fakeClient,
+				Scheme:        fakeClient.Scheme(),
+				EventRecorder: record.NewFakeRecorder(10),
+			}
+			key := types.NamespacedName{Namespace: tt.dpa.Namespace, Name: tt.dpa.Name}
+			if _, err := r.Reconcile(newContextForTest(), ctrl.Request{NamespacedName: key}); err != nil {
+				t.Errorf("Reconcile() unexpected error = %v", err)
+			}
+
+			dpa := &oadpv1alpha1.DataProtectionApplication{}
+			if err := fakeClient.Get(newContextForTest(), key, dpa); err != nil {
+				t.Fatalf("unable to get DPA: %v", err)
+			}
+			if paused := apimeta.IsStatusConditionTrue(dpa.Status.Conditions, oadpv1alpha1.ConditionPaused); paused != tt.wantPaused {
+				t.Errorf("expected Paused condition %v, got %v", tt.wantPaused, dpa.Status.Conditions)
+			}
+			err = fakeClient.Get(newContextForTest(), types.NamespacedName{Namespace: key.Namespace, Name: common.Velero}, &appsv1.Deployment{})
+			if tt.wantPaused && !errors.IsNotFound(err) {
+				t.Errorf("expected velero deployment not to be created while paused, got %v", err)
+			}
+			if !tt.wantPaused && err != nil {
+				t.Errorf("expected velero deployment to be created, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_deferRollout(t *testing.T) {
+	closedWindowNow := func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+	openWindowNow := func() time.Time { return time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name        string
+		now         func() time.Time
+		existing    bool
+		wantImage   string
+		wantRequeue bool
+	}{
+		{
+			name:      "outside window, rollout deferred",
+			now:       closedWindowNow,
+			existing:  true,
+			wantImage: "quay.io/konveyor/velero:old",
+			// window opens at 02:00 next day
+			wantRequeue: true,
+		},
+		{
+			name:      "inside window, rollout applied",
+			now:       openWindowNow,
+			existing:  true,
+			wantImage: getVeleroImage(createTestDryRunDPA()),
+		},
+		{
+			name:      "outside window, first deployment applied",
+			now:       closedWindowNow,
+			wantImage: getVeleroImage(createTestDryRunDPA()),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() { timeNow = time.Now }()
+			timeNow = tt.now
+
+			dpa := createTestDryRunDPA()
+			dpa.Annotations = nil
+			dpa.Spec.MaintenanceWindow = &oadpv1alpha1.MaintenanceWindow{StartHour: 2, DurationHours: 2}
+			objects := []client.Object{dpa, testGenericInfrastructure}
+			if tt.existing {
+				objects = append(objects, &appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              common.Velero,
+						Namespace:         dpa.Namespace,
+						CreationTimestamp: metav1.Now(),
+					},
+					Spec: appsv1.DeploymentSpec{
+						Selector: &metav1.LabelSelector{MatchLabels: getDpaAppLabels(dpa)},
+						Template: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{{Name: common.Velero, Image: "quay.io/konveyor/velero:old"}},
+							},
+						},
+					},
+				})
+			}
+			fakeClient, err := getFakeClientFromObjects(objects...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:  fakeClient,
+				Scheme:  fakeClient.Scheme(),
+				Log:     logr.Discard(),
+				Context: newContextForTest(),
+				NamespacedName: types.NamespacedName{
+					Namespace: dpa.Namespace,
+					Name:      dpa.Name,
+				},
+				EventRecorder: record.NewFakeRecorder(10),
+				dpa:           dpa,
+			}
+			if _, err := r.ReconcileVeleroDeployment(r.Log); err != nil {
+				t.Fatalf("ReconcileVeleroDeployment() unexpected error = %v", err)
+			}
+			deployment := &appsv1.Deployment{}
+			if err := fakeClient.Get(r.Context, types.NamespacedName{Namespace: dpa.Namespace, Name: common.Velero}, deployment); err != nil {
+				t.Fatalf("unable to get velero deployment: %v", err)
+			}
+			if image := deployment.Spec.Template.Spec.Containers[0].Image; image != tt.wantImage {
+				t.Errorf("expected velero image %s, got %s", tt.wantImage, image)
+			}
+			requeueAfter := r.updateRolloutDeferredCondition()
+			if (requeueAfter > 0) != tt.wantRequeue {
+				t.Errorf("expected requeue %v, got %v", tt.wantRequeue, requeueAfter)
+			}
+			if deferred := apimeta.IsStatusConditionTrue(r.dpa.Status.Conditions, oadpv1alpha1.ConditionRolloutDeferred); deferred != tt.wantRequeue {
+				t.Errorf("expected RolloutDeferred condition %v, got %v", tt.wantRequeue, r.dpa.Status.Conditions)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+	"github.com/openshift/oadp-operator/pkg/image"
+)
+
+// pluginCompatibilityManifest is the content of the plugin compatibility ConfigMap, for example
+//
+//	velero:
+//	- image: registry.example.com/oadp/velero
+//	  version: 1.15.2
+//	plugins:
+//	- image: registry.example.com/oadp/velero-plugin-for-example
+//	  veleroVersions: ">=1.15.0 <1.16.0"
+//
+// Images without tag or digest match all tags and digests of the repository.
+type pluginCompatibilityManifest struct {
+	Velero  []veleroImageVersion  `json:"velero,omitempty"`
+	Plugins []pluginVeleroVersion `json:"plugins,omitempty"`
+}
+
+type veleroImageVersion struct {
+	Image   string `json:"image"`
+	Version string `json:"version"`
+}
+
+type pluginVeleroVersion struct {
+	Image          string `json:"image"`
+	VeleroVersions string `json:"veleroVersions"`
+}
+
+// ValidatePluginCompatibility checks the Velero plugin images against the version of the Velero image,
+// and refuses to deploy Velero with plugins declared incompatible with it.
+// Velero versions and plugin compatible Velero version ranges are declared by the plugin compatibility
+// ConfigMap, or by image labels when spec.pinImageDigests is set. Plugins without a declared range are not checked.
+func (r *DataProtectionApplicationReconciler) ValidatePluginCompatibility(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	manifest, err := r.getPluginCompatibilityManifest()
+	if err != nil {
+		return false, err
+	}
+
+	veleroImage := getVeleroImage(dpa)
+	veleroVersion, err := r.getVeleroVersion(manifest, veleroImage)
+	if err != nil {
+		return false, err
+	}
+
+	incompatible := []string{}
+	declared := []string{}
+	for _, plugin := range r.getManagedImages() {
+		if !strings.HasPrefix(plugin.component, "plugin/") {
+			continue
+		}
+		veleroVersions := r.getPluginVeleroVersions(manifest, plugin.image)
+		if len(veleroVersions) == 0 {
+			continue
+		}
+		declared = append(declared, plugin.component)
+		compatible, err := semver.ParseRange(veleroVersions)
+		if err != nil {
+			return false, fmt.Errorf("invalid Velero version range %q for plugin image %s: %w", veleroVersions, plugin.image, err)
+		}
+		if veleroVersion != nil && !compatible(*veleroVersion) {
+			incompatible = append(incompatible, fmt.Sprintf("%s (%s) requires Velero %s", plugin.component, plugin.image, veleroVersions))
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:    oadpv1alpha1.ConditionPluginsCompatible,
+		Status:  metav1.ConditionTrue,
+		Reason:  oadpv1alpha1.PluginsReasonCompatible,
+		Message: "no plugin declares incompatibility with the Velero image",
+	}
+	switch {
+	case veleroVersion == nil && len(declared) > 0:
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = oadpv1alpha1.PluginsReasonVeleroVersionUnknown
+		condition.Message = fmt.Sprintf("Velero version of image %s is unknown, compatibility of %s not checked", veleroImage, strings.Join(declared, ", "))
+	case len(incompatible) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = oadpv1alpha1.PluginsReasonIncompatible
+		condition.Message = fmt.Sprintf("plugins are not compatible with Velero %s: %s", veleroVersion, strings.Join(incompatible, ", "))
+	case veleroVersion != nil:
+		condition.Message = fmt.Sprintf("plugins are compatible with Velero %s", veleroVersion)
+	}
+	apimeta.SetStatusCondition(&dpa.Status.Conditions, condition)
+	if condition.Status == metav1.ConditionFalse {
+		return false, fmt.Errorf("%s", condition.Message)
+	}
+	return true, nil
+}
+
+// getPluginCompatibilityManifest returns the plugin compatibility manifest of the DPA namespace, nil if there is none
+func (r *DataProtectionApplicationReconciler) getPluginCompatibilityManifest() (*pluginCompatibilityManifest, error) {
+	configMap := &corev1.ConfigMap{}
+	if err := r.Get(r.Context, types.NamespacedName{Namespace: r.dpa.Namespace, Name: common.PluginCompatibilityConfigMapName}, configMap); err != nil {
+		if k8serror.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	manifest := &pluginCompatibilityManifest{}
+	if err := yaml.UnmarshalStrict([]byte(configMap.Data[common.PluginCompatibilityManifestKey]), manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s in ConfigMap %s/%s: %w", common.PluginCompatibilityManifestKey, configMap.Namespace, configMap.Name, err)
+	}
+	return manifest, nil
+}
+
+// getVeleroVersion returns the Velero version of veleroImage, from the compatibility manifest or the image labels.
+// Nil if the version is unknown, as the default and RELATED_IMAGE_VELERO images have moving tags.
+func (r *DataProtectionApplicationReconciler) getVeleroVersion(manifest *pluginCompatibilityManifest, veleroImage string) (*semver.Version, error) {
+	version := ""
+	if manifest != nil {
+		for _, entry := range manifest.Velero {
+			if imageMatches(entry.Image, veleroImage) {
+				version = entry.Version
+				break
+			}
+		}
+	}
+	if len(version) == 0 {
+		version = r.imageLabels[veleroImage][common.VeleroVersionImageLabel]
+	}
+	if len(version) == 0 {
+		return nil, nil
+	}
+	parsed, err := semver.ParseTolerant(version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Velero version %q for image %s: %w", version, veleroImage, err)
+	}
+	return &parsed, nil
+}
+
+// getPluginVeleroVersions returns the Velero version range pluginImage is compatible with, from the
+// compatibility manifest or the image labels. Empty if the plugin does not declare one.
+func (r *DataProtectionApplicationReconciler) getPluginVeleroVersions(manifest *pluginCompatibilityManifest, pluginImage string) string {
+	if manifest != nil {
+		for _, entry := range manifest.Plugins {
+			if imageMatches(entry.Image, pluginImage) {
+				return entry.VeleroVersions
+			}
+		}
+	}
+	return r.imageLabels[pluginImage][common.PluginVeleroVersionsImageLabel]
+}
+
+// imageMatches returns true if imageName is pattern, or is in the pattern repository when pattern has no tag or digest
+func imageMatches(pattern, imageName string) bool {
+	patternRef, err := image.ParseReference(pattern)
+	if err != nil {
+		return false
+	}
+	ref, err := image.ParseReference(imageName)
+	if err != nil || patternRef.Name() != ref.Name() {
+		return false
+	}
+	if strings.Contains(pattern, "@") {
+		return patternRef.Digest == ref.Digest
+	}
+	if strings.Contains(pattern[strings.LastIndex(pattern, "/")+1:], ":") {
+		return patternRef.Tag == ref.Tag
+	}
+	return true
+}
@@ -0,0 +1,226 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func TestDPAReconciler_ValidatePluginCompatibility(t *testing.T) {
+	compatibilityConfigMap := func(manifest string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: common.PluginCompatibilityConfigMapName, Namespace: "test-ns"},
+			Data:       map[string]string{common.PluginCompatibilityManifestKey: manifest},
+		}
+	}
+	tests := []struct {
+		name          string
+		env           map[string]string
+		overrides     map[oadpv1alpha1.UnsupportedImageKey]string
+		customPlugins []oadpv1alpha1.CustomPlugin
+		imageLabels   map[string]map[string]string
+		objects       []client.Object
+		wantStatus    metav1.ConditionStatus
+		wantReason    string
+		wantMessage   string
+		wantErr       bool
+	}{
+		{
+			name:        "default images, no declarations",
+			wantStatus:  metav1.ConditionTrue,
+			wantReason:  oadpv1alpha1.PluginsReasonCompatible,
+			wantMessage: "no plugin declares incompatibility with the Velero image",
+		},
+		{
+			name:          "default Velero image without declared version",
+			customPlugins: []oadpv1alpha1.CustomPlugin{{Name: "example", Image: "quay.io/example/velero-plugin:v1.0"}},
+			objects: []client.Object{compatibilityConfigMap(`
+plugins:
+- image: quay.io/example/velero-plugin
+  veleroVersions: ">=1.16.0 <2.0.0"
+`)},
+			wantStatus:  metav1.ConditionUnknown,
+			wantReason:  oadpv1alpha1.PluginsReasonVeleroVersionUnknown,
+			wantMessage: "Velero version of image " + common.VeleroImage + " is unknown, compatibility of plugin/example not checked",
+		},
+		{
+			name:          "RELATED_IMAGE_VELERO image without declared version",
+			env:           map[string]string{"RELATED_IMAGE_VELERO": "registry.example.com/oadp/velero@sha256:abc"},
+			customPlugins: []oadpv1alpha1.CustomPlugin{{Name: "example", Image: "quay.io/example/velero-plugin:v1.0"}},
+			objects: []client.Object{compatibilityConfigMap(`
+plugins:
+- image: quay.io/example/velero-plugin
+  veleroVersions: ">=1.16.0 <2.0.0"
+`)},
+			wantStatus:  metav1.ConditionUnknown,
+			wantReason:  oadpv1alpha1.PluginsReasonVeleroVersionUnknown,
+			wantMessage: "Velero version of image registry.example.com/oadp/velero@sha256:abc is unknown, compatibility of plugin/example not checked",
+		},
+		{
+			name:          "custom plugin declared compatible with default Velero in ConfigMap",
+			customPlugins: []oadpv1alpha1.CustomPlugin{{Name: "example", Image: "quay.io/example/velero-plugin:v1.0"}},
+			objects: []client.Object{compatibilityConfigMap(`
+velero:
+- image: ` + common.VeleroImage + `
+  version: 1.16.0
+plugins:
+- image: quay.io/example/velero-plugin
+  veleroVersions: ">=1.16.0 <2.0.0"
+`)},
+			wantStatus:  metav1.ConditionTrue,
+			wantReason:  oadpv1alpha1.PluginsReasonCompatible,
+			wantMessage: "plugins are compatible with Velero 1.16.0",
+		},
+		{
+			name:          "custom plugin built for another Velero major version is refused",
+			customPlugins: []oadpv1alpha1.CustomPlugin{{Name: "example", Image: "quay.io/example/velero-plugin:v1.0"}},
+			objects: []client.Object{compatibilityConfigMap(`
+velero:
+- image: ` + common.VeleroImage + `
+  version: 1.16.0
+plugins:
+- image: quay.io/example/velero-plugin:v1.0
+  veleroVersions: ">=2.0.0"
+`)},
+			wantStatus:  metav1.ConditionFalse,
+			wantReason:  oadpv1alpha1.PluginsReasonIncompatible,
+			wantMessage: "plugins are not compatible with Velero 1.16.0: plugin/example (quay.io/example/velero-plugin:v1.0) requires Velero >=2.0.0",
+			wantErr:     true,
+		},
+		{
+			name: "overridden Velero and plugin images declared by image labels",
+			overrides: map[oadpv1alpha1.UnsupportedImageKey]string{
+				oadpv1alpha1.VeleroImageKey:          "registry.example.com/velero:v1.14",
+				oadpv1alpha1.OpenShiftPluginImageKey: "registry.example.com/openshift-velero-plugin:v1.16",
+			},
+			imageLabels: map[string]map[string]string{
+				"registry.example.com/velero:v1.14":                  {common.VeleroVersionImageLabel: "v1.14.1"},
+				"registry.example.com/openshift-velero-plugin:v1.16": {common.PluginVeleroVersionsImageLabel: ">=1.16.0 <1.17.0"},
+			},
+			wantStatus:  metav1.ConditionFalse,
+			wantReason:  oadpv1alpha1.PluginsReasonIncompatible,
+			wantMessage: "plugins are not compatible with Velero 1.14.1: plugin/openshift (registry.example.com/openshift-velero-plugin:v1.16) requires Velero >=1.16.0 <1.17.0",
+			wantErr:     true,
+		},
+		{
+			name: "ConfigMap takes precedence over image labels",
+			overrides: map[oadpv1alpha1.UnsupportedImageKey]string{
+				oadpv1alpha1.VeleroImageKey: "registry.example.com/velero:v1.14",
+			},
+			imageLabels: map[string]map[string]string{
+				"registry.example.com/velero:v1.14": {common.VeleroVersionImageLabel: "1.14.1"},
+			},
+			objects: []client.Object{compatibilityConfigMap(`
+velero:
+- image: registry.example.com/velero:v1.14
+  version: 1.16.2
+`)},
+			wantStatus:  metav1.ConditionTrue,
+			wantReason:  oadpv1alpha1.PluginsReasonCompatible,
+			wantMessage: "plugins are compatible with Velero 1.16.2",
+		},
+		{
+			name: "overridden Velero image without declared version",
+			overrides: map[oadpv1alpha1.UnsupportedImageKey]string{
+				oadpv1alpha1.VeleroImageKey: "registry.example.com/velero:v1.14",
+			},
+			customPlugins: []oadpv1alpha1.CustomPlugin{{Name: "example", Image: "quay.io/example/velero-plugin:v1.0"}},
+			imageLabels: map[string]map[string]string{
+				"quay.io/example/velero-plugin:v1.0": {common.PluginVeleroVersionsImageLabel: ">=1.16.0"},
+			},
+			wantStatus:  metav1.ConditionUnknown,
+			wantReason:  oadpv1alpha1.PluginsReasonVeleroVersionUnknown,
+			wantMessage: "Velero version of image registry.example.com/velero:v1.14 is unknown, compatibility of plugin/example not checked",
+		},
+		{
+			name:          "invalid version range",
+			customPlugins: []oadpv1alpha1.CustomPlugin{{Name: "example", Image: "quay.io/example/velero-plugin:v1.0"}},
+			objects: []client.Object{compatibilityConfigMap(`
+plugins:
+- image: quay.io/example/velero-plugin
+  veleroVersions: "any"
+`)},
+			wantErr: true,
+		},
+		{
+			name: "invalid manifest",
+			objects: []client.Object{compatibilityConfigMap(`
+plugin:
+- image: quay.io/example/velero-plugin
+`)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{
+				Configuration: &oadpv1alpha1.ApplicationConfig{
+					Velero: &oadpv1alpha1.VeleroConfig{
+						DefaultPlugins: []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginOpenShift},
+						CustomPlugins:  tt.customPlugins,
+					},
+				},
+				UnsupportedOverrides: tt.overrides,
+			})
+			fakeClient, err := getFakeClientFromObjects(append(tt.objects, dpa)...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:      fakeClient,
+				Scheme:      fakeClient.Scheme(),
+				Log:         logr.Discard(),
+				Context:     newContextForTest(),
+				dpa:         dpa,
+				imageLabels: tt.imageLabels,
+			}
+			_, err = r.ValidatePluginCompatibility(r.Log)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidatePluginCompatibility() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(tt.wantStatus) == 0 {
+				return
+			}
+			condition := apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionPluginsCompatible)
+			if condition == nil {
+				t.Fatalf("expected %s condition", oadpv1alpha1.ConditionPluginsCompatible)
+			}
+			if condition.Status != tt.wantStatus || condition.Reason != tt.wantReason || condition.Message != tt.wantMessage {
+				t.Errorf("expected condition %s %s %q, got %s %s %q", tt.wantStatus, tt.wantReason, tt.wantMessage, condition.Status, condition.Reason, condition.Message)
+			}
+		})
+	}
+}
+
+func TestImageMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		image   string
+		want    bool
+	}{
+		{pattern: "quay.io/example/plugin", image: "quay.io/example/plugin:v1.0", want: true},
+		{pattern: "quay.io/example/plugin", image: "quay.io/example/plugin@sha256:abc", want: true},
+		{pattern: "quay.io/example/plugin:v1.0", image: "quay.io/example/plugin:v1.0", want: true},
+		{pattern: "quay.io/example/plugin:v1.0", image: "quay.io/example/plugin:v2.0", want: false},
+		{pattern: "quay.io/example/plugin@sha256:abc", image: "quay.io/example/plugin@sha256:def", want: false},
+		{pattern: "localhost:5000/example/plugin", image: "localhost:5000/example/plugin:v1.0", want: true},
+		{pattern: "quay.io/example/plugin", image: "quay.io/example/plugin-other:v1.0", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.image, func(t *testing.T) {
+			if got := imageMatches(tt.pattern, tt.image); got != tt.want {
+				t.Errorf("imageMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,30 +1,40 @@
 package controller
 
 import (
+	"reflect"
+
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
 )
 
 func veleroPredicate(scheme *runtime.Scheme) predicate.Predicate {
 	return predicate.Funcs{
 		// Update returns true if the Update event should be processed
 		UpdateFunc: func(e event.UpdateEvent) bool {
-			if e.ObjectOld.GetGeneration() == e.ObjectNew.GetGeneration() {
+			if e.ObjectOld.GetGeneration() == e.ObjectNew.GetGeneration() &&
+				!workloadReadinessChanged(e.ObjectOld, e.ObjectNew) &&
+				!dpaBehaviorAnnotationsChanged(e.ObjectOld, e.ObjectNew) &&
+				!configMapDataChanged(e.ObjectOld, e.ObjectNew) &&
+				!backupStorageLocationPhaseChanged(e.ObjectOld, e.ObjectNew) {
 				return false
 			}
-			return isObjectOurs(scheme, e.ObjectOld)
+			return isObjectOurs(scheme, e.ObjectOld) || isUserObject(e.ObjectOld)
 		},
 		// Create returns true if the Create event should be processed
 		CreateFunc: func(e event.CreateEvent) bool {
-			return isObjectOurs(scheme, e.Object)
+			return isObjectOurs(scheme, e.Object) || isUserObject(e.Object)
 		},
 		// Delete returns true if the Delete event should be processed
 		DeleteFunc: func(e event.DeleteEvent) bool {
-			return !e.DeleteStateUnknown && isObjectOurs(scheme, e.Object)
+			return !e.DeleteStateUnknown && (isObjectOurs(scheme, e.Object) || isUserObject(e.Object))
 		},
 	}
 }
@@ -46,3 +56,73 @@ func isObjectOurs(scheme *runtime.Scheme, object client.Object) bool {
 	}
 	return object.GetLabels()[oadpv1alpha1.OadpOperatorLabel] != ""
 }
+
+// workloadReadinessChanged returns true if the object is a Deployment or a
+// DaemonSet whose ready pod count changed, so DPA component status is kept
+// up to date even though status updates do not bump the generation.
+func workloadReadinessChanged(oldObject, newObject client.Object) bool {
+	switch oldWorkload := oldObject.(type) {
+	case *appsv1.Deployment:
+		newWorkload, ok := newObject.(*appsv1.Deployment)
+		return ok && (oldWorkload.Status.ReadyReplicas != newWorkload.Status.ReadyReplicas ||
+			oldWorkload.Status.UpdatedReplicas != newWorkload.Status.UpdatedReplicas)
+	case *appsv1.DaemonSet:
+		newWorkload, ok := newObject.(*appsv1.DaemonSet)
+		return ok && (oldWorkload.Status.NumberReady != newWorkload.Status.NumberReady ||
+			oldWorkload.Status.DesiredNumberScheduled != newWorkload.Status.DesiredNumberScheduled)
+	}
+	return false
+}
+
+// dpaBehaviorAnnotationsChanged returns true if the object is a DPA whose
+// paused, dry-run, unsupported server args or force backup location deletion
+// annotation changed, as annotation
+// updates do not bump the generation.
+func dpaBehaviorAnnotationsChanged(oldObject, newObject client.Object) bool {
+	if _, ok := oldObject.(*oadpv1alpha1.DataProtectionApplication); !ok {
+		return false
+	}
+	for _, annotation := range []string{common.PausedAnnotation, common.DryRunAnnotation, common.UnsupportedVeleroServerArgsAnnotation, common.UnsupportedNodeAgentServerArgsAnnotation, common.ForceBackupLocationDeletionAnnotation} {
+		if oldObject.GetAnnotations()[annotation] != newObject.GetAnnotations()[annotation] {
+			return true
+		}
+	}
+	return false
+}
+
+// configMapDataChanged returns true if the object is a ConfigMap whose data
+// changed, so the trusted CA bundle is mounted into the workloads once
+// injected and unsupported server args and plugin compatibility ConfigMap
+// edits are applied.
+func configMapDataChanged(oldObject, newObject client.Object) bool {
+	oldConfigMap, ok := oldObject.(*corev1.ConfigMap)
+	if !ok {
+		return false
+	}
+	newConfigMap, ok := newObject.(*corev1.ConfigMap)
+	return ok && !reflect.DeepEqual(oldConfigMap.Data, newConfigMap.Data)
+}
+
+// backupStorageLocationPhaseChanged returns true if the object is a
+// BackupStorageLocation whose phase or message changed, so unavailable
+// locations are reported in the DPA status when Velero validates them.
+func backupStorageLocationPhaseChanged(oldObject, newObject client.Object) bool {
+	oldBSL, ok := oldObject.(*velerov1.BackupStorageLocation)
+	if !ok {
+		return false
+	}
+	newBSL, ok := newObject.(*velerov1.BackupStorageLocation)
+	return ok && (oldBSL.Status.Phase != newBSL.Status.Phase || oldBSL.Status.Message != newBSL.Status.Message)
+}
+
+// isUserObject returns true if the object is a ConfigMap or a
+// BackupStorageLocation. They pass the predicate even if they are not ours, as
+// unsupported server args, plugin compatibility ConfigMaps and BackupStorageLocations are created by
+// users; their events are only mapped to the DPAs referencing them.
+func isUserObject(object client.Object) bool {
+	switch object.(type) {
+	case *corev1.ConfigMap, *velerov1.BackupStorageLocation:
+		return true
+	}
+	return false
+}
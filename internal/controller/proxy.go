@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+const (
+	trustedCABundleVolumeName = "trusted-ca-bundle"
+	// trustedCABundleMountPath replaces the image CA bundle, the injected bundle contains the system CAs as well
+	trustedCABundleMountPath = "/etc/pki/ca-trust/extracted/pem"
+	trustedCABundleFileName  = "tls-ca-bundle.pem"
+)
+
+// getClusterProxy returns the OpenShift cluster wide proxy configuration, nil if the cluster is not OpenShift
+func (r *DataProtectionApplicationReconciler) getClusterProxy() (*configv1.Proxy, error) {
+	clusterProxy := &configv1.Proxy{}
+	if err := r.Get(r.Context, types.NamespacedName{Name: Cluster}, clusterProxy); err != nil {
+		if k8serror.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return clusterProxy, nil
+}
+
+// clusterProxyEnvVars returns the proxy environment variables of the cluster wide proxy configuration
+func clusterProxyEnvVars(clusterProxy *configv1.Proxy) []corev1.EnvVar {
+	if clusterProxy == nil {
+		return nil
+	}
+	envVars := []corev1.EnvVar{}
+	for _, envVar := range []corev1.EnvVar{
+		{Name: common.HTTPProxyEnvVar, Value: clusterProxy.Status.HTTPProxy},
+		{Name: common.HTTPSProxyEnvVar, Value: clusterProxy.Status.HTTPSProxy},
+		{Name: common.NoProxyEnvVar, Value: clusterProxy.Status.NoProxy},
+	} {
+		if len(envVar.Value) != 0 {
+			envVars = append(envVars, envVar)
+		}
+	}
+	return envVars
+}
+
+// ReconcileTrustedCABundleConfigMap creates the ConfigMap the OpenShift network operator injects
+// the cluster trusted CA bundle into. It is only created on OpenShift.
+func (r *DataProtectionApplicationReconciler) ReconcileTrustedCABundleConfigMap(log logr.Logger) (bool, error) {
+	clusterProxy, err := r.getClusterProxy()
+	if err != nil || clusterProxy == nil {
+		return err == nil, err
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      common.TrustedCABundleConfigMapName,
+			Namespace: r.dpa.Namespace,
+		},
+	}
+	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, configMap, r.withDriftDetection(configMap, func() error {
+		// data is owned by the network operator, only labels are set
+		configMap.Labels = common.AppendTTMapAsCopy(configMap.Labels, getDpaAppLabels(r.dpa), map[string]string{
+			common.InjectTrustedCABundleLabel: TrueVal,
+		})
+		return controllerutil.SetControllerReference(r.dpa, configMap, r.Scheme)
+	}))
+	if err != nil {
+		return false, err
+	}
+	if op == controllerutil.OperationResultCreated || op == controllerutil.OperationResultUpdated {
+		r.EventRecorder.Event(configMap,
+			corev1.EventTypeNormal,
+			"TrustedCABundleConfigMapReconciled",
+			fmt.Sprintf("performed %s on trusted CA bundle configmap %s/%s", op, configMap.Namespace, configMap.Name),
+		)
+	}
+	return true, nil
+}
+
+// trustedCABundleInjected returns true if the cluster trusted CA bundle was injected in the DPA namespace
+func (r *DataProtectionApplicationReconciler) trustedCABundleInjected() (bool, error) {
+	configMap := &corev1.ConfigMap{}
+	if err := r.Get(r.Context, types.NamespacedName{Namespace: r.dpa.Namespace, Name: common.TrustedCABundleConfigMapName}, configMap); err != nil {
+		if k8serror.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return len(configMap.Data[common.TrustedCABundleKey]) != 0, nil
+}
+
+// appendClusterProxyAndTrustedCA adds the cluster wide proxy environment variables to all containers of a
+// workload pod template, and mounts the trusted CA bundle once it was injected.
+// Environment variables already set on a container take precedence.
+func (r *DataProtectionApplicationReconciler) appendClusterProxyAndTrustedCA(template *corev1.PodTemplateSpec) error {
+	clusterProxy, err := r.getClusterProxy()
+	if err != nil || clusterProxy == nil {
+		return err
+	}
+	proxyEnvVars := clusterProxyEnvVars(clusterProxy)
+	for i := range template.Spec.InitContainers {
+		template.Spec.InitContainers[i].Env = common.AppendUniqueEnvVars(template.Spec.InitContainers[i].Env, proxyEnvVars)
+	}
+	for i := range template.Spec.Containers {
+		template.Spec.Containers[i].Env = common.AppendUniqueEnvVars(template.Spec.Containers[i].Env, proxyEnvVars)
+	}
+
+	injected, err := r.trustedCABundleInjected()
+	if err != nil || !injected {
+		return err
+	}
+	volume := corev1.Volume{
+		Name: trustedCABundleVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: common.TrustedCABundleConfigMapName},
+				Items:                []corev1.KeyToPath{{Key: common.TrustedCABundleKey, Path: trustedCABundleFileName}},
+				DefaultMode:          ptr.To(int32(0644)),
+			},
+		},
+	}
+	template.Spec.Volumes = setVolume(template.Spec.Volumes, volume)
+	for i := range template.Spec.Containers {
+		template.Spec.Containers[i].VolumeMounts = setVolumeMount(template.Spec.Containers[i].VolumeMounts, corev1.VolumeMount{
+			Name:      trustedCABundleVolumeName,
+			MountPath: trustedCABundleMountPath,
+			ReadOnly:  true,
+		})
+	}
+	return nil
+}
+
+// setVolume adds volume to volumes, replacing the volume with the same name if present
+func setVolume(volumes []corev1.Volume, volume corev1.Volume) []corev1.Volume {
+	for i := range volumes {
+		if volumes[i].Name == volume.Name {
+			volumes[i] = volume
+			return volumes
+		}
+	}
+	return append(volumes, volume)
+}
+
+// setVolumeMount adds mount to mounts, replacing the mount of the same volume if present
+func setVolumeMount(mounts []corev1.VolumeMount, mount corev1.VolumeMount) []corev1.VolumeMount {
+	for i := range mounts {
+		if mounts[i].Name == mount.Name {
+			mounts[i] = mount
+			return mounts
+		}
+	}
+	return append(mounts, mount)
+}
+
+// trustedHTTPClient returns an HTTP client trusting the cluster trusted CA bundle injected in namespace,
+// in addition to the system CAs. Proxy settings are read from the operator environment.
+func trustedHTTPClient(ctx context.Context, c client.Client, namespace string) (*http.Client, error) {
+	configMap := &corev1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: common.TrustedCABundleConfigMapName}, configMap); err != nil {
+		if k8serror.IsNotFound(err) {
+			return http.DefaultClient, nil
+		}
+		return nil, err
+	}
+	bundle := configMap.Data[common.TrustedCABundleKey]
+	if len(bundle) == 0 {
+		return http.DefaultClient, nil
+	}
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil {
+		rootCAs = x509.NewCertPool()
+	}
+	if !rootCAs.AppendCertsFromPEM([]byte(bundle)) {
+		return nil, fmt.Errorf("no certificates found in %s/%s", namespace, common.TrustedCABundleConfigMapName)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+var testClusterProxy = &configv1.Proxy{
+	ObjectMeta: metav1.ObjectMeta{Name: Cluster},
+	Status: configv1.ProxyStatus{
+		HTTPProxy:  "http://proxy.example.com:3128",
+		HTTPSProxy: "http://proxy.example.com:3128",
+		NoProxy:    ".cluster.local,.svc,10.0.0.0/16",
+	},
+}
+
+func TestDPAReconciler_appendClusterProxyAndTrustedCA(t *testing.T) {
+	tests := []struct {
+		name            string
+		objects         []client.Object
+		containerEnv    []corev1.EnvVar
+		wantEnv         []corev1.EnvVar
+		wantVolumeMount bool
+	}{
+		{
+			name: "not OpenShift",
+		},
+		{
+			name:    "cluster proxy, CA bundle not injected yet",
+			objects: []client.Object{testClusterProxy},
+			wantEnv: []corev1.EnvVar{
+				{Name: common.HTTPProxyEnvVar, Value: "http://proxy.example.com:3128"},
+				{Name: common.HTTPSProxyEnvVar, Value: "http://proxy.example.com:3128"},
+				{Name: common.NoProxyEnvVar, Value: ".cluster.local,.svc,10.0.0.0/16"},
+			},
+		},
+		{
+			name: "user proxy env takes precedence, CA bundle injected",
+			objects: []client.Object{
+				testClusterProxy,
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: common.TrustedCABundleConfigMapName, Namespace: "test-ns"},
+					Data:       map[string]string{common.TrustedCABundleKey: "-----BEGIN CERTIFICATE-----"},
+				},
+			},
+			containerEnv: []corev1.EnvVar{{Name: common.NoProxyEnvVar, Value: "example.com"}},
+			wantEnv: []corev1.EnvVar{
+				{Name: common.NoProxyEnvVar, Value: "example.com"},
+				{Name: common.HTTPProxyEnvVar, Value: "http://proxy.example.com:3128"},
+				{Name: common.HTTPSProxyEnvVar, Value: "http://proxy.example.com:3128"},
+			},
+			wantVolumeMount: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := createTestDryRunDPA()
+			fakeClient, err := getFakeClientFromObjects(append(tt.objects, dpa)...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:  fakeClient,
+				Scheme:  fakeClient.Scheme(),
+				Context: newContextForTest(),
+				dpa:     dpa,
+			}
+			template := &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: common.Velero, Env: tt.containerEnv}},
+				},
+			}
+			// applying twice must not duplicate volumes or mounts
+			for i := 0; i < 2; i++ {
+				if err := r.appendClusterProxyAndTrustedCA(template); err != nil {
+					t.Fatalf("appendClusterProxyAndTrustedCA() unexpected error = %v", err)
+				}
+			}
+			if env := template.Spec.Containers[0].Env; len(env) != 0 || len(tt.wantEnv) != 0 {
+				if !reflect.DeepEqual(env, tt.wantEnv) {
+					t.Errorf("expected env %v, got %v", tt.wantEnv, env)
+				}
+			}
+			wantVolumes, wantMounts := 0, 0
+			if tt.wantVolumeMount {
+				wantVolumes, wantMounts = 1, 1
+			}
+			if len(template.Spec.Volumes) != wantVolumes || len(template.Spec.Containers[0].VolumeMounts) != wantMounts {
+				t.Errorf("expected %d trusted CA volume and mount, got %v and %v", wantVolumes, template.Spec.Volumes, template.Spec.Containers[0].VolumeMounts)
+			}
+			if tt.wantVolumeMount && template.Spec.Containers[0].VolumeMounts[0].MountPath != trustedCABundleMountPath {
+				t.Errorf("expected trusted CA bundle mounted at %s, got %v", trustedCABundleMountPath, template.Spec.Containers[0].VolumeMounts[0])
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_ReconcileTrustedCABundleConfigMap(t *testing.T) {
+	tests := []struct {
+		name          string
+		objects       []client.Object
+		wantConfigMap bool
+	}{
+		{
+			name: "not OpenShift, no ConfigMap",
+		},
+		{
+			name:          "OpenShift, ConfigMap created with inject label",
+			objects:       []client.Object{testClusterProxy},
+			wantConfigMap: true,
+		},
+		{
+			name: "injected bundle is kept",
+			objects: []client.Object{
+				testClusterProxy,
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: common.TrustedCABundleConfigMapName, Namespace: "test-ns"},
+					Data:       map[string]string{common.TrustedCABundleKey: "bundle"},
+				},
+			},
+			wantConfigMap: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := createTestDryRunDPA()
+			dpa.UID = "test-dpa-uid"
+			fakeClient, err := getFakeClientFromObjects(append(tt.objects, dpa)...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:        fakeClient,
+				Scheme:        fakeClient.Scheme(),
+				Log:           logr.Discard(),
+				Context:       newContextForTest(),
+				EventRecorder: record.NewFakeRecorder(10),
+				dpa:           dpa,
+			}
+			if _, err := r.ReconcileTrustedCABundleConfigMap(r.Log); err != nil {
+				t.Fatalf("ReconcileTrustedCABundleConfigMap() unexpected error = %v", err)
+			}
+			configMap := &corev1.ConfigMap{}
+			err = fakeClient.Get(r.Context, types.NamespacedName{Namespace: dpa.Namespace, Name: common.TrustedCABundleConfigMapName}, configMap)
+			if !tt.wantConfigMap {
+				if !k8serror.IsNotFound(err) {
+					t.Errorf("expected no trusted CA bundle ConfigMap, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to get trusted CA bundle ConfigMap: %v", err)
+			}
+			if configMap.Labels[common.InjectTrustedCABundleLabel] != TrueVal {
+				t.Errorf("expected inject label on trusted CA bundle ConfigMap, got %v", configMap.Labels)
+			}
+			for _, existing := range tt.objects {
+				if existingConfigMap, ok := existing.(*corev1.ConfigMap); ok && !reflect.DeepEqual(existingConfigMap.Data, configMap.Data) {
+					t.Errorf("expected injected bundle to be kept, got %v", configMap.Data)
+				}
+			}
+			if !metav1.IsControlledBy(configMap, dpa) {
+				t.Errorf("expected trusted CA bundle ConfigMap to be controlled by the DPA")
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+// restoreOnlyPolicyName is the name of the ValidatingAdmissionPolicy and of its binding
+// rejecting the Schedules created in the namespaces of RestoreOnly DPAs
+const restoreOnlyPolicyName = "oadp-restore-only-schedules"
+
+// ReconcileRestoreOnlyPolicy creates the cluster scoped ValidatingAdmissionPolicy rejecting the Schedules created
+// in the namespace of a RestoreOnly DPA. The policy reads the mode of the DPAs of the namespace of the Schedule,
+// so it is shared by the DPAs of all namespaces. It is created by the first RestoreOnly DPA and is not deleted,
+// as it allows Schedules in namespaces without RestoreOnly DPA.
+func (r *DataProtectionApplicationReconciler) ReconcileRestoreOnlyPolicy(log logr.Logger) (bool, error) {
+	if !r.dpa.IsRestoreOnly() {
+		return true, nil
+	}
+	labels := map[string]string{
+		"app.kubernetes.io/name":       common.OADPOperator,
+		"app.kubernetes.io/managed-by": common.OADPOperator,
+		"app.kubernetes.io/component":  "restore-only",
+	}
+
+	policy := &admissionregistrationv1.ValidatingAdmissionPolicy{ObjectMeta: metav1.ObjectMeta{Name: restoreOnlyPolicyName}}
+	policyOp, err := controllerutil.CreateOrPatch(r.Context, r.ClusterWideClient, policy, func() error {
+		policy.Labels = common.AppendTTMapAsCopy(policy.Labels, labels)
+		// defaulted fields are set to avoid patching the policy on each reconcile
+		policy.Spec = admissionregistrationv1.ValidatingAdmissionPolicySpec{
+			ParamKind: &admissionregistrationv1.ParamKind{
+				APIVersion: oadpv1alpha1.GroupVersion.String(),
+				Kind:       "DataProtectionApplication",
+			},
+			MatchConstraints: &admissionregistrationv1.MatchResources{
+				NamespaceSelector: &metav1.LabelSelector{},
+				ObjectSelector:    &metav1.LabelSelector{},
+				MatchPolicy:       ptr.To(admissionregistrationv1.Equivalent),
+				ResourceRules: []admissionregistrationv1.NamedRuleWithOperations{{
+					RuleWithOperations: admissionregistrationv1.RuleWithOperations{
+						Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create},
+						Rule: admissionregistrationv1.Rule{
+							APIGroups:   []string{velerov1.SchemeGroupVersion.Group},
+							APIVersions: []string{velerov1.SchemeGroupVersion.Version},
+							Resources:   []string{"schedules"},
+							Scope:       ptr.To(admissionregistrationv1.AllScopes),
+						},
+					},
+				}},
+			},
+			Validations: []admissionregistrationv1.Validation{{
+				Expression: fmt.Sprintf("!has(params.spec.mode) || params.spec.mode != '%s'", oadpv1alpha1.DataProtectionApplicationModeRestoreOnly),
+				MessageExpression: fmt.Sprintf("'Schedules cannot be created in namespace ' + request.namespace + ', DataProtectionApplication ' + params.metadata.name + ' is in %s mode'",
+					oadpv1alpha1.DataProtectionApplicationModeRestoreOnly),
+				Reason: ptr.To(metav1.StatusReasonForbidden),
+			}},
+			FailurePolicy: ptr.To(admissionregistrationv1.Fail),
+		}
+		return nil
+	})
+	if err != nil {
+		if apimeta.IsNoMatchError(err) {
+			// the Velero schedule controller is disabled anyway, Schedules are created but never run
+			r.EventRecorder.Event(r.dpa, corev1.EventTypeWarning, "RestoreOnlyPolicyUnavailable",
+				fmt.Sprintf("Schedules cannot be rejected in namespace %s, the %s API is not available", r.dpa.Namespace, admissionregistrationv1.SchemeGroupVersion))
+			return true, nil
+		}
+		return false, err
+	}
+
+	binding := &admissionregistrationv1.ValidatingAdmissionPolicyBinding{ObjectMeta: metav1.ObjectMeta{Name: restoreOnlyPolicyName}}
+	bindingOp, err := controllerutil.CreateOrPatch(r.Context, r.ClusterWideClient, binding, func() error {
+		binding.Labels = common.AppendTTMapAsCopy(binding.Labels, labels)
+		binding.Spec = admissionregistrationv1.ValidatingAdmissionPolicyBindingSpec{
+			PolicyName: restoreOnlyPolicyName,
+			// without namespace, the params are the DPAs of the namespace of the Schedule,
+			// Schedules of namespaces without DPA are allowed
+			ParamRef: &admissionregistrationv1.ParamRef{
+				Selector:                &metav1.LabelSelector{},
+				ParameterNotFoundAction: ptr.To(admissionregistrationv1.AllowAction),
+			},
+			ValidationActions: []admissionregistrationv1.ValidationAction{admissionregistrationv1.Deny},
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	if policyOp == controllerutil.OperationResultCreated || policyOp == controllerutil.OperationResultUpdated ||
+		bindingOp == controllerutil.OperationResultCreated || bindingOp == controllerutil.OperationResultUpdated {
+		r.EventRecorder.Event(r.dpa,
+			corev1.EventTypeNormal,
+			"RestoreOnlyPolicyReconciled",
+			fmt.Sprintf("performed %s on ValidatingAdmissionPolicy %s and %s on its binding", policyOp, restoreOnlyPolicyName, bindingOp),
+		)
+	}
+
+	// Schedules created before the DPA was RestoreOnly are kept, but never run
+	schedules := &velerov1.ScheduleList{}
+	if err := r.List(r.Context, schedules, client.InNamespace(r.dpa.Namespace)); err != nil {
+		return false, err
+	}
+	if len(schedules.Items) > 0 {
+		names := []string{}
+		for _, schedule := range schedules.Items {
+			names = append(names, schedule.Name)
+		}
+		log.Info("Schedules are not run in RestoreOnly mode", "schedules", names)
+		r.EventRecorder.Event(r.dpa, corev1.EventTypeWarning, "RestoreOnlySchedulesFound",
+			fmt.Sprintf("Schedules %s are not run in %s mode", strings.Join(names, ", "), oadpv1alpha1.DataProtectionApplicationModeRestoreOnly))
+	}
+	return true, nil
+}
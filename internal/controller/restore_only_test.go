@@ -0,0 +1,211 @@
+package controller
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func TestDPAReconciler_ReconcileRestoreOnlyPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       oadpv1alpha1.DataProtectionApplicationMode
+		objects    []client.Object
+		wantPolicy bool
+		wantEvents []string
+	}{
+		{
+			name: "ReadWrite DPA does not create the policy",
+			mode: oadpv1alpha1.DataProtectionApplicationModeReadWrite,
+		},
+		{
+			name:       "RestoreOnly DPA creates the policy",
+			mode:       oadpv1alpha1.DataProtectionApplicationModeRestoreOnly,
+			wantPolicy: true,
+			wantEvents: []string{"Normal RestoreOnlyPolicyReconciled performed created on ValidatingAdmissionPolicy oadp-restore-only-schedules"},
+		},
+		{
+			name: "existing Schedules are reported",
+			mode: oadpv1alpha1.DataProtectionApplicationModeRestoreOnly,
+			objects: []client.Object{
+				&velerov1.Schedule{ObjectMeta: metav1.ObjectMeta{Name: "daily", Namespace: "test-ns"}},
+				&velerov1.Schedule{ObjectMeta: metav1.ObjectMeta{Name: "daily", Namespace: "other-ns"}},
+			},
+			wantPolicy: true,
+			wantEvents: []string{
+				"Normal RestoreOnlyPolicyReconciled",
+				"Warning RestoreOnlySchedulesFound Schedules daily are not run in RestoreOnly mode",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{Mode: tt.mode})
+			fakeClient, err := getFakeClientFromObjects(append(tt.objects, dpa)...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			recorder := record.NewFakeRecorder(10)
+			r := &DataProtectionApplicationReconciler{
+				Client:            fakeClient,
+				ClusterWideClient: fakeClient,
+				Scheme:            fakeClient.Scheme(),
+				Log:               logr.Discard(),
+				Context:           newContextForTest(),
+				NamespacedName:    types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+				EventRecorder:     recorder,
+				dpa:               dpa,
+			}
+			if _, err := r.ReconcileRestoreOnlyPolicy(r.Log); err != nil {
+				t.Fatalf("ReconcileRestoreOnlyPolicy() error = %v", err)
+			}
+
+			policy := &admissionregistrationv1.ValidatingAdmissionPolicy{}
+			err = fakeClient.Get(r.Context, types.NamespacedName{Name: restoreOnlyPolicyName}, policy)
+			if !tt.wantPolicy {
+				if !k8serror.IsNotFound(err) {
+					t.Errorf("expected no ValidatingAdmissionPolicy, got error %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if policy.Spec.ParamKind == nil || policy.Spec.ParamKind.Kind != "DataProtectionApplication" {
+				t.Errorf("expected DataProtectionApplication params, got %#v", policy.Spec.ParamKind)
+			}
+			if rules := policy.Spec.MatchConstraints.ResourceRules; len(rules) != 1 || !reflect.DeepEqual(rules[0].Resources, []string{"schedules"}) ||
+				!reflect.DeepEqual(rules[0].Operations, []admissionregistrationv1.OperationType{admissionregistrationv1.Create}) {
+				t.Errorf("expected the policy to match the creation of schedules, got %#v", rules)
+			}
+			binding := &admissionregistrationv1.ValidatingAdmissionPolicyBinding{}
+			if err := fakeClient.Get(r.Context, types.NamespacedName{Name: restoreOnlyPolicyName}, binding); err != nil {
+				t.Fatal(err)
+			}
+			if binding.Spec.ParamRef == nil || binding.Spec.ParamRef.Namespace != "" ||
+				*binding.Spec.ParamRef.ParameterNotFoundAction != admissionregistrationv1.AllowAction {
+				t.Errorf("expected the binding to use the DPAs of the namespace of the Schedule, got %#v", binding.Spec.ParamRef)
+			}
+
+			gotEvents := []string{}
+			for len(recorder.Events) > 0 {
+				gotEvents = append(gotEvents, <-recorder.Events)
+			}
+			if len(gotEvents) != len(tt.wantEvents) {
+				t.Fatalf("expected events %v, got %v", tt.wantEvents, gotEvents)
+			}
+			for i, want := range tt.wantEvents {
+				if !strings.HasPrefix(gotEvents[i], want) {
+					t.Errorf("expected event %q, got %q", want, gotEvents[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_RestoreOnlyLocations(t *testing.T) {
+	restoreOnlyDpa := func(accessMode velerov1.BackupStorageLocationAccessMode) *oadpv1alpha1.DataProtectionApplication {
+		dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{
+			Mode: oadpv1alpha1.DataProtectionApplicationModeRestoreOnly,
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{
+					DefaultPlugins: []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginAWS, oadpv1alpha1.DefaultPluginGCP},
+				},
+			},
+			BackupLocations: []oadpv1alpha1.BackupLocation{{
+				Name: "primary",
+				Velero: &velerov1.BackupStorageLocationSpec{
+					Provider:   AWSProvider,
+					Default:    true,
+					AccessMode: accessMode,
+					Config:     map[string]string{Region: "us-east-1"},
+					Credential: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "cloud-credentials"},
+						Key:                  "cloud",
+					},
+					StorageType: velerov1.StorageType{ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "primary-bucket", Prefix: "velero"}},
+				},
+			}},
+			SnapshotLocations: []oadpv1alpha1.SnapshotLocation{{
+				Velero: &velerov1.VolumeSnapshotLocationSpec{Provider: GCPProvider},
+			}},
+		})
+		dpa.UID = "test-uid"
+		return dpa
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials", Namespace: "test-ns"},
+		Data:       map[string][]byte{"cloud": []byte("[default]\naws_access_key_id=test-key\naws_secret_access_key=test-secret")},
+	}
+	tests := []struct {
+		name       string
+		accessMode velerov1.BackupStorageLocationAccessMode
+		wantErr    bool
+	}{
+		{name: "BSL without accessMode is ReadOnly"},
+		{name: "ReadOnly BSL", accessMode: velerov1.BackupStorageLocationAccessModeReadOnly},
+		{name: "ReadWrite BSL is rejected", accessMode: velerov1.BackupStorageLocationAccessModeReadWrite, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := restoreOnlyDpa(tt.accessMode)
+			fakeClient, err := getFakeClientFromObjects(dpa, secret.DeepCopy())
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:         fakeClient,
+				Scheme:         fakeClient.Scheme(),
+				Log:            logr.Discard(),
+				Context:        newContextForTest(),
+				NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+				EventRecorder:  record.NewFakeRecorder(10),
+				dpa:            dpa,
+			}
+			_, err = r.ValidateBackupStorageLocations()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateBackupStorageLocations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			// the VSL does not need the missing default GCP credentials
+			if _, err := r.ValidateVolumeSnapshotLocations(); err != nil {
+				t.Errorf("ValidateVolumeSnapshotLocations() error = %v", err)
+			}
+			if _, err := r.LabelVSLSecrets(r.Log); err != nil {
+				t.Errorf("LabelVSLSecrets() error = %v", err)
+			}
+			providerNeedsDefaultCreds, err := r.noDefaultCredentials()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := map[string]bool{AWSProvider: false}; !reflect.DeepEqual(providerNeedsDefaultCreds, want) {
+				t.Errorf("expected providers needing default credentials %v, got %v", want, providerNeedsDefaultCreds)
+			}
+
+			if _, err := r.ReconcileBackupStorageLocations(r.Log); err != nil {
+				t.Fatalf("ReconcileBackupStorageLocations() error = %v", err)
+			}
+			bsl := &velerov1.BackupStorageLocation{}
+			if err := fakeClient.Get(r.Context, types.NamespacedName{Namespace: "test-ns", Name: "primary"}, bsl); err != nil {
+				t.Fatal(err)
+			}
+			if bsl.Spec.AccessMode != velerov1.BackupStorageLocationAccessModeReadOnly {
+				t.Errorf("expected ReadOnly BSL, got accessMode %q", bsl.Spec.AccessMode)
+			}
+		})
+	}
+}
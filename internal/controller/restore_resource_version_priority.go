@@ -26,14 +26,14 @@ func (r *DataProtectionApplicationReconciler) ReconcileRestoreResourcesVersionPr
 		},
 	}
 	// Create ConfigMap
-	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, &configMap, func() error {
+	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, &configMap, r.withDriftDetection(&configMap, func() error {
 		if err := controllerutil.SetControllerReference(dpa, &configMap, r.Scheme); err != nil {
 			return err
 		}
 		configMap.Data = make(map[string]string, 1)
 		configMap.Data[restoreResourcesVersionPriorityDataKey] = dpa.Spec.Configuration.Velero.RestoreResourcesVersionPriority
 		return nil
-	})
+	}))
 	if err != nil {
 		return false, err
 	}
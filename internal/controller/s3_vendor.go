@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+)
+
+// S3 vendors detected from the response headers of an S3 endpoint
+const (
+	s3VendorAWS     = "AWS"
+	s3VendorMinIO   = "MinIO"
+	s3VendorCeph    = "Ceph"
+	s3VendorNooBaa  = "NooBaa"
+	s3VendorUnknown = "Unknown"
+)
+
+const (
+	// detected vendors are cached so every reconcile does not query the endpoints, failures are retried sooner
+	s3VendorCacheTTL        = time.Hour
+	s3VendorFailureCacheTTL = 5 * time.Minute
+	s3VendorDetectTimeout   = 10 * time.Second
+)
+
+// checksumAlgorithms are the checksumAlgorithm values of the AWS plugin, the empty value disables the checksums
+var checksumAlgorithms = []string{"", "CRC32", "CRC32C", "SHA1", "SHA256"}
+
+// s3VendorChecksumAlgorithms are the checksum algorithms accepted by the S3 vendors. Ceph RGW and NooBaa reject
+// the checksum headers of the AWS SDK, vendors not listed are not known to reject them.
+var s3VendorChecksumAlgorithms = map[string][]string{
+	s3VendorAWS:    checksumAlgorithms,
+	s3VendorMinIO:  checksumAlgorithms,
+	s3VendorCeph:   {""},
+	s3VendorNooBaa: {""},
+}
+
+// s3VendorDefaultChecksumAlgorithms are the checksum algorithms of the vendors when checksumAlgorithm is not set,
+// the checksums are disabled for the other vendors
+var s3VendorDefaultChecksumAlgorithms = map[string]string{
+	s3VendorAWS: "CRC32",
+}
+
+type s3VendorCacheEntry struct {
+	vendor  string
+	err     error
+	expires time.Time
+}
+
+// s3VendorCache caches the detected vendors by S3 endpoint
+var s3VendorCache = struct {
+	sync.Mutex
+	entries map[string]s3VendorCacheEntry
+}{entries: map[string]s3VendorCacheEntry{}}
+
+// detectS3Vendor sends a HEAD request to s3Url and returns the vendor from the Server header and the known
+// vendor headers, the lower case Server header when the vendor is not known, or Unknown without headers
+func detectS3Vendor(ctx context.Context, httpClient *http.Client, s3Url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s3Url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HEAD request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("HEAD request to %s failed: %w", s3Url, err)
+	}
+	defer resp.Body.Close()
+
+	server := strings.ToLower(resp.Header.Get("Server"))
+	xAmzReqID := resp.Header.Get("x-amz-request-id")
+	minioRegion := resp.Header.Get("x-minio-region")
+	rgwReqID := resp.Header.Get("x-rgw-request-id")
+
+	// S3 compatible vendors also send x-amz-request-id, so it only identifies AWS without another Server
+	switch {
+	case strings.Contains(server, "minio") || minioRegion != "":
+		return s3VendorMinIO, nil
+	case strings.Contains(server, "ceph") || rgwReqID != "":
+		return s3VendorCeph, nil
+	case strings.Contains(server, "noobaa"):
+		return s3VendorNooBaa, nil
+	case strings.Contains(server, "amazon") || (xAmzReqID != "" && server == ""):
+		return s3VendorAWS, nil
+	case server != "":
+		return server, nil
+	}
+	return s3VendorUnknown, nil
+}
+
+// isAWSS3Url returns true if s3Url is empty, the AWS plugin then uses the AWS endpoints, or an AWS endpoint
+func isAWSS3Url(s3Url string) bool {
+	if s3Url == "" {
+		return true
+	}
+	parsed, err := url.Parse(s3Url)
+	return err == nil && strings.HasSuffix(parsed.Hostname(), ".amazonaws.com")
+}
+
+// getS3Vendor returns the vendor of the S3 endpoint of an AWS BSL: AWS without s3Url or with an AWS s3Url,
+// otherwise detected from the endpoint and cached. It returns an error when the vendor cannot be detected.
+func (r *DataProtectionApplicationReconciler) getS3Vendor(bslSpec velerov1.BackupStorageLocationSpec) (string, error) {
+	s3Url := bslSpec.Config[S3URL]
+	if isAWSS3Url(s3Url) {
+		return s3VendorAWS, nil
+	}
+
+	s3VendorCache.Lock()
+	entry, found := s3VendorCache.entries[s3Url]
+	s3VendorCache.Unlock()
+	if found && time.Now().Before(entry.expires) {
+		return entry.vendor, entry.err
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context, s3VendorDetectTimeout)
+	defer cancel()
+	bsl := &velerov1.BackupStorageLocation{Spec: bslSpec}
+	if bsl.Spec.ObjectStorage == nil {
+		bsl.Spec.ObjectStorage = &velerov1.ObjectStorageLocation{}
+	}
+	httpClient, err := r.getBackupStorageLocationProbeClient(ctx, bsl)
+	if err == nil {
+		entry.vendor, entry.err = detectS3Vendor(ctx, httpClient, s3Url)
+	} else {
+		entry.vendor, entry.err = "", err
+	}
+	entry.expires = time.Now().Add(s3VendorCacheTTL)
+	if entry.err != nil {
+		entry.expires = time.Now().Add(s3VendorFailureCacheTTL)
+	}
+	s3VendorCache.Lock()
+	s3VendorCache.entries[s3Url] = entry
+	s3VendorCache.Unlock()
+	return entry.vendor, entry.err
+}
+
+// validateChecksumAlgorithm checks the checksumAlgorithm of an AWS BSL is an algorithm of the AWS plugin,
+// accepted by the vendor of the S3 endpoint. Endpoints whose vendor cannot be detected are not checked.
+// The detected vendor is kept for the reconcile, for getDefaultChecksumAlgorithm.
+func (r *DataProtectionApplicationReconciler) validateChecksumAlgorithm(bslSpec velerov1.BackupStorageLocationSpec) error {
+	algorithm, set := bslSpec.Config[checksumAlgorithm]
+	if set && !slices.Contains(checksumAlgorithms, algorithm) {
+		return fmt.Errorf("checksumAlgorithm %s for AWS backupstoragelocation is invalid, valid values are %q", algorithm, checksumAlgorithms)
+	}
+	vendor, err := r.getS3Vendor(bslSpec)
+	if err != nil {
+		r.Log.Info("unable to detect the S3 vendor, checksumAlgorithm is not checked", "s3Url", bslSpec.Config[S3URL], "error", err.Error())
+		return nil
+	}
+	if r.s3Vendors == nil {
+		r.s3Vendors = map[string]string{}
+	}
+	r.s3Vendors[bslSpec.Config[S3URL]] = vendor
+	if !set || algorithm == "" {
+		return nil
+	}
+	if supported, known := s3VendorChecksumAlgorithms[vendor]; known && !slices.Contains(supported, algorithm) {
+		return fmt.Errorf("checksumAlgorithm %s for AWS backupstoragelocation is not supported by the %s S3 endpoint %s, set checksumAlgorithm to \"\" to disable the checksums",
+			algorithm, vendor, bslSpec.Config[S3URL])
+	}
+	return nil
+}
+
+// getDefaultChecksumAlgorithm returns the checksumAlgorithm of an AWS BSL without one, for the vendor of its S3
+// endpoint resolved by validateChecksumAlgorithm. It returns false when the vendor was not detected.
+func (r *DataProtectionApplicationReconciler) getDefaultChecksumAlgorithm(bslSpec velerov1.BackupStorageLocationSpec) (string, bool) {
+	s3Url := bslSpec.Config[S3URL]
+	vendor, resolved := r.s3Vendors[s3Url]
+	if isAWSS3Url(s3Url) {
+		vendor, resolved = s3VendorAWS, true
+	}
+	if !resolved {
+		return "", false
+	}
+	return s3VendorDefaultChecksumAlgorithms[vendor], true
+}
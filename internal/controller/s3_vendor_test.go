@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	"k8s.io/utils/ptr"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func TestDPAReconciler_validateChecksumAlgorithm(t *testing.T) {
+	vendorServer := func(server string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Server", server)
+		}))
+	}
+	closedServer := vendorServer("Ceph")
+	closedServer.Close()
+
+	tests := []struct {
+		name           string
+		server         *httptest.Server
+		s3Url          string
+		algorithm      *string
+		wantErr        bool
+		wantDefault    string
+		wantUnresolved bool
+	}{
+		{
+			name:        "AWS without s3Url",
+			algorithm:   ptr.To("SHA256"),
+			wantDefault: "CRC32",
+		},
+		{
+			name:        "AWS s3Url",
+			s3Url:       "https://s3.eu-west-1.amazonaws.com",
+			algorithm:   ptr.To("CRC32C"),
+			wantDefault: "CRC32",
+		},
+		{
+			name:        "invalid algorithm",
+			algorithm:   ptr.To("MD5"),
+			wantErr:     true,
+			wantDefault: "CRC32",
+		},
+		{
+			name:      "MinIO with checksums",
+			server:    vendorServer("MinIO"),
+			algorithm: ptr.To("CRC32"),
+		},
+		{
+			name:      "Ceph with checksums",
+			server:    vendorServer("Ceph"),
+			algorithm: ptr.To("CRC32"),
+			wantErr:   true,
+		},
+		{
+			name:      "Ceph without checksums",
+			server:    vendorServer("Ceph"),
+			algorithm: ptr.To(""),
+		},
+		{
+			name:   "Ceph without checksumAlgorithm",
+			server: vendorServer("Ceph"),
+		},
+		{
+			name:      "NooBaa with checksums",
+			server:    vendorServer("NooBaa"),
+			algorithm: ptr.To("SHA1"),
+			wantErr:   true,
+		},
+		{
+			name:      "unknown vendor with checksums",
+			server:    vendorServer("SomethingElse"),
+			algorithm: ptr.To("CRC32"),
+		},
+		{
+			name:           "vendor not detected",
+			s3Url:          closedServer.URL,
+			algorithm:      ptr.To("CRC32"),
+			wantUnresolved: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s3Url := tt.s3Url
+			if tt.server != nil {
+				defer tt.server.Close()
+				s3Url = tt.server.URL
+			}
+			bslSpec := velerov1.BackupStorageLocationSpec{
+				Provider: AWSProvider,
+				Config:   map[string]string{Region: "us-east-1"},
+				StorageType: velerov1.StorageType{
+					ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "velero"},
+				},
+			}
+			if s3Url != "" {
+				bslSpec.Config[S3URL] = s3Url
+			}
+			if tt.algorithm != nil {
+				bslSpec.Config[checksumAlgorithm] = *tt.algorithm
+			}
+			dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{})
+			fakeClient, err := getFakeClientFromObjects(dpa)
+			if err != nil {
+				t.Fatalf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:  fakeClient,
+				Log:     logr.Discard(),
+				Context: newContextForTest(),
+				dpa:     dpa,
+			}
+			if err := r.validateChecksumAlgorithm(bslSpec); (err != nil) != tt.wantErr {
+				t.Errorf("validateChecksumAlgorithm() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got, resolved := r.getDefaultChecksumAlgorithm(bslSpec)
+			if got != tt.wantDefault || resolved == tt.wantUnresolved {
+				t.Errorf("getDefaultChecksumAlgorithm() = %q, %v, want %q, %v", got, resolved, tt.wantDefault, !tt.wantUnresolved)
+			}
+		})
+	}
+}
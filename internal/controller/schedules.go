@@ -0,0 +1,331 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	"github.com/vmware-tanzu/velero/pkg/label"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+const (
+	// backupScheduleComponent is the component label of the Velero Schedules of spec.schedules
+	backupScheduleComponent = "schedule"
+	// backupScheduleLabel is the label of the Velero Schedules of spec.schedules with the name of their schedule
+	backupScheduleLabel = "oadp.openshift.io/schedule"
+	// backupScheduleRequeueInterval is the interval between the prunings of the Backups of spec.schedules
+	backupScheduleRequeueInterval = 15 * time.Minute
+)
+
+// backupScheduleTiers are the retention tiers of spec.schedules, from the shortest, with the cron of the Velero
+// Schedule when the tier is the shortest kept, the period of the tier and the start of the period of a time in UTC
+var backupScheduleTiers = []struct {
+	tier   oadpv1alpha1.BackupScheduleTier
+	cron   string
+	period time.Duration
+	start  func(time.Time) time.Time
+	retain func(oadpv1alpha1.BackupScheduleRetention) int32
+}{
+	{
+		tier:   oadpv1alpha1.BackupScheduleTierHourly,
+		cron:   "0 * * * *",
+		period: time.Hour,
+		start:  func(t time.Time) time.Time { return t.UTC().Truncate(time.Hour) },
+		retain: func(retention oadpv1alpha1.BackupScheduleRetention) int32 { return retention.Hourly },
+	},
+	{
+		tier:   oadpv1alpha1.BackupScheduleTierDaily,
+		cron:   "0 0 * * *",
+		period: 24 * time.Hour,
+		start:  startOfDay,
+		retain: func(retention oadpv1alpha1.BackupScheduleRetention) int32 { return retention.Daily },
+	},
+	{
+		tier:   oadpv1alpha1.BackupScheduleTierWeekly,
+		cron:   "0 0 * * 0",
+		period: 7 * 24 * time.Hour,
+		start: func(t time.Time) time.Time {
+			day := startOfDay(t)
+			return day.AddDate(0, 0, -int(day.Weekday()))
+		},
+		retain: func(retention oadpv1alpha1.BackupScheduleRetention) int32 { return retention.Weekly },
+	},
+	{
+		tier:   oadpv1alpha1.BackupScheduleTierMonthly,
+		cron:   "0 0 1 * *",
+		period: 31 * 24 * time.Hour,
+		start: func(t time.Time) time.Time {
+			t = t.UTC()
+			return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		},
+		retain: func(retention oadpv1alpha1.BackupScheduleRetention) int32 { return retention.Monthly },
+	},
+	{
+		tier:   oadpv1alpha1.BackupScheduleTierYearly,
+		cron:   "0 0 1 1 *",
+		period: 366 * 24 * time.Hour,
+		start: func(t time.Time) time.Time {
+			return time.Date(t.UTC().Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		},
+		retain: func(retention oadpv1alpha1.BackupScheduleRetention) int32 { return retention.Yearly },
+	},
+}
+
+// startOfDay returns the start of the day of t, in UTC
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// finalizedBackupPhases are the phases of the Backups that can be pruned
+var finalizedBackupPhases = []velerov1.BackupPhase{
+	velerov1.BackupPhaseCompleted,
+	velerov1.BackupPhasePartiallyFailed,
+	velerov1.BackupPhaseFailed,
+	velerov1.BackupPhaseFailedValidation,
+}
+
+// validateBackupSchedules checks the schedules of spec.schedules have different names and keep Backups in at
+// least one tier, and are not set in RestoreOnly mode
+func validateBackupSchedules(dpa *oadpv1alpha1.DataProtectionApplication) error {
+	if len(dpa.Spec.Schedules) == 0 {
+		return nil
+	}
+	if dpa.IsRestoreOnly() {
+		return fmt.Errorf("DPA spec.schedules cannot be set in mode %s", oadpv1alpha1.DataProtectionApplicationModeRestoreOnly)
+	}
+	names := map[string]bool{}
+	for _, backupSchedule := range dpa.Spec.Schedules {
+		if backupSchedule.Name == "" {
+			return errors.New("DPA spec.schedules name must be set")
+		}
+		if names[backupSchedule.Name] {
+			return fmt.Errorf("DPA spec.schedules name %s is duplicated", backupSchedule.Name)
+		}
+		names[backupSchedule.Name] = true
+		kept := false
+		for _, tier := range backupScheduleTiers {
+			retain := tier.retain(backupSchedule.Retention)
+			if retain < 0 {
+				return fmt.Errorf("DPA spec.schedules %s retention %s cannot be negative", backupSchedule.Name, tier.tier)
+			}
+			kept = kept || retain > 0
+		}
+		if !kept {
+			return fmt.Errorf("DPA spec.schedules %s retention must keep Backups in at least one tier", backupSchedule.Name)
+		}
+	}
+	return nil
+}
+
+// getBackupScheduleName returns the name of the Velero Schedule of a schedule of spec.schedules
+func getBackupScheduleName(dpa *oadpv1alpha1.DataProtectionApplication, name string) string {
+	return fmt.Sprintf("%s-%s", dpa.Name, name)
+}
+
+// ReconcileBackupSchedules creates the Velero Schedule of each schedule of spec.schedules, taking a Backup every
+// period of its shortest retention tier, prunes the Backups no tier retains, and reports the Backups retained by
+// each tier in status.schedules. The Velero Schedules of removed schedules are deleted, their Backups are kept until
+// their ttl expires.
+func (r *DataProtectionApplicationReconciler) ReconcileBackupSchedules(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	scheduleNames := []string{}
+	statuses := []oadpv1alpha1.BackupScheduleStatus{}
+	for _, backupSchedule := range dpa.Spec.Schedules {
+		cron := ""
+		var ttl time.Duration
+		for _, tier := range backupScheduleTiers {
+			retain := tier.retain(backupSchedule.Retention)
+			if retain <= 0 {
+				continue
+			}
+			if cron == "" {
+				cron = tier.cron
+			}
+			// the ttl outlives the retention of the longest tier, so only the Backups not pruned expire
+			ttl = max(ttl, time.Duration(retain+1)*tier.period)
+		}
+		name := getBackupScheduleName(dpa, backupSchedule.Name)
+		schedule := &velerov1.Schedule{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: dpa.Namespace}}
+		op, err := controllerutil.CreateOrPatch(r.Context, r.Client, schedule, func() error {
+			schedule.Labels = common.AppendTTMapAsCopy(schedule.Labels, getDpaAppLabels(dpa), map[string]string{
+				"app.kubernetes.io/component": backupScheduleComponent,
+				backupScheduleLabel:           backupSchedule.Name,
+			})
+			template := *backupSchedule.Template.DeepCopy()
+			template.TTL = metav1.Duration{Duration: ttl}
+			// keep the backup storage location the Schedule was repointed to by spec.backupLocationFailover
+			if from, found := schedule.Annotations[common.FailoverFromAnnotation]; found && from == template.StorageLocation {
+				template.StorageLocation = schedule.Spec.Template.StorageLocation
+			}
+			schedule.Spec.Schedule = cron
+			schedule.Spec.Template = template
+			schedule.Spec.Paused = backupSchedule.Paused
+			return controllerutil.SetControllerReference(dpa, schedule, r.Scheme)
+		})
+		if err != nil {
+			return false, fmt.Errorf("unable to reconcile Schedule %s: %w", name, err)
+		}
+		if op == controllerutil.OperationResultCreated || op == controllerutil.OperationResultUpdated {
+			r.EventRecorder.Event(schedule,
+				corev1.EventTypeNormal,
+				"BackupScheduleReconciled",
+				fmt.Sprintf("performed %s on schedule %s/%s", op, schedule.Namespace, schedule.Name),
+			)
+		}
+		tiers, err := r.pruneBackupSchedule(log, name, backupSchedule.Retention)
+		if err != nil {
+			return false, err
+		}
+		scheduleNames = append(scheduleNames, name)
+		statuses = append(statuses, oadpv1alpha1.BackupScheduleStatus{
+			Name:     backupSchedule.Name,
+			Schedule: name,
+			Tiers:    tiers,
+		})
+	}
+	if len(statuses) == 0 {
+		statuses = nil
+	}
+	dpa.Status.Schedules = statuses
+
+	return true, r.deleteStaleBackupSchedules(scheduleNames)
+}
+
+// getBackupTime returns when a Backup started, or was created when it did not start
+func getBackupTime(backup *velerov1.Backup) time.Time {
+	if backup.Status.StartTimestamp != nil {
+		return backup.Status.StartTimestamp.Time
+	}
+	return backup.CreationTimestamp.Time
+}
+
+// pruneBackupSchedule retains, in each tier kept, the most recent completed Backup of the Velero Schedule name in
+// each of the last retain periods of the tier, and creates a DeleteBackupRequest for the completed Backups no tier
+// retains. The other finalized Backups are pruned once older than the oldest Backup retained by the shortest tier,
+// when it retains as many Backups as its retention. It returns the Backups retained by each tier, most recent first.
+func (r *DataProtectionApplicationReconciler) pruneBackupSchedule(log logr.Logger, name string, retention oadpv1alpha1.BackupScheduleRetention) ([]oadpv1alpha1.BackupScheduleTierStatus, error) {
+	backups := &velerov1.BackupList{}
+	if err := r.List(r.Context, backups, client.InNamespace(r.dpa.Namespace), client.MatchingLabels{
+		velerov1.ScheduleNameLabel: label.GetValidName(name),
+	}); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(backups.Items, func(a, b velerov1.Backup) int {
+		return getBackupTime(&b).Compare(getBackupTime(&a))
+	})
+	tiers := []oadpv1alpha1.BackupScheduleTierStatus{}
+	retained := map[string]bool{}
+	// finalized Backups older than failedBefore are pruned, nothing is pruned while it is zero
+	var failedBefore time.Time
+	for _, tier := range backupScheduleTiers {
+		retain := tier.retain(retention)
+		if retain <= 0 {
+			continue
+		}
+		status := oadpv1alpha1.BackupScheduleTierStatus{Tier: tier.tier, RetainedBackups: []string{}}
+		var period, oldest time.Time
+		for i := range backups.Items {
+			backup := &backups.Items[i]
+			if backup.Status.Phase != velerov1.BackupPhaseCompleted {
+				continue
+			}
+			// the Backups are sorted from the most recent, the first Backup of a period is its most recent
+			start := tier.start(getBackupTime(backup))
+			if len(status.RetainedBackups) > 0 && !start.Before(period) {
+				continue
+			}
+			period, oldest = start, getBackupTime(backup)
+			status.RetainedBackups = append(status.RetainedBackups, backup.Name)
+			retained[backup.Name] = true
+			if len(status.RetainedBackups) == int(retain) {
+				break
+			}
+		}
+		if len(tiers) == 0 && len(status.RetainedBackups) == int(retain) {
+			failedBefore = oldest
+		}
+		tiers = append(tiers, status)
+	}
+
+	deleteRequests := &velerov1.DeleteBackupRequestList{}
+	if err := r.List(r.Context, deleteRequests, client.InNamespace(r.dpa.Namespace)); err != nil {
+		return nil, err
+	}
+	deleting := map[string]bool{}
+	for _, deleteRequest := range deleteRequests.Items {
+		deleting[deleteRequest.Spec.BackupName] = true
+	}
+	pruned := []string{}
+	for i := range backups.Items {
+		backup := &backups.Items[i]
+		if retained[backup.Name] || deleting[backup.Name] || !slices.Contains(finalizedBackupPhases, backup.Status.Phase) {
+			continue
+		}
+		if backup.Status.Phase != velerov1.BackupPhaseCompleted && !getBackupTime(backup).Before(failedBefore) {
+			continue
+		}
+		deleteRequest := &velerov1.DeleteBackupRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: backup.Name + "-",
+				Namespace:    r.dpa.Namespace,
+				Labels: map[string]string{
+					velerov1.BackupNameLabel:       label.GetValidName(backup.Name),
+					velerov1.BackupUIDLabel:        string(backup.UID),
+					"app.kubernetes.io/managed-by": common.OADPOperator,
+					"app.kubernetes.io/component":  backupScheduleComponent,
+				},
+			},
+			Spec: velerov1.DeleteBackupRequestSpec{BackupName: backup.Name},
+		}
+		if err := r.Create(r.Context, deleteRequest); err != nil {
+			return nil, fmt.Errorf("unable to request the deletion of Backup %s of Schedule %s: %w", backup.Name, name, err)
+		}
+		pruned = append(pruned, backup.Name)
+	}
+	if len(pruned) > 0 {
+		log.Info("pruning Backups not retained by the tiers of the Schedule", "schedule", name, "backups", pruned)
+		r.EventRecorder.Event(r.dpa, corev1.EventTypeNormal, "BackupSchedulePruned",
+			fmt.Sprintf("requested the deletion of Backups %s of Schedule %s, not retained by its tiers", strings.Join(pruned, ", "), name))
+	}
+	return tiers, nil
+}
+
+// deleteStaleBackupSchedules deletes the Velero Schedules of spec.schedules owned by the DPA that are not in
+// scheduleNames, the Schedules of removed schedules
+func (r *DataProtectionApplicationReconciler) deleteStaleBackupSchedules(scheduleNames []string) error {
+	schedules := &velerov1.ScheduleList{}
+	if err := r.List(r.Context, schedules, client.InNamespace(r.dpa.Namespace), client.MatchingLabels{
+		"app.kubernetes.io/managed-by": common.OADPOperator,
+		"app.kubernetes.io/component":  backupScheduleComponent,
+	}); err != nil {
+		return err
+	}
+	for i := range schedules.Items {
+		schedule := &schedules.Items[i]
+		if slices.Contains(scheduleNames, schedule.Name) || !metav1.IsControlledBy(schedule, r.dpa) {
+			continue
+		}
+		if err := r.Delete(r.Context, schedule); err != nil && !k8serror.IsNotFound(err) {
+			return err
+		}
+		r.EventRecorder.Event(r.dpa,
+			corev1.EventTypeNormal,
+			"BackupScheduleDeleted",
+			fmt.Sprintf("deleted schedule %s/%s no longer in spec.schedules", schedule.Namespace, schedule.Name),
+		)
+	}
+	return nil
+}
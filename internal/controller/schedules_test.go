@@ -0,0 +1,253 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func TestValidateBackupSchedules(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      oadpv1alpha1.DataProtectionApplicationMode
+		schedules []oadpv1alpha1.BackupSchedule
+		wantErr   bool
+	}{
+		{
+			name: "no schedules",
+		},
+		{
+			name: "schedules",
+			schedules: []oadpv1alpha1.BackupSchedule{
+				{Name: "apps", Retention: oadpv1alpha1.BackupScheduleRetention{Daily: 7, Weekly: 4}},
+				{Name: "cluster", Retention: oadpv1alpha1.BackupScheduleRetention{Yearly: 1}},
+			},
+		},
+		{
+			name:      "schedules in RestoreOnly mode",
+			mode:      oadpv1alpha1.DataProtectionApplicationModeRestoreOnly,
+			schedules: []oadpv1alpha1.BackupSchedule{{Name: "apps", Retention: oadpv1alpha1.BackupScheduleRetention{Daily: 7}}},
+			wantErr:   true,
+		},
+		{
+			name: "duplicated name",
+			schedules: []oadpv1alpha1.BackupSchedule{
+				{Name: "apps", Retention: oadpv1alpha1.BackupScheduleRetention{Daily: 7}},
+				{Name: "apps", Retention: oadpv1alpha1.BackupScheduleRetention{Weekly: 4}},
+			},
+			wantErr: true,
+		},
+		{
+			name:      "no Backups kept",
+			schedules: []oadpv1alpha1.BackupSchedule{{Name: "apps"}},
+			wantErr:   true,
+		},
+		{
+			name:      "negative retention",
+			schedules: []oadpv1alpha1.BackupSchedule{{Name: "apps", Retention: oadpv1alpha1.BackupScheduleRetention{Daily: 7, Hourly: -1}}},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{Mode: tt.mode, Schedules: tt.schedules})
+			if err := validateBackupSchedules(dpa); (err != nil) != tt.wantErr {
+				t.Errorf("validateBackupSchedules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_ReconcileBackupSchedules(t *testing.T) {
+	ownerReference := metav1.OwnerReference{
+		APIVersion: oadpv1alpha1.GroupVersion.String(),
+		Kind:       "DataProtectionApplication",
+		Name:       testDpaName,
+		UID:        "test-uid",
+		Controller: ptr.To(true),
+	}
+	appsSchedule := testDpaName + "-apps"
+	backup := func(name string, phase velerov1.BackupPhase, year int, month time.Month, day int) *velerov1.Backup {
+		return &velerov1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "test-ns",
+				UID:       types.UID(name + "-uid"),
+				Labels:    map[string]string{velerov1.ScheduleNameLabel: appsSchedule},
+			},
+			Status: velerov1.BackupStatus{
+				Phase:          phase,
+				StartTimestamp: &metav1.Time{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)},
+			},
+		}
+	}
+	objects := []client.Object{
+		// Schedule of a tier, from the previous versions of the operator
+		&velerov1.Schedule{ObjectMeta: metav1.ObjectMeta{
+			Name:            testDpaName + "-apps-weekly",
+			Namespace:       "test-ns",
+			Labels:          map[string]string{"app.kubernetes.io/managed-by": common.OADPOperator, "app.kubernetes.io/component": backupScheduleComponent},
+			OwnerReferences: []metav1.OwnerReference{ownerReference},
+		}},
+		// repointed to the secondary backup storage location
+		&velerov1.Schedule{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        appsSchedule,
+				Namespace:   "test-ns",
+				Annotations: map[string]string{common.FailoverFromAnnotation: "primary"},
+			},
+			Spec: velerov1.ScheduleSpec{Template: velerov1.BackupSpec{StorageLocation: "secondary"}},
+		},
+		&velerov1.Schedule{ObjectMeta: metav1.ObjectMeta{Name: "user", Namespace: "test-ns"}},
+		backup("backup-20260102", velerov1.BackupPhaseFailed, 2026, time.January, 2),
+		// a Thursday, the Backup of the day, the week, the month and the year
+		backup("backup-20260101", velerov1.BackupPhaseCompleted, 2026, time.January, 1),
+		backup("backup-20251231", velerov1.BackupPhasePartiallyFailed, 2025, time.December, 31),
+		backup("backup-20251230", velerov1.BackupPhaseCompleted, 2025, time.December, 30),
+		backup("backup-20251229", velerov1.BackupPhaseCompleted, 2025, time.December, 29),
+		backup("backup-20251228", velerov1.BackupPhaseFailed, 2025, time.December, 28),
+		backup("backup-20251227", velerov1.BackupPhaseCompleted, 2025, time.December, 27),
+		backup("backup-20251220", velerov1.BackupPhaseCompleted, 2025, time.December, 20),
+		backup("backup-20251130", velerov1.BackupPhaseCompleted, 2025, time.November, 30),
+		backup("backup-20250101", velerov1.BackupPhaseCompleted, 2025, time.January, 1),
+		backup("backup-20241231", velerov1.BackupPhaseCompleted, 2024, time.December, 31),
+		&velerov1.Backup{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "test-ns", Labels: map[string]string{velerov1.ScheduleNameLabel: "user"}}},
+		&velerov1.DeleteBackupRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup-20251228-abcde", Namespace: "test-ns"},
+			Spec:       velerov1.DeleteBackupRequestSpec{BackupName: "backup-20251228"},
+		},
+	}
+	dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{
+		Schedules: []oadpv1alpha1.BackupSchedule{{
+			Name: "apps",
+			Template: velerov1.BackupSpec{
+				IncludedNamespaces: []string{"app"},
+				StorageLocation:    "primary",
+			},
+			Retention: oadpv1alpha1.BackupScheduleRetention{Daily: 3, Weekly: 2, Monthly: 2, Yearly: 2},
+		}},
+	})
+	dpa.UID = "test-uid"
+	fakeClient, err := getFakeClientFromObjects(append(objects, dpa)...)
+	if err != nil {
+		t.Fatalf("error in creating fake client, likely programmer error")
+	}
+	r := &DataProtectionApplicationReconciler{
+		Client:         fakeClient,
+		Scheme:         fakeClient.Scheme(),
+		Log:            logr.Discard(),
+		Context:        newContextForTest(),
+		NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+		EventRecorder:  record.NewFakeRecorder(10),
+		dpa:            dpa,
+	}
+	if _, err := r.ReconcileBackupSchedules(r.Log); err != nil {
+		t.Fatalf("ReconcileBackupSchedules() error = %v", err)
+	}
+
+	// a single Velero Schedule takes the Backups of all the tiers, every day for the daily tier
+	schedules := &velerov1.ScheduleList{}
+	if err := fakeClient.List(r.Context, schedules, client.InNamespace("test-ns")); err != nil {
+		t.Fatal(err)
+	}
+	scheduleNames := []string{}
+	for _, schedule := range schedules.Items {
+		scheduleNames = append(scheduleNames, schedule.Name)
+	}
+	if want := []string{appsSchedule, "user"}; !reflect.DeepEqual(scheduleNames, want) {
+		t.Errorf("expected Schedules %v, got %v", want, scheduleNames)
+	}
+	schedule := &velerov1.Schedule{}
+	if err := fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: appsSchedule}, schedule); err != nil {
+		t.Fatalf("expected Schedule %s: %v", appsSchedule, err)
+	}
+	wantSpec := velerov1.ScheduleSpec{
+		Schedule: "0 0 * * *",
+		Template: velerov1.BackupSpec{
+			IncludedNamespaces: []string{"app"},
+			StorageLocation:    "secondary",
+			TTL:                metav1.Duration{Duration: 3 * 366 * 24 * time.Hour},
+		},
+	}
+	if !reflect.DeepEqual(schedule.Spec, wantSpec) {
+		t.Errorf("expected Schedule %s spec %#v, got %#v", appsSchedule, wantSpec, schedule.Spec)
+	}
+	if !metav1.IsControlledBy(schedule, dpa) || schedule.Labels[backupScheduleLabel] != "apps" {
+		t.Errorf("expected Schedule %s to be owned by the DPA and labelled with its schedule, got %v", appsSchedule, schedule.Labels)
+	}
+
+	deleteRequests := &velerov1.DeleteBackupRequestList{}
+	if err := fakeClient.List(r.Context, deleteRequests, client.InNamespace("test-ns")); err != nil {
+		t.Fatal(err)
+	}
+	deleted := map[string]bool{}
+	for _, deleteRequest := range deleteRequests.Items {
+		deleted[deleteRequest.Spec.BackupName] = true
+		if deleteRequest.Spec.BackupName == "backup-20251220" && deleteRequest.Labels[velerov1.BackupUIDLabel] != "backup-20251220-uid" {
+			t.Errorf("expected DeleteBackupRequest of backup-20251220 labelled with its uid, got %v", deleteRequest.Labels)
+		}
+	}
+	wantDeleted := map[string]bool{
+		"backup-20251228": true,
+		"backup-20251220": true,
+		"backup-20251130": true,
+		"backup-20250101": true,
+		"backup-20241231": true,
+	}
+	if len(deleteRequests.Items) != len(wantDeleted) || !reflect.DeepEqual(deleted, wantDeleted) {
+		t.Errorf("expected DeleteBackupRequests of %v, got %v", wantDeleted, deleteRequests.Items)
+	}
+
+	wantStatus := []oadpv1alpha1.BackupScheduleStatus{{
+		Name:     "apps",
+		Schedule: appsSchedule,
+		Tiers: []oadpv1alpha1.BackupScheduleTierStatus{
+			{Tier: oadpv1alpha1.BackupScheduleTierDaily, RetainedBackups: []string{"backup-20260101", "backup-20251230", "backup-20251229"}},
+			{Tier: oadpv1alpha1.BackupScheduleTierWeekly, RetainedBackups: []string{"backup-20260101", "backup-20251227"}},
+			{Tier: oadpv1alpha1.BackupScheduleTierMonthly, RetainedBackups: []string{"backup-20260101", "backup-20251230"}},
+			{Tier: oadpv1alpha1.BackupScheduleTierYearly, RetainedBackups: []string{"backup-20260101", "backup-20251230"}},
+		},
+	}}
+	if !reflect.DeepEqual(dpa.Status.Schedules, wantStatus) {
+		t.Errorf("expected status.schedules %#v, got %#v", wantStatus, dpa.Status.Schedules)
+	}
+
+	// the shortest tier kept sets the cron of the Velero Schedule
+	dpa.Spec.Schedules[0].Retention = oadpv1alpha1.BackupScheduleRetention{Hourly: 24, Weekly: 4}
+	if _, err := r.ReconcileBackupSchedules(r.Log); err != nil {
+		t.Fatalf("ReconcileBackupSchedules() error = %v", err)
+	}
+	if err := fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: appsSchedule}, schedule); err != nil {
+		t.Fatalf("expected Schedule %s: %v", appsSchedule, err)
+	}
+	if schedule.Spec.Schedule != "0 * * * *" || schedule.Spec.Template.TTL.Duration != 5*7*24*time.Hour {
+		t.Errorf("expected Schedule %s hourly with a ttl of 5 weeks, got %s %s", appsSchedule, schedule.Spec.Schedule, schedule.Spec.Template.TTL.Duration)
+	}
+
+	// removing the schedules deletes their Velero Schedules
+	dpa.Spec.Schedules = nil
+	if _, err := r.ReconcileBackupSchedules(r.Log); err != nil {
+		t.Fatalf("ReconcileBackupSchedules() error = %v", err)
+	}
+	if err := fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: appsSchedule}, &velerov1.Schedule{}); !k8serror.IsNotFound(err) {
+		t.Errorf("expected the Schedule of the removed schedule to be deleted, got %v", err)
+	}
+	if err := fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: "user"}, &velerov1.Schedule{}); err != nil {
+		t.Errorf("expected the user Schedule to be kept, got %v", err)
+	}
+	if dpa.Status.Schedules != nil {
+		t.Errorf("expected no status.schedules, got %v", dpa.Status.Schedules)
+	}
+}
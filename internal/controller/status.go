@@ -0,0 +1,321 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+// UpdateComponentStatus reads back the objects created by the DPA and
+// reflects their observed state in the DPA status, so that users do not
+// need to inspect each object kind to know whether OADP is healthy.
+// Errors reading an object are returned, but every component is still
+// attempted so a single failure does not hide the rest of the status.
+func (r *DataProtectionApplicationReconciler) UpdateComponentStatus(log logr.Logger) error {
+	var errs []string
+	for _, update := range []func() error{
+		r.updateVeleroStatus,
+		r.updateNodeAgentStatus,
+		r.updateNonAdminStatus,
+		r.updateBackupLocationsStatus,
+		r.updateSnapshotLocationsStatus,
+	} {
+		if err := update(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("unable to update DPA component status: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (r *DataProtectionApplicationReconciler) updateVeleroStatus() error {
+	veleroDeployment := &appsv1.Deployment{}
+	err := r.Get(r.Context, types.NamespacedName{Name: common.Velero, Namespace: r.NamespacedName.Namespace}, veleroDeployment)
+	if err != nil {
+		if !k8serror.IsNotFound(err) {
+			return err
+		}
+		r.dpa.Status.Velero = nil
+		r.dpa.Status.Plugins = nil
+		apimeta.SetStatusCondition(&r.dpa.Status.Conditions,
+			metav1.Condition{
+				Type:    oadpv1alpha1.ConditionVeleroReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  oadpv1alpha1.ComponentReasonNotFound,
+				Message: fmt.Sprintf("Velero deployment %s/%s not found", r.NamespacedName.Namespace, common.Velero),
+			},
+		)
+		return nil
+	}
+
+	r.dpa.Status.Velero = getDeploymentComponentStatus(veleroDeployment, common.Velero)
+	r.dpa.Status.Plugins = getPluginsStatus(veleroDeployment)
+	apimeta.SetStatusCondition(&r.dpa.Status.Conditions, getDeploymentReadyCondition(oadpv1alpha1.ConditionVeleroReady, veleroDeployment))
+	return nil
+}
+
+func (r *DataProtectionApplicationReconciler) updateNodeAgentStatus() error {
+	if !isNodeAgentEnabled(r.dpa) {
+		r.dpa.Status.NodeAgent = nil
+		apimeta.RemoveStatusCondition(&r.dpa.Status.Conditions, oadpv1alpha1.ConditionNodeAgentReady)
+		return nil
+	}
+
+	ds := &appsv1.DaemonSet{}
+	err := r.Get(r.Context, types.NamespacedName{Name: common.NodeAgent, Namespace: r.NamespacedName.Namespace}, ds)
+	if err != nil {
+		if !k8serror.IsNotFound(err) {
+			return err
+		}
+		r.dpa.Status.NodeAgent = nil
+		apimeta.SetStatusCondition(&r.dpa.Status.Conditions,
+			metav1.Condition{
+				Type:    oadpv1alpha1.ConditionNodeAgentReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  oadpv1alpha1.ComponentReasonNotFound,
+				Message: fmt.Sprintf("NodeAgent daemonset %s/%s not found", r.NamespacedName.Namespace, common.NodeAgent),
+			},
+		)
+		return nil
+	}
+
+	r.dpa.Status.NodeAgent = &oadpv1alpha1.NodeAgentStatus{
+		Image:                  getContainerImage(ds.Spec.Template.Spec.Containers, common.NodeAgent),
+		DesiredNumberScheduled: ds.Status.DesiredNumberScheduled,
+		NumberReady:            ds.Status.NumberReady,
+	}
+	condition := metav1.Condition{
+		Type:    oadpv1alpha1.ConditionNodeAgentReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  oadpv1alpha1.ComponentReasonReady,
+		Message: fmt.Sprintf("%d/%d node-agent pods are ready", ds.Status.NumberReady, ds.Status.DesiredNumberScheduled),
+	}
+	if ds.Status.ObservedGeneration < ds.Generation || ds.Status.NumberReady < ds.Status.DesiredNumberScheduled {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = oadpv1alpha1.ComponentReasonNotReady
+	}
+	apimeta.SetStatusCondition(&r.dpa.Status.Conditions, condition)
+	return nil
+}
+
+func (r *DataProtectionApplicationReconciler) updateNonAdminStatus() error {
+	if !r.checkNonAdminEnabled() {
+		r.dpa.Status.NonAdmin = nil
+		apimeta.RemoveStatusCondition(&r.dpa.Status.Conditions, oadpv1alpha1.ConditionNonAdminReady)
+		return nil
+	}
+
+	nonAdminDeployment := &appsv1.Deployment{}
+	err := r.Get(r.Context, types.NamespacedName{Name: nonAdminObjectName, Namespace: r.NamespacedName.Namespace}, nonAdminDeployment)
+	if err != nil {
+		if !k8serror.IsNotFound(err) {
+			return err
+		}
+		r.dpa.Status.NonAdmin = nil
+		apimeta.SetStatusCondition(&r.dpa.Status.Conditions,
+			metav1.Condition{
+				Type:    oadpv1alpha1.ConditionNonAdminReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  oadpv1alpha1.ComponentReasonNotFound,
+				Message: fmt.Sprintf("Non-Admin controller deployment %s/%s not found", r.NamespacedName.Namespace, nonAdminObjectName),
+			},
+		)
+		return nil
+	}
+
+	r.dpa.Status.NonAdmin = getDeploymentComponentStatus(nonAdminDeployment, nonAdminObjectName)
+	apimeta.SetStatusCondition(&r.dpa.Status.Conditions, getDeploymentReadyCondition(oadpv1alpha1.ConditionNonAdminReady, nonAdminDeployment))
+	return nil
+}
+
+func (r *DataProtectionApplicationReconciler) updateBackupLocationsStatus() error {
+	bsls := velerov1.BackupStorageLocationList{}
+	err := r.List(r.Context, &bsls, client.InNamespace(r.NamespacedName.Namespace), client.MatchingLabels(map[string]string{
+		"app.kubernetes.io/name":       common.OADPOperatorVelero,
+		"app.kubernetes.io/managed-by": common.OADPOperator,
+		"app.kubernetes.io/component":  "bsl",
+	}))
+	if err != nil {
+		return err
+	}
+	sort.Slice(bsls.Items, func(i, j int) bool { return bsls.Items[i].Name < bsls.Items[j].Name })
+
+	previousStatus := map[string]oadpv1alpha1.BackupStorageLocationStatus{}
+	for _, status := range r.dpa.Status.BackupLocations {
+		previousStatus[status.Name] = status
+	}
+	r.dpa.Status.BackupLocations = nil
+	backupStorageLocationAvailable.Reset()
+	var unavailable, pending []string
+	for _, bsl := range bsls.Items {
+		if !metav1.IsControlledBy(&bsl, r.dpa) {
+			continue
+		}
+		updateBackupStorageLocationMetric(&bsl)
+		status := oadpv1alpha1.BackupStorageLocationStatus{
+			Name:               bsl.Name,
+			Default:            bsl.Spec.Default,
+			Phase:              bsl.Status.Phase,
+			LastValidationTime: bsl.Status.LastValidationTime,
+			Message:            bsl.Status.Message,
+		}
+		var previous *oadpv1alpha1.BackupStorageLocationStatus
+		if status, ok := previousStatus[bsl.Name]; ok {
+			previous = &status
+		}
+		switch bsl.Status.Phase {
+		case velerov1.BackupStorageLocationPhaseAvailable:
+		case velerov1.BackupStorageLocationPhaseUnavailable:
+			status.FailureReason, status.ProbeMessage = r.getBackupStorageLocationFailure(&bsl, previous)
+			unavailable = append(unavailable, fmt.Sprintf("%s (%s)", bsl.Name, status.FailureReason))
+		default:
+			pending = append(pending, bsl.Name)
+		}
+		r.recordBackupStorageLocationEvent(previous, status)
+		r.dpa.Status.BackupLocations = append(r.dpa.Status.BackupLocations, status)
+	}
+
+	if len(r.dpa.Status.BackupLocations) == 0 {
+		apimeta.RemoveStatusCondition(&r.dpa.Status.Conditions, oadpv1alpha1.ConditionBackupLocationsAvailable)
+		return nil
+	}
+	condition := metav1.Condition{
+		Type:    oadpv1alpha1.ConditionBackupLocationsAvailable,
+		Status:  metav1.ConditionTrue,
+		Reason:  oadpv1alpha1.ComponentReasonAvailable,
+		Message: "All backup storage locations are available",
+	}
+	if len(unavailable) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = oadpv1alpha1.ComponentReasonUnavailable
+		condition.Message = fmt.Sprintf("Backup storage locations unavailable: %s", strings.Join(unavailable, ", "))
+	} else if len(pending) > 0 {
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = oadpv1alpha1.ComponentReasonPending
+		condition.Message = fmt.Sprintf("Backup storage locations not yet validated: %s", strings.Join(pending, ", "))
+	}
+	apimeta.SetStatusCondition(&r.dpa.Status.Conditions, condition)
+	return nil
+}
+
+func (r *DataProtectionApplicationReconciler) updateSnapshotLocationsStatus() error {
+	vsls := velerov1.VolumeSnapshotLocationList{}
+	err := r.List(r.Context, &vsls, client.InNamespace(r.NamespacedName.Namespace), client.MatchingLabels(map[string]string{
+		"app.kubernetes.io/name":       common.OADPOperatorVelero,
+		"app.kubernetes.io/managed-by": common.OADPOperator,
+		"app.kubernetes.io/component":  "vsl",
+	}))
+	if err != nil {
+		return err
+	}
+	sort.Slice(vsls.Items, func(i, j int) bool { return vsls.Items[i].Name < vsls.Items[j].Name })
+
+	previousStatus := map[string]oadpv1alpha1.VolumeSnapshotLocationStatus{}
+	for _, status := range r.dpa.Status.SnapshotLocations {
+		previousStatus[status.Name] = status
+	}
+	r.dpa.Status.SnapshotLocations = nil
+	var notReady []string
+	for _, vsl := range vsls.Items {
+		if !metav1.IsControlledBy(&vsl, r.dpa) {
+			continue
+		}
+		var previous *oadpv1alpha1.VolumeSnapshotLocationStatus
+		if status, ok := previousStatus[vsl.Name]; ok {
+			previous = &status
+		}
+		status := r.getVolumeSnapshotLocationStatus(&vsl, previous)
+		if !status.Ready {
+			notReady = append(notReady, fmt.Sprintf("%s (%s)", vsl.Name, status.Message))
+		}
+		r.recordVolumeSnapshotLocationEvent(previous, status)
+		r.dpa.Status.SnapshotLocations = append(r.dpa.Status.SnapshotLocations, status)
+	}
+
+	if len(r.dpa.Status.SnapshotLocations) == 0 {
+		apimeta.RemoveStatusCondition(&r.dpa.Status.Conditions, oadpv1alpha1.ConditionSnapshotLocationsReady)
+		return nil
+	}
+	condition := metav1.Condition{
+		Type:    oadpv1alpha1.ConditionSnapshotLocationsReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  oadpv1alpha1.ComponentReasonReady,
+		Message: "All volume snapshot locations are ready",
+	}
+	if len(notReady) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = oadpv1alpha1.ComponentReasonNotReady
+		condition.Message = fmt.Sprintf("Volume snapshot locations not ready: %s", strings.Join(notReady, ", "))
+	}
+	apimeta.SetStatusCondition(&r.dpa.Status.Conditions, condition)
+	return nil
+}
+
+func getDeploymentComponentStatus(deployment *appsv1.Deployment, containerName string) *oadpv1alpha1.DeploymentComponentStatus {
+	status := &oadpv1alpha1.DeploymentComponentStatus{
+		Image:         getContainerImage(deployment.Spec.Template.Spec.Containers, containerName),
+		ReadyReplicas: deployment.Status.ReadyReplicas,
+	}
+	if deployment.Spec.Replicas != nil {
+		status.Replicas = *deployment.Spec.Replicas
+	}
+	return status
+}
+
+// getDeploymentReadyCondition returns a condition of conditionType which is
+// true when the deployment rolled out its latest generation and all of its
+// desired replicas are ready.
+func getDeploymentReadyCondition(conditionType string, deployment *appsv1.Deployment) metav1.Condition {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	condition := metav1.Condition{
+		Type:    conditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  oadpv1alpha1.ComponentReasonReady,
+		Message: fmt.Sprintf("%d/%d %s pods are ready", deployment.Status.ReadyReplicas, replicas, deployment.Name),
+	}
+	if deployment.Status.ObservedGeneration < deployment.Generation ||
+		deployment.Status.UpdatedReplicas < replicas ||
+		deployment.Status.ReadyReplicas < replicas {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = oadpv1alpha1.ComponentReasonNotReady
+	}
+	return condition
+}
+
+func getPluginsStatus(veleroDeployment *appsv1.Deployment) []oadpv1alpha1.PluginStatus {
+	var plugins []oadpv1alpha1.PluginStatus
+	for _, container := range veleroDeployment.Spec.Template.Spec.InitContainers {
+		plugins = append(plugins, oadpv1alpha1.PluginStatus{
+			Name:  container.Name,
+			Image: container.Image,
+		})
+	}
+	return plugins
+}
+
+func getContainerImage(containers []corev1.Container, name string) string {
+	for _, container := range containers {
+		if container.Name == name {
+			return container.Image
+		}
+	}
+	return ""
+}
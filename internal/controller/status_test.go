@@ -0,0 +1,254 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func createTestStatusDPA(nodeAgent bool, nonAdmin bool) *oadpv1alpha1.DataProtectionApplication {
+	return &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-dpa",
+			Namespace: "test-ns",
+			UID:       "test-dpa-uid",
+		},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{},
+				NodeAgent: &oadpv1alpha1.NodeAgentConfig{
+					NodeAgentCommonFields: oadpv1alpha1.NodeAgentCommonFields{
+						Enable: ptr.To(nodeAgent),
+					},
+					UploaderType: "kopia",
+				},
+			},
+			NonAdmin: &oadpv1alpha1.NonAdmin{
+				Enable: ptr.To(nonAdmin),
+			},
+		},
+	}
+}
+
+func createTestStatusDeployment(name string, container string, replicas int32, readyReplicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  "test-ns",
+			Generation: 1,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To(replicas),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{
+						{Name: common.VeleroPluginForAWS, Image: "quay.io/konveyor/velero-plugin-for-aws:test"},
+					},
+					Containers: []corev1.Container{
+						{Name: container, Image: "quay.io/konveyor/" + container + ":test"},
+					},
+				},
+			},
+		},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 1,
+			Replicas:           replicas,
+			UpdatedReplicas:    replicas,
+			ReadyReplicas:      readyReplicas,
+		},
+	}
+}
+
+func createTestStatusBSL(dpa *oadpv1alpha1.DataProtectionApplication, name string, phase velerov1.BackupStorageLocationPhase) *velerov1.BackupStorageLocation {
+	return &velerov1.BackupStorageLocation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "test-ns",
+			Labels: map[string]string{
+				"app.kubernetes.io/name":       common.OADPOperatorVelero,
+				"app.kubernetes.io/managed-by": common.OADPOperator,
+				"app.kubernetes.io/component":  "bsl",
+			},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: oadpv1alpha1.GroupVersion.String(),
+				Kind:       "DataProtectionApplication",
+				Name:       dpa.Name,
+				UID:        dpa.UID,
+				Controller: ptr.To(true),
+			}},
+		},
+		Spec: velerov1.BackupStorageLocationSpec{
+			Provider: AWSProvider,
+		},
+		Status: velerov1.BackupStorageLocationStatus{
+			Phase:   phase,
+			Message: string(phase),
+		},
+	}
+}
+
+func TestDPAReconciler_UpdateComponentStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		dpa            *oadpv1alpha1.DataProtectionApplication
+		objects        []client.Object
+		wantConditions map[string]metav1.ConditionStatus
+		wantVelero     *oadpv1alpha1.DeploymentComponentStatus
+		wantNodeAgent  *oadpv1alpha1.NodeAgentStatus
+		wantBSLs       int
+		wantPlugins    int
+	}{
+		{
+			name: "all components ready",
+			dpa:  createTestStatusDPA(true, true),
+			objects: []client.Object{
+				createTestStatusDeployment(common.Velero, common.Velero, 1, 1),
+				createTestStatusDeployment(nonAdminObjectName, nonAdminObjectName, 1, 1),
+				&appsv1.DaemonSet{
+					ObjectMeta: metav1.ObjectMeta{Name: common.NodeAgent, Namespace: "test-ns"},
+					Spec: appsv1.DaemonSetSpec{
+						Template: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{{Name: common.NodeAgent, Image: "quay.io/konveyor/velero:test"}},
+							},
+						},
+					},
+					Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, NumberReady: 3},
+				},
+			},
+			wantConditions: map[string]metav1.ConditionStatus{
+				oadpv1alpha1.ConditionVeleroReady:    metav1.ConditionTrue,
+				oadpv1alpha1.ConditionNodeAgentReady: metav1.ConditionTrue,
+				oadpv1alpha1.ConditionNonAdminReady:  metav1.ConditionTrue,
+			},
+			wantVelero: &oadpv1alpha1.DeploymentComponentStatus{
+				Image:         "quay.io/konveyor/velero:test",
+				Replicas:      1,
+				ReadyReplicas: 1,
+			},
+			wantNodeAgent: &oadpv1alpha1.NodeAgentStatus{
+				Image:                  "quay.io/konveyor/velero:test",
+				DesiredNumberScheduled: 3,
+				NumberReady:            3,
+			},
+			wantPlugins: 1,
+		},
+		{
+			name: "velero not ready, node agent missing and non-admin disabled",
+			dpa:  createTestStatusDPA(true, false),
+			objects: []client.Object{
+				createTestStatusDeployment(common.Velero, common.Velero, 1, 0),
+			},
+			wantConditions: map[string]metav1.ConditionStatus{
+				oadpv1alpha1.ConditionVeleroReady:    metav1.ConditionFalse,
+				oadpv1alpha1.ConditionNodeAgentReady: metav1.ConditionFalse,
+			},
+			wantVelero: &oadpv1alpha1.DeploymentComponentStatus{
+				Image:    "quay.io/konveyor/velero:test",
+				Replicas: 1,
+			},
+			wantPlugins: 1,
+		},
+		{
+			name: "velero missing and one BSL unavailable",
+			dpa:  createTestStatusDPA(false, false),
+			objects: []client.Object{
+				createTestStatusBSL(createTestStatusDPA(false, false), "test-dpa-1", velerov1.BackupStorageLocationPhaseAvailable),
+				createTestStatusBSL(createTestStatusDPA(false, false), "test-dpa-2", velerov1.BackupStorageLocationPhaseUnavailable),
+			},
+			wantConditions: map[string]metav1.ConditionStatus{
+				oadpv1alpha1.ConditionVeleroReady:              metav1.ConditionFalse,
+				oadpv1alpha1.ConditionBackupLocationsAvailable: metav1.ConditionFalse,
+			},
+			wantBSLs: 2,
+		},
+		{
+			name: "BSL not yet validated",
+			dpa:  createTestStatusDPA(false, false),
+			objects: []client.Object{
+				createTestStatusDeployment(common.Velero, common.Velero, 1, 1),
+				createTestStatusBSL(createTestStatusDPA(false, false), "test-dpa-1", ""),
+			},
+			wantConditions: map[string]metav1.ConditionStatus{
+				oadpv1alpha1.ConditionVeleroReady:              metav1.ConditionTrue,
+				oadpv1alpha1.ConditionBackupLocationsAvailable: metav1.ConditionUnknown,
+			},
+			wantVelero: &oadpv1alpha1.DeploymentComponentStatus{
+				Image:         "quay.io/konveyor/velero:test",
+				Replicas:      1,
+				ReadyReplicas: 1,
+			},
+			wantBSLs:    1,
+			wantPlugins: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient, err := getFakeClientFromObjects(append(tt.objects, tt.dpa)...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:  fakeClient,
+				Scheme:  fakeClient.Scheme(),
+				Log:     logr.Discard(),
+				Context: newContextForTest(),
+				NamespacedName: types.NamespacedName{
+					Namespace: tt.dpa.Namespace,
+					Name:      tt.dpa.Name,
+				},
+				EventRecorder: record.NewFakeRecorder(10),
+				dpa:           tt.dpa,
+			}
+			if err := r.UpdateComponentStatus(r.Log); err != nil {
+				t.Errorf("UpdateComponentStatus() unexpected error = %v", err)
+			}
+			for _, conditionType := range []string{
+				oadpv1alpha1.ConditionVeleroReady,
+				oadpv1alpha1.ConditionNodeAgentReady,
+				oadpv1alpha1.ConditionNonAdminReady,
+				oadpv1alpha1.ConditionBackupLocationsAvailable,
+			} {
+				condition := apimeta.FindStatusCondition(r.dpa.Status.Conditions, conditionType)
+				wantStatus, want := tt.wantConditions[conditionType]
+				if !want {
+					if condition != nil {
+						t.Errorf("expected no %s condition, got %v", conditionType, condition)
+					}
+					continue
+				}
+				if condition == nil {
+					t.Errorf("expected %s condition to be set", conditionType)
+					continue
+				}
+				if condition.Status != wantStatus {
+					t.Errorf("expected %s condition status %s, got %s: %s", conditionType, wantStatus, condition.Status, condition.Message)
+				}
+			}
+			if (tt.wantVelero == nil) != (r.dpa.Status.Velero == nil) || (tt.wantVelero != nil && *tt.wantVelero != *r.dpa.Status.Velero) {
+				t.Errorf("expected velero status %v, got %v", tt.wantVelero, r.dpa.Status.Velero)
+			}
+			if (tt.wantNodeAgent == nil) != (r.dpa.Status.NodeAgent == nil) || (tt.wantNodeAgent != nil && *tt.wantNodeAgent != *r.dpa.Status.NodeAgent) {
+				t.Errorf("expected node agent status %v, got %v", tt.wantNodeAgent, r.dpa.Status.NodeAgent)
+			}
+			if len(r.dpa.Status.BackupLocations) != tt.wantBSLs {
+				t.Errorf("expected %d backup locations in status, got %v", tt.wantBSLs, r.dpa.Status.BackupLocations)
+			}
+			if len(r.dpa.Status.Plugins) != tt.wantPlugins {
+				t.Errorf("expected %d plugins in status, got %v", tt.wantPlugins, r.dpa.Status.Plugins)
+			}
+		})
+	}
+}
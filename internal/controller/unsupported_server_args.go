@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+	veleroserver "github.com/openshift/oadp-operator/pkg/velero/server"
+)
+
+// applyUnsupportedServerArgs overrides the container args with the unsupported server args ConfigMap configMapName,
+// and records the difference with the computed args and the invalid flags of the override.
+// serverType is common.Velero or common.NodeAgent.
+func (r *DataProtectionApplicationReconciler) applyUnsupportedServerArgs(container *corev1.Container, configMapName, serverType string) error {
+	unsupportedServerArgsCM := corev1.ConfigMap{}
+	if err := r.Get(r.Context, types.NamespacedName{Namespace: r.dpa.Namespace, Name: configMapName}, &unsupportedServerArgsCM); err != nil {
+		return err
+	}
+	computedArgs := slices.Clone(container.Args)
+	common.ApplyUnsupportedServerArgsOverride(container, unsupportedServerArgsCM, serverType)
+
+	status := oadpv1alpha1.UnsupportedServerArgsStatus{Component: serverType, ConfigMap: configMapName}
+	for _, arg := range container.Args {
+		if !slices.Contains(computedArgs, arg) {
+			status.Added = append(status.Added, arg)
+		}
+	}
+	for _, arg := range computedArgs {
+		if !slices.Contains(container.Args, arg) {
+			status.Removed = append(status.Removed, arg)
+		}
+	}
+	switch serverType {
+	case common.Velero:
+		status.InvalidFlags = veleroserver.InvalidVeleroServerArgs(container.Args)
+	case common.NodeAgent:
+		status.InvalidFlags = veleroserver.InvalidNodeAgentServerArgs(container.Args)
+	}
+	if len(status.InvalidFlags) == 0 {
+		status.InvalidFlags = nil
+	}
+
+	r.unsupportedServerArgs = slices.DeleteFunc(r.unsupportedServerArgs, func(existing oadpv1alpha1.UnsupportedServerArgsStatus) bool {
+		return existing.Component == serverType
+	})
+	r.unsupportedServerArgs = append(r.unsupportedServerArgs, status)
+	return nil
+}
+
+// ReconcileUnsupportedServerArgsStatus reports the server args overridden by the unsupported server args ConfigMaps
+// in the DPA status, and their unknown or malformed flags in the UnsupportedServerArgsValid condition.
+// Overrides with invalid flags are still applied, the condition explains why the server does not start.
+func (r *DataProtectionApplicationReconciler) ReconcileUnsupportedServerArgsStatus(log logr.Logger) (bool, error) {
+	r.dpa.Status.UnsupportedServerArgs = r.unsupportedServerArgs
+	if len(r.unsupportedServerArgs) == 0 {
+		apimeta.RemoveStatusCondition(&r.dpa.Status.Conditions, oadpv1alpha1.ConditionUnsupportedServerArgsValid)
+		return true, nil
+	}
+
+	condition := metav1.Condition{
+		Type:    oadpv1alpha1.ConditionUnsupportedServerArgsValid,
+		Status:  metav1.ConditionTrue,
+		Reason:  oadpv1alpha1.UnsupportedServerArgsReasonValid,
+		Message: "unsupported server args are known flags of the servers",
+	}
+	invalid := []string{}
+	for _, status := range r.unsupportedServerArgs {
+		if len(status.InvalidFlags) > 0 {
+			invalid = append(invalid, fmt.Sprintf("%s (ConfigMap %s): %s", status.Component, status.ConfigMap, strings.Join(status.InvalidFlags, ", ")))
+		}
+	}
+	if len(invalid) > 0 {
+		log.Info("unsupported server args have invalid flags", "invalid", invalid)
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = oadpv1alpha1.UnsupportedServerArgsReasonInvalidFlags
+		condition.Message = "unsupported server args have invalid flags, " + strings.Join(invalid, "; ")
+	}
+	apimeta.SetStatusCondition(&r.dpa.Status.Conditions, condition)
+	return true, nil
+}
+
+// configMapRequests maps a ConfigMap to the DPAs of its namespace referencing it as unsupported server args
+// ConfigMap, or to all the DPAs of its namespace for the plugin compatibility ConfigMap, so ConfigMap edits are applied
+func (r *DataProtectionApplicationReconciler) configMapRequests(ctx context.Context, object client.Object) []reconcile.Request {
+	dpaList := &oadpv1alpha1.DataProtectionApplicationList{}
+	if err := r.List(ctx, dpaList, client.InNamespace(object.GetNamespace())); err != nil {
+		r.Log.Error(err, "unable to list DataProtectionApplications for ConfigMap", "configMap", object.GetName())
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, dpa := range dpaList.Items {
+		if object.GetName() == common.PluginCompatibilityConfigMapName {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name}})
+			continue
+		}
+		for _, annotation := range []string{common.UnsupportedVeleroServerArgsAnnotation, common.UnsupportedNodeAgentServerArgsAnnotation} {
+			if dpa.Annotations[annotation] == object.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name}})
+				break
+			}
+		}
+	}
+	return requests
+}
@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func TestDPAReconciler_ReconcileUnsupportedServerArgsStatus(t *testing.T) {
+	unsupportedServerArgsCM := func(name string, data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespaceName},
+			Data:       data,
+		}
+	}
+	type override struct {
+		serverType    string
+		configMapName string
+		computedArgs  []string
+	}
+	tests := []struct {
+		name        string
+		overrides   []override
+		objects     []client.Object
+		wantArgs    map[string][]string
+		wantStatus  []oadpv1alpha1.UnsupportedServerArgsStatus
+		wantReason  string
+		wantMessage string
+		wantErr     bool
+	}{
+		{
+			name: "no override",
+		},
+		{
+			name: "valid Velero server args override",
+			overrides: []override{{
+				serverType:    common.Velero,
+				configMapName: "velero-args",
+				computedArgs:  []string{"server", "--features=EnableCSI", "--uploader-type=kopia"},
+			}},
+			objects: []client.Object{unsupportedServerArgsCM("velero-args", map[string]string{
+				"features":          "EnableCSI",
+				"fs-backup-timeout": "10h",
+			})},
+			wantArgs: map[string][]string{common.Velero: {"server", "--features=EnableCSI", "--fs-backup-timeout=10h"}},
+			wantStatus: []oadpv1alpha1.UnsupportedServerArgsStatus{{
+				Component: common.Velero,
+				ConfigMap: "velero-args",
+				Added:     []string{"--fs-backup-timeout=10h"},
+				Removed:   []string{"--uploader-type=kopia"},
+			}},
+			wantReason:  oadpv1alpha1.UnsupportedServerArgsReasonValid,
+			wantMessage: "unsupported server args are known flags of the servers",
+		},
+		{
+			name: "invalid node-agent server args override is applied and reported",
+			overrides: []override{
+				{
+					serverType:    common.Velero,
+					configMapName: "velero-args",
+					computedArgs:  []string{"server"},
+				},
+				{
+					serverType:    common.NodeAgent,
+					configMapName: "node-agent-args",
+					computedArgs:  []string{"node-agent", "server"},
+				},
+			},
+			objects: []client.Object{
+				unsupportedServerArgsCM("velero-args", map[string]string{}),
+				unsupportedServerArgsCM("node-agent-args", map[string]string{
+					"resource-timout": "10m",
+					"log-level":       "debug",
+				}),
+			},
+			wantArgs: map[string][]string{
+				common.Velero:    {"server"},
+				common.NodeAgent: {"node-agent", "server", "--log-level=debug", "--resource-timout=10m"},
+			},
+			wantStatus: []oadpv1alpha1.UnsupportedServerArgsStatus{
+				{
+					Component: common.Velero,
+					ConfigMap: "velero-args",
+				},
+				{
+					Component:    common.NodeAgent,
+					ConfigMap:    "node-agent-args",
+					Added:        []string{"--log-level=debug", "--resource-timout=10m"},
+					InvalidFlags: []string{"unknown flag: --resource-timout"},
+				},
+			},
+			wantReason:  oadpv1alpha1.UnsupportedServerArgsReasonInvalidFlags,
+			wantMessage: "unsupported server args have invalid flags, node-agent (ConfigMap node-agent-args): unknown flag: --resource-timout",
+		},
+		{
+			name: "missing ConfigMap",
+			overrides: []override{{
+				serverType:    common.Velero,
+				configMapName: "velero-args",
+				computedArgs:  []string{"server"},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{})
+			apimeta.SetStatusCondition(&dpa.Status.Conditions, metav1.Condition{
+				Type:   oadpv1alpha1.ConditionUnsupportedServerArgsValid,
+				Status: metav1.ConditionTrue,
+				Reason: oadpv1alpha1.UnsupportedServerArgsReasonValid,
+			})
+			fakeClient, err := getFakeClientFromObjects(append(tt.objects, dpa)...)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:  fakeClient,
+				Scheme:  fakeClient.Scheme(),
+				Log:     logr.Discard(),
+				Context: newContextForTest(),
+				dpa:     dpa,
+			}
+			for _, override := range tt.overrides {
+				container := &corev1.Container{Args: override.computedArgs}
+				err := r.applyUnsupportedServerArgs(container, override.configMapName, override.serverType)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("applyUnsupportedServerArgs() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if err != nil {
+					return
+				}
+				if !reflect.DeepEqual(container.Args, tt.wantArgs[override.serverType]) {
+					t.Errorf("expected %s args %v, got %v", override.serverType, tt.wantArgs[override.serverType], container.Args)
+				}
+			}
+			if _, err := r.ReconcileUnsupportedServerArgsStatus(r.Log); err != nil {
+				t.Fatalf("ReconcileUnsupportedServerArgsStatus() error = %v", err)
+			}
+			if !reflect.DeepEqual(dpa.Status.UnsupportedServerArgs, tt.wantStatus) {
+				t.Errorf("expected unsupported server args status %v, got %v", tt.wantStatus, dpa.Status.UnsupportedServerArgs)
+			}
+			condition := apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionUnsupportedServerArgsValid)
+			if len(tt.wantReason) == 0 {
+				if condition != nil {
+					t.Errorf("expected no %s condition, got %v", oadpv1alpha1.ConditionUnsupportedServerArgsValid, condition)
+				}
+				return
+			}
+			if condition == nil {
+				t.Fatalf("expected %s condition", oadpv1alpha1.ConditionUnsupportedServerArgsValid)
+			}
+			if condition.Reason != tt.wantReason || condition.Message != tt.wantMessage {
+				t.Errorf("expected condition %s %q, got %s %q", tt.wantReason, tt.wantMessage, condition.Reason, condition.Message)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_configMapRequests(t *testing.T) {
+	referencing := createTestDpaWith(map[string]string{common.UnsupportedNodeAgentServerArgsAnnotation: "node-agent-args"}, oadpv1alpha1.DataProtectionApplicationSpec{})
+	fakeClient, err := getFakeClientFromObjects(referencing)
+	if err != nil {
+		t.Errorf("error in creating fake client, likely programmer error")
+	}
+	r := &DataProtectionApplicationReconciler{Client: fakeClient, Log: logr.Discard()}
+
+	got := r.configMapRequests(newContextForTest(), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "node-agent-args", Namespace: testNamespaceName}})
+	want := []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: referencing.Namespace, Name: referencing.Name}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected requests %v, got %v", want, got)
+	}
+	if got := r.configMapRequests(newContextForTest(), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: common.PluginCompatibilityConfigMapName, Namespace: testNamespaceName}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected requests %v for the plugin compatibility ConfigMap, got %v", want, got)
+	}
+	if got := r.configMapRequests(newContextForTest(), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: testNamespaceName}}); len(got) != 0 {
+		t.Errorf("expected no requests, got %v", got)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
 	"github.com/openshift/oadp-operator/pkg/common"
 	"github.com/openshift/oadp-operator/pkg/credentials"
+	veleroserver "github.com/openshift/oadp-operator/pkg/velero/server"
 )
 
 const NACNonEnforceableErr = "DPA %s is non-enforceable by admins"
@@ -41,14 +42,14 @@ func (r *DataProtectionApplicationReconciler) ValidateDataProtectionCR(log logr.
 	}
 
 	if r.dpa.Spec.Configuration.Velero.NoDefaultBackupLocation {
-		if len(r.dpa.Spec.BackupLocations) != 0 {
+		if len(r.dpa.Spec.BackupLocations) != 0 || len(r.pendingBackupLocations) != 0 {
 			return false, errors.New("DPA CR Velero configuration cannot have backup locations if noDefaultBackupLocation is set")
 		}
 		if r.dpa.BackupImages() {
 			return false, errors.New("backupImages needs to be set to false when noDefaultBackupLocation is set")
 		}
 	} else {
-		if len(r.dpa.Spec.BackupLocations) == 0 {
+		if len(r.dpa.Spec.BackupLocations) == 0 && len(r.pendingBackupLocations) == 0 {
 			return false, errors.New("no backupstoragelocations configured, ensure a backupstoragelocation has been configured or use the noDefaultBackupLocation flag")
 		}
 	}
@@ -92,6 +93,19 @@ func (r *DataProtectionApplicationReconciler) ValidateDataProtectionCR(log logr.
 		}
 	}
 
+	if err := veleroserver.ValidateArgs(r.dpa); err != nil {
+		return false, err
+	}
+
+	// Ensure data path concurrency is set only once, either in node-agent server args or in loadConcurrency
+	if r.dpa.Spec.Configuration.NodeAgent != nil &&
+		r.dpa.Spec.Configuration.NodeAgent.Args != nil &&
+		r.dpa.Spec.Configuration.NodeAgent.Args.DataPathConcurrency != nil &&
+		r.dpa.Spec.Configuration.NodeAgent.LoadConcurrency != nil &&
+		r.dpa.Spec.Configuration.NodeAgent.LoadConcurrency.GlobalConfig != 0 {
+		return false, errors.New("spec.configuration.nodeAgent.args.data-path-concurrency and spec.configuration.nodeAgent.loadConcurrency.globalConfig must not be set together")
+	}
+
 	// ENSURE UPGRADES --------------------------------------------------------
 	// check for VSM/Volsync DataMover (OADP 1.2 or below) syntax
 	if r.dpa.Spec.Features != nil && r.dpa.Spec.Features.DataMover != nil {
@@ -135,6 +149,14 @@ func (r *DataProtectionApplicationReconciler) ValidateDataProtectionCR(log logr.
 		return false, err
 	}
 
+	if err := validateAvailability(r.dpa.Spec.Availability); err != nil {
+		return false, err
+	}
+
+	if err := validateBackupSchedules(r.dpa); err != nil {
+		return false, err
+	}
+
 	// validate non-admin enable
 	if r.dpa.Spec.NonAdmin != nil {
 		if r.dpa.Spec.NonAdmin.Enable != nil {
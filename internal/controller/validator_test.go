@@ -122,6 +122,67 @@ func TestDPAReconciler_ValidateDataProtectionCR(t *testing.T) {
 			wantErr:    true,
 			messageErr: "only mtc operator type override is supported",
 		},
+		{
+			name: "given valid DPA CR, velero server args conflict with velero config, error case",
+			dpa: &oadpv1alpha1.DataProtectionApplication{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-DPA-CR",
+					Namespace: "test-ns",
+				},
+				Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+					Configuration: &oadpv1alpha1.ApplicationConfig{
+						Velero: &oadpv1alpha1.VeleroConfig{
+							DefaultPlugins: []oadpv1alpha1.DefaultPlugin{
+								oadpv1alpha1.DefaultPluginAWS,
+							},
+							NoDefaultBackupLocation: true,
+							ClientBurst:             pointer.Int(10),
+							Args: &oadpv1alpha1.VeleroServerArgs{
+								ServerFlags: oadpv1alpha1.ServerFlags{
+									ClientBurst: pointer.Int(20),
+								},
+							},
+						},
+					},
+					BackupImages: pointer.Bool(false),
+				},
+			},
+			objects:    []client.Object{},
+			wantErr:    true,
+			messageErr: "spec.configuration.velero.args.client-burst conflicts with spec.configuration.velero.client-burst, set only one of them",
+		},
+		{
+			name: "given valid DPA CR, node-agent data path concurrency set in args and loadConcurrency, error case",
+			dpa: &oadpv1alpha1.DataProtectionApplication{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-DPA-CR",
+					Namespace: "test-ns",
+				},
+				Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+					Configuration: &oadpv1alpha1.ApplicationConfig{
+						Velero: &oadpv1alpha1.VeleroConfig{
+							DefaultPlugins: []oadpv1alpha1.DefaultPlugin{
+								oadpv1alpha1.DefaultPluginAWS,
+							},
+							NoDefaultBackupLocation: true,
+						},
+						NodeAgent: &oadpv1alpha1.NodeAgentConfig{
+							UploaderType: "kopia",
+							NodeAgentConfigMapSettings: oadpv1alpha1.NodeAgentConfigMapSettings{
+								LoadConcurrency: &oadpv1alpha1.LoadConcurrency{GlobalConfig: 2},
+							},
+							Args: &oadpv1alpha1.NodeAgentServerArgs{
+								DataPathConcurrency: pointer.Int(4),
+							},
+						},
+					},
+					BackupImages: pointer.Bool(false),
+				},
+			},
+			objects:    []client.Object{},
+			wantErr:    true,
+			messageErr: "spec.configuration.nodeAgent.args.data-path-concurrency and spec.configuration.nodeAgent.loadConcurrency.globalConfig must not be set together",
+		},
 		{
 			name: "given valid DPA CR, no default backup location, backup images cannot be nil, error case",
 			dpa: &oadpv1alpha1.DataProtectionApplication{
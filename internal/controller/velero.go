@@ -2,6 +2,7 @@ package controller
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"reflect"
 	"strconv"
@@ -39,8 +40,6 @@ const (
 	enableCSIFeatureFlag = "EnableCSI"
 	veleroIOPrefix       = "velero.io/"
 
-	VeleroReplicaOverride = "VELERO_DEBUG_REPLICAS_OVERRIDE"
-
 	defaultFsBackupTimeout = "4h"
 
 	TrueVal  = "true"
@@ -80,7 +79,7 @@ func (r *DataProtectionApplicationReconciler) ReconcileVeleroDeployment(log logr
 		},
 	}
 	var orig *appsv1.Deployment // for debugging purposes
-	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, veleroDeployment, func() error {
+	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, veleroDeployment, r.withDriftDetection(veleroDeployment, func() error {
 		if debugMode {
 			orig = veleroDeployment.DeepCopy() // for debugging purposes
 		}
@@ -91,15 +90,25 @@ func (r *DataProtectionApplicationReconciler) ReconcileVeleroDeployment(log logr
 			}
 		}
 
+		var currentTemplate *corev1.PodTemplateSpec
+		if !veleroDeployment.ObjectMeta.CreationTimestamp.IsZero() {
+			currentTemplate = veleroDeployment.Spec.Template.DeepCopy()
+		}
+
 		// update the Deployment template
 		err := r.buildVeleroDeployment(veleroDeployment)
 		if err != nil {
 			return err
 		}
+		if err := r.appendClusterProxyAndTrustedCA(&veleroDeployment.Spec.Template); err != nil {
+			return err
+		}
+		r.pinImages(&veleroDeployment.Spec.Template)
+		r.deferRollout(common.Velero, currentTemplate, &veleroDeployment.Spec.Template)
 
 		// Setting controller owner reference on the velero deployment
 		return controllerutil.SetControllerReference(dpa, veleroDeployment, r.Scheme)
-	})
+	}))
 	if debugMode && op != controllerutil.OperationResultNone { // for debugging purposes
 		fmt.Printf("DEBUG: There was a diff which resulted in an operation on Velero Deployment: %s\n", cmp.Diff(orig, veleroDeployment))
 	}
@@ -167,7 +176,10 @@ func (r *DataProtectionApplicationReconciler) buildVeleroDeployment(veleroDeploy
 	// get resource requirements for velero deployment
 	// ignoring err here as it is checked in validator.go
 	veleroResourceReqs, _ := r.getVeleroResourceReqs()
-	podAnnotations, err := common.AppendUniqueKeyTOfTMaps(dpa.Spec.PodAnnotations, veleroDeployment.Annotations)
+	// the desired state hash is bookkeeping of the Deployment, in the pod template it would roll out the pods on each change
+	deploymentAnnotations := maps.Clone(veleroDeployment.Annotations)
+	delete(deploymentAnnotations, desiredStateHashAnnotation)
+	podAnnotations, err := common.AppendUniqueKeyTOfTMaps(dpa.Spec.PodAnnotations, deploymentAnnotations)
 	if err != nil {
 		return fmt.Errorf("error appending pod annotations: %v", err)
 	}
@@ -239,14 +251,8 @@ func (r *DataProtectionApplicationReconciler) customizeVeleroDeployment(veleroDe
 		}
 	}
 
-	// Selector: veleroDeployment.Spec.Selector,
-	replicas := int32(1)
-	if value, present := os.LookupEnv(VeleroReplicaOverride); present {
-		if converted, err := strconv.Atoi(value); err == nil {
-			replicas = int32(converted)
-		}
-	}
-	veleroDeployment.Spec.Replicas = &replicas
+	// the Velero server has no leader election, it runs a single replica
+	veleroDeployment.Spec.Replicas = ptr.To(int32(1))
 	if dpa.Spec.Configuration.Velero.PodConfig != nil {
 		veleroDeployment.Spec.Template.Spec.Tolerations = dpa.Spec.Configuration.Velero.PodConfig.Tolerations
 		if len(dpa.Spec.Configuration.Velero.PodConfig.NodeSelector) != 0 {
@@ -263,6 +269,7 @@ func (r *DataProtectionApplicationReconciler) customizeVeleroDeployment(veleroDe
 		affinity := kube.ToSystemAffinity(veleroAffinityStruct)
 		veleroDeployment.Spec.Template.Spec.Affinity = affinity
 	}
+	applyPodConfig(&veleroDeployment.Spec.Template, dpa.Spec.Configuration.Velero.PodConfig)
 
 	veleroDeployment.Spec.Template.Spec.Volumes = append(veleroDeployment.Spec.Template.Spec.Volumes,
 		corev1.Volume{
@@ -397,6 +404,19 @@ func (r *DataProtectionApplicationReconciler) customizeVeleroDeployment(veleroDe
 		veleroContainer.Args = append(veleroContainer.Args, fmt.Sprintf("--item-block-worker-count=%v", dpa.Spec.Configuration.Velero.ItemBlockWorkerCount))
 	}
 
+	if dpa.IsRestoreOnly() {
+		veleroContainer.Args = append(veleroContainer.Args, fmt.Sprintf("--disable-controllers=%s", strings.Join(veleroserver.RestoreOnlyDisabledControllers, ",")))
+	}
+
+	// if server args is set, override the default server args,
+	// the ConfigMaps created by the operator are still passed below
+	if dpa.Spec.Configuration.Velero.Args != nil {
+		veleroContainer.Args, err = veleroserver.GetArgs(dpa)
+		if err != nil {
+			return err
+		}
+	}
+
 	// check for backup-repository-configmap parameter
 	if isBackupRepositoryCmRequired(dpa.Spec.Configuration.NodeAgent) {
 		// Add the --backup-repository-configmap parameter with the name
@@ -434,14 +454,15 @@ func (r *DataProtectionApplicationReconciler) customizeVeleroDeployment(veleroDe
 		veleroDeployment.Spec.ProgressDeadlineSeconds = ptr.To(int32(600))
 	}
 	r.appendPluginSpecificSpecs(veleroDeployment, veleroContainer, providerNeedsDefaultCreds)
+	if err := r.useDefaultBackupLocationCredentials(veleroDeployment.Spec.Template.Spec.Volumes); err != nil {
+		return err
+	}
 	setPodTemplateSpecDefaults(&veleroDeployment.Spec.Template)
 	if configMapName, ok := dpa.Annotations[common.UnsupportedVeleroServerArgsAnnotation]; ok {
 		if configMapName != "" {
-			unsupportedServerArgsCM := corev1.ConfigMap{}
-			if err := r.Get(r.Context, types.NamespacedName{Namespace: dpa.Namespace, Name: configMapName}, &unsupportedServerArgsCM); err != nil {
+			if err := r.applyUnsupportedServerArgs(veleroContainer, configMapName, common.Velero); err != nil {
 				return err
 			}
-			common.ApplyUnsupportedServerArgsOverride(veleroContainer, unsupportedServerArgsCM, common.Velero)
 		}
 	}
 
@@ -608,6 +629,9 @@ func (r *DataProtectionApplicationReconciler) customizeVeleroContainer(veleroCon
 		Privileged:               ptr.To(false),
 		AllowPrivilegeEscalation: ptr.To(false),
 	}
+	if dpa.Spec.Configuration != nil && dpa.Spec.Configuration.Velero != nil {
+		applyContainerPodConfig(veleroContainer, dpa.Spec.Configuration.Velero.PodConfig)
+	}
 
 	// append velero PodConfig envs to container
 	if dpa.Spec.Configuration != nil && dpa.Spec.Configuration.Velero != nil && dpa.Spec.Configuration.Velero.PodConfig != nil && dpa.Spec.Configuration.Velero.PodConfig.Env != nil {
@@ -652,14 +676,6 @@ func (r *DataProtectionApplicationReconciler) customizeVeleroContainer(veleroCon
 		veleroContainer.Args = append(veleroContainer.Args, fmt.Sprintf("--client-qps=%v", *dpa.Spec.Configuration.Velero.ClientQPS))
 	}
 	setContainerDefaults(veleroContainer)
-	// if server args is set, override the default server args
-	if dpa.Spec.Configuration.Velero.Args != nil {
-		var err error
-		veleroContainer.Args, err = veleroserver.GetArgs(dpa)
-		if err != nil {
-			return err
-		}
-	}
 	return nil
 }
 
@@ -866,6 +882,10 @@ func (r DataProtectionApplicationReconciler) noDefaultCredentials() (map[string]
 		}
 	}
 	for _, vsl := range dpa.Spec.SnapshotLocations {
+		// a restore only cluster does not create snapshots, VSLs without credential do not need the default credentials
+		if dpa.IsRestoreOnly() && vsl.Velero != nil && vsl.Velero.Credential == nil {
+			continue
+		}
 		if vsl.Velero != nil {
 			// To handle the case where we want to manually hand the credentials for a cloud storage created
 			// Bucket credentials via configuration. Only AWS is supported
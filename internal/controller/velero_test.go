@@ -37,7 +37,7 @@ import (
 const (
 	proxyEnvKey                      = "HTTP_PROXY"
 	proxyEnvValue                    = "http://proxy.example.com:8080"
-	argsMetricsPortTest              = 69420
+	argsMetricsPortTest              = 9420
 	defaultFileSystemBackupTimeout   = "--fs-backup-timeout=4h"
 	defaultRestoreResourcePriorities = "--restore-resource-priorities=securitycontextconstraints,customresourcedefinitions,klusterletconfigs.config.open-cluster-management.io,managedcluster.cluster.open-cluster-management.io,namespaces,roles,rolebindings,clusterrolebindings,klusterletaddonconfig.agent.open-cluster-management.io,managedclusteraddon.addon.open-cluster-management.io,storageclasses,volumesnapshotclass.snapshot.storage.k8s.io,volumesnapshotcontents.snapshot.storage.k8s.io,volumesnapshots.snapshot.storage.k8s.io,datauploads.velero.io,persistentvolumes,persistentvolumeclaims,serviceaccounts,secrets,configmaps,limitranges,pods,replicasets.apps,clusterclasses.cluster.x-k8s.io,endpoints,services,-,clusterbootstraps.run.tanzu.vmware.com,clusters.cluster.x-k8s.io,clusterresourcesets.addons.cluster.x-k8s.io"
 	defaultDisableInformerCache      = "--disable-informer-cache=false"
@@ -371,22 +371,27 @@ func createTestDpaWith(
 }
 
 type TestBuiltVeleroDeploymentOptions struct {
-	args             []string
-	customLabels     map[string]string
-	labels           map[string]string
-	annotations      map[string]string
-	metricsPort      int
-	initContainers   []corev1.Container
-	volumes          []corev1.Volume
-	volumeMounts     []corev1.VolumeMount
-	env              []corev1.EnvVar
-	dnsPolicy        corev1.DNSPolicy
-	dnsConfig        *corev1.PodDNSConfig
-	resourceLimits   corev1.ResourceList
-	resourceRequests corev1.ResourceList
-	toleration       []corev1.Toleration
-	nodeSelector     map[string]string
-	loadAffinity     []corev1.NodeSelectorTerm
+	args              []string
+	customLabels      map[string]string
+	labels            map[string]string
+	annotations       map[string]string
+	metricsPort       int
+	initContainers    []corev1.Container
+	volumes           []corev1.Volume
+	volumeMounts      []corev1.VolumeMount
+	env               []corev1.EnvVar
+	dnsPolicy         corev1.DNSPolicy
+	dnsConfig         *corev1.PodDNSConfig
+	resourceLimits    corev1.ResourceList
+	resourceRequests  corev1.ResourceList
+	toleration        []corev1.Toleration
+	nodeSelector      map[string]string
+	loadAffinity      []corev1.NodeSelectorTerm
+	podAntiAffinity   *corev1.PodAntiAffinity
+	priorityClass     string
+	topologySpread    []corev1.TopologySpreadConstraint
+	podSecurity       *corev1.PodSecurityContext
+	containerSecurity *corev1.SecurityContext
 }
 
 func createTestBuiltVeleroDeployment(options TestBuiltVeleroDeploymentOptions) *appsv1.Deployment {
@@ -513,6 +518,29 @@ func createTestBuiltVeleroDeployment(options TestBuiltVeleroDeploymentOptions) *
 		}
 	}
 
+	if options.podAntiAffinity != nil {
+		if testBuiltVeleroDeployment.Spec.Template.Spec.Affinity == nil {
+			testBuiltVeleroDeployment.Spec.Template.Spec.Affinity = &corev1.Affinity{}
+		}
+		testBuiltVeleroDeployment.Spec.Template.Spec.Affinity.PodAntiAffinity = options.podAntiAffinity
+	}
+
+	if len(options.priorityClass) > 0 {
+		testBuiltVeleroDeployment.Spec.Template.Spec.PriorityClassName = options.priorityClass
+	}
+
+	if options.topologySpread != nil {
+		testBuiltVeleroDeployment.Spec.Template.Spec.TopologySpreadConstraints = options.topologySpread
+	}
+
+	if options.podSecurity != nil {
+		testBuiltVeleroDeployment.Spec.Template.Spec.SecurityContext = options.podSecurity
+	}
+
+	if options.containerSecurity != nil {
+		testBuiltVeleroDeployment.Spec.Template.Spec.Containers[0].SecurityContext = options.containerSecurity
+	}
+
 	if options.metricsPort != 0 {
 		testBuiltVeleroDeployment.Spec.Template.Annotations = common.AppendTTMapAsCopy(testBuiltVeleroDeployment.Spec.Template.Annotations, map[string]string{"prometheus.io/port": strconv.Itoa(options.metricsPort)})
 		testBuiltVeleroDeployment.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort = int32(options.metricsPort)
@@ -1553,6 +1581,95 @@ func TestDPAReconciler_buildVeleroDeployment(t *testing.T) {
 				},
 			}),
 		},
+		{
+			name: "valid DPA CR with Velero scheduling and security PodConfig, Velero Deployment is built with them",
+			dpa: createTestDpaWith(
+				nil,
+				oadpv1alpha1.DataProtectionApplicationSpec{
+					PodAnnotations: map[string]string{"team": "backup", "tier": "default"},
+					Configuration: &oadpv1alpha1.ApplicationConfig{
+						Velero: &oadpv1alpha1.VeleroConfig{
+							PodConfig: &oadpv1alpha1.PodConfig{
+								PriorityClassName: "system-cluster-critical",
+								TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+									{
+										MaxSkew:           1,
+										TopologyKey:       "topology.kubernetes.io/zone",
+										WhenUnsatisfiable: corev1.ScheduleAnyway,
+									},
+								},
+								PodAntiAffinity: &corev1.PodAntiAffinity{
+									PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+										{
+											Weight: 100,
+											PodAffinityTerm: corev1.PodAffinityTerm{
+												TopologyKey: "kubernetes.io/hostname",
+											},
+										},
+									},
+								},
+								Annotations:        map[string]string{"tier": "velero"},
+								PodSecurityContext: &corev1.PodSecurityContext{RunAsNonRoot: ptr.To(true)},
+								ContainerSecurityContext: &corev1.SecurityContext{
+									ReadOnlyRootFilesystem: ptr.To(true),
+									Capabilities:           &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+								},
+							},
+							LoadAffinityConfig: []*oadpv1alpha1.LoadAffinity{
+								{
+									NodeSelector: metav1.LabelSelector{
+										MatchLabels: map[string]string{"foos": "bars"},
+									},
+								},
+							},
+						},
+					},
+				},
+			),
+			veleroDeployment: testVeleroDeployment.DeepCopy(),
+			wantVeleroDeployment: createTestBuiltVeleroDeployment(TestBuiltVeleroDeploymentOptions{
+				annotations: map[string]string{"team": "backup", "tier": "velero"},
+				loadAffinity: []corev1.NodeSelectorTerm{
+					{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{
+								Key:      "foos",
+								Operator: corev1.NodeSelectorOpIn,
+								Values:   []string{"bars"},
+							},
+						},
+					},
+				},
+				podAntiAffinity: &corev1.PodAntiAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+						{
+							Weight: 100,
+							PodAffinityTerm: corev1.PodAffinityTerm{
+								TopologyKey: "kubernetes.io/hostname",
+							},
+						},
+					},
+				},
+				priorityClass: "system-cluster-critical",
+				topologySpread: []corev1.TopologySpreadConstraint{
+					{
+						MaxSkew:           1,
+						TopologyKey:       "topology.kubernetes.io/zone",
+						WhenUnsatisfiable: corev1.ScheduleAnyway,
+					},
+				},
+				podSecurity: &corev1.PodSecurityContext{RunAsNonRoot: ptr.To(true)},
+				containerSecurity: &corev1.SecurityContext{
+					ReadOnlyRootFilesystem: ptr.To(true),
+					Capabilities:           &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				},
+				args: []string{
+					defaultFileSystemBackupTimeout,
+					defaultRestoreResourcePriorities,
+					defaultDisableInformerCache,
+				},
+			}),
+		},
 		{
 			name: "valid DPA CR with complex Velero loadAffinity, Velero Deployment is built with loadAffinity",
 			dpa: createTestDpaWith(
@@ -2013,8 +2130,6 @@ func TestDPAReconciler_buildVeleroDeployment(t *testing.T) {
 				oadpv1alpha1.DataProtectionApplicationSpec{
 					Configuration: &oadpv1alpha1.ApplicationConfig{
 						Velero: &oadpv1alpha1.VeleroConfig{
-							ClientBurst: ptr.To(123),
-							ClientQPS:   ptr.To(123),
 							Args: &oadpv1alpha1.VeleroServerArgs{
 								ServerFlags: oadpv1alpha1.ServerFlags{
 									ClientBurst: ptr.To(321),
@@ -2031,7 +2146,7 @@ func TestDPAReconciler_buildVeleroDeployment(t *testing.T) {
 			veleroDeployment: testVeleroDeployment.DeepCopy(),
 			wantVeleroDeployment: createTestBuiltVeleroDeployment(TestBuiltVeleroDeploymentOptions{
 				args: []string{
-					// should be present... "--uploader-type=kopia",
+					"--uploader-type=kopia",
 					"--client-burst=321",
 					"--client-qps=321",
 					"--fs-backup-timeout=4h0m0s",
@@ -2040,6 +2155,69 @@ func TestDPAReconciler_buildVeleroDeployment(t *testing.T) {
 				},
 			}),
 		},
+		{
+			name: "valid DPA CR with Velero Args and fields outside Args, Velero Deployment is built with both",
+			dpa: createTestDpaWith(
+				nil,
+				oadpv1alpha1.DataProtectionApplicationSpec{
+					LogFormat: oadpv1alpha1.LogFormatJSON,
+					Configuration: &oadpv1alpha1.ApplicationConfig{
+						Velero: &oadpv1alpha1.VeleroConfig{
+							LogLevel:                "debug",
+							ClientBurst:             ptr.To(123),
+							ClientQPS:               ptr.To(123),
+							DefaultSnapshotMoveData: ptr.To(true),
+							DisableInformerCache:    ptr.To(true),
+							ItemBlockWorkerCount:    2,
+							ResourceTimeout:         "20m",
+							Args: &oadpv1alpha1.VeleroServerArgs{
+								ServerFlags: oadpv1alpha1.ServerFlags{
+									ClientQPS:       ptr.To("123.0"),
+									ResourceTimeout: ptr.To(20 * time.Minute),
+								},
+							},
+						},
+					},
+				},
+			),
+			veleroDeployment: testVeleroDeployment.DeepCopy(),
+			wantVeleroDeployment: createTestBuiltVeleroDeployment(TestBuiltVeleroDeploymentOptions{
+				args: []string{
+					"--log-level=debug",
+					"--client-burst=123",
+					"--client-qps=123.0",
+					"--resource-timeout=20m0s",
+					"--log-format=json",
+					"--fs-backup-timeout=4h0m0s",
+					defaultRestoreResourcePriorities,
+					"--default-snapshot-move-data=true",
+					"--disable-informer-cache=true",
+					"--item-block-worker-count=2",
+				},
+			}),
+		},
+		{
+			name: "invalid DPA CR with Velero Args conflicting with fields outside Args, error is returned",
+			dpa: createTestDpaWith(
+				nil,
+				oadpv1alpha1.DataProtectionApplicationSpec{
+					Configuration: &oadpv1alpha1.ApplicationConfig{
+						Velero: &oadpv1alpha1.VeleroConfig{
+							ClientBurst: ptr.To(123),
+							ClientQPS:   ptr.To(123),
+							Args: &oadpv1alpha1.VeleroServerArgs{
+								ServerFlags: oadpv1alpha1.ServerFlags{
+									ClientBurst: ptr.To(321),
+									ClientQPS:   ptr.To("321"),
+								},
+							},
+						},
+					},
+				},
+			),
+			veleroDeployment: testVeleroDeployment.DeepCopy(),
+			errorMessage:     "spec.configuration.velero.args.client-burst conflicts with spec.configuration.velero.client-burst, spec.configuration.velero.args.client-qps conflicts with spec.configuration.velero.client-qps, set only one of them",
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -2378,88 +2556,6 @@ func TestDPAReconciler_noDefaultCredentials(t *testing.T) {
 	}
 }
 
-func TestDPAReconciler_VeleroDebugEnvironment(t *testing.T) {
-	tests := []struct {
-		name     string
-		replicas *int
-		wantErr  bool
-	}{
-		{
-			name:     "debug replica override not set",
-			replicas: nil,
-			wantErr:  false,
-		},
-		{
-			name:     "debug replica override set to 1",
-			replicas: ptr.To(1),
-			wantErr:  false,
-		},
-		{
-			name:     "debug replica override set to 0",
-			replicas: ptr.To(0),
-			wantErr:  false,
-		},
-	}
-	for _, tt := range tests {
-		if tt.replicas != nil {
-			t.Setenv(VeleroReplicaOverride, strconv.Itoa(*tt.replicas))
-		}
-
-		dpa := &oadpv1alpha1.DataProtectionApplication{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      testDpaName,
-				Namespace: testNamespaceName,
-			},
-			Spec: oadpv1alpha1.DataProtectionApplicationSpec{
-				Configuration: &oadpv1alpha1.ApplicationConfig{
-					Velero: &oadpv1alpha1.VeleroConfig{
-						DefaultPlugins:          allDefaultPluginsList,
-						NoDefaultBackupLocation: true,
-					},
-				},
-			},
-		}
-
-		deployment := &appsv1.Deployment{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      common.Velero,
-				Namespace: dpa.Namespace,
-			},
-		}
-
-		t.Run(tt.name, func(t *testing.T) {
-			fakeClient, err := getFakeClientFromObjects(dpa)
-			if err != nil {
-				t.Errorf("error in creating fake client, likely programmer error")
-			}
-			r := DataProtectionApplicationReconciler{
-				Client: fakeClient,
-				dpa:    dpa,
-			}
-			err = r.buildVeleroDeployment(deployment)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("DataProtectionApplicationReconciler.VeleroDebugEnvironment error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if deployment.Spec.Replicas == nil {
-				t.Error("deployment replicas not set")
-				return
-			}
-			if tt.replicas == nil {
-				if *deployment.Spec.Replicas != 1 {
-					t.Errorf("unexpected deployment replica count: %d", *deployment.Spec.Replicas)
-					return
-				}
-			} else {
-				if *deployment.Spec.Replicas != int32(*tt.replicas) {
-					t.Error("debug replica override did not apply")
-					return
-				}
-			}
-		})
-	}
-}
-
 func TestDPAReconciler_buildVeleroDeploymentWithAzureWorkloadIdentity(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -3,8 +3,11 @@ package controller
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
@@ -32,6 +35,15 @@ const (
 	AzureResourceGroup    = "resourceGroup"
 )
 
+var (
+	awsRegionRegex           = regexp.MustCompile(`^[a-z]{2,4}(-[a-z]+)+-[0-9]+$`)
+	azureSubscriptionIdRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}(-[0-9a-fA-F]{4}){3}-[0-9a-fA-F]{12}$`)
+	azureResourceGroupRegex  = regexp.MustCompile(`^[-\pL\pN_.()]{1,90}$`)
+	gcpSnapshotLocationRegex = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+	// project IDs, optionally prefixed by the domain of domain-scoped projects
+	gcpProjectRegex = regexp.MustCompile(`^([a-z0-9.-]+:)?[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+)
+
 var validAWSKeys = map[string]bool{
 	AWSProfile:            true,
 	AWSRegion:             true,
@@ -54,6 +66,10 @@ var validAzureKeys = map[string]bool{
 func (r *DataProtectionApplicationReconciler) LabelVSLSecrets(log logr.Logger) (bool, error) {
 	dpa := r.dpa
 	for _, vsl := range dpa.Spec.SnapshotLocations {
+		// a restore only cluster does not use the default credentials for the VSLs, see noDefaultCredentials
+		if dpa.IsRestoreOnly() && vsl.Velero.Credential == nil {
+			continue
+		}
 		provider := strings.TrimPrefix(vsl.Velero.Provider, veleroIOPrefix)
 		switch provider {
 		case "aws":
@@ -175,6 +191,10 @@ func (r *DataProtectionApplicationReconciler) ValidateVolumeSnapshotLocations()
 			}
 		}
 
+		if err := validateVolumeSnapshotLocationConfig(vslSpec.Velero.Provider, vslSpec.Velero.Config); err != nil {
+			return false, fmt.Errorf("DPA %s.config is invalid: %w", veleroVSLYAMLPath, err)
+		}
+
 		if err := r.ensureVslSecretDataExists(&vslSpec); err != nil {
 			return false, err
 		}
@@ -186,6 +206,7 @@ func (r *DataProtectionApplicationReconciler) ValidateVolumeSnapshotLocations()
 func (r *DataProtectionApplicationReconciler) ReconcileVolumeSnapshotLocations(log logr.Logger) (bool, error) {
 	dpa := r.dpa
 	dpaVSLNames := []string{}
+	credentialsSecretNames := []string{}
 	// Loop through all configured VSLs
 	for i, vslSpec := range dpa.Spec.SnapshotLocations {
 		// Create VSL as is, we can safely assume they are valid from
@@ -198,6 +219,16 @@ func (r *DataProtectionApplicationReconciler) ReconcileVolumeSnapshotLocations(l
 		}
 		dpaVSLNames = append(dpaVSLNames, vslName)
 
+		// Render the location config the credentials lack, for example the Azure resource group, in a secret of the VSL
+		secretName, secretKey, _ := r.getSecretNameAndKey(vslSpec.Velero.Config, vslSpec.Velero.Credential, oadpv1alpha1.DefaultPlugin(vslSpec.Velero.Provider))
+		credential, err := r.reconcileLocationCredentialsSecret(vslName, vslCredentialsComponent, vslSpec.Velero.Provider, secretName, secretKey, vslSpec.Velero.Config, "")
+		if err != nil {
+			return false, err
+		}
+		if credential != nil {
+			credentialsSecretNames = append(credentialsSecretNames, credential.Name)
+		}
+
 		vsl := velerov1.VolumeSnapshotLocation{
 			ObjectMeta: metav1.ObjectMeta{
 				// TODO: Use a hash instead of i
@@ -228,6 +259,9 @@ func (r *DataProtectionApplicationReconciler) ReconcileVolumeSnapshotLocations(l
 			}
 
 			vsl.Spec = *vslSpec.Velero
+			if credential != nil {
+				vsl.Spec.Credential = credential
+			}
 			return nil
 		})
 		if err != nil {
@@ -243,6 +277,9 @@ func (r *DataProtectionApplicationReconciler) ReconcileVolumeSnapshotLocations(l
 		}
 
 	}
+	if err := r.deleteStaleLocationCredentialsSecrets(vslCredentialsComponent, credentialsSecretNames); err != nil {
+		return false, err
+	}
 
 	dpaVSLs := velerov1.VolumeSnapshotLocationList{}
 	dpaVslLabels := map[string]string{
@@ -274,6 +311,39 @@ func (r *DataProtectionApplicationReconciler) ReconcileVolumeSnapshotLocations(l
 	return true, nil
 }
 
+// validateVolumeSnapshotLocationConfig checks the values of the provider specific config of a VSL:
+// the AWS region, the Azure subscriptionId, resourceGroup, apiTimeout and incremental,
+// and the GCP snapshotLocation and project
+func validateVolumeSnapshotLocationConfig(provider string, config map[string]string) error {
+	checks := map[string]func(string) bool{}
+	switch provider {
+	case AWSProvider:
+		checks[AWSRegion] = awsRegionRegex.MatchString
+	case AzureProvider:
+		checks[AzureSubscriptionId] = azureSubscriptionIdRegex.MatchString
+		checks[AzureResourceGroup] = func(value string) bool {
+			return azureResourceGroupRegex.MatchString(value) && !strings.HasSuffix(value, ".")
+		}
+		checks[AzureApiTimeout] = func(value string) bool {
+			timeout, err := time.ParseDuration(value)
+			return err == nil && timeout > 0
+		}
+		checks[AzureIncremental] = func(value string) bool {
+			_, err := strconv.ParseBool(value)
+			return err == nil
+		}
+	case GCPProvider:
+		checks[GCPSnapshotLocation] = gcpSnapshotLocationRegex.MatchString
+		checks[GCPProject] = gcpProjectRegex.MatchString
+	}
+	for key, valid := range checks {
+		if value, ok := config[key]; ok && !valid(value) {
+			return fmt.Errorf("invalid %s %s for provider %s", key, value, provider)
+		}
+	}
+	return nil
+}
+
 func containsPlugin(d []oadpv1alpha1.DefaultPlugin, value string) bool {
 	for _, elem := range d {
 		if credentials.PluginSpecificFields[elem].ProviderName == value {
@@ -304,6 +374,10 @@ func (r *DataProtectionApplicationReconciler) ensureVslSecretDataExists(vsl *oad
 			if vsl.Velero.Config[CredentialsFileKey] != "" {
 				return nil
 			}
+			// a restore only cluster does not use the default credentials for the VSLs, see noDefaultCredentials
+			if r.dpa.IsRestoreOnly() && vsl.Velero.Credential == nil {
+				return nil
+			}
 			_, _, err := r.getSecretNameAndKey(vsl.Velero.Config, vsl.Velero.Credential, oadpv1alpha1.DefaultPlugin(vsl.Velero.Provider))
 			if err != nil {
 				return err
@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	"google.golang.org/api/option"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/cloudprovider"
+	"github.com/openshift/oadp-operator/pkg/credentials"
+	"github.com/openshift/oadp-operator/pkg/utils"
+)
+
+const (
+	// defaultSnapshotLocationCheckInterval is the interval of spec.snapshotLocationCheck when not set
+	defaultSnapshotLocationCheckInterval = time.Hour
+	snapshotLocationCheckTimeout         = 30 * time.Second
+)
+
+// snapshot permission checks of the providers, replaced in tests
+var (
+	checkAWSSnapshotPermission = cloudprovider.CheckAWSSnapshotPermission
+	checkGCPSnapshotPermission = cloudprovider.CheckGCPSnapshotPermission
+)
+
+// getSnapshotLocationCheckInterval returns the interval between the snapshot permission checks, 0 when disabled
+func (r *DataProtectionApplicationReconciler) getSnapshotLocationCheckInterval() time.Duration {
+	check := r.dpa.Spec.SnapshotLocationCheck
+	if check == nil || !check.Enable {
+		return 0
+	}
+	if check.Interval != nil && check.Interval.Duration > 0 {
+		return check.Interval.Duration
+	}
+	return defaultSnapshotLocationCheckInterval
+}
+
+// getVolumeSnapshotLocationStatus returns the status of the VSL: it is ready when the provider config and
+// credentials needed to create snapshots are complete, and the snapshot permission check passes when enabled.
+// previous is the status of the VSL in the DPA, whose check result is kept until the check interval elapsed.
+func (r *DataProtectionApplicationReconciler) getVolumeSnapshotLocationStatus(vsl *velerov1.VolumeSnapshotLocation, previous *oadpv1alpha1.VolumeSnapshotLocationStatus) oadpv1alpha1.VolumeSnapshotLocationStatus {
+	status := oadpv1alpha1.VolumeSnapshotLocationStatus{
+		Name:     vsl.Name,
+		Provider: vsl.Spec.Provider,
+	}
+	provider := strings.TrimPrefix(vsl.Spec.Provider, veleroIOPrefix)
+	if vsl.Spec.Config[CredentialsFileKey] != "" {
+		status.Ready = true
+		status.Message = "credentials are read from credentialsFile and are not checked"
+		return status
+	}
+	if vsl.Spec.Credential == nil && r.dpa.IsRestoreOnly() {
+		status.Message = fmt.Sprintf("a credential is required in %s mode", oadpv1alpha1.DataProtectionApplicationModeRestoreOnly)
+		return status
+	}
+	secretName := credentials.PluginSpecificFields[oadpv1alpha1.DefaultPlugin(provider)].SecretName
+	secretKey := credentials.PluginSpecificFields[oadpv1alpha1.DefaultPlugin(provider)].PluginSecretKey
+	if vsl.Spec.Credential != nil {
+		secretName, secretKey = vsl.Spec.Credential.Name, vsl.Spec.Credential.Key
+	}
+	secret := &corev1.Secret{}
+	if err := r.Get(r.Context, client.ObjectKey{Namespace: r.dpa.Namespace, Name: secretName}, secret); err != nil {
+		if k8serror.IsNotFound(err) {
+			status.Message = fmt.Sprintf("credentials secret %s not found", secretName)
+		} else {
+			status.Message = fmt.Sprintf("unable to get credentials secret %s: %v", secretName, err)
+		}
+		return status
+	}
+	data := secret.Data[secretKey]
+	if len(data) == 0 {
+		status.Message = fmt.Sprintf("key %s of credentials secret %s is empty", secretKey, secretName)
+		return status
+	}
+
+	gcpProject := vsl.Spec.Config[GCPProject]
+	switch provider {
+	case AzureProvider:
+		if vsl.Spec.Config[AzureResourceGroup] == "" && !strings.Contains(string(data), "AZURE_RESOURCE_GROUP=") {
+			status.Message = fmt.Sprintf("%s is not set in the config nor AZURE_RESOURCE_GROUP in the credentials", AzureResourceGroup)
+			return status
+		}
+		if vsl.Spec.Config[AzureSubscriptionId] == "" && !strings.Contains(string(data), "AZURE_SUBSCRIPTION_ID=") {
+			status.Message = fmt.Sprintf("%s is not set in the config nor AZURE_SUBSCRIPTION_ID in the credentials", AzureSubscriptionId)
+			return status
+		}
+	case GCPProvider:
+		gcpCredentials := struct {
+			ProjectID string `json:"project_id"`
+		}{}
+		if err := json.Unmarshal(data, &gcpCredentials); err != nil {
+			status.Message = fmt.Sprintf("credentials of secret %s are not valid JSON: %v", secretName, err)
+			return status
+		}
+		if gcpProject == "" {
+			gcpProject = gcpCredentials.ProjectID
+		}
+		if gcpProject == "" {
+			status.Message = fmt.Sprintf("%s is not set in the config nor project_id in the credentials", GCPProject)
+			return status
+		}
+	}
+	status.Ready = true
+
+	interval := r.getSnapshotLocationCheckInterval()
+	if interval == 0 {
+		return status
+	}
+	if previous != nil && previous.LastCheckTime != nil && time.Since(previous.LastCheckTime.Time) < interval {
+		status.Ready, status.Message, status.LastCheckTime = previous.Ready, previous.Message, previous.LastCheckTime
+		return status
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context, snapshotLocationCheckTimeout)
+	defer cancel()
+	var err error
+	switch provider {
+	case AWSProvider:
+		profile := "default"
+		if vsl.Spec.Config[AWSProfile] != "" {
+			profile = vsl.Spec.Config[AWSProfile]
+		}
+		accessKey, secretAccessKey, parseErr := utils.ParseAWSSecret(*secret, secretKey, profile)
+		if parseErr != nil {
+			status.Message = fmt.Sprintf("snapshot permissions are not checked for credentials without access keys: %v", parseErr)
+			return status
+		}
+		httpClient, clientErr := trustedHTTPClient(ctx, r.Client, r.dpa.Namespace)
+		if clientErr != nil {
+			status.Message = fmt.Sprintf("unable to check snapshot permissions: %v", clientErr)
+			return status
+		}
+		err = checkAWSSnapshotPermission(ctx, vsl.Spec.Config[AWSRegion], "", accessKey, secretAccessKey, httpClient)
+	case GCPProvider:
+		err = checkGCPSnapshotPermission(ctx, gcpProject, option.WithCredentialsJSON(data))
+	default:
+		status.Message = fmt.Sprintf("snapshot permissions are not checked for provider %s", provider)
+		return status
+	}
+	status.LastCheckTime = &metav1.Time{Time: time.Now()}
+	if err != nil {
+		status.Ready = false
+		status.Message = err.Error()
+		if !errors.Is(err, cloudprovider.ErrSnapshotPermissionDenied) {
+			status.Message = fmt.Sprintf("unable to check snapshot permissions: %v", err)
+		}
+		return status
+	}
+	status.Message = "allowed to create snapshots"
+	return status
+}
+
+// recordVolumeSnapshotLocationEvent emits an event on the DPA when a VSL becomes not ready, or ready again
+func (r *DataProtectionApplicationReconciler) recordVolumeSnapshotLocationEvent(previous *oadpv1alpha1.VolumeSnapshotLocationStatus, current oadpv1alpha1.VolumeSnapshotLocationStatus) {
+	switch {
+	case !current.Ready && (previous == nil || previous.Ready || previous.Message != current.Message):
+		r.EventRecorder.Event(r.dpa, corev1.EventTypeWarning, "VolumeSnapshotLocationNotReady",
+			fmt.Sprintf("VolumeSnapshotLocation %s is not ready: %s", current.Name, current.Message))
+	case current.Ready && previous != nil && !previous.Ready:
+		r.EventRecorder.Event(r.dpa, corev1.EventTypeNormal, "VolumeSnapshotLocationReady",
+			fmt.Sprintf("VolumeSnapshotLocation %s is ready", current.Name))
+	}
+}
@@ -0,0 +1,280 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	"google.golang.org/api/option"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/cloudprovider"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func createTestStatusVSL(dpa *oadpv1alpha1.DataProtectionApplication, name, provider string, config map[string]string, credential *corev1.SecretKeySelector) *velerov1.VolumeSnapshotLocation {
+	return &velerov1.VolumeSnapshotLocation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "test-ns",
+			Labels: map[string]string{
+				"app.kubernetes.io/name":       common.OADPOperatorVelero,
+				"app.kubernetes.io/managed-by": common.OADPOperator,
+				"app.kubernetes.io/component":  "vsl",
+			},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: oadpv1alpha1.GroupVersion.String(),
+				Kind:       "DataProtectionApplication",
+				Name:       dpa.Name,
+				UID:        dpa.UID,
+				Controller: ptr.To(true),
+			}},
+		},
+		Spec: velerov1.VolumeSnapshotLocationSpec{
+			Provider:   provider,
+			Config:     config,
+			Credential: credential,
+		},
+	}
+}
+
+func TestDPAReconciler_updateSnapshotLocationsStatus(t *testing.T) {
+	recently := &metav1.Time{Time: time.Now().Add(-time.Minute)}
+	longAgo := &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}
+	secret := func(name, key, data string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns"},
+			Data:       map[string][]byte{key: []byte(data)},
+		}
+	}
+	awsSecret := secret("cloud-credentials", "cloud", "[default]\naws_access_key_id=test-key\naws_secret_access_key=test-secret")
+	tests := []struct {
+		name          string
+		mode          oadpv1alpha1.DataProtectionApplicationMode
+		check         *oadpv1alpha1.SnapshotLocationCheck
+		vsl           func(dpa *oadpv1alpha1.DataProtectionApplication) *velerov1.VolumeSnapshotLocation
+		objects       []client.Object
+		previous      *oadpv1alpha1.VolumeSnapshotLocationStatus
+		checkErr      error
+		wantReady     bool
+		wantMessage   string
+		wantChecked   bool
+		wantCondition metav1.ConditionStatus
+		wantEvent     string
+	}{
+		{
+			name: "aws credentials present",
+			vsl: func(dpa *oadpv1alpha1.DataProtectionApplication) *velerov1.VolumeSnapshotLocation {
+				return createTestStatusVSL(dpa, "vsl-1", AWSProvider, map[string]string{AWSRegion: "us-east-1"}, nil)
+			},
+			objects:       []client.Object{awsSecret},
+			wantReady:     true,
+			wantCondition: metav1.ConditionTrue,
+		},
+		{
+			name: "credentials secret not found",
+			vsl: func(dpa *oadpv1alpha1.DataProtectionApplication) *velerov1.VolumeSnapshotLocation {
+				return createTestStatusVSL(dpa, "vsl-1", AWSProvider, map[string]string{AWSRegion: "us-east-1"}, nil)
+			},
+			wantMessage:   "credentials secret cloud-credentials not found",
+			wantCondition: metav1.ConditionFalse,
+			wantEvent:     "Warning VolumeSnapshotLocationNotReady",
+		},
+		{
+			name: "credentials file is not checked",
+			vsl: func(dpa *oadpv1alpha1.DataProtectionApplication) *velerov1.VolumeSnapshotLocation {
+				return createTestStatusVSL(dpa, "vsl-1", AWSProvider, map[string]string{AWSRegion: "us-east-1", CredentialsFileKey: "/tmp/credentials/cloud"}, nil)
+			},
+			wantReady:     true,
+			wantMessage:   "credentials are read from credentialsFile and are not checked",
+			wantCondition: metav1.ConditionTrue,
+		},
+		{
+			name: "restore only without credential",
+			mode: oadpv1alpha1.DataProtectionApplicationModeRestoreOnly,
+			vsl: func(dpa *oadpv1alpha1.DataProtectionApplication) *velerov1.VolumeSnapshotLocation {
+				return createTestStatusVSL(dpa, "vsl-1", AWSProvider, map[string]string{AWSRegion: "us-east-1"}, nil)
+			},
+			objects:       []client.Object{awsSecret},
+			wantMessage:   "a credential is required in RestoreOnly mode",
+			wantCondition: metav1.ConditionFalse,
+			wantEvent:     "Warning VolumeSnapshotLocationNotReady",
+		},
+		{
+			name: "azure without resource group",
+			vsl: func(dpa *oadpv1alpha1.DataProtectionApplication) *velerov1.VolumeSnapshotLocation {
+				return createTestStatusVSL(dpa, "vsl-1", AzureProvider, map[string]string{AzureSubscriptionId: "00000000-0000-0000-0000-000000000001"}, nil)
+			},
+			objects:       []client.Object{secret("cloud-credentials-azure", "cloud", "AZURE_CLIENT_ID=test\nAZURE_SUBSCRIPTION_ID=test")},
+			wantMessage:   "resourceGroup is not set in the config nor AZURE_RESOURCE_GROUP in the credentials",
+			wantCondition: metav1.ConditionFalse,
+			wantEvent:     "Warning VolumeSnapshotLocationNotReady",
+		},
+		{
+			name: "azure resource group from credentials",
+			vsl: func(dpa *oadpv1alpha1.DataProtectionApplication) *velerov1.VolumeSnapshotLocation {
+				return createTestStatusVSL(dpa, "vsl-1", AzureProvider, nil, nil)
+			},
+			objects:       []client.Object{secret("cloud-credentials-azure", "cloud", "AZURE_RESOURCE_GROUP=test\nAZURE_SUBSCRIPTION_ID=test")},
+			wantReady:     true,
+			wantCondition: metav1.ConditionTrue,
+		},
+		{
+			name: "gcp project from credentials",
+			vsl: func(dpa *oadpv1alpha1.DataProtectionApplication) *velerov1.VolumeSnapshotLocation {
+				return createTestStatusVSL(dpa, "vsl-1", GCPProvider, nil, nil)
+			},
+			objects:       []client.Object{secret("cloud-credentials-gcp", "cloud", `{"type":"service_account","project_id":"test-project"}`)},
+			wantReady:     true,
+			wantCondition: metav1.ConditionTrue,
+		},
+		{
+			name: "gcp without project",
+			vsl: func(dpa *oadpv1alpha1.DataProtectionApplication) *velerov1.VolumeSnapshotLocation {
+				return createTestStatusVSL(dpa, "vsl-1", GCPProvider, nil, nil)
+			},
+			objects:       []client.Object{secret("cloud-credentials-gcp", "cloud", `{"type":"service_account"}`)},
+			wantMessage:   "project is not set in the config nor project_id in the credentials",
+			wantCondition: metav1.ConditionFalse,
+			wantEvent:     "Warning VolumeSnapshotLocationNotReady",
+		},
+		{
+			name:  "snapshot permission allowed",
+			check: &oadpv1alpha1.SnapshotLocationCheck{Enable: true},
+			vsl: func(dpa *oadpv1alpha1.DataProtectionApplication) *velerov1.VolumeSnapshotLocation {
+				return createTestStatusVSL(dpa, "vsl-1", AWSProvider, map[string]string{AWSRegion: "us-east-1"}, nil)
+			},
+			objects:       []client.Object{awsSecret},
+			previous:      &oadpv1alpha1.VolumeSnapshotLocationStatus{Name: "vsl-1", Ready: false, Message: "old", LastCheckTime: longAgo},
+			wantReady:     true,
+			wantMessage:   "allowed to create snapshots",
+			wantChecked:   true,
+			wantCondition: metav1.ConditionTrue,
+			wantEvent:     "Normal VolumeSnapshotLocationReady",
+		},
+		{
+			name:  "snapshot permission denied",
+			check: &oadpv1alpha1.SnapshotLocationCheck{Enable: true},
+			vsl: func(dpa *oadpv1alpha1.DataProtectionApplication) *velerov1.VolumeSnapshotLocation {
+				return createTestStatusVSL(dpa, "vsl-1", AWSProvider, map[string]string{AWSRegion: "us-east-1"}, nil)
+			},
+			objects:       []client.Object{awsSecret},
+			checkErr:      fmt.Errorf("%w in region us-east-1: denied", cloudprovider.ErrSnapshotPermissionDenied),
+			wantMessage:   "not allowed to create snapshots in region us-east-1: denied",
+			wantChecked:   true,
+			wantCondition: metav1.ConditionFalse,
+			wantEvent:     "Warning VolumeSnapshotLocationNotReady",
+		},
+		{
+			name:  "snapshot permission check result kept within the interval",
+			check: &oadpv1alpha1.SnapshotLocationCheck{Enable: true},
+			vsl: func(dpa *oadpv1alpha1.DataProtectionApplication) *velerov1.VolumeSnapshotLocation {
+				return createTestStatusVSL(dpa, "vsl-1", AWSProvider, map[string]string{AWSRegion: "us-east-1"}, nil)
+			},
+			objects:       []client.Object{awsSecret},
+			previous:      &oadpv1alpha1.VolumeSnapshotLocationStatus{Name: "vsl-1", Ready: false, Message: "previous check", LastCheckTime: recently},
+			checkErr:      errors.New("should not be checked"),
+			wantMessage:   "previous check",
+			wantChecked:   true,
+			wantCondition: metav1.ConditionFalse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkAWSSnapshotPermission = func(_ context.Context, region, _, accessKey, _ string, _ *http.Client) error {
+				if region != "us-east-1" || accessKey != "test-key" {
+					t.Errorf("unexpected snapshot permission check of region %s with access key %s", region, accessKey)
+				}
+				return tt.checkErr
+			}
+			checkGCPSnapshotPermission = func(context.Context, string, ...option.ClientOption) error { return tt.checkErr }
+			defer func() {
+				checkAWSSnapshotPermission = cloudprovider.CheckAWSSnapshotPermission
+				checkGCPSnapshotPermission = cloudprovider.CheckGCPSnapshotPermission
+			}()
+
+			dpa := createTestStatusDPA(false, false)
+			dpa.Spec.Mode = tt.mode
+			dpa.Spec.SnapshotLocationCheck = tt.check
+			if tt.previous != nil {
+				dpa.Status.SnapshotLocations = []oadpv1alpha1.VolumeSnapshotLocationStatus{*tt.previous}
+			}
+			fakeClient, err := getFakeClientFromObjects(append(tt.objects, dpa, tt.vsl(dpa))...)
+			if err != nil {
+				t.Fatalf("error in creating fake client, likely programmer error")
+			}
+			recorder := record.NewFakeRecorder(10)
+			r := &DataProtectionApplicationReconciler{
+				Client:         fakeClient,
+				Scheme:         fakeClient.Scheme(),
+				Log:            logr.Discard(),
+				Context:        newContextForTest(),
+				NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+				EventRecorder:  recorder,
+				dpa:            dpa,
+			}
+			if err := r.updateSnapshotLocationsStatus(); err != nil {
+				t.Fatalf("updateSnapshotLocationsStatus() error = %v", err)
+			}
+			if len(dpa.Status.SnapshotLocations) != 1 {
+				t.Fatalf("expected 1 snapshot location status, got %v", dpa.Status.SnapshotLocations)
+			}
+			status := dpa.Status.SnapshotLocations[0]
+			if status.Ready != tt.wantReady {
+				t.Errorf("expected ready %v, got %v: %s", tt.wantReady, status.Ready, status.Message)
+			}
+			if tt.wantMessage != "" && status.Message != tt.wantMessage {
+				t.Errorf("expected message %q, got %q", tt.wantMessage, status.Message)
+			}
+			if (status.LastCheckTime != nil) != tt.wantChecked {
+				t.Errorf("expected checked %v, got last check time %v", tt.wantChecked, status.LastCheckTime)
+			}
+			condition := apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionSnapshotLocationsReady)
+			if condition == nil || condition.Status != tt.wantCondition {
+				t.Errorf("expected %s condition %s, got %v", oadpv1alpha1.ConditionSnapshotLocationsReady, tt.wantCondition, condition)
+			}
+			gotEvent := ""
+			select {
+			case gotEvent = <-recorder.Events:
+			default:
+			}
+			if !strings.HasPrefix(gotEvent, tt.wantEvent) || (tt.wantEvent == "") != (gotEvent == "") {
+				t.Errorf("expected event %q, got %q", tt.wantEvent, gotEvent)
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_getSnapshotLocationCheckInterval(t *testing.T) {
+	tests := []struct {
+		name  string
+		check *oadpv1alpha1.SnapshotLocationCheck
+		want  time.Duration
+	}{
+		{name: "no check"},
+		{name: "check disabled", check: &oadpv1alpha1.SnapshotLocationCheck{Interval: &metav1.Duration{Duration: time.Minute}}},
+		{name: "default interval", check: &oadpv1alpha1.SnapshotLocationCheck{Enable: true}, want: defaultSnapshotLocationCheckInterval},
+		{name: "interval", check: &oadpv1alpha1.SnapshotLocationCheck{Enable: true, Interval: &metav1.Duration{Duration: 10 * time.Minute}}, want: 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &DataProtectionApplicationReconciler{dpa: createTestDpaWith(nil, oadpv1alpha1.DataProtectionApplicationSpec{SnapshotLocationCheck: tt.check})}
+			if got := r.getSnapshotLocationCheckInterval(); got != tt.want {
+				t.Errorf("getSnapshotLocationCheckInterval() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
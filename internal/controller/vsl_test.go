@@ -592,7 +592,7 @@ func TestDPAReconciler_ValidateVolumeSnapshotLocation(t *testing.T) {
 							Velero: &velerov1.VolumeSnapshotLocationSpec{
 								Provider: AzureProvider,
 								Config: map[string]string{
-									AzureSubscriptionId: "test-alt-sub",
+									AzureSubscriptionId: "00000000-0000-0000-0000-000000000001",
 								},
 							},
 						},
@@ -801,3 +801,39 @@ func TestDPAReconciler_ReconcileVolumeSnapshotLocations(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateVolumeSnapshotLocationConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		config   map[string]string
+		wantErr  bool
+	}{
+		{name: "aws region", provider: AWSProvider, config: map[string]string{AWSRegion: "us-gov-west-1"}},
+		{name: "aws invalid region", provider: AWSProvider, config: map[string]string{AWSRegion: "US East"}, wantErr: true},
+		{
+			name:     "azure config",
+			provider: AzureProvider,
+			config: map[string]string{
+				AzureSubscriptionId: "00000000-0000-0000-0000-000000000001",
+				AzureResourceGroup:  "my_group(1)",
+				AzureApiTimeout:     "5m",
+				AzureIncremental:    "true",
+			},
+		},
+		{name: "azure invalid subscription", provider: AzureProvider, config: map[string]string{AzureSubscriptionId: "my-subscription"}, wantErr: true},
+		{name: "azure resource group ending with a period", provider: AzureProvider, config: map[string]string{AzureResourceGroup: "group."}, wantErr: true},
+		{name: "azure invalid api timeout", provider: AzureProvider, config: map[string]string{AzureApiTimeout: "5"}, wantErr: true},
+		{name: "azure invalid incremental", provider: AzureProvider, config: map[string]string{AzureIncremental: "yes"}, wantErr: true},
+		{name: "gcp config", provider: GCPProvider, config: map[string]string{GCPProject: "my-project-1", GCPSnapshotLocation: "us-central1"}},
+		{name: "gcp invalid project", provider: GCPProvider, config: map[string]string{GCPProject: "My_Project"}, wantErr: true},
+		{name: "gcp invalid snapshot location", provider: GCPProvider, config: map[string]string{GCPSnapshotLocation: "US Central"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateVolumeSnapshotLocationConfig(tt.provider, tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("validateVolumeSnapshotLocationConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -24,10 +25,12 @@ type AWSProvider struct {
 }
 
 // NewAWSProvider creates an AWSProvider using region, endpoint, and credentials.
-func NewAWSProvider(region, endpoint, accessKey, secretKey string) *AWSProvider {
+// httpClient is used for all S3 requests, the AWS SDK default client is used if nil.
+func NewAWSProvider(region, endpoint, accessKey, secretKey string, httpClient *http.Client) *AWSProvider {
 	awsConfig := &aws.Config{
 		Region:      aws.String(region),
 		Credentials: credentials.NewStaticCredentials(accessKey, secretKey, ""),
+		HTTPClient:  httpClient,
 	}
 
 	// Optional custom S3-compatible endpoint (e.g., MinIO, Ceph)
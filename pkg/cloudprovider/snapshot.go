@@ -0,0 +1,87 @@
+package cloudprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"google.golang.org/api/cloudresourcemanager/v1"
+	"google.golang.org/api/option"
+)
+
+// ErrSnapshotPermissionDenied is returned when the credentials are not allowed to create snapshots
+var ErrSnapshotPermissionDenied = errors.New("not allowed to create snapshots")
+
+// awsDryRunVolumeID is the volume of the CreateSnapshot dry run, permissions are checked before the volume
+const awsDryRunVolumeID = "vol-0123456789abcdef0"
+
+// gcpSnapshotPermissions are the permissions the Velero GCP plugin needs to snapshot disks
+var gcpSnapshotPermissions = []string{"compute.disks.createSnapshot", "compute.snapshots.create", "compute.snapshots.get"}
+
+// CheckAWSSnapshotPermission checks the credentials are allowed to create EBS snapshots in region,
+// with a CreateSnapshot dry run that does not create a snapshot. endpoint overrides the EC2 endpoint if set,
+// and httpClient is used for the request, the AWS SDK default client is used if nil.
+func CheckAWSSnapshotPermission(ctx context.Context, region, endpoint, accessKey, secretKey string, httpClient *http.Client) error {
+	awsConfig := &aws.Config{
+		Region:      aws.String(region),
+		Credentials: credentials.NewStaticCredentials(accessKey, secretKey, ""),
+		HTTPClient:  httpClient,
+	}
+	if endpoint != "" {
+		awsConfig.Endpoint = aws.String(endpoint)
+	}
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS session: %w", err)
+	}
+	_, err = ec2.New(sess).CreateSnapshotWithContext(ctx, &ec2.CreateSnapshotInput{
+		DryRun:   aws.Bool(true),
+		VolumeId: aws.String(awsDryRunVolumeID),
+	})
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		if err == nil {
+			return nil
+		}
+		return fmt.Errorf("snapshot permission check failed: %w", err)
+	}
+	switch awsErr.Code() {
+	case "DryRunOperation":
+		return nil
+	case "UnauthorizedOperation", "AuthFailure", "InvalidClientTokenId", "SignatureDoesNotMatch":
+		return fmt.Errorf("%w in region %s: %s", ErrSnapshotPermissionDenied, region, awsErr.Message())
+	}
+	return fmt.Errorf("snapshot permission check failed: %w", err)
+}
+
+// CheckGCPSnapshotPermission checks the credentials of opts are allowed to create disk snapshots in project,
+// with a testIamPermissions request on the project
+func CheckGCPSnapshotPermission(ctx context.Context, project string, opts ...option.ClientOption) error {
+	service, err := cloudresourcemanager.NewService(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create GCP resource manager client: %w", err)
+	}
+	resp, err := service.Projects.TestIamPermissions(project, &cloudresourcemanager.TestIamPermissionsRequest{
+		Permissions: gcpSnapshotPermissions,
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("snapshot permission check failed: %w", err)
+	}
+	missing := []string{}
+	for _, permission := range gcpSnapshotPermissions {
+		if !slices.Contains(resp.Permissions, permission) {
+			missing = append(missing, permission)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w in project %s: missing permissions %v", ErrSnapshotPermissionDenied, project, missing)
+	}
+	return nil
+}
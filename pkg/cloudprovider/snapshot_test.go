@@ -0,0 +1,91 @@
+package cloudprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/option"
+)
+
+func TestCheckAWSSnapshotPermission(t *testing.T) {
+	ec2Error := func(code string) string {
+		return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?><Response><Errors><Error><Code>%s</Code><Message>%s message</Message></Error></Errors><RequestID>test</RequestID></Response>`, code, code)
+	}
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantErr    bool
+		wantDenied bool
+	}{
+		{name: "dry run succeeds", status: http.StatusPreconditionFailed, body: ec2Error("DryRunOperation")},
+		{name: "unauthorized", status: http.StatusForbidden, body: ec2Error("UnauthorizedOperation"), wantErr: true, wantDenied: true},
+		{name: "invalid key", status: http.StatusUnauthorized, body: ec2Error("AuthFailure"), wantErr: true, wantDenied: true},
+		{name: "other error", status: http.StatusBadRequest, body: ec2Error("InvalidParameterValue"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if err := req.ParseForm(); err != nil || req.Form.Get("Action") != "CreateSnapshot" || req.Form.Get("DryRun") != "true" {
+					t.Errorf("expected a CreateSnapshot dry run, got %v", req.Form)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			err := CheckAWSSnapshotPermission(context.Background(), "us-east-1", server.URL, "test-key", "test-secret", server.Client())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckAWSSnapshotPermission() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrSnapshotPermissionDenied) != tt.wantDenied {
+				t.Errorf("expected permission denied %v, got %v", tt.wantDenied, err)
+			}
+		})
+	}
+}
+
+func TestCheckGCPSnapshotPermission(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantErr    bool
+		wantDenied bool
+	}{
+		{
+			name: "all permissions",
+			body: `{"permissions":["compute.disks.createSnapshot","compute.snapshots.create","compute.snapshots.get"]}`,
+		},
+		{
+			name:       "missing permissions",
+			body:       `{"permissions":["compute.snapshots.get"]}`,
+			wantErr:    true,
+			wantDenied: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if !strings.HasSuffix(req.URL.Path, "/projects/test-project:testIamPermissions") {
+					t.Errorf("unexpected request %s", req.URL.Path)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			err := CheckGCPSnapshotPermission(context.Background(), "test-project", option.WithEndpoint(server.URL), option.WithoutAuthentication())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckGCPSnapshotPermission() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrSnapshotPermissionDenied) != tt.wantDenied {
+				t.Errorf("expected permission denied %v, got %v", tt.wantDenied, err)
+			}
+		})
+	}
+}
@@ -77,6 +77,19 @@ const (
 	HypershiftPluginImage = "quay.io/redhat-user-workloads/ocp-art-tenant/oadp-hypershift-oadp-plugin-main:main"
 )
 
+// Plugin compatibility
+const (
+	// PluginCompatibilityConfigMapName is the optional ConfigMap in the DPA namespace declaring
+	// the Velero versions of Velero images and the Velero versions plugin images are compatible with
+	PluginCompatibilityConfigMapName = "oadp-plugin-compatibility"
+	// PluginCompatibilityManifestKey is the ConfigMap key holding the plugin compatibility manifest
+	PluginCompatibilityManifestKey = "manifest.yaml"
+	// VeleroVersionImageLabel is the Velero image label declaring its Velero version
+	VeleroVersionImageLabel = "oadp.openshift.io/velero-version"
+	// PluginVeleroVersionsImageLabel is the plugin image label declaring the range of Velero versions it is compatible with
+	PluginVeleroVersionsImageLabel = "oadp.openshift.io/velero-versions"
+)
+
 // Plugin names
 const (
 	VeleroPluginForAWS       = "velero-plugin-for-aws"
@@ -109,6 +122,32 @@ const (
 	UnsupportedNodeAgentServerArgsAnnotation = "oadp.openshift.io/unsupported-node-agent-server-args"
 )
 
+// DPA behavior annotation keys
+const (
+	// DryRunAnnotation renders the DPA managed objects into a ConfigMap instead of applying them, with the values
+	// of the Secrets redacted
+	DryRunAnnotation = "oadp.openshift.io/dry-run"
+	// PausedAnnotation stops the DPA from being reconciled, same as spec.paused
+	PausedAnnotation = "oadp.openshift.io/paused"
+	// ForceBackupLocationDeletionAnnotation deletes the BackupStorageLocations removed from the DPA spec
+	// even if Backups or Schedules still reference them
+	ForceBackupLocationDeletionAnnotation = "oadp.openshift.io/force-backup-location-deletion"
+)
+
+// FailoverFromAnnotation is set on the Schedules repointed to the secondary backup storage location
+// of spec.backupLocationFailover, to the name of the primary, so failback only repoints these Schedules
+const FailoverFromAnnotation = "oadp.openshift.io/failover-from"
+
+// Trusted CA bundle
+const (
+	// TrustedCABundleConfigMapName is the ConfigMap in the DPA namespace the cluster trusted CA bundle is injected into
+	TrustedCABundleConfigMapName = "oadp-trusted-ca-bundle"
+	// InjectTrustedCABundleLabel asks the OpenShift network operator to inject the cluster trusted CA bundle
+	InjectTrustedCABundleLabel = "config.openshift.io/inject-trusted-cabundle"
+	// TrustedCABundleKey is the ConfigMap key holding the injected PEM bundle
+	TrustedCABundleKey = "ca-bundle.crt"
+)
+
 // Volume permissions
 const (
 	// Owner and Group can read; Public do not have any permissions
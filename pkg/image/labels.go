@@ -0,0 +1,91 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+)
+
+// manifest holds the fields of image manifests and manifest lists needed to find the image configuration
+type manifest struct {
+	MediaType string `json:"mediaType"`
+	Config    struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Manifests []struct {
+		Digest   string `json:"digest"`
+		Platform struct {
+			OS           string `json:"os"`
+			Architecture string `json:"architecture"`
+		} `json:"platform"`
+	} `json:"manifests"`
+}
+
+// Labels returns the labels of image, looking it up through the configured mirrors.
+// For multi-arch images, the labels of the image for the operator architecture are returned.
+func (r *Resolver) Labels(ctx context.Context, image string) (map[string]string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+	errs := []error{}
+	for _, candidate := range candidates(ref, r.Mirrors) {
+		labels, err := r.labels(ctx, candidate)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", candidate.Name(), err))
+			continue
+		}
+		return labels, nil
+	}
+	return nil, fmt.Errorf("unable to read labels of image %s: %w", image, errors.Join(errs...))
+}
+
+func (r *Resolver) labels(ctx context.Context, ref Reference) (map[string]string, error) {
+	reference := ref.Digest
+	if len(reference) == 0 {
+		reference = ref.Tag
+	}
+	imageManifest := &manifest{}
+	if err := r.getJSON(ctx, ref, "manifests/"+reference, imageManifest); err != nil {
+		return nil, err
+	}
+	if len(imageManifest.Manifests) != 0 {
+		digest := imageManifest.Manifests[0].Digest
+		for _, platformManifest := range imageManifest.Manifests {
+			if platformManifest.Platform.OS == "linux" && platformManifest.Platform.Architecture == runtime.GOARCH {
+				digest = platformManifest.Digest
+				break
+			}
+		}
+		imageManifest = &manifest{}
+		if err := r.getJSON(ctx, ref, "manifests/"+digest, imageManifest); err != nil {
+			return nil, err
+		}
+	}
+	if len(imageManifest.Config.Digest) == 0 {
+		return nil, fmt.Errorf("image manifest has no configuration")
+	}
+
+	config := struct {
+		Config struct {
+			Labels map[string]string `json:"Labels"`
+		} `json:"config"`
+	}{}
+	if err := r.getJSON(ctx, ref, "blobs/"+imageManifest.Config.Digest, &config); err != nil {
+		return nil, err
+	}
+	return config.Config.Labels, nil
+}
+
+// getJSON decodes the registry API object at path in the ref repository into object
+func (r *Resolver) getJSON(ctx context.Context, ref Reference, path string, object interface{}) error {
+	response, err := r.do(ctx, http.MethodGet, fmt.Sprintf("https://%s/v2/%s/%s", ref.apiHost(), ref.Repository, path), ref)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	return json.NewDecoder(response.Body).Decode(object)
+}
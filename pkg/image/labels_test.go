@@ -0,0 +1,70 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestResolver_Labels(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/oadp/plugin/manifests/v1.0":
+			fmt.Fprintf(w, `{"manifests":[
+				{"digest":"sha256:other","platform":{"os":"linux","architecture":"other"}},
+				{"digest":"sha256:platform","platform":{"os":"linux","architecture":"%s"}}
+			]}`, runtime.GOARCH)
+		case "/v2/oadp/plugin/manifests/sha256:platform":
+			w.Write([]byte(`{"config":{"digest":"sha256:config"}}`))
+		case "/v2/oadp/plugin/blobs/sha256:config":
+			w.Write([]byte(`{"config":{"Labels":{"oadp.openshift.io/velero-versions":">=1.16.0 <1.17.0"}}}`))
+		case "/v2/oadp/single/manifests/v1.0":
+			w.Write([]byte(`{"config":{"digest":"sha256:empty"}}`))
+		case "/v2/oadp/single/blobs/sha256:empty":
+			w.Write([]byte(`{"config":{}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	registry := strings.TrimPrefix(server.URL, "https://")
+
+	tests := []struct {
+		name    string
+		image   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:  "multi-arch image, labels of operator architecture",
+			image: registry + "/oadp/plugin:v1.0",
+			want:  map[string]string{"oadp.openshift.io/velero-versions": ">=1.16.0 <1.17.0"},
+		},
+		{
+			name:  "image without labels",
+			image: registry + "/oadp/single:v1.0",
+		},
+		{
+			name:    "missing image",
+			image:   registry + "/oadp/missing:v1.0",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := &Resolver{HTTPClient: server.Client()}
+			got, err := resolver.Labels(context.Background(), tt.image)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Labels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Labels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package image
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	dockerHubRegistry    = "docker.io"
+	dockerHubAPIRegistry = "registry-1.docker.io"
+)
+
+// Reference is a parsed container image reference
+type Reference struct {
+	// Registry is the registry host, with its port if any
+	Registry string
+	// Repository is the repository path in the registry
+	Repository string
+	// Tag is the image tag, empty if the reference has a digest only
+	Tag string
+	// Digest is the image digest, empty if the reference has a tag only
+	Digest string
+}
+
+// ParseReference parses an image reference. Images without a registry are considered to be from docker.io,
+// and images without a tag or digest to have the latest tag.
+func ParseReference(image string) (Reference, error) {
+	ref := Reference{}
+	name := image
+	if index := strings.Index(name, "@"); index != -1 {
+		ref.Digest = name[index+1:]
+		name = name[:index]
+		if !strings.Contains(ref.Digest, ":") {
+			return Reference{}, fmt.Errorf("invalid digest in image %q", image)
+		}
+	}
+	if index := strings.LastIndex(name, ":"); index != -1 && !strings.Contains(name[index+1:], "/") {
+		ref.Tag = name[index+1:]
+		name = name[:index]
+	}
+	if len(name) == 0 {
+		return Reference{}, fmt.Errorf("invalid image %q", image)
+	}
+
+	registry, repository, found := strings.Cut(name, "/")
+	if !found || !(strings.ContainsAny(registry, ".:") || registry == "localhost") {
+		registry, repository = dockerHubRegistry, name
+		if !strings.Contains(repository, "/") {
+			repository = "library/" + repository
+		}
+	}
+	ref.Registry, ref.Repository = registry, repository
+	if len(ref.Tag) == 0 && len(ref.Digest) == 0 {
+		ref.Tag = "latest"
+	}
+	return ref, nil
+}
+
+// Name returns the registry and repository of the reference
+func (r Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// String returns the reference with its tag and digest
+func (r Reference) String() string {
+	image := r.Name()
+	if len(r.Tag) != 0 {
+		image += ":" + r.Tag
+	}
+	if len(r.Digest) != 0 {
+		image += "@" + r.Digest
+	}
+	return image
+}
+
+// apiHost returns the host serving the registry API
+func (r Reference) apiHost() string {
+	if r.Registry == dockerHubRegistry {
+		return dockerHubAPIRegistry
+	}
+	return r.Registry
+}
+
+// MirrorRule maps a source repository, or a registry or namespace containing it, to its mirrors,
+// as defined by ImageDigestMirrorSets, ImageTagMirrorSets and ImageContentSourcePolicies
+type MirrorRule struct {
+	// Source is the repository, namespace or registry the mirrors are for
+	Source string
+	// Mirrors are the repositories, namespaces or registries mirroring Source, in order of preference
+	Mirrors []string
+	// NeverContactSource is set when images must only be pulled through the mirrors
+	NeverContactSource bool
+	// ForTags is set for ImageTagMirrorSet rules, that apply to tag references,
+	// other rules only apply to digest references
+	ForTags bool
+}
+
+// matches returns the part of name after the rule source, and true if the rule applies to name
+func (m MirrorRule) matches(name string) (string, bool) {
+	if name == m.Source {
+		return "", true
+	}
+	if strings.HasPrefix(name, m.Source+"/") {
+		return name[len(m.Source):], true
+	}
+	return "", false
+}
+
+// candidates returns the references to try, in order, to look up ref through the mirror rules
+func candidates(ref Reference, rules []MirrorRule) []Reference {
+	result := []Reference{}
+	contactSource := true
+	for _, rule := range rules {
+		if rule.ForTags != (len(ref.Digest) == 0) {
+			continue
+		}
+		suffix, ok := rule.matches(ref.Name())
+		if !ok {
+			continue
+		}
+		if rule.NeverContactSource {
+			contactSource = false
+		}
+		for _, mirror := range rule.Mirrors {
+			mirrorRef, err := ParseReference(mirror + suffix)
+			if err != nil {
+				continue
+			}
+			mirrorRef.Tag, mirrorRef.Digest = ref.Tag, ref.Digest
+			result = append(result, mirrorRef)
+		}
+	}
+	if contactSource {
+		result = append(result, ref)
+	}
+	return result
+}
@@ -0,0 +1,250 @@
+package image
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// manifestMediaTypes are the manifest media types accepted when resolving digests,
+// manifest lists and indexes first so multi-arch images resolve to their list digest
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Credential is a registry username and password
+type Credential struct {
+	Username string
+	Password string
+}
+
+// Result is the outcome of resolving an image
+type Result struct {
+	// Image is the image pinned to its digest, in the repository it was requested from
+	Image string
+	// Digest is the image manifest digest
+	Digest string
+	// From is the repository the digest was resolved from, a mirror or the source
+	From string
+}
+
+// Resolver resolves image tags to digests through registry mirrors
+type Resolver struct {
+	// HTTPClient is used for all registry requests, http.DefaultClient if nil
+	HTTPClient *http.Client
+	// Mirrors are the cluster image mirror rules
+	Mirrors []MirrorRule
+	// Credentials are the registry credentials, by registry host
+	Credentials map[string]Credential
+}
+
+// Resolve resolves image to its digest, looking it up through the configured mirrors.
+// Images already pinned to a digest are checked to be reachable.
+func (r *Resolver) Resolve(ctx context.Context, image string) (Result, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return Result{}, err
+	}
+	errs := []error{}
+	for _, candidate := range candidates(ref, r.Mirrors) {
+		digest, err := r.manifestDigest(ctx, candidate)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", candidate.Name(), err))
+			continue
+		}
+		pinned := Reference{Registry: ref.Registry, Repository: ref.Repository, Digest: digest}
+		return Result{Image: pinned.String(), Digest: digest, From: candidate.Name()}, nil
+	}
+	return Result{}, fmt.Errorf("unable to resolve image %s: %w", image, errors.Join(errs...))
+}
+
+// manifestDigest returns the digest of the manifest of ref
+func (r *Resolver) manifestDigest(ctx context.Context, ref Reference) (string, error) {
+	reference := ref.Digest
+	if len(reference) == 0 {
+		reference = ref.Tag
+	}
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", ref.apiHost(), ref.Repository, reference)
+
+	response, err := r.do(ctx, http.MethodHead, manifestURL, ref)
+	if err != nil {
+		return "", err
+	}
+	response.Body.Close()
+	if digest := response.Header.Get("Docker-Content-Digest"); len(digest) != 0 {
+		return digest, nil
+	}
+	if len(ref.Digest) != 0 {
+		return ref.Digest, nil
+	}
+
+	// some registries do not return the digest on HEAD requests, compute it from the manifest
+	response, err = r.do(ctx, http.MethodGet, manifestURL, ref)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if digest := response.Header.Get("Docker-Content-Digest"); len(digest) != 0 {
+		return digest, nil
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, response.Body); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sha256:%x", hash.Sum(nil)), nil
+}
+
+// do sends a registry API request, authenticating when the registry asks for it
+func (r *Resolver) do(ctx context.Context, method, requestURL string, ref Reference) (*http.Response, error) {
+	response, err := r.send(ctx, method, requestURL, "")
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode == http.StatusUnauthorized {
+		challenge := response.Header.Get("WWW-Authenticate")
+		response.Body.Close()
+		authorization, err := r.authorize(ctx, challenge, ref)
+		if err != nil {
+			return nil, err
+		}
+		response, err = r.send(ctx, method, requestURL, authorization)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("registry request returned %s", response.Status)
+	}
+	return response, nil
+}
+
+func (r *Resolver) send(ctx context.Context, method, requestURL, authorization string) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, method, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if len(authorization) != 0 {
+		request.Header.Set("Authorization", authorization)
+	}
+	return r.httpClient().Do(request)
+}
+
+// authorize returns the Authorization header answering a registry WWW-Authenticate challenge
+func (r *Resolver) authorize(ctx context.Context, challenge string, ref Reference) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	credential, hasCredential := r.Credentials[ref.Registry]
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if !hasCredential {
+			return "", fmt.Errorf("registry %s requires credentials", ref.Registry)
+		}
+		return "Basic " + basicAuth(credential), nil
+	case "bearer":
+		realm, err := url.Parse(params["realm"])
+		if err != nil || len(realm.Host) == 0 {
+			return "", fmt.Errorf("invalid authentication realm %q", params["realm"])
+		}
+		query := realm.Query()
+		if service, ok := params["service"]; ok {
+			query.Set("service", service)
+		}
+		query.Set("scope", fmt.Sprintf("repository:%s:pull", ref.Repository))
+		realm.RawQuery = query.Encode()
+
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		if hasCredential {
+			request.Header.Set("Authorization", "Basic "+basicAuth(credential))
+		}
+		response, err := r.httpClient().Do(request)
+		if err != nil {
+			return "", err
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("token request returned %s", response.Status)
+		}
+		token := struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}{}
+		if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+			return "", err
+		}
+		if len(token.Token) == 0 {
+			token.Token = token.AccessToken
+		}
+		return "Bearer " + token.Token, nil
+	}
+	return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+}
+
+func (r *Resolver) httpClient() *http.Client {
+	if r.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return r.HTTPClient
+}
+
+// parseChallenge parses a WWW-Authenticate header into its scheme and parameters
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := map[string]string{}
+	for _, param := range strings.Split(rest, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if found {
+			params[strings.ToLower(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return scheme, params
+}
+
+func basicAuth(credential Credential) string {
+	return base64.StdEncoding.EncodeToString([]byte(credential.Username + ":" + credential.Password))
+}
+
+// ParsePullSecret returns the registry credentials of a kubernetes.io/dockerconfigjson pull secret
+func ParsePullSecret(dockerConfigJSON []byte) (map[string]Credential, error) {
+	config := struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"auths"`
+	}{}
+	if err := json.Unmarshal(dockerConfigJSON, &config); err != nil {
+		return nil, fmt.Errorf("invalid pull secret: %w", err)
+	}
+	credentials := map[string]Credential{}
+	for registry, auth := range config.Auths {
+		credential := Credential{Username: auth.Username, Password: auth.Password}
+		if len(auth.Auth) != 0 {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("invalid auth for registry %s in pull secret: %w", registry, err)
+			}
+			credential.Username, credential.Password, _ = strings.Cut(string(decoded), ":")
+		}
+		registry = strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+		registry, _, _ = strings.Cut(registry, "/")
+		if registry == "index.docker.io" {
+			registry = dockerHubRegistry
+		}
+		credentials[registry] = credential
+	}
+	return credentials, nil
+}
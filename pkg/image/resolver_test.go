@@ -0,0 +1,211 @@
+package image
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		name    string
+		image   string
+		want    Reference
+		wantErr bool
+	}{
+		{
+			name:  "quay image with tag",
+			image: "quay.io/konveyor/velero:latest",
+			want:  Reference{Registry: "quay.io", Repository: "konveyor/velero", Tag: "latest"},
+		},
+		{
+			name:  "registry with port and digest",
+			image: "registry.example.com:5000/oadp/velero@sha256:abc",
+			want:  Reference{Registry: "registry.example.com:5000", Repository: "oadp/velero", Digest: "sha256:abc"},
+		},
+		{
+			name:  "docker hub library image without tag",
+			image: "busybox",
+			want:  Reference{Registry: "docker.io", Repository: "library/busybox", Tag: "latest"},
+		},
+		{
+			name:  "docker hub image with tag and digest",
+			image: "velero/velero:v1.14.0@sha256:abc",
+			want:  Reference{Registry: "docker.io", Repository: "velero/velero", Tag: "v1.14.0", Digest: "sha256:abc"},
+		},
+		{
+			name:    "invalid digest",
+			image:   "quay.io/konveyor/velero@abc",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseReference(tt.image)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseReference() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	tests := []struct {
+		name  string
+		image string
+		rules []MirrorRule
+		want  []string
+	}{
+		{
+			name:  "no mirrors",
+			image: "quay.io/konveyor/velero:latest",
+			want:  []string{"quay.io/konveyor/velero:latest"},
+		},
+		{
+			name:  "digest mirror rules do not apply to tags",
+			image: "quay.io/konveyor/velero:latest",
+			rules: []MirrorRule{{Source: "quay.io/konveyor", Mirrors: []string{"mirror.example.com/konveyor"}}},
+			want:  []string{"quay.io/konveyor/velero:latest"},
+		},
+		{
+			name:  "tag mirror rule for namespace, source tried last",
+			image: "quay.io/konveyor/velero:latest",
+			rules: []MirrorRule{{Source: "quay.io/konveyor", Mirrors: []string{"mirror.example.com/konveyor"}, ForTags: true}},
+			want:  []string{"mirror.example.com/konveyor/velero:latest", "quay.io/konveyor/velero:latest"},
+		},
+		{
+			name:  "digest mirror rule for registry, never contact source",
+			image: "quay.io/konveyor/velero@sha256:abc",
+			rules: []MirrorRule{
+				{Source: "quay.io", Mirrors: []string{"mirror.example.com", "backup.example.com/quay"}, NeverContactSource: true},
+				{Source: "quay.io/konveyo", Mirrors: []string{"wrong.example.com"}},
+			},
+			want: []string{"mirror.example.com/konveyor/velero@sha256:abc", "backup.example.com/quay/konveyor/velero@sha256:abc"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := ParseReference(tt.image)
+			if err != nil {
+				t.Fatalf("ParseReference() error = %v", err)
+			}
+			got := []string{}
+			for _, candidate := range candidates(ref, tt.rules) {
+				got = append(got, candidate.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("candidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	const digest = "sha256:0123456789abcdef"
+	tokenRequests := 0
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			tokenRequests++
+			if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("scope") != "repository:oadp/velero:pull" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`{"token":"pull-token"}`))
+		case r.URL.Path == "/v2/oadp/velero/manifests/v1.0":
+			if r.Header.Get("Authorization") != "Bearer pull-token" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if !strings.Contains(r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json") {
+				w.WriteHeader(http.StatusNotAcceptable)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", digest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	mirror := strings.TrimPrefix(server.URL, "https://")
+
+	tests := []struct {
+		name        string
+		image       string
+		rules       []MirrorRule
+		credentials map[string]Credential
+		want        Result
+		wantErr     bool
+	}{
+		{
+			name:        "resolved from source registry",
+			image:       mirror + "/oadp/velero:v1.0",
+			credentials: map[string]Credential{mirror: {Username: "user", Password: "secret"}},
+			want:        Result{Image: mirror + "/oadp/velero@" + digest, Digest: digest, From: mirror + "/oadp/velero"},
+		},
+		{
+			name:  "resolved through tag mirror, never contacting source",
+			image: "quay.invalid/konveyor/velero:v1.0",
+			rules: []MirrorRule{
+				{Source: "quay.invalid/konveyor", Mirrors: []string{mirror + "/missing", mirror + "/oadp"}, NeverContactSource: true, ForTags: true},
+			},
+			credentials: map[string]Credential{mirror: {Username: "user", Password: "secret"}},
+			want:        Result{Image: "quay.invalid/konveyor/velero@" + digest, Digest: digest, From: mirror + "/oadp/velero"},
+		},
+		{
+			name:    "missing credentials",
+			image:   mirror + "/oadp/velero:v1.0",
+			wantErr: true,
+		},
+		{
+			name:        "unknown tag",
+			image:       mirror + "/oadp/velero:v2.0",
+			credentials: map[string]Credential{mirror: {Username: "user", Password: "secret"}},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := &Resolver{HTTPClient: server.Client(), Mirrors: tt.rules, Credentials: tt.credentials}
+			got, err := resolver.Resolve(context.Background(), tt.image)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if tokenRequests == 0 {
+		t.Errorf("expected bearer token requests")
+	}
+}
+
+func TestParsePullSecret(t *testing.T) {
+	got, err := ParsePullSecret([]byte(`{"auths":{
+		"quay.io":{"auth":"dXNlcjpwYXNz"},
+		"https://index.docker.io/v1/":{"username":"hub","password":"secret"}
+	}}`))
+	if err != nil {
+		t.Fatalf("ParsePullSecret() error = %v", err)
+	}
+	want := map[string]Credential{
+		"quay.io":   {Username: "user", Password: "pass"},
+		"docker.io": {Username: "hub", Password: "secret"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParsePullSecret() = %v, want %v", got, want)
+	}
+}
@@ -2,45 +2,61 @@ package server
 
 import (
 	"fmt"
+	"net"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/vmware-tanzu/velero/pkg/util/boolptr"
+	veleroserverconfig "github.com/vmware-tanzu/velero/pkg/cmd/server/config"
+	"github.com/vmware-tanzu/velero/pkg/constant"
+	"github.com/vmware-tanzu/velero/pkg/types"
 
 	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
 )
 
-// GetArgs returns the Velero server arguments as a string array
-// Most validations are done in the DPA CRD except float32 validation
+// RestoreOnlyDisabledControllers are the Velero controllers disabled in RestoreOnly mode,
+// a restore only cluster does not create backups
+var RestoreOnlyDisabledControllers = []string{constant.ControllerBackup, constant.ControllerSchedule}
+
+// GetArgs returns the Velero server arguments as a string array.
+// Fields outside Args setting the same server arguments are used when Args does not set them.
+// Most validations are done in the DPA CRD, the others are done by ValidateArgs.
 func GetArgs(dpa *oadpv1alpha1.DataProtectionApplication) ([]string, error) {
-	serverArgs := dpa.Spec.Configuration.Velero.Args
+	if err := ValidateArgs(dpa); err != nil {
+		return nil, err
+	}
+	veleroConfig := dpa.Spec.Configuration.Velero
+	serverArgs := veleroConfig.Args
 	args := []string{"server"}
 	// we are overriding args, so recreate args from scratch
-	if len(dpa.Spec.Configuration.Velero.FeatureFlags) > 0 {
-		args = append(args, fmt.Sprintf("--features=%s", strings.Join(dpa.Spec.Configuration.Velero.FeatureFlags, ",")))
+	if len(veleroConfig.FeatureFlags) > 0 {
+		args = append(args, fmt.Sprintf("--features=%s", strings.Join(veleroConfig.FeatureFlags, ",")))
+	}
+	if dpa.Spec.Configuration.NodeAgent != nil && dpa.Spec.Configuration.NodeAgent.UploaderType != "" {
+		args = append(args, fmt.Sprintf("--uploader-type=%s", dpa.Spec.Configuration.NodeAgent.UploaderType))
 	}
-	if boolptr.IsSetToTrue(serverArgs.DefaultVolumesToFsBackup) {
-		args = append(args, "--default-volumes-to-fs-backup=true")
-	} else if boolptr.IsSetToFalse(serverArgs.DefaultVolumesToFsBackup) {
-		args = append(args, "--default-volumes-to-fs-backup=false")
+	if defaultVolumesToFsBackup := firstBool(serverArgs.DefaultVolumesToFsBackup, veleroConfig.DefaultVolumesToFSBackup); defaultVolumesToFsBackup != nil {
+		args = append(args, fmt.Sprintf("--default-volumes-to-fs-backup=%s", strconv.FormatBool(*defaultVolumesToFsBackup))) // bool
 	}
-	if dpa.Spec.Configuration.Velero.LogLevel != "" {
-		args = append(args, fmt.Sprintf("--log-level=%s", dpa.Spec.Configuration.Velero.LogLevel))
+	if veleroConfig.LogLevel != "" {
+		args = append(args, fmt.Sprintf("--log-level=%s", veleroConfig.LogLevel))
 	}
 	if serverArgs.BackupSyncPeriod != nil {
 		args = append(args, fmt.Sprintf("--backup-sync-period=%s", serverArgs.BackupSyncPeriod.String())) // duration
 	}
 	if serverArgs.ClientBurst != nil {
 		args = append(args, fmt.Sprintf("--client-burst=%s", strconv.Itoa(*serverArgs.ClientBurst))) // int
+	} else if veleroConfig.ClientBurst != nil {
+		args = append(args, fmt.Sprintf("--client-burst=%s", strconv.Itoa(*veleroConfig.ClientBurst)))
 	}
 	if serverArgs.ClientPageSize != nil {
 		args = append(args, fmt.Sprintf("--client-page-size=%s", strconv.Itoa(*serverArgs.ClientPageSize))) // int
 	}
 	if serverArgs.ClientQPS != nil {
-		if _, err := strconv.ParseFloat(*serverArgs.ClientQPS, 32); err != nil {
-			return nil, err
-		}
 		args = append(args, fmt.Sprintf("--client-qps=%s", *serverArgs.ClientQPS)) // float32
+	} else if veleroConfig.ClientQPS != nil {
+		args = append(args, fmt.Sprintf("--client-qps=%s", strconv.Itoa(*veleroConfig.ClientQPS)))
 	}
 	// default-backup-storage-location set outside Args
 	if serverArgs.DefaultBackupTTL != nil {
@@ -48,22 +64,36 @@ func GetArgs(dpa *oadpv1alpha1.DataProtectionApplication) ([]string, error) {
 	}
 	if serverArgs.DefaultItemOperationTimeout != nil {
 		args = append(args, fmt.Sprintf("--default-item-operation-timeout=%s", serverArgs.DefaultItemOperationTimeout.String())) // duration
+	} else if veleroConfig.DefaultItemOperationTimeout != "" {
+		args = append(args, fmt.Sprintf("--default-item-operation-timeout=%s", veleroConfig.DefaultItemOperationTimeout))
 	}
 	if serverArgs.ResourceTimeout != nil {
 		args = append(args, fmt.Sprintf("--resource-timeout=%s", serverArgs.ResourceTimeout.String())) // duration
+	} else if veleroConfig.ResourceTimeout != "" {
+		args = append(args, fmt.Sprintf("--resource-timeout=%s", veleroConfig.ResourceTimeout))
 	}
 	if serverArgs.RepoMaintenanceFrequency != nil {
 		args = append(args, fmt.Sprintf("--default-repo-maintain-frequency=%s", serverArgs.RepoMaintenanceFrequency.String())) // duration
 	}
 	// default-volume-snapshot-locations set outside Args
-	if serverArgs.DisabledControllers != nil {
-		args = append(args, fmt.Sprintf("--disable-controllers=%s", strings.Join(serverArgs.DisabledControllers, ","))) // strings
+	disabledControllers := serverArgs.DisabledControllers
+	if dpa.IsRestoreOnly() {
+		for _, controller := range RestoreOnlyDisabledControllers {
+			if !slices.Contains(disabledControllers, controller) {
+				disabledControllers = append(slices.Clone(disabledControllers), controller)
+			}
+		}
+	}
+	if disabledControllers != nil {
+		args = append(args, fmt.Sprintf("--disable-controllers=%s", strings.Join(disabledControllers, ","))) // strings
 	}
 	if serverArgs.GarbageCollectionFrequency != nil {
 		args = append(args, fmt.Sprintf("--garbage-collection-frequency=%s", serverArgs.GarbageCollectionFrequency.String())) // duration
 	}
 	if serverArgs.FormatFlag != "" {
 		args = append(args, fmt.Sprintf("--log-format=%s", serverArgs.FormatFlag)) // format
+	} else if dpa.Spec.LogFormat != "" {
+		args = append(args, fmt.Sprintf("--log-format=%s", dpa.Spec.LogFormat))
 	}
 	if serverArgs.MetricsAddress != "" {
 		args = append(args, fmt.Sprintf("--metrics-address=%s", serverArgs.MetricsAddress)) // string
@@ -74,9 +104,13 @@ func GetArgs(dpa *oadpv1alpha1.DataProtectionApplication) ([]string, error) {
 	}
 	if serverArgs.PodVolumeOperationTimeout != nil {
 		args = append(args, fmt.Sprintf("--fs-backup-timeout=%s", serverArgs.PodVolumeOperationTimeout.String())) // duration
+	} else if dpa.Spec.Configuration.NodeAgent != nil && dpa.Spec.Configuration.NodeAgent.Timeout != "" {
+		args = append(args, fmt.Sprintf("--fs-backup-timeout=%s", dpa.Spec.Configuration.NodeAgent.Timeout))
 	}
 	if serverArgs.ItemOperationSyncFrequency != nil {
 		args = append(args, fmt.Sprintf("--item-operation-sync-frequency=%s", serverArgs.ItemOperationSyncFrequency.String())) // duration
+	} else if veleroConfig.ItemOperationSyncFrequency != "" {
+		args = append(args, fmt.Sprintf("--item-operation-sync-frequency=%s", veleroConfig.ItemOperationSyncFrequency))
 	}
 	if serverArgs.MaxConcurrentK8SConnections != nil {
 		args = append(args, fmt.Sprintf("--max-concurrent-k8s-connections=%d", *serverArgs.MaxConcurrentK8SConnections)) // uint
@@ -93,48 +127,258 @@ func GetArgs(dpa *oadpv1alpha1.DataProtectionApplication) ([]string, error) {
 	if serverArgs.ResourceTerminatingTimeout != nil {
 		args = append(args, fmt.Sprintf("--terminating-resource-timeout=%s", serverArgs.ResourceTerminatingTimeout.String())) // duration
 	}
-	if serverArgs.AddDirHeader != nil {
-		args = append(args, fmt.Sprintf("--add_dir_header=%s", strconv.FormatBool(*serverArgs.AddDirHeader))) // optionalBool
+	if defaultSnapshotMoveData := firstBool(serverArgs.DefaultSnapshotMoveData, veleroConfig.DefaultSnapshotMoveData); defaultSnapshotMoveData != nil {
+		args = append(args, fmt.Sprintf("--default-snapshot-move-data=%s", strconv.FormatBool(*defaultSnapshotMoveData))) // bool
+	}
+	// disable-informer-cache is always set, as when args is not set
+	disableInformerCache := firstBool(serverArgs.DisableInformerCache, veleroConfig.DisableInformerCache)
+	args = append(args, fmt.Sprintf("--disable-informer-cache=%s", strconv.FormatBool(disableInformerCache != nil && *disableInformerCache))) // bool
+	if serverArgs.ScheduleSkipImmediately != nil {
+		args = append(args, fmt.Sprintf("--schedule-skip-immediately=%s", strconv.FormatBool(*serverArgs.ScheduleSkipImmediately))) // bool
 	}
-	if serverArgs.AlsoToStderr != nil {
-		args = append(args, fmt.Sprintf("--alsologtostderr=%s", strconv.FormatBool(*serverArgs.AlsoToStderr))) // alsologtostderr
+	if serverArgs.KeepLatestMaintenanceJobs != nil {
+		args = append(args, fmt.Sprintf("--keep-latest-maintenance-jobs=%d", *serverArgs.KeepLatestMaintenanceJobs)) // int
 	}
-	if serverArgs.Colorized != nil {
-		args = append(args, fmt.Sprintf("--colorized=%s", strconv.FormatBool(*serverArgs.Colorized))) // optionalBool
+	if serverArgs.ItemBlockWorkerCount != nil {
+		args = append(args, fmt.Sprintf("--item-block-worker-count=%d", *serverArgs.ItemBlockWorkerCount)) // int
+	} else if veleroConfig.ItemBlockWorkerCount > 0 {
+		args = append(args, fmt.Sprintf("--item-block-worker-count=%d", veleroConfig.ItemBlockWorkerCount))
 	}
 	// features set outside Args
 	// args = append(args, "--kubeconfig")        // string
 	// args = append(args, "--kubecontext")       // string
-	if serverArgs.TraceLocation != "" {
-		args = append(args, fmt.Sprintf("--log_backtrace_at=%s", serverArgs.TraceLocation)) // traceLocation
+	// args = append(args, "--namespace")         // string
+	args = append(args, getGlobalFlagsArgs(serverArgs.GlobalFlags)...)
+	return args, nil
+}
+
+// ValidateArgs validates the Velero server args that the DPA CRD does not validate, and that
+// fields outside Args setting the same server arguments do not conflict with them
+func ValidateArgs(dpa *oadpv1alpha1.DataProtectionApplication) error {
+	if dpa.Spec.Configuration == nil || dpa.Spec.Configuration.Velero == nil || dpa.Spec.Configuration.Velero.Args == nil {
+		return nil
+	}
+	veleroConfig := dpa.Spec.Configuration.Velero
+	serverArgs := veleroConfig.Args
+
+	nodeAgentTimeout := ""
+	if dpa.Spec.Configuration.NodeAgent != nil {
+		nodeAgentTimeout = dpa.Spec.Configuration.NodeAgent.Timeout
+	}
+	durations := []durationArg{
+		{argsField: "backup-sync-period", args: serverArgs.BackupSyncPeriod},
+		{argsField: "fs-backup-timeout", args: serverArgs.PodVolumeOperationTimeout, field: "spec.configuration.nodeAgent.timeout", value: nodeAgentTimeout},
+		{argsField: "terminating-resource-timeout", args: serverArgs.ResourceTerminatingTimeout},
+		{argsField: "default-backup-ttl", args: serverArgs.DefaultBackupTTL},
+		{argsField: "store-validation-frequency", args: serverArgs.StoreValidationFrequency},
+		{argsField: "item-operation-sync-frequency", args: serverArgs.ItemOperationSyncFrequency, field: "spec.configuration.velero.itemOperationSyncFrequency", value: veleroConfig.ItemOperationSyncFrequency},
+		{argsField: "default-repo-maintain-frequency", args: serverArgs.RepoMaintenanceFrequency},
+		{argsField: "garbage-collection-frequency", args: serverArgs.GarbageCollectionFrequency},
+		{argsField: "default-item-operation-timeout", args: serverArgs.DefaultItemOperationTimeout, field: "spec.configuration.velero.defaultItemOperationTimeout", value: veleroConfig.DefaultItemOperationTimeout},
+		{argsField: "resource-timeout", args: serverArgs.ResourceTimeout, field: "spec.configuration.velero.resourceTimeout", value: veleroConfig.ResourceTimeout},
+	}
+	for _, duration := range durations {
+		if duration.args != nil && *duration.args < 0 {
+			return fmt.Errorf("spec.configuration.velero.args.%s must not be negative", duration.argsField)
+		}
+	}
+	var clientQPS *float64
+	if serverArgs.ClientQPS != nil {
+		qps, err := strconv.ParseFloat(*serverArgs.ClientQPS, 32)
+		if err != nil || qps <= 0 {
+			return fmt.Errorf("spec.configuration.velero.args.client-qps must be a positive number, got %q", *serverArgs.ClientQPS)
+		}
+		clientQPS = &qps
+	}
+	if serverArgs.MetricsAddress != "" {
+		if _, err := GetMetricsPort(serverArgs.MetricsAddress); err != nil {
+			return fmt.Errorf("spec.configuration.velero.args.metrics-address: %w", err)
+		}
+	}
+	if serverArgs.ProfilerAddress != "" {
+		if _, err := getAddressPort("profiler address", serverArgs.ProfilerAddress); err != nil {
+			return fmt.Errorf("spec.configuration.velero.args.profiler-address: %w", err)
+		}
 	}
-	if serverArgs.LogDir != "" {
-		args = append(args, fmt.Sprintf("--log_dir=%s", serverArgs.LogDir)) // string
+	if serverArgs.RestoreResourcePriorities != "" {
+		if err := (&types.Priorities{}).Set(serverArgs.RestoreResourcePriorities); err != nil {
+			return fmt.Errorf("spec.configuration.velero.args.restore-resource-priorities: %w", err)
+		}
 	}
-	if serverArgs.LogFile != "" {
-		args = append(args, fmt.Sprintf("--log_file=%s", serverArgs.LogFile)) // string
+	for _, controller := range serverArgs.DisabledControllers {
+		if !slices.Contains(veleroserverconfig.DisableableControllers, controller) {
+			return fmt.Errorf("spec.configuration.velero.args.disabled-controllers: invalid controller %q, valid values are %s", controller, strings.Join(veleroserverconfig.DisableableControllers, ","))
+		}
 	}
-	if serverArgs.LogFileMaxSizeMB != nil {
-		args = append(args, fmt.Sprintf("--log_file_max_size=%d", *serverArgs.LogFileMaxSizeMB)) // uint
+
+	// fields outside args setting the same server arguments
+	conflicts := []string{}
+	conflict := func(argsField, field string) {
+		conflicts = append(conflicts, fmt.Sprintf("spec.configuration.velero.args.%s conflicts with %s", argsField, field))
 	}
-	if serverArgs.ToStderr != nil {
-		args = append(args, fmt.Sprintf("--logtostderr=%s", strconv.FormatBool(*serverArgs.ToStderr))) // optionalBool
+	if serverArgs.FormatFlag != "" && dpa.Spec.LogFormat != "" && serverArgs.FormatFlag != string(dpa.Spec.LogFormat) {
+		conflict("log-format", "spec.logFormat")
 	}
-	// args = append(args, "--namespace")         // string
-	if serverArgs.SkipHeaders != nil {
-		args = append(args, fmt.Sprintf("--skip_headers=%s", strconv.FormatBool(*serverArgs.SkipHeaders))) // optionalBool
+	if serverArgs.DefaultVolumesToFsBackup != nil && veleroConfig.DefaultVolumesToFSBackup != nil && *serverArgs.DefaultVolumesToFsBackup != *veleroConfig.DefaultVolumesToFSBackup {
+		conflict("default-volumes-to-fs-backup", "spec.configuration.velero.defaultVolumesToFSBackup")
+	}
+	if serverArgs.DefaultSnapshotMoveData != nil && veleroConfig.DefaultSnapshotMoveData != nil && *serverArgs.DefaultSnapshotMoveData != *veleroConfig.DefaultSnapshotMoveData {
+		conflict("default-snapshot-move-data", "spec.configuration.velero.defaultSnapshotMoveData")
 	}
-	if serverArgs.SkipLogHeaders != nil {
-		args = append(args, fmt.Sprintf("--skip_log_headers=%s", strconv.FormatBool(*serverArgs.SkipLogHeaders))) // optionalBool
+	if serverArgs.DisableInformerCache != nil && veleroConfig.DisableInformerCache != nil && *serverArgs.DisableInformerCache != *veleroConfig.DisableInformerCache {
+		conflict("disable-informer-cache", "spec.configuration.velero.disableInformerCache")
 	}
-	if serverArgs.StderrThreshold != nil {
-		args = append(args, fmt.Sprintf("--stderrthreshold=%d", *serverArgs.StderrThreshold)) // severity
+	if serverArgs.ClientBurst != nil && veleroConfig.ClientBurst != nil && *serverArgs.ClientBurst != *veleroConfig.ClientBurst {
+		conflict("client-burst", "spec.configuration.velero.client-burst")
 	}
-	if serverArgs.Verbosity != nil {
-		args = append(args, fmt.Sprintf("--v=%d", *serverArgs.Verbosity)) // count
+	if clientQPS != nil && veleroConfig.ClientQPS != nil && *clientQPS != float64(*veleroConfig.ClientQPS) {
+		conflict("client-qps", "spec.configuration.velero.client-qps")
 	}
-	if serverArgs.Vmodule != "" {
-		args = append(args, fmt.Sprintf("--vmodule=%s", serverArgs.Vmodule)) // string
+	if serverArgs.ItemBlockWorkerCount != nil && veleroConfig.ItemBlockWorkerCount > 0 && *serverArgs.ItemBlockWorkerCount != veleroConfig.ItemBlockWorkerCount {
+		conflict("item-block-worker-count", "spec.configuration.velero.itemBlockWorkerCount")
 	}
+	for _, duration := range durations {
+		if duration.args == nil || duration.value == "" {
+			continue
+		}
+		value, err := time.ParseDuration(duration.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", duration.field, err)
+		}
+		if value != *duration.args {
+			conflict(duration.argsField, duration.field)
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%s, set only one of them", strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+func firstBool(values ...*bool) *bool {
+	for _, value := range values {
+		if value != nil {
+			return value
+		}
+	}
+	return nil
+}
+
+// durationArg is a duration server arg, and the field outside Args setting the same server argument if any
+type durationArg struct {
+	argsField string
+	args      *time.Duration
+	field     string
+	value     string
+}
+
+// GetNodeAgentArgs returns the Velero node-agent server arguments as a string array.
+// Most validations are done in the DPA CRD except metrics address validation.
+// nodeAgentConfigMap is the name of the node-agent ConfigMap, empty if there is none.
+// Timeouts and log format set outside Args are used when Args does not set them.
+func GetNodeAgentArgs(dpa *oadpv1alpha1.DataProtectionApplication, nodeAgentConfigMap string) ([]string, error) {
+	nodeAgent := dpa.Spec.Configuration.NodeAgent
+	serverArgs := nodeAgent.Args
+	args := []string{"node-agent", "server"}
+	// we are overriding args, so recreate args from scratch
+	if nodeAgentConfigMap != "" {
+		args = append(args, fmt.Sprintf("--node-agent-configmap=%s", nodeAgentConfigMap)) // string
+	}
+	if serverArgs.LogLevel != "" {
+		args = append(args, fmt.Sprintf("--log-level=%s", serverArgs.LogLevel)) // level
+	}
+	if serverArgs.FormatFlag != "" {
+		args = append(args, fmt.Sprintf("--log-format=%s", serverArgs.FormatFlag)) // format
+	} else if dpa.Spec.LogFormat != "" {
+		args = append(args, fmt.Sprintf("--log-format=%s", dpa.Spec.LogFormat))
+	}
+	if serverArgs.MetricsAddress != "" {
+		if _, err := GetMetricsPort(serverArgs.MetricsAddress); err != nil {
+			return nil, err
+		}
+		args = append(args, fmt.Sprintf("--metrics-address=%s", serverArgs.MetricsAddress)) // string
+	}
+	if serverArgs.DataMoverPrepareTimeout != nil {
+		args = append(args, fmt.Sprintf("--data-mover-prepare-timeout=%s", serverArgs.DataMoverPrepareTimeout.String())) // duration
+	} else if nodeAgent.DataMoverPrepareTimeout != nil {
+		args = append(args, fmt.Sprintf("--data-mover-prepare-timeout=%s", nodeAgent.DataMoverPrepareTimeout.Duration))
+	}
+	if serverArgs.ResourceTimeout != nil {
+		args = append(args, fmt.Sprintf("--resource-timeout=%s", serverArgs.ResourceTimeout.String())) // duration
+	} else if nodeAgent.ResourceTimeout != nil {
+		args = append(args, fmt.Sprintf("--resource-timeout=%s", nodeAgent.ResourceTimeout.Duration))
+	}
+	// data-path-concurrency is set in the node-agent ConfigMap
+	// host-pods-path and host-plugins-path are set in the DaemonSet volumes
+	args = append(args, getGlobalFlagsArgs(serverArgs.GlobalFlags)...)
 	return args, nil
 }
+
+// GetMetricsPort returns the port of a metrics address in host:port form
+func GetMetricsPort(metricsAddress string) (int, error) {
+	return getAddressPort("metrics address", metricsAddress)
+}
+
+func getAddressPort(name, address string) (int, error) {
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", name, address, err)
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil || portNumber < 1 || portNumber > 65535 {
+		return 0, fmt.Errorf("invalid %s %q: invalid port %q", name, address, port)
+	}
+	return portNumber, nil
+}
+
+// getGlobalFlagsArgs returns the arguments of the flags shared by Velero commands
+func getGlobalFlagsArgs(globalFlags oadpv1alpha1.GlobalFlags) []string {
+	args := []string{}
+	if globalFlags.AddDirHeader != nil {
+		args = append(args, fmt.Sprintf("--add_dir_header=%s", strconv.FormatBool(*globalFlags.AddDirHeader))) // optionalBool
+	}
+	if globalFlags.AlsoToStderr != nil {
+		args = append(args, fmt.Sprintf("--alsologtostderr=%s", strconv.FormatBool(*globalFlags.AlsoToStderr))) // alsologtostderr
+	}
+	if globalFlags.Colorized != nil {
+		args = append(args, fmt.Sprintf("--colorized=%s", strconv.FormatBool(*globalFlags.Colorized))) // optionalBool
+	}
+	// features set outside Args
+	// args = append(args, "--kubeconfig")        // string
+	// args = append(args, "--kubecontext")       // string
+	if globalFlags.TraceLocation != "" {
+		args = append(args, fmt.Sprintf("--log_backtrace_at=%s", globalFlags.TraceLocation)) // traceLocation
+	}
+	if globalFlags.LogDir != "" {
+		args = append(args, fmt.Sprintf("--log_dir=%s", globalFlags.LogDir)) // string
+	}
+	if globalFlags.LogFile != "" {
+		args = append(args, fmt.Sprintf("--log_file=%s", globalFlags.LogFile)) // string
+	}
+	if globalFlags.LogFileMaxSizeMB != nil {
+		args = append(args, fmt.Sprintf("--log_file_max_size=%d", *globalFlags.LogFileMaxSizeMB)) // uint
+	}
+	if globalFlags.ToStderr != nil {
+		args = append(args, fmt.Sprintf("--logtostderr=%s", strconv.FormatBool(*globalFlags.ToStderr))) // optionalBool
+	}
+	// args = append(args, "--namespace")         // string
+	if globalFlags.OneOutput != nil {
+		args = append(args, fmt.Sprintf("--one_output=%s", strconv.FormatBool(*globalFlags.OneOutput))) // optionalBool
+	}
+	if globalFlags.SkipHeaders != nil {
+		args = append(args, fmt.Sprintf("--skip_headers=%s", strconv.FormatBool(*globalFlags.SkipHeaders))) // optionalBool
+	}
+	if globalFlags.SkipLogHeaders != nil {
+		args = append(args, fmt.Sprintf("--skip_log_headers=%s", strconv.FormatBool(*globalFlags.SkipLogHeaders))) // optionalBool
+	}
+	if globalFlags.StderrThreshold != nil {
+		args = append(args, fmt.Sprintf("--stderrthreshold=%d", *globalFlags.StderrThreshold)) // severity
+	}
+	if globalFlags.Verbosity != nil {
+		args = append(args, fmt.Sprintf("--v=%d", *globalFlags.Verbosity)) // count
+	}
+	if globalFlags.Vmodule != "" {
+		args = append(args, fmt.Sprintf("--vmodule=%s", globalFlags.Vmodule)) // string
+	}
+	return args
+}
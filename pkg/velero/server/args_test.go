@@ -0,0 +1,405 @@
+package server
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func TestGetNodeAgentArgs(t *testing.T) {
+	tests := []struct {
+		name               string
+		logFormat          oadpv1alpha1.LogFormat
+		nodeAgent          oadpv1alpha1.NodeAgentConfig
+		nodeAgentConfigMap string
+		want               []string
+		wantErr            bool
+	}{
+		{
+			name:      "empty args",
+			nodeAgent: oadpv1alpha1.NodeAgentConfig{Args: &oadpv1alpha1.NodeAgentServerArgs{}},
+			want:      []string{"node-agent", "server"},
+		},
+		{
+			name: "all args",
+			nodeAgent: oadpv1alpha1.NodeAgentConfig{
+				Args: &oadpv1alpha1.NodeAgentServerArgs{
+					LogLevel:                "debug",
+					FormatFlag:              "json",
+					MetricsAddress:          "0.0.0.0:9085",
+					DataMoverPrepareTimeout: ptr.To(time.Hour),
+					ResourceTimeout:         ptr.To(20 * time.Minute),
+					DataPathConcurrency:     ptr.To(2),
+					HostPodsPath:            "/var/data/kubelet/pods",
+					GlobalFlags: oadpv1alpha1.GlobalFlags{
+						Colorized: ptr.To(false),
+						LoggingFlags: oadpv1alpha1.LoggingFlags{
+							SkipHeaders: ptr.To(true),
+							Verbosity:   ptr.To(2),
+						},
+					},
+				},
+			},
+			nodeAgentConfigMap: "node-agent-test-dpa",
+			want: []string{
+				"node-agent",
+				"server",
+				"--node-agent-configmap=node-agent-test-dpa",
+				"--log-level=debug",
+				"--log-format=json",
+				"--metrics-address=0.0.0.0:9085",
+				"--data-mover-prepare-timeout=1h0m0s",
+				"--resource-timeout=20m0s",
+				"--colorized=false",
+				"--skip_headers=true",
+				"--v=2",
+			},
+		},
+		{
+			name:      "timeouts and log format set outside args are used when args do not set them",
+			logFormat: oadpv1alpha1.LogFormatText,
+			nodeAgent: oadpv1alpha1.NodeAgentConfig{
+				DataMoverPrepareTimeout: &metav1.Duration{Duration: 10 * time.Second},
+				ResourceTimeout:         &metav1.Duration{Duration: 10 * time.Minute},
+				Args:                    &oadpv1alpha1.NodeAgentServerArgs{LogLevel: "info"},
+			},
+			want: []string{
+				"node-agent",
+				"server",
+				"--log-level=info",
+				"--log-format=text",
+				"--data-mover-prepare-timeout=10s",
+				"--resource-timeout=10m0s",
+			},
+		},
+		{
+			name:      "args take precedence over timeouts and log format set outside args",
+			logFormat: oadpv1alpha1.LogFormatText,
+			nodeAgent: oadpv1alpha1.NodeAgentConfig{
+				DataMoverPrepareTimeout: &metav1.Duration{Duration: 10 * time.Second},
+				ResourceTimeout:         &metav1.Duration{Duration: 10 * time.Minute},
+				Args: &oadpv1alpha1.NodeAgentServerArgs{
+					FormatFlag:              "json",
+					DataMoverPrepareTimeout: ptr.To(time.Minute),
+					ResourceTimeout:         ptr.To(time.Hour),
+				},
+			},
+			want: []string{
+				"node-agent",
+				"server",
+				"--log-format=json",
+				"--data-mover-prepare-timeout=1m0s",
+				"--resource-timeout=1h0m0s",
+			},
+		},
+		{
+			name:      "metrics address without port",
+			nodeAgent: oadpv1alpha1.NodeAgentConfig{Args: &oadpv1alpha1.NodeAgentServerArgs{MetricsAddress: "localhost"}},
+			wantErr:   true,
+		},
+		{
+			name:      "metrics address with invalid port",
+			nodeAgent: oadpv1alpha1.NodeAgentConfig{Args: &oadpv1alpha1.NodeAgentServerArgs{MetricsAddress: ":metrics"}},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := &oadpv1alpha1.DataProtectionApplication{
+				Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+					LogFormat: tt.logFormat,
+					Configuration: &oadpv1alpha1.ApplicationConfig{
+						NodeAgent: &tt.nodeAgent,
+					},
+				},
+			}
+			got, err := GetNodeAgentArgs(dpa, tt.nodeAgentConfigMap)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetNodeAgentArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetNodeAgentArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		logFormat    oadpv1alpha1.LogFormat
+		mode         oadpv1alpha1.DataProtectionApplicationMode
+		veleroConfig oadpv1alpha1.VeleroConfig
+		nodeAgent    *oadpv1alpha1.NodeAgentConfig
+		want         []string
+		wantErr      bool
+	}{
+		{
+			name:         "empty args",
+			veleroConfig: oadpv1alpha1.VeleroConfig{Args: &oadpv1alpha1.VeleroServerArgs{}},
+			want:         []string{"server", "--disable-informer-cache=false"},
+		},
+		{
+			name:      "fields set outside args are used when args do not set them",
+			logFormat: oadpv1alpha1.LogFormatJSON,
+			veleroConfig: oadpv1alpha1.VeleroConfig{
+				FeatureFlags:                []string{"EnableCSI"},
+				LogLevel:                    "debug",
+				ClientBurst:                 ptr.To(50),
+				ClientQPS:                   ptr.To(20),
+				DefaultItemOperationTimeout: "2h",
+				ResourceTimeout:             "5m",
+				ItemOperationSyncFrequency:  "30s",
+				DefaultSnapshotMoveData:     ptr.To(true),
+				DisableInformerCache:        ptr.To(true),
+				ItemBlockWorkerCount:        2,
+				Args:                        &oadpv1alpha1.VeleroServerArgs{},
+			},
+			nodeAgent: &oadpv1alpha1.NodeAgentConfig{UploaderType: "kopia", NodeAgentCommonFields: oadpv1alpha1.NodeAgentCommonFields{Timeout: "4h"}},
+			want: []string{
+				"server",
+				"--features=EnableCSI",
+				"--uploader-type=kopia",
+				"--log-level=debug",
+				"--client-burst=50",
+				"--client-qps=20",
+				"--default-item-operation-timeout=2h",
+				"--resource-timeout=5m",
+				"--log-format=json",
+				"--fs-backup-timeout=4h",
+				"--item-operation-sync-frequency=30s",
+				"--default-snapshot-move-data=true",
+				"--disable-informer-cache=true",
+				"--item-block-worker-count=2",
+			},
+		},
+		{
+			name: "fields set outside args matching args",
+			veleroConfig: oadpv1alpha1.VeleroConfig{
+				ClientQPS:       ptr.To(20),
+				ResourceTimeout: "5m",
+				Args: &oadpv1alpha1.VeleroServerArgs{
+					ServerFlags: oadpv1alpha1.ServerFlags{
+						ClientQPS:       ptr.To("20.0"),
+						ResourceTimeout: ptr.To(5 * time.Minute),
+					},
+				},
+			},
+			want: []string{"server", "--client-qps=20.0", "--resource-timeout=5m0s", "--disable-informer-cache=false"},
+		},
+		{
+			name: "negative duration",
+			veleroConfig: oadpv1alpha1.VeleroConfig{Args: &oadpv1alpha1.VeleroServerArgs{
+				ServerFlags: oadpv1alpha1.ServerFlags{BackupSyncPeriod: ptr.To(-time.Minute)},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "invalid client qps",
+			veleroConfig: oadpv1alpha1.VeleroConfig{Args: &oadpv1alpha1.VeleroServerArgs{
+				ServerFlags: oadpv1alpha1.ServerFlags{ClientQPS: ptr.To("fast")},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "negative client qps",
+			veleroConfig: oadpv1alpha1.VeleroConfig{Args: &oadpv1alpha1.VeleroServerArgs{
+				ServerFlags: oadpv1alpha1.ServerFlags{ClientQPS: ptr.To("-1")},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "invalid profiler address",
+			veleroConfig: oadpv1alpha1.VeleroConfig{Args: &oadpv1alpha1.VeleroServerArgs{
+				ServerFlags: oadpv1alpha1.ServerFlags{ProfilerAddress: "localhost"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "invalid restore resource priorities",
+			veleroConfig: oadpv1alpha1.VeleroConfig{Args: &oadpv1alpha1.VeleroServerArgs{
+				ServerFlags: oadpv1alpha1.ServerFlags{RestoreResourcePriorities: "pods,-,services,-,secrets"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "invalid disabled controller",
+			veleroConfig: oadpv1alpha1.VeleroConfig{Args: &oadpv1alpha1.VeleroServerArgs{
+				ServerFlags: oadpv1alpha1.ServerFlags{DisabledControllers: []string{"backup", "velero"}},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "restore only mode disables the backup and schedule controllers",
+			mode: oadpv1alpha1.DataProtectionApplicationModeRestoreOnly,
+			veleroConfig: oadpv1alpha1.VeleroConfig{Args: &oadpv1alpha1.VeleroServerArgs{
+				ServerFlags: oadpv1alpha1.ServerFlags{DisabledControllers: []string{"gc", "backup"}},
+			}},
+			want: []string{"server", "--disable-controllers=gc,backup,schedule", "--disable-informer-cache=false"},
+		},
+		{
+			name:      "log format conflicts with spec.logFormat",
+			logFormat: oadpv1alpha1.LogFormatText,
+			veleroConfig: oadpv1alpha1.VeleroConfig{Args: &oadpv1alpha1.VeleroServerArgs{
+				ServerFlags: oadpv1alpha1.ServerFlags{FormatFlag: "json"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "client qps conflicts with spec.configuration.velero.client-qps",
+			veleroConfig: oadpv1alpha1.VeleroConfig{
+				ClientQPS: ptr.To(10),
+				Args: &oadpv1alpha1.VeleroServerArgs{
+					ServerFlags: oadpv1alpha1.ServerFlags{ClientQPS: ptr.To("10.5")},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "default volumes to fs backup conflicts",
+			veleroConfig: oadpv1alpha1.VeleroConfig{
+				DefaultVolumesToFSBackup: ptr.To(true),
+				Args: &oadpv1alpha1.VeleroServerArgs{
+					ServerFlags: oadpv1alpha1.ServerFlags{DefaultVolumesToFsBackup: ptr.To(false)},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "item block worker count conflicts",
+			veleroConfig: oadpv1alpha1.VeleroConfig{
+				ItemBlockWorkerCount: 2,
+				Args: &oadpv1alpha1.VeleroServerArgs{
+					ServerFlags: oadpv1alpha1.ServerFlags{ItemBlockWorkerCount: ptr.To(3)},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name:      "fs backup timeout conflicts with spec.configuration.nodeAgent.timeout",
+			nodeAgent: &oadpv1alpha1.NodeAgentConfig{NodeAgentCommonFields: oadpv1alpha1.NodeAgentCommonFields{Timeout: "1h"}},
+			veleroConfig: oadpv1alpha1.VeleroConfig{Args: &oadpv1alpha1.VeleroServerArgs{
+				ServerFlags: oadpv1alpha1.ServerFlags{PodVolumeOperationTimeout: ptr.To(2 * time.Hour)},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := &oadpv1alpha1.DataProtectionApplication{
+				Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+					LogFormat: tt.logFormat,
+					Mode:      tt.mode,
+					Configuration: &oadpv1alpha1.ApplicationConfig{
+						Velero:    &tt.veleroConfig,
+						NodeAgent: tt.nodeAgent,
+					},
+				},
+			}
+			got, err := GetArgs(dpa)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGetArgs_AllFlagsRendered ensures no VeleroServerArgs field is silently ignored by GetArgs
+func TestGetArgs_AllFlagsRendered(t *testing.T) {
+	// flags whose argument name differs from their json name
+	argNames := map[string]string{
+		"disabled-controllers": "disable-controllers",
+	}
+	// string values GetArgs validates
+	stringValues := map[string]string{
+		"metrics-address":             ":8085",
+		"profiler-address":            "localhost:6060",
+		"log-format":                  "json",
+		"restore-resource-priorities": "pods,-,services",
+	}
+
+	var fields [][]int
+	var collect func(typ reflect.Type, index []int)
+	collect = func(typ reflect.Type, index []int) {
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			fieldIndex := append(append([]int{}, index...), i)
+			if field.Anonymous {
+				collect(field.Type, fieldIndex)
+				continue
+			}
+			fields = append(fields, fieldIndex)
+		}
+	}
+	collect(reflect.TypeOf(oadpv1alpha1.VeleroServerArgs{}), nil)
+
+	for _, index := range fields {
+		serverArgs := oadpv1alpha1.VeleroServerArgs{}
+		field := reflect.TypeOf(serverArgs).FieldByIndex(index)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		t.Run(name, func(t *testing.T) {
+			value := reflect.ValueOf(&serverArgs).Elem().FieldByIndex(index)
+			setNonZero(t, value, stringValues[name])
+
+			dpa := &oadpv1alpha1.DataProtectionApplication{
+				Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+					Configuration: &oadpv1alpha1.ApplicationConfig{
+						Velero: &oadpv1alpha1.VeleroConfig{Args: &serverArgs},
+					},
+				},
+			}
+			got, err := GetArgs(dpa)
+			if err != nil {
+				t.Fatalf("GetArgs() error = %v", err)
+			}
+			argName := name
+			if renamed, ok := argNames[name]; ok {
+				argName = renamed
+			}
+			for _, arg := range got {
+				if strings.HasPrefix(arg, "--"+argName+"=") {
+					return
+				}
+			}
+			t.Errorf("GetArgs() = %v, missing --%s", got, argName)
+		})
+	}
+}
+
+// setNonZero sets value to a valid non zero value of its type, stringValue for strings when not empty
+func setNonZero(t *testing.T, value reflect.Value, stringValue string) {
+	t.Helper()
+	if value.Kind() == reflect.Pointer {
+		value.Set(reflect.New(value.Type().Elem()))
+		value = value.Elem()
+	}
+	switch {
+	case value.Type() == reflect.TypeOf(time.Duration(0)):
+		value.SetInt(int64(time.Second))
+	case value.Kind() == reflect.Bool:
+		value.SetBool(true)
+	case value.CanInt():
+		value.SetInt(2)
+	case value.CanUint():
+		value.SetUint(2)
+	case value.Kind() == reflect.String:
+		if stringValue == "" {
+			// client-qps is the only numeric string
+			stringValue = "1.5"
+		}
+		value.SetString(stringValue)
+	case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.String:
+		value.Set(reflect.ValueOf([]string{"backup"}))
+	default:
+		t.Fatalf("unsupported field type %s, update setNonZero", value.Type())
+	}
+}
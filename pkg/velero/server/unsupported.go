@@ -0,0 +1,85 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	veleroserverconfig "github.com/vmware-tanzu/velero/pkg/cmd/server/config"
+	"github.com/vmware-tanzu/velero/pkg/util/logging"
+)
+
+// InvalidVeleroServerArgs returns the unknown or malformed flags of the Velero server args,
+// checked against the flags of the Velero server binary. args starts with the server subcommand.
+func InvalidVeleroServerArgs(args []string) []string {
+	return invalidArgs(args, "server", func(flags *pflag.FlagSet) {
+		veleroserverconfig.GetDefaultConfig().BindFlags(flags)
+	})
+}
+
+// InvalidNodeAgentServerArgs returns the unknown or malformed flags of the node-agent server args,
+// checked against the flags of the node-agent server binary. args starts with the node-agent server subcommands.
+func InvalidNodeAgentServerArgs(args []string) []string {
+	return invalidArgs(args, "node-agent server", func(flags *pflag.FlagSet) {
+		// from velero pkg/cmd/cli/nodeagent NewServerCommand
+		flags.Var(logging.LogLevelFlag(logrus.InfoLevel), "log-level", "")
+		flags.Var(logging.NewFormatFlag(), "log-format", "")
+		flags.Duration("resource-timeout", 0, "")
+		flags.Duration("data-mover-prepare-timeout", 0, "")
+		flags.String("metrics-address", "", "")
+		flags.String("node-agent-configmap", "", "")
+	})
+}
+
+// invalidArgs parses each flag of args after subcommand with the flags bound by bindFlags and the Velero global flags,
+// and returns the parse errors
+func invalidArgs(args []string, subcommand string, bindFlags func(flags *pflag.FlagSet)) []string {
+	invalid := []string{}
+	subcommands := strings.Fields(subcommand)
+	for i, name := range subcommands {
+		if i >= len(args) || args[i] != name {
+			return append(invalid, fmt.Sprintf("args must start with %q", subcommand))
+		}
+	}
+
+	flags := pflag.NewFlagSet(subcommand, pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.Usage = func() {}
+	bindFlags(flags)
+	bindGlobalFlags(flags)
+	for _, arg := range args[len(subcommands):] {
+		if !strings.HasPrefix(arg, "-") {
+			invalid = append(invalid, fmt.Sprintf("unexpected argument %q", arg))
+			continue
+		}
+		if err := flags.Parse([]string{arg}); err != nil {
+			invalid = append(invalid, err.Error())
+		}
+	}
+	return invalid
+}
+
+// bindGlobalFlags binds the flags shared by Velero commands, including the klog flags.
+// klog flags are not bound with klog.InitFlags, which would set the operator logging when parsing.
+func bindGlobalFlags(flags *pflag.FlagSet) {
+	flags.String("features", "", "")
+	flags.Bool("colorized", false, "")
+	flags.String("kubeconfig", "", "")
+	flags.String("kubecontext", "", "")
+	flags.StringP("namespace", "n", "", "")
+	flags.Bool("add_dir_header", false, "")
+	flags.Bool("alsologtostderr", false, "")
+	flags.String("log_backtrace_at", "", "")
+	flags.String("log_dir", "", "")
+	flags.String("log_file", "", "")
+	flags.Uint64("log_file_max_size", 0, "")
+	flags.Bool("logtostderr", false, "")
+	flags.Bool("one_output", false, "")
+	flags.Bool("skip_headers", false, "")
+	flags.Bool("skip_log_headers", false, "")
+	flags.String("stderrthreshold", "", "")
+	flags.Int32P("v", "v", 0, "")
+	flags.String("vmodule", "", "")
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInvalidVeleroServerArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "known flags",
+			args: []string{"server", "--features=EnableCSI", "--fs-backup-timeout=10h", "--restore-only=true", "--uploader-type=kopia", "--v=2", "-n=openshift-adp"},
+			want: []string{},
+		},
+		{
+			name: "unknown flag",
+			args: []string{"server", "--fs-backup-timout=10h"},
+			want: []string{"unknown flag: --fs-backup-timout"},
+		},
+		{
+			name: "malformed flags",
+			args: []string{"server", "--client-qps=fast", "--default-volumes-to-fs-backup=maybe", "--log-level=loud"},
+			want: []string{
+				`invalid argument "fast" for "--client-qps" flag: strconv.ParseFloat: parsing "fast": invalid syntax`,
+				`invalid argument "maybe" for "--default-volumes-to-fs-backup" flag: strconv.ParseBool: parsing "maybe": invalid syntax`,
+				`invalid argument "loud" for "--log-level" flag: invalid value: "loud"`,
+			},
+		},
+		{
+			name: "node-agent flag",
+			args: []string{"server", "--node-agent-configmap=node-agent-dpa"},
+			want: []string{"unknown flag: --node-agent-configmap"},
+		},
+		{
+			name: "missing subcommand",
+			args: []string{"--features=EnableCSI"},
+			want: []string{`args must start with "server"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InvalidVeleroServerArgs(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InvalidVeleroServerArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidNodeAgentServerArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "known flags",
+			args: []string{"node-agent", "server", "--data-mover-prepare-timeout=45m", "--resource-timeout=15m", "--log-format=json", "--skip_headers=true"},
+			want: []string{},
+		},
+		{
+			name: "Velero server flag",
+			args: []string{"node-agent", "server", "--client-qps=10"},
+			want: []string{"unknown flag: --client-qps"},
+		},
+		{
+			name: "malformed flag",
+			args: []string{"node-agent", "server", "--resource-timeout=15"},
+			want: []string{`invalid argument "15" for "--resource-timeout" flag: time: missing unit in duration "15"`},
+		},
+		{
+			name: "missing subcommand",
+			args: []string{"server", "--resource-timeout=15m"},
+			want: []string{`args must start with "node-agent server"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InvalidNodeAgentServerArgs(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InvalidNodeAgentServerArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}